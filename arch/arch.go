@@ -7,6 +7,8 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+
+	"github.com/juju/errors"
 )
 
 // The following constants define the machine architectures supported by Juju.
@@ -92,3 +94,12 @@ func IsSupportedArch(arch string) bool {
 	}
 	return false
 }
+
+// ValidateArch returns a NotValid error if arch, once normalised, is not
+// one supported by Juju.
+func ValidateArch(arch string) error {
+	if !IsSupportedArch(NormaliseArch(arch)) {
+		return errors.NotValidf("architecture %q", arch)
+	}
+	return nil
+}
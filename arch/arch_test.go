@@ -4,6 +4,7 @@
 package arch_test
 
 import (
+	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
@@ -40,6 +41,9 @@ func (s *archSuite) TestNormaliseArch(c *gc.C) {
 		{"ppc64le", "ppc64el"},
 		{"ppc64", "ppc64el"},
 		{"s390x", "s390x"},
+		{"i686", "i386"},
+		{"armv7l", "armhf"},
+		{" x86_64\n", "amd64"},
 	} {
 		arch := arch.NormaliseArch(test.raw)
 		c.Check(arch, gc.Equals, test.arch)
@@ -59,3 +63,13 @@ func (s *archSuite) TestArchInfo(c *gc.C) {
 		c.Assert(ok, jc.IsTrue)
 	}
 }
+
+func (s *archSuite) TestValidateArch(c *gc.C) {
+	for _, a := range arch.AllSupportedArches {
+		c.Check(arch.ValidateArch(a), jc.ErrorIsNil)
+	}
+	c.Check(arch.ValidateArch("aarch64"), jc.ErrorIsNil)
+	err := arch.ValidateArch("invalid")
+	c.Assert(err, gc.ErrorMatches, `architecture "invalid" not valid`)
+	c.Assert(errors.IsNotValid(err), jc.IsTrue)
+}
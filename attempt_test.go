@@ -6,6 +6,9 @@ package utils_test
 import (
 	"time"
 
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/utils"
@@ -90,3 +93,30 @@ func (*utilsSuite) TestAttemptNextHasNext(c *gc.C) {
 	c.Assert(a.HasNext(), gc.Equals, false)
 	c.Assert(a.Next(), gc.Equals, false)
 }
+
+func (*utilsSuite) TestAttemptClock(c *gc.C) {
+	clk := testclock.NewClock(time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC))
+	a := utils.AttemptStrategy{
+		Total: 250 * time.Millisecond,
+		Delay: 100 * time.Millisecond,
+		Clock: clk,
+	}.Start()
+	c.Assert(a.Next(), jc.IsTrue)
+
+	next := make(chan bool)
+	go func() {
+		next <- a.Next()
+	}()
+	c.Assert(clk.WaitAdvance(100*time.Millisecond, testing.LongWait, 1), jc.ErrorIsNil)
+	c.Assert(<-next, jc.IsTrue)
+
+	go func() {
+		next <- a.Next()
+	}()
+	c.Assert(clk.WaitAdvance(100*time.Millisecond, testing.LongWait, 1), jc.ErrorIsNil)
+	c.Assert(<-next, jc.IsTrue)
+
+	// The next attempt would start after the total duration.
+	c.Assert(a.HasNext(), jc.IsFalse)
+	c.Assert(a.Next(), jc.IsFalse)
+}
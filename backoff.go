@@ -0,0 +1,121 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
+)
+
+// BackoffConfig holds the configuration for a Backoff.
+type BackoffConfig struct {
+	// Initial is the first wait.
+	Initial time.Duration
+
+	// Max is the longest wait, before jitter is applied.
+	Max time.Duration
+
+	// Factor is the factor by which each wait is longer than the
+	// previous one. It must be at least 1.
+	Factor float64
+
+	// Jitter is the proportion of each wait, in [0, 1], that is
+	// randomised. With a jitter of 0.2 a wait of 10s is anywhere
+	// between 8s and 12s. Jitter stops many clients that lost their
+	// connections at the same time from reconnecting in lock step.
+	Jitter float64
+
+	// Clock is used by Wait. If it is nil, clock.WallClock is used.
+	Clock clock.Clock
+}
+
+// Validate returns an error if the config cannot be used to create a
+// Backoff.
+func (config BackoffConfig) Validate() error {
+	if config.Initial <= 0 {
+		return errors.NotValidf("non-positive Initial")
+	}
+	if config.Max < config.Initial {
+		return errors.NotValidf("Max less than Initial")
+	}
+	if config.Factor < 1 {
+		return errors.NotValidf("Factor less than 1")
+	}
+	if config.Jitter < 0 || config.Jitter > 1 {
+		return errors.NotValidf("Jitter outside [0, 1]")
+	}
+	return nil
+}
+
+// Backoff produces increasingly long waits, for use in loops that
+// reconnect to long-lived streams such as websockets, server-sent events
+// or long polls. Unlike a retry strategy it has no notion of a total
+// number of attempts or duration: the caller decides when to give up,
+// and calls Reset once a connection has been established.
+//
+// A Backoff is not safe for concurrent use.
+type Backoff struct {
+	config  BackoffConfig
+	clock   clock.Clock
+	rand    *rand.Rand
+	current time.Duration
+}
+
+// NewBackoff returns a new Backoff with the given configuration.
+func NewBackoff(config BackoffConfig) (*Backoff, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errors.Annotate(err, "invalid backoff config")
+	}
+	clk := config.Clock
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	return &Backoff{
+		config: config,
+		clock:  clk,
+		rand:   rand.New(rand.NewSource(clk.Now().UnixNano())),
+	}, nil
+}
+
+// Next returns the next wait, with jitter applied, and lengthens the
+// wait after it.
+func (b *Backoff) Next() time.Duration {
+	if b.current == 0 {
+		b.current = b.config.Initial
+	} else {
+		next := time.Duration(float64(b.current) * b.config.Factor)
+		if next > b.config.Max || next < b.current {
+			// The comparison with current catches overflow.
+			next = b.config.Max
+		}
+		b.current = next
+	}
+	if b.config.Jitter == 0 {
+		return b.current
+	}
+	// Get a factor in [-1, 1).
+	randFactor := b.rand.Float64()*2 - 1
+	return b.current + time.Duration(float64(b.current)*b.config.Jitter*randFactor)
+}
+
+// Wait waits for the duration returned by Next. It returns the
+// context's error if the context is done first.
+func (b *Backoff) Wait(ctx context.Context) error {
+	select {
+	case <-b.clock.After(b.Next()):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reset restores the wait returned by the next call to Next to
+// Initial.
+func (b *Backoff) Reset() {
+	b.current = 0
+}
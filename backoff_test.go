@@ -0,0 +1,121 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type backoffSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&backoffSuite{})
+
+func (*backoffSuite) TestValidate(c *gc.C) {
+	valid := utils.BackoffConfig{
+		Initial: time.Second,
+		Max:     time.Minute,
+		Factor:  2,
+	}
+	c.Assert(valid.Validate(), jc.ErrorIsNil)
+	for i, test := range []struct {
+		mutate func(*utils.BackoffConfig)
+		err    string
+	}{
+		{func(config *utils.BackoffConfig) { config.Initial = 0 }, "non-positive Initial not valid"},
+		{func(config *utils.BackoffConfig) { config.Max = time.Millisecond }, "Max less than Initial not valid"},
+		{func(config *utils.BackoffConfig) { config.Factor = 0.5 }, "Factor less than 1 not valid"},
+		{func(config *utils.BackoffConfig) { config.Jitter = 1.5 }, `Jitter outside \[0, 1\] not valid`},
+	} {
+		c.Logf("test %d", i)
+		config := valid
+		test.mutate(&config)
+		c.Check(config.Validate(), gc.ErrorMatches, test.err)
+		_, err := utils.NewBackoff(config)
+		c.Check(err, gc.ErrorMatches, "invalid backoff config: "+test.err)
+	}
+}
+
+func (*backoffSuite) TestNext(c *gc.C) {
+	b, err := utils.NewBackoff(utils.BackoffConfig{
+		Initial: time.Second,
+		Max:     10 * time.Second,
+		Factor:  2,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	for i, expected := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	} {
+		c.Logf("wait %d", i)
+		c.Check(b.Next(), gc.Equals, expected)
+	}
+	b.Reset()
+	c.Assert(b.Next(), gc.Equals, time.Second)
+}
+
+func (*backoffSuite) TestJitter(c *gc.C) {
+	b, err := utils.NewBackoff(utils.BackoffConfig{
+		Initial: 10 * time.Second,
+		Max:     10 * time.Second,
+		Factor:  1,
+		Jitter:  0.2,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	for i := 0; i < 100; i++ {
+		wait := b.Next()
+		c.Assert(wait >= 8*time.Second, jc.IsTrue, gc.Commentf("wait %v", wait))
+		c.Assert(wait <= 12*time.Second, jc.IsTrue, gc.Commentf("wait %v", wait))
+	}
+}
+
+func (*backoffSuite) TestWait(c *gc.C) {
+	clk := testclock.NewClock(time.Time{})
+	b, err := utils.NewBackoff(utils.BackoffConfig{
+		Initial: time.Second,
+		Max:     time.Minute,
+		Factor:  2,
+		Clock:   clk,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(context.Background())
+	}()
+	c.Assert(clk.WaitAdvance(time.Second, testing.LongWait, 1), jc.ErrorIsNil)
+	select {
+	case err := <-done:
+		c.Assert(err, jc.ErrorIsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for backoff")
+	}
+	c.Assert(b.Next(), gc.Equals, 2*time.Second)
+}
+
+func (*backoffSuite) TestWaitContextDone(c *gc.C) {
+	b, err := utils.NewBackoff(utils.BackoffConfig{
+		Initial: time.Hour,
+		Max:     time.Hour,
+		Factor:  1,
+		Clock:   testclock.NewClock(time.Time{}),
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(b.Wait(ctx), gc.Equals, context.Canceled)
+}
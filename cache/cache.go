@@ -3,7 +3,9 @@
 
 // Package cache provides a simple caching mechanism
 // that limits the age of cache entries and tries to avoid large
-// repopulation events by staggering refresh times.
+// repopulation events by staggering refresh times. It also
+// provides LRU, a cache with a bounded size, and TTLCache, in which
+// each entry has its own time to live.
 package cache
 
 import (
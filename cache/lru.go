@@ -0,0 +1,201 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"gopkg.in/errgo.v1"
+)
+
+// LRUConfig holds the configuration of an LRU.
+type LRUConfig struct {
+	// MaxEntries holds the maximum number of entries in the cache. If
+	// it is zero, the number of entries is not limited.
+	MaxEntries int
+
+	// MaxCost holds the maximum total cost of the entries in the
+	// cache, as measured by Cost. If it is zero, the cost is not
+	// limited.
+	MaxCost int64
+
+	// Cost returns the cost of an entry, typically its size in
+	// bytes. It must be set if MaxCost is set.
+	Cost func(key Key, value interface{}) int64
+
+	// OnEvict, if not nil, is called with each entry that is evicted
+	// to keep the cache within its limits. It is not called for
+	// entries that are removed or replaced explicitly. It is called
+	// without the cache's lock held, so it may use the cache.
+	OnEvict func(key Key, value interface{})
+}
+
+// Validate returns an error if the configuration cannot be used to
+// create an LRU.
+func (config LRUConfig) Validate() error {
+	if config.MaxEntries < 0 {
+		return errgo.Newf("negative MaxEntries")
+	}
+	if config.MaxCost < 0 {
+		return errgo.Newf("negative MaxCost")
+	}
+	if config.MaxEntries == 0 && config.MaxCost == 0 {
+		return errgo.Newf("neither MaxEntries nor MaxCost set")
+	}
+	if config.MaxCost > 0 && config.Cost == nil {
+		return errgo.Newf("MaxCost set without Cost")
+	}
+	return nil
+}
+
+// LRUStats holds statistics about the use of an LRU.
+type LRUStats struct {
+	// Hits and Misses hold the number of calls to Get that found
+	// and didn't find an entry.
+	Hits, Misses int64
+
+	// Evictions holds the number of entries evicted to keep the
+	// cache within its limits.
+	Evictions int64
+}
+
+// LRU is a cache that holds a bounded number of entries, or entries of
+// a bounded total cost, evicting the least recently used entries to
+// make room for new ones. It is safe for concurrent use.
+type LRU struct {
+	config LRUConfig
+
+	// mu guards the fields below it.
+	mu      sync.Mutex
+	entries map[Key]*list.Element
+	// order holds the entries, most recently used first.
+	order *list.List
+	cost  int64
+	stats LRUStats
+}
+
+// lruEntry holds an entry in an LRU.
+type lruEntry struct {
+	key   Key
+	value interface{}
+	cost  int64
+}
+
+// NewLRU returns a new LRU with the given configuration.
+func NewLRU(config LRUConfig) (*LRU, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errgo.Notef(err, "invalid LRU config")
+	}
+	return &LRU{
+		config:  config,
+		entries: make(map[Key]*list.Element),
+		order:   list.New(),
+	}, nil
+}
+
+// Get returns the value for the given key and whether it was found,
+// marking the entry as recently used.
+func (c *LRU) Get(key Key) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).value, true
+}
+
+// Add adds or replaces the value for the given key, evicting other
+// entries if needed, and reports whether the value was added. A value
+// that costs more than MaxCost on its own is not added, and any
+// existing entry for the key is removed.
+func (c *LRU) Add(key Key, value interface{}) bool {
+	var cost int64
+	if c.config.Cost != nil {
+		cost = c.config.Cost(key, value)
+	}
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	if c.config.MaxCost > 0 && cost > c.config.MaxCost {
+		c.mu.Unlock()
+		return false
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{
+		key:   key,
+		value: value,
+		cost:  cost,
+	})
+	c.cost += cost
+	var evicted []*lruEntry
+	for c.overLimit() {
+		e := c.removeElement(c.order.Back())
+		c.stats.Evictions++
+		evicted = append(evicted, e)
+	}
+	c.mu.Unlock()
+
+	if c.config.OnEvict != nil {
+		for _, e := range evicted {
+			c.config.OnEvict(e.key, e.value)
+		}
+	}
+	return true
+}
+
+// Remove removes the entry with the given key from the cache, and
+// reports whether it was present.
+func (c *LRU) Remove(key Key) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if ok {
+		c.removeElement(elem)
+	}
+	return ok
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Cost returns the total cost of the entries in the cache.
+func (c *LRU) Cost() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cost
+}
+
+// Stats returns statistics about the use of the cache.
+func (c *LRU) Stats() LRUStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// overLimit reports whether the cache holds more entries, or more
+// cost, than its configuration allows. Called with c.mu held.
+func (c *LRU) overLimit() bool {
+	if c.config.MaxEntries > 0 && len(c.entries) > c.config.MaxEntries {
+		return true
+	}
+	return c.config.MaxCost > 0 && c.cost > c.config.MaxCost
+}
+
+// removeElement removes the given element from the cache and returns
+// its entry. Called with c.mu held.
+func (c *LRU) removeElement(elem *list.Element) *lruEntry {
+	e := c.order.Remove(elem).(*lruEntry)
+	delete(c.entries, e.key)
+	c.cost -= e.cost
+	return e
+}
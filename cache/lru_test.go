@@ -0,0 +1,178 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package cache_test
+
+import (
+	"fmt"
+	"sync"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/cache"
+)
+
+type lruSuite struct{}
+
+var _ = gc.Suite(&lruSuite{})
+
+func (*lruSuite) TestValidate(c *gc.C) {
+	cost := func(cache.Key, interface{}) int64 { return 1 }
+	for i, test := range []struct {
+		config cache.LRUConfig
+		err    string
+	}{{
+		config: cache.LRUConfig{},
+		err:    "neither MaxEntries nor MaxCost set",
+	}, {
+		config: cache.LRUConfig{MaxEntries: -1},
+		err:    "negative MaxEntries",
+	}, {
+		config: cache.LRUConfig{MaxCost: -1, Cost: cost},
+		err:    "negative MaxCost",
+	}, {
+		config: cache.LRUConfig{MaxCost: 10},
+		err:    "MaxCost set without Cost",
+	}} {
+		c.Logf("test %d", i)
+		c.Check(test.config.Validate(), gc.ErrorMatches, test.err)
+		_, err := cache.NewLRU(test.config)
+		c.Check(err, gc.ErrorMatches, "invalid LRU config: "+test.err)
+	}
+}
+
+func (*lruSuite) TestMaxEntries(c *gc.C) {
+	var evicted []string
+	lru, err := cache.NewLRU(cache.LRUConfig{
+		MaxEntries: 2,
+		OnEvict: func(key cache.Key, value interface{}) {
+			evicted = append(evicted, fmt.Sprintf("%v=%v", key, value))
+		},
+	})
+	c.Assert(err, gc.IsNil)
+
+	c.Assert(lru.Add("a", 1), gc.Equals, true)
+	c.Assert(lru.Add("b", 2), gc.Equals, true)
+	// Using a makes b the least recently used.
+	v, ok := lru.Get("a")
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(v, gc.Equals, 1)
+	c.Assert(lru.Add("c", 3), gc.Equals, true)
+
+	c.Assert(lru.Len(), gc.Equals, 2)
+	c.Assert(evicted, gc.DeepEquals, []string{"b=2"})
+	_, ok = lru.Get("b")
+	c.Assert(ok, gc.Equals, false)
+	v, ok = lru.Get("c")
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(v, gc.Equals, 3)
+
+	c.Assert(lru.Stats(), gc.Equals, cache.LRUStats{
+		Hits:      2,
+		Misses:    1,
+		Evictions: 1,
+	})
+}
+
+func (*lruSuite) TestReplace(c *gc.C) {
+	evictions := 0
+	lru, err := cache.NewLRU(cache.LRUConfig{
+		MaxEntries: 2,
+		OnEvict: func(cache.Key, interface{}) {
+			evictions++
+		},
+	})
+	c.Assert(err, gc.IsNil)
+	lru.Add("a", 1)
+	lru.Add("b", 2)
+	lru.Add("a", 3)
+	c.Assert(lru.Len(), gc.Equals, 2)
+	c.Assert(evictions, gc.Equals, 0)
+	v, _ := lru.Get("a")
+	c.Assert(v, gc.Equals, 3)
+
+	// Replacing a made it the most recently used.
+	lru.Add("c", 4)
+	_, ok := lru.Get("b")
+	c.Assert(ok, gc.Equals, false)
+	c.Assert(evictions, gc.Equals, 1)
+}
+
+func (*lruSuite) TestMaxCost(c *gc.C) {
+	var evicted []cache.Key
+	lru, err := cache.NewLRU(cache.LRUConfig{
+		MaxCost: 10,
+		Cost: func(key cache.Key, value interface{}) int64 {
+			return int64(len(value.(string)))
+		},
+		OnEvict: func(key cache.Key, value interface{}) {
+			evicted = append(evicted, key)
+		},
+	})
+	c.Assert(err, gc.IsNil)
+
+	c.Assert(lru.Add("a", "1234"), gc.Equals, true)
+	c.Assert(lru.Add("b", "1234"), gc.Equals, true)
+	c.Assert(lru.Cost(), gc.Equals, int64(8))
+	c.Assert(lru.Add("c", "123456"), gc.Equals, true)
+	c.Assert(evicted, gc.DeepEquals, []cache.Key{"a"})
+	c.Assert(lru.Cost(), gc.Equals, int64(10))
+
+	// An entry that could never fit isn't added, and the old value
+	// for the key goes.
+	c.Assert(lru.Add("c", "12345678901"), gc.Equals, false)
+	c.Assert(lru.Len(), gc.Equals, 1)
+	c.Assert(lru.Cost(), gc.Equals, int64(4))
+	c.Assert(evicted, gc.HasLen, 1)
+}
+
+func (*lruSuite) TestRemove(c *gc.C) {
+	evictions := 0
+	lru, err := cache.NewLRU(cache.LRUConfig{
+		MaxEntries: 2,
+		OnEvict: func(cache.Key, interface{}) {
+			evictions++
+		},
+	})
+	c.Assert(err, gc.IsNil)
+	lru.Add("a", 1)
+	c.Assert(lru.Remove("a"), gc.Equals, true)
+	c.Assert(lru.Remove("a"), gc.Equals, false)
+	c.Assert(lru.Len(), gc.Equals, 0)
+	c.Assert(evictions, gc.Equals, 0)
+}
+
+func (*lruSuite) TestOnEvictCanUseCache(c *gc.C) {
+	var lru *cache.LRU
+	lru, err := cache.NewLRU(cache.LRUConfig{
+		MaxEntries: 1,
+		OnEvict: func(key cache.Key, value interface{}) {
+			lru.Len()
+		},
+	})
+	c.Assert(err, gc.IsNil)
+	lru.Add("a", 1)
+	lru.Add("b", 2)
+	c.Assert(lru.Stats().Evictions, gc.Equals, int64(1))
+}
+
+func (*lruSuite) TestConcurrentUse(c *gc.C) {
+	lru, err := cache.NewLRU(cache.LRUConfig{MaxEntries: 10})
+	c.Assert(err, gc.IsNil)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				lru.Add(i*100+j, j)
+				lru.Get(i*100 + j - 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	c.Assert(lru.Len(), gc.Equals, 10)
+	stats := lru.Stats()
+	c.Assert(stats.Hits+stats.Misses, gc.Equals, int64(1000))
+	c.Assert(stats.Evictions, gc.Equals, int64(990))
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/clock"
+	"gopkg.in/errgo.v1"
+)
+
+// ErrNotFound is returned by TTLCache.Get when there is no entry for a
+// key and the cache has no Loader.
+var ErrNotFound = errgo.New("cache entry not found")
+
+// TTLConfig holds the configuration of a TTLCache.
+type TTLConfig struct {
+	// DefaultTTL is how long entries stay in the cache when no other
+	// duration is given. It must be positive.
+	DefaultTTL time.Duration
+
+	// Loader, if not nil, is called by Get to load the value for a
+	// key that isn't in the cache. It returns the value and how long
+	// it should stay in the cache; if ttl is zero or negative,
+	// DefaultTTL is used.
+	Loader func(key Key) (value interface{}, ttl time.Duration, err error)
+
+	// ExpiryInterval, if positive, is the interval at which a
+	// background goroutine removes expired entries; Close must be
+	// called to stop it. Otherwise expired entries are only removed
+	// when they are accessed or by calling RemoveExpired.
+	ExpiryInterval time.Duration
+
+	// Clock is used to tell the time. If it is nil, clock.WallClock
+	// is used.
+	Clock clock.Clock
+}
+
+// Validate returns an error if the configuration cannot be used to
+// create a TTLCache.
+func (config TTLConfig) Validate() error {
+	if config.DefaultTTL <= 0 {
+		return errgo.Newf("non-positive DefaultTTL")
+	}
+	if config.ExpiryInterval < 0 {
+		return errgo.Newf("negative ExpiryInterval")
+	}
+	return nil
+}
+
+// TTLCache is a cache in which each entry expires after its own time to
+// live. With a Loader it acts as a read-through cache, loading each
+// missing key only once however many callers ask for it at the same
+// time. It is safe for concurrent use.
+type TTLCache struct {
+	config TTLConfig
+	clock  clock.Clock
+	done   chan struct{}
+
+	// mu guards the fields below it.
+	mu       sync.Mutex
+	entries  map[Key]entry
+	inFlight map[Key]*fetchCall
+	closed   bool
+}
+
+// NewTTLCache returns a new TTLCache with the given configuration.
+func NewTTLCache(config TTLConfig) (*TTLCache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errgo.Notef(err, "invalid TTL cache config")
+	}
+	clk := config.Clock
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	c := &TTLCache{
+		config:   config,
+		clock:    clk,
+		done:     make(chan struct{}),
+		entries:  make(map[Key]entry),
+		inFlight: make(map[Key]*fetchCall),
+	}
+	if config.ExpiryInterval > 0 {
+		go c.expireLoop()
+	}
+	return c, nil
+}
+
+// Close stops the background expiry goroutine, if there is one.
+func (c *TTLCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+}
+
+// Lookup returns the unexpired value for the given key and whether it
+// was found. It never calls the Loader.
+func (c *TTLCache) Lookup(key Key) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookup(key, c.clock.Now())
+}
+
+// Get returns the unexpired value for the given key, loading it with
+// the Loader if it is not in the cache. Errors from the Loader are
+// returned with their cause preserved, and are not cached. Without a
+// Loader, Get returns an error with an ErrNotFound cause for missing
+// keys. If the Loader panics, the panic is propagated, and any Get
+// calls waiting for the same key return an error.
+func (c *TTLCache) Get(key Key) (interface{}, error) {
+	c.mu.Lock()
+	if val, ok := c.lookup(key, c.clock.Now()); ok {
+		c.mu.Unlock()
+		return val, nil
+	}
+	if c.config.Loader == nil {
+		c.mu.Unlock()
+		return nil, errgo.WithCausef(nil, ErrNotFound, "cache entry %v not found", key)
+	}
+	if f, ok := c.inFlight[key]; ok {
+		// There's already an in-flight load of the key, so wait
+		// for that to complete and use its results.
+		c.mu.Unlock()
+		f.wg.Wait()
+		if f.err == nil {
+			return f.val, nil
+		}
+		return nil, errgo.Mask(f.err, errgo.Any)
+	}
+	var f fetchCall
+	f.wg.Add(1)
+	c.inFlight[key] = &f
+	defer f.wg.Done()
+
+	// Load the value without the mutex held so that one slow load
+	// doesn't hold up all the other cache accesses.
+	c.mu.Unlock()
+	loaded := false
+	defer func() {
+		if loaded {
+			return
+		}
+		// The Loader panicked. Let the waiters go and allow the key
+		// to be loaded again, leaving the panic to carry on.
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		f.err = errgo.Newf("cache entry %v load panicked", key)
+		delete(c.inFlight, key)
+	}()
+	val, ttl, err := c.config.Loader(key)
+	loaded = true
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f.val, f.err = val, err
+	delete(c.inFlight, key)
+	if err != nil {
+		return nil, errgo.Mask(err, errgo.Any)
+	}
+	c.set(key, val, ttl)
+	return val, nil
+}
+
+// Set adds or replaces the value for the given key, to expire after the
+// given time to live. If ttl is zero or negative, DefaultTTL is used.
+func (c *TTLCache) Set(key Key, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+}
+
+// Remove removes the entry with the given key from the cache if
+// present.
+func (c *TTLCache) Remove(key Key) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Len returns the number of entries in the cache, including any that
+// have expired but not yet been removed.
+func (c *TTLCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// RemoveExpired removes all expired entries from the cache.
+func (c *TTLCache) RemoveExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	for key, e := range c.entries {
+		if now.After(e.expire) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// lookup returns the unexpired value for the given key, deleting it if
+// it has expired. Called with c.mu held.
+func (c *TTLCache) lookup(key Key, now time.Time) (interface{}, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if now.After(e.expire) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// set stores the value for the given key. Called with c.mu held.
+func (c *TTLCache) set(key Key, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = c.config.DefaultTTL
+	}
+	c.entries[key] = entry{
+		value:  value,
+		expire: c.clock.Now().Add(ttl),
+	}
+}
+
+func (c *TTLCache) expireLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.clock.After(c.config.ExpiryInterval):
+			c.RemoveExpired()
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package cache_test
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/errgo.v1"
+
+	"github.com/juju/utils/cache"
+)
+
+type ttlSuite struct {
+	clock *testclock.Clock
+}
+
+var _ = gc.Suite(&ttlSuite{})
+
+func (s *ttlSuite) SetUpTest(c *gc.C) {
+	s.clock = testclock.NewClock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func (s *ttlSuite) newCache(c *gc.C, config cache.TTLConfig) *cache.TTLCache {
+	config.Clock = s.clock
+	if config.DefaultTTL == 0 {
+		config.DefaultTTL = time.Minute
+	}
+	ttlCache, err := cache.NewTTLCache(config)
+	c.Assert(err, gc.IsNil)
+	return ttlCache
+}
+
+func (*ttlSuite) TestValidate(c *gc.C) {
+	for i, test := range []struct {
+		config cache.TTLConfig
+		err    string
+	}{{
+		config: cache.TTLConfig{},
+		err:    "non-positive DefaultTTL",
+	}, {
+		config: cache.TTLConfig{DefaultTTL: time.Second, ExpiryInterval: -1},
+		err:    "negative ExpiryInterval",
+	}} {
+		c.Logf("test %d", i)
+		c.Check(test.config.Validate(), gc.ErrorMatches, test.err)
+		_, err := cache.NewTTLCache(test.config)
+		c.Check(err, gc.ErrorMatches, "invalid TTL cache config: "+test.err)
+	}
+}
+
+func (s *ttlSuite) TestSetAndExpire(c *gc.C) {
+	ttlCache := s.newCache(c, cache.TTLConfig{})
+	ttlCache.Set("a", 1, 0)
+	ttlCache.Set("b", 2, time.Hour)
+
+	v, ok := ttlCache.Lookup("a")
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(v, gc.Equals, 1)
+
+	s.clock.Advance(time.Minute + time.Second)
+	_, ok = ttlCache.Lookup("a")
+	c.Assert(ok, gc.Equals, false)
+	v, err := ttlCache.Get("b")
+	c.Assert(err, gc.IsNil)
+	c.Assert(v, gc.Equals, 2)
+	c.Assert(ttlCache.Len(), gc.Equals, 1)
+
+	ttlCache.Remove("b")
+	_, err = ttlCache.Get("b")
+	c.Assert(err, gc.ErrorMatches, "cache entry b not found")
+	c.Assert(errgo.Cause(err), gc.Equals, cache.ErrNotFound)
+}
+
+func (s *ttlSuite) TestRemoveExpired(c *gc.C) {
+	ttlCache := s.newCache(c, cache.TTLConfig{})
+	ttlCache.Set("a", 1, time.Second)
+	ttlCache.Set("b", 2, time.Hour)
+	s.clock.Advance(time.Minute)
+	c.Assert(ttlCache.Len(), gc.Equals, 2)
+	ttlCache.RemoveExpired()
+	c.Assert(ttlCache.Len(), gc.Equals, 1)
+}
+
+func (s *ttlSuite) TestBackgroundExpiry(c *gc.C) {
+	ttlCache := s.newCache(c, cache.TTLConfig{ExpiryInterval: time.Minute})
+	defer ttlCache.Close()
+	ttlCache.Set("a", 1, time.Second)
+
+	err := s.clock.WaitAdvance(time.Minute, testing.LongWait, 1)
+	c.Assert(err, gc.IsNil)
+	deadline := time.Now().Add(testing.LongWait)
+	for ttlCache.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(ttlCache.Len(), gc.Equals, 0)
+	ttlCache.Close()
+	ttlCache.Close()
+}
+
+func (s *ttlSuite) TestLoader(c *gc.C) {
+	loads := 0
+	ttlCache := s.newCache(c, cache.TTLConfig{
+		Loader: func(key cache.Key) (interface{}, time.Duration, error) {
+			loads++
+			if key == "bad" {
+				return nil, 0, errgo.New("bad key")
+			}
+			return loads, 10 * time.Second, nil
+		},
+	})
+	v, err := ttlCache.Get("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(v, gc.Equals, 1)
+	v, err = ttlCache.Get("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(v, gc.Equals, 1)
+
+	// The loader's TTL is used.
+	s.clock.Advance(11 * time.Second)
+	v, err = ttlCache.Get("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(v, gc.Equals, 2)
+
+	// Errors aren't cached.
+	_, err = ttlCache.Get("bad")
+	c.Assert(err, gc.ErrorMatches, "bad key")
+	_, err = ttlCache.Get("bad")
+	c.Assert(err, gc.ErrorMatches, "bad key")
+	c.Assert(loads, gc.Equals, 4)
+}
+
+func (s *ttlSuite) TestConcurrentLoads(c *gc.C) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loads := 0
+	ttlCache := s.newCache(c, cache.TTLConfig{
+		Loader: func(key cache.Key) (interface{}, time.Duration, error) {
+			loads++
+			close(started)
+			<-release
+			return "value", 0, nil
+		},
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := ttlCache.Get("a")
+			c.Check(err, gc.IsNil)
+			c.Check(v, gc.Equals, "value")
+		}()
+	}
+	<-started
+	close(release)
+	wg.Wait()
+	c.Assert(loads, gc.Equals, 1)
+}
+
+func (s *ttlSuite) TestLoaderPanic(c *gc.C) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loads := 0
+	ttlCache := s.newCache(c, cache.TTLConfig{
+		Loader: func(key cache.Key) (interface{}, time.Duration, error) {
+			loads++
+			if loads == 1 {
+				close(started)
+				<-release
+				panic("loader failed")
+			}
+			return "value", 0, nil
+		},
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			c.Check(recover(), gc.Equals, "loader failed")
+		}()
+		ttlCache.Get("a")
+	}()
+	<-started
+	waited := make(chan error)
+	go func() {
+		_, err := ttlCache.Get("a")
+		waited <- err
+	}()
+	// Give the second Get a chance to start waiting for the first.
+	time.Sleep(testing.ShortWait)
+	close(release)
+	<-done
+	c.Assert(<-waited, gc.ErrorMatches, "cache entry a load panicked")
+
+	// The key isn't left in flight, so it can be loaded again.
+	v, err := ttlCache.Get("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(v, gc.Equals, "value")
+}
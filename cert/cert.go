@@ -5,6 +5,9 @@
 package cert
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha1"
@@ -37,6 +40,20 @@ type GeneralNames struct {
 	GeneralName `asn1:"tag:0"`
 }
 
+// KeyType identifies the type of private key generated for a
+// certificate.
+type KeyType string
+
+const (
+	// KeyTypeRSA selects an RSA key, with the size given by
+	// Config.KeyBits. This is the default.
+	KeyTypeRSA KeyType = "rsa"
+
+	// KeyTypeECDSA selects an ECDSA key. Config.KeyBits selects the
+	// curve: 256 (the default), 384 or 521.
+	KeyTypeECDSA KeyType = "ecdsa"
+)
+
 // Config type used for specifing different params for NewLeaf func
 // This will effect the generation of certificates.
 type Config struct {
@@ -50,6 +67,7 @@ type Config struct {
 	ExtKeyUsage []x509.ExtKeyUsage // ExtKeyUsage extra flags for special usage of the cert
 	KeyBits     int                // KeyBits is used to set the lenght of the RSA key, default value 2048 bytes
 	Client      bool               // generate client certificate for certificate authentication
+	KeyType     KeyType            // KeyType is the type of key to generate, default KeyTypeRSA
 }
 
 // NewLeaf generates a certificate/key pair suitable for use
@@ -58,7 +76,7 @@ type Config struct {
 func NewLeaf(cfg *Config) (certPEM, keyPEM string, err error) {
 	var (
 		caCert *x509.Certificate
-		caKey  *rsa.PrivateKey
+		caKey  crypto.Signer
 	)
 
 	if cfg.CA != nil && cfg.CAKey != nil && !cfg.IsCA {
@@ -77,20 +95,18 @@ func NewLeaf(cfg *Config) (certPEM, keyPEM string, err error) {
 		if !caCert.BasicConstraintsValid || !caCert.IsCA {
 			return "", "", errors.Errorf("CA certificate is not a valid CA")
 		}
-		var ok bool
-		caKey, ok = tlsCert.PrivateKey.(*rsa.PrivateKey)
-		if !ok {
+		switch key := tlsCert.PrivateKey.(type) {
+		case *rsa.PrivateKey:
+			caKey = key
+		case *ecdsa.PrivateKey:
+			caKey = key
+		default:
 			return "", "", errors.Errorf("CA private key has unexpected type %T", tlsCert.PrivateKey)
 		}
 	}
 
-	// if none assign default
-	if cfg.KeyBits == 0 {
-		cfg.KeyBits = 2048
-	}
-
 	// generate private key
-	key, err := rsa.GenerateKey(rand.Reader, cfg.KeyBits)
+	key, err := generateKey(cfg)
 	if err != nil {
 		return "", "", errors.Errorf("cannot generate key: %v", err)
 	}
@@ -121,7 +137,7 @@ func NewLeaf(cfg *Config) (certPEM, keyPEM string, err error) {
 		NotBefore:    now.UTC().AddDate(0, 0, -7),
 		Version:      2,
 		NotAfter:     cfg.Expiry.UTC(),
-		SubjectKeyId: bigIntHash(key.N),
+		SubjectKeyId: subjectKeyId(key),
 		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyAgreement,
 		ExtKeyUsage:  cfg.ExtKeyUsage,
 	}
@@ -138,6 +154,11 @@ func NewLeaf(cfg *Config) (certPEM, keyPEM string, err error) {
 		template.KeyUsage = x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign
 	}
 
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		// Key encipherment is only meaningful for RSA keys.
+		template.KeyUsage &^= x509.KeyUsageKeyEncipherment
+	}
+
 	for _, hostname := range cfg.Hostnames {
 		if ip := net.ParseIP(hostname); ip != nil {
 			template.IPAddresses = append(template.IPAddresses, ip)
@@ -161,13 +182,76 @@ func NewLeaf(cfg *Config) (certPEM, keyPEM string, err error) {
 		Bytes: certDER,
 	})
 
-	keyPEMData := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(key),
-	})
+	keyBlock, err := keyPEMBlock(key)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	keyPEMData := pem.EncodeToMemory(keyBlock)
 	return string(certPEMData), string(keyPEMData), nil
 }
 
+// generateKey generates a private key of the type and size given in
+// cfg.
+func generateKey(cfg *Config) (crypto.Signer, error) {
+	switch cfg.KeyType {
+	case "", KeyTypeRSA:
+		// if none assign default
+		if cfg.KeyBits == 0 {
+			cfg.KeyBits = 2048
+		}
+		return rsa.GenerateKey(rand.Reader, cfg.KeyBits)
+	case KeyTypeECDSA:
+		var curve elliptic.Curve
+		switch cfg.KeyBits {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, errors.NotSupportedf("ECDSA key size %d", cfg.KeyBits)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	}
+	return nil, errors.NotSupportedf("key type %q", cfg.KeyType)
+}
+
+// keyPEMBlock returns the PEM block holding the given private key.
+func keyPEMBlock(key crypto.Signer) (*pem.Block, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return &pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		}, nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: der,
+		}, nil
+	}
+	return nil, errors.Errorf("private key has unexpected type %T", key)
+}
+
+// subjectKeyId returns the subject key identifier for the given
+// private key.
+func subjectKeyId(key crypto.Signer) []byte {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return bigIntHash(key.N)
+	case *ecdsa.PrivateKey:
+		h := sha1.New()
+		h.Write(elliptic.Marshal(key.Curve, key.X, key.Y))
+		return h.Sum(nil)
+	}
+	return nil
+}
+
 var (
 	// https://support.microsoft.com/en-us/kb/287547
 	//  szOID_NT_PRINCIPAL_NAME 1.3.6.1.4.1.311.20.2.3
@@ -193,6 +277,32 @@ func NewCA(commonName, UUID string, expiry time.Time, keyBits int) (certPEM, key
 	return
 }
 
+// NewServer generates a certificate/key pair suitable for use by a
+// server with the given hostnames, signed by the given CA. Hostnames
+// that are IP addresses are added to the certificate as IP address
+// SANs, and the others as DNS name SANs.
+func NewServer(caCertPEM, caKeyPEM string, expiry time.Time, hostnames []string) (certPEM, keyPEM string, err error) {
+	return NewServerWithKeyType(caCertPEM, caKeyPEM, expiry, hostnames, KeyTypeRSA)
+}
+
+// NewServerWithKeyType is like NewServer, but generates a key of the
+// given type, using its default size.
+func NewServerWithKeyType(caCertPEM, caKeyPEM string, expiry time.Time, hostnames []string, keyType KeyType) (certPEM, keyPEM string, err error) {
+	certPEM, keyPEM, err = NewLeaf(&Config{
+		CommonName:  "*",
+		CA:          []byte(caCertPEM),
+		CAKey:       []byte(caKeyPEM),
+		Expiry:      expiry,
+		Hostnames:   hostnames,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyType:     keyType,
+	})
+	if err != nil {
+		return "", "", errors.Annotatef(err, "cannot generate server certificate")
+	}
+	return certPEM, keyPEM, nil
+}
+
 // NewClientCert generates a x509 client certificate used for https authentication sessions.
 func NewClientCert(commonName, UUID string, expiry time.Time, keyBits int) (certPEM string, keyPEM string, err error) {
 	certPEM, keyPEM, err = NewLeaf(&Config{
@@ -246,11 +356,13 @@ func newSerialNumber() (*big.Int, error) {
 }
 
 // getPublicKey fetch public key from a PrivateKey type
-// this will return nil if the key is not RSA type
+// this will return nil if the key is not RSA or ECDSA type
 func getPublicKey(p interface{}) interface{} {
 	switch t := p.(type) {
 	case *rsa.PrivateKey:
 		return t.Public()
+	case *ecdsa.PrivateKey:
+		return t.Public()
 	default:
 		return nil
 	}
@@ -274,8 +386,23 @@ func ParseCert(certPEM string) (*x509.Certificate, error) {
 }
 
 // ParseCertAndKey parses the given PEM-formatted X509 certificate
-// and RSA private key.
+// and RSA private key. Use ParseCertAndSigner for keys that may be
+// ECDSA.
 func ParseCertAndKey(certPEM, keyPEM string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	cert, signer, err := ParseCertAndSigner(certPEM, keyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("private key with unexpected type %T", signer)
+	}
+	return cert, key, nil
+}
+
+// ParseCertAndSigner parses the given PEM-formatted X509 certificate
+// and RSA or ECDSA private key, as created by NewLeaf.
+func ParseCertAndSigner(certPEM, keyPEM string) (*x509.Certificate, crypto.Signer, error) {
 	tlsCert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
 	if err != nil {
 		return nil, nil, err
@@ -286,9 +413,11 @@ func ParseCertAndKey(certPEM, keyPEM string) (*x509.Certificate, *rsa.PrivateKey
 		return nil, nil, err
 	}
 
-	key, ok := tlsCert.PrivateKey.(*rsa.PrivateKey)
-	if !ok {
-		return nil, nil, fmt.Errorf("private key with unexpected type %T", key)
+	switch key := tlsCert.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		return cert, key, nil
+	case *ecdsa.PrivateKey:
+		return cert, key, nil
 	}
-	return cert, key, nil
+	return nil, nil, fmt.Errorf("private key with unexpected type %T", tlsCert.PrivateKey)
 }
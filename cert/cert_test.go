@@ -6,6 +6,7 @@ package cert_test
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
@@ -69,6 +70,26 @@ func (certSuite) TestParseCertAndKey(c *gc.C) {
 	c.Assert(xcert.PublicKey.(*rsa.PublicKey), gc.DeepEquals, &key.PublicKey)
 }
 
+func (certSuite) TestParseCertAndSigner(c *gc.C) {
+	xcert, key, err := cert.ParseCertAndSigner(caCertPEM, caKeyPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(xcert.PublicKey.(*rsa.PublicKey), gc.DeepEquals, &key.(*rsa.PrivateKey).PublicKey)
+
+	ecCertPEM, ecKeyPEM, err := cert.NewLeaf(&cert.Config{
+		CommonName: "ecdsa CA",
+		Expiry:     time.Now().AddDate(1, 0, 0),
+		IsCA:       true,
+		KeyType:    cert.KeyTypeECDSA,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	xcert, key, err = cert.ParseCertAndSigner(ecCertPEM, ecKeyPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(xcert.PublicKey.(*ecdsa.PublicKey), gc.DeepEquals, &key.(*ecdsa.PrivateKey).PublicKey)
+
+	_, _, err = cert.ParseCertAndKey(ecCertPEM, ecKeyPEM)
+	c.Assert(err, gc.ErrorMatches, `private key with unexpected type \*ecdsa.PrivateKey`)
+}
+
 func (certSuite) TestNewCA(c *gc.C) {
 	now := time.Now()
 	expiry := roundTime(now.AddDate(0, 0, 1))
@@ -90,6 +111,87 @@ func (certSuite) TestNewCA(c *gc.C) {
 	//c.Assert(caCert.MaxPathLen, Equals, 0)	TODO it ends up as -1 - check that this is ok.
 }
 
+func (certSuite) TestNewServer(c *gc.C) {
+	now := time.Now()
+	expiry := roundTime(now.AddDate(1, 0, 0))
+	caCertPEM, caKeyPEM, err := cert.NewCA("foo", "1", expiry, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	caCert, err := cert.ParseCert(caCertPEM)
+	c.Assert(err, jc.ErrorIsNil)
+
+	srvCertPEM, srvKeyPEM, err := cert.NewServer(caCertPEM, caKeyPEM, expiry, []string{"anyServer", "10.0.0.1"})
+	c.Assert(err, jc.ErrorIsNil)
+	checkCertificate(c, caCert, srvCertPEM, srvKeyPEM, now, expiry)
+
+	srvCert, err := cert.ParseCert(srvCertPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(srvCert.DNSNames, jc.DeepEquals, []string{"anyServer"})
+	c.Assert(srvCert.IPAddresses, gc.HasLen, 1)
+	c.Assert(srvCert.IPAddresses[0].String(), gc.Equals, "10.0.0.1")
+}
+
+func (certSuite) TestNewServerWithInvalidCA(c *gc.C) {
+	expiry := time.Now().AddDate(1, 0, 0)
+	leafCertPEM, leafKeyPEM, err := cert.NewClientCert("foo", "1", expiry, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	_, _, err = cert.NewServer(leafCertPEM, leafKeyPEM, expiry, nil)
+	c.Assert(err, gc.ErrorMatches, "cannot generate server certificate: CA certificate is not a valid CA")
+}
+
+func (certSuite) TestNewLeafECDSA(c *gc.C) {
+	now := time.Now()
+	expiry := roundTime(now.AddDate(1, 0, 0))
+	caCertPEM, caKeyPEM, err := cert.NewLeaf(&cert.Config{
+		CommonName: "ecdsa CA",
+		Expiry:     expiry,
+		IsCA:       true,
+		KeyType:    cert.KeyTypeECDSA,
+		KeyBits:    384,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caKeyPEM, jc.Contains, "EC PRIVATE KEY")
+	caCert, err := cert.ParseCert(caCertPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(caCert.PublicKeyAlgorithm, gc.Equals, x509.ECDSA)
+	c.Assert(caCert.IsCA, jc.IsTrue)
+	c.Assert(caCert.KeyUsage&x509.KeyUsageKeyEncipherment, gc.Equals, x509.KeyUsage(0))
+
+	srvCertPEM, srvKeyPEM, err := cert.NewServerWithKeyType(caCertPEM, caKeyPEM, expiry, []string{"example.com"}, cert.KeyTypeECDSA)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = tls.X509KeyPair([]byte(srvCertPEM), []byte(srvKeyPEM))
+	c.Assert(err, jc.ErrorIsNil)
+	srvCert, err := cert.ParseCert(srvCertPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(srvCert.PublicKeyAlgorithm, gc.Equals, x509.ECDSA)
+	checkNotBefore(c, srvCert, now)
+	checkNotAfter(c, srvCert, expiry)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	_, err = srvCert.Verify(x509.VerifyOptions{
+		DNSName: "example.com",
+		Roots:   pool,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (certSuite) TestNewLeafInvalidKeyType(c *gc.C) {
+	_, _, err := cert.NewLeaf(&cert.Config{
+		CommonName: "foo",
+		Expiry:     time.Now().AddDate(1, 0, 0),
+		KeyType:    "dsa",
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot generate key: key type "dsa" not supported`)
+
+	_, _, err = cert.NewLeaf(&cert.Config{
+		CommonName: "foo",
+		Expiry:     time.Now().AddDate(1, 0, 0),
+		KeyType:    cert.KeyTypeECDSA,
+		KeyBits:    2048,
+	})
+	c.Assert(err, gc.ErrorMatches, "cannot generate key: ECDSA key size 2048 not supported")
+}
+
 func checkCertificate(c *gc.C, caCert *x509.Certificate, srvCertPEM, srvKeyPEM string, now, expiry time.Time) {
 	srvCert, srvKey, err := cert.ParseCertAndKey(srvCertPEM, srvKeyPEM)
 	c.Assert(err, jc.ErrorIsNil)
@@ -0,0 +1,129 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// ParseCertPEM parses all the X509 certificates in the given PEM
+// data, such as a certificate followed by its intermediate CAs, in
+// the order they appear. Blocks of other types are ignored.
+func ParseCertPEM(certPEM string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	data := []byte(certPEM)
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot parse certificate %d", len(certs))
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
+
+// CertExpiry returns the time at which the first certificate in the
+// given PEM data expires.
+func CertExpiry(certPEM string) (time.Time, error) {
+	cert, err := ParseCert(certPEM)
+	if err != nil {
+		return time.Time{}, errors.Trace(err)
+	}
+	return cert.NotAfter, nil
+}
+
+// WarningKind identifies the kind of problem reported by a Warning.
+type WarningKind string
+
+const (
+	// WarningNotYetValid is reported for a certificate whose validity
+	// period has not started.
+	WarningNotYetValid WarningKind = "not-yet-valid"
+
+	// WarningExpired is reported for a certificate that has expired.
+	WarningExpired WarningKind = "expired"
+
+	// WarningExpiringSoon is reported for a certificate that expires
+	// within the duration passed to CheckCertValidity.
+	WarningExpiringSoon WarningKind = "expiring-soon"
+
+	// WarningHostMismatch is reported for each expected host that the
+	// certificate is not valid for.
+	WarningHostMismatch WarningKind = "host-mismatch"
+)
+
+// Warning describes a problem found by CheckCertValidity.
+type Warning struct {
+	// Kind is the kind of problem.
+	Kind WarningKind
+
+	// Host holds the host that did not match, for warnings of kind
+	// WarningHostMismatch.
+	Host string
+
+	// Message is a human readable description of the problem.
+	Message string
+}
+
+// String implements fmt.Stringer.
+func (w Warning) String() string {
+	return w.Message
+}
+
+// CheckCertValidity checks the first certificate in the given PEM data,
+// returning a warning if it is not yet valid, has expired or expires
+// within the given duration, and one for each of the given hosts that
+// is not covered by the certificate's subject alternative names. An
+// error is returned only if the certificate cannot be parsed.
+func CheckCertValidity(certPEM string, within time.Duration, hosts ...string) ([]Warning, error) {
+	cert, err := ParseCert(certPEM)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var warnings []Warning
+	now := time.Now()
+	switch {
+	case now.Before(cert.NotBefore):
+		warnings = append(warnings, Warning{
+			Kind:    WarningNotYetValid,
+			Message: fmt.Sprintf("certificate is not valid until %s", cert.NotBefore.UTC().Format(time.RFC3339)),
+		})
+	case now.After(cert.NotAfter):
+		warnings = append(warnings, Warning{
+			Kind:    WarningExpired,
+			Message: fmt.Sprintf("certificate expired at %s", cert.NotAfter.UTC().Format(time.RFC3339)),
+		})
+	case now.Add(within).After(cert.NotAfter):
+		warnings = append(warnings, Warning{
+			Kind:    WarningExpiringSoon,
+			Message: fmt.Sprintf("certificate expires at %s", cert.NotAfter.UTC().Format(time.RFC3339)),
+		})
+	}
+	for _, host := range hosts {
+		if err := cert.VerifyHostname(host); err != nil {
+			warnings = append(warnings, Warning{
+				Kind:    WarningHostMismatch,
+				Host:    host,
+				Message: fmt.Sprintf("certificate is not valid for host %q", host),
+			})
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package cert_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/cert"
+)
+
+type inspectSuite struct{}
+
+var _ = gc.Suite(inspectSuite{})
+
+func newServerCert(c *gc.C, expiry time.Time, hostnames ...string) (caCertPEM, srvCertPEM string) {
+	caCertPEM, caKeyPEM, err := cert.NewCA("foo", "1", time.Now().AddDate(1, 0, 0), 0)
+	c.Assert(err, jc.ErrorIsNil)
+	srvCertPEM, _, err = cert.NewServer(caCertPEM, caKeyPEM, expiry, hostnames)
+	c.Assert(err, jc.ErrorIsNil)
+	return caCertPEM, srvCertPEM
+}
+
+func (inspectSuite) TestParseCertPEM(c *gc.C) {
+	caCertPEM, srvCertPEM := newServerCert(c, time.Now().AddDate(0, 1, 0), "example.com")
+	certs, err := cert.ParseCertPEM(srvCertPEM + caKeyPEM + caCertPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(certs, gc.HasLen, 2)
+	c.Assert(certs[0].Subject.CommonName, gc.Equals, "*")
+	c.Assert(certs[1].Subject.CommonName, gc.Equals, "foo")
+
+	_, err = cert.ParseCertPEM(caKeyPEM)
+	c.Assert(err, gc.ErrorMatches, "no certificates found")
+}
+
+func (inspectSuite) TestCertExpiry(c *gc.C) {
+	expiry := roundTime(time.Now().AddDate(0, 1, 0))
+	_, srvCertPEM := newServerCert(c, expiry)
+	result, err := cert.CertExpiry(srvCertPEM)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Equal(expiry), jc.IsTrue, gc.Commentf("got %v, want %v", result, expiry))
+
+	_, err = cert.CertExpiry("hello")
+	c.Assert(err, gc.ErrorMatches, "no certificates found")
+}
+
+func (inspectSuite) TestCheckCertValidity(c *gc.C) {
+	_, srvCertPEM := newServerCert(c, time.Now().AddDate(0, 1, 0), "example.com", "10.0.0.1")
+
+	warnings, err := cert.CheckCertValidity(srvCertPEM, 7*24*time.Hour, "example.com", "10.0.0.1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(warnings, gc.HasLen, 0)
+
+	warnings, err = cert.CheckCertValidity(srvCertPEM, 60*24*time.Hour, "example.com", "other.com")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(warnings, gc.HasLen, 2)
+	c.Assert(warnings[0].Kind, gc.Equals, cert.WarningExpiringSoon)
+	c.Assert(warnings[0].String(), gc.Matches, "certificate expires at .*")
+	c.Assert(warnings[1], jc.DeepEquals, cert.Warning{
+		Kind:    cert.WarningHostMismatch,
+		Host:    "other.com",
+		Message: `certificate is not valid for host "other.com"`,
+	})
+}
+
+func (inspectSuite) TestCheckCertValidityExpired(c *gc.C) {
+	_, srvCertPEM := newServerCert(c, time.Now().AddDate(0, 0, -1))
+	warnings, err := cert.CheckCertValidity(srvCertPEM, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(warnings, gc.HasLen, 1)
+	c.Assert(warnings[0].Kind, gc.Equals, cert.WarningExpired)
+}
+
+func (inspectSuite) TestCheckCertValidityInvalidPEM(c *gc.C) {
+	_, err := cert.CheckCertValidity("hello", time.Hour)
+	c.Assert(err, gc.ErrorMatches, "no certificates found")
+}
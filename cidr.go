@@ -0,0 +1,157 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"net"
+
+	"github.com/juju/errors"
+)
+
+// maxSplitSubnets holds the largest number of subnets that SplitCIDR
+// will return.
+const maxSplitSubnets = 1 << 16
+
+// ParseCIDRs parses each of the given values as a CIDR, such as
+// "10.0.0.0/8" or "2001:db8::/32".
+func ParseCIDRs(values ...string) ([]*net.IPNet, error) {
+	cidrs := make([]*net.IPNet, len(values))
+	for i, value := range values {
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		cidrs[i] = ipNet
+	}
+	return cidrs, nil
+}
+
+// IPInCIDRs reports whether ip is in any of the given networks.
+func IPInCIDRs(ip net.IP, cidrs []*net.IPNet) bool {
+	for _, ipNet := range cidrs {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CIDRsOverlap reports whether the two networks have any addresses in
+// common. Networks of different address families never overlap.
+func CIDRsOverlap(a, b *net.IPNet) bool {
+	if len(a.Mask) != len(b.Mask) {
+		return false
+	}
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// SplitCIDR splits the given network into subnets with the given
+// prefix length, returning them in order. For example, splitting
+// 10.0.0.0/23 with a prefix length of 24 gives 10.0.0.0/24 and
+// 10.0.1.0/24. It returns an error if the prefix length is shorter
+// than the network's or too long for its address family, or if there
+// would be more than 65536 subnets.
+func SplitCIDR(ipNet *net.IPNet, prefixLen int) ([]*net.IPNet, error) {
+	ones, bits := ipNet.Mask.Size()
+	if bits == 0 {
+		return nil, errors.NotValidf("non-canonical mask in %v", ipNet)
+	}
+	if prefixLen < ones || prefixLen > bits {
+		return nil, errors.NotValidf("prefix length %d for %v", prefixLen, ipNet)
+	}
+	if prefixLen-ones > 16 {
+		return nil, errors.Errorf("splitting %v into /%d subnets would give more than %d subnets", ipNet, prefixLen, maxSplitSubnets)
+	}
+	count := 1 << uint(prefixLen-ones)
+	base := ipToInt(networkIP(ipNet))
+	step := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefixLen))
+	mask := net.CIDRMask(prefixLen, bits)
+	subnets := make([]*net.IPNet, count)
+	for i := range subnets {
+		subnets[i] = &net.IPNet{
+			IP:   intToIP(base, bits/8),
+			Mask: mask,
+		}
+		base.Add(base, step)
+	}
+	return subnets, nil
+}
+
+// ForEachHost calls f with each usable host address in the given
+// network, in order, until f returns false. For IPv4 networks the
+// network and broadcast addresses are not usable, except in /31 and
+// /32 networks. For IPv6 networks the first address, the Subnet-Router
+// anycast address, is not usable, except in /127 and /128 networks.
+//
+// The IP passed to f is not reused, so it may be retained.
+func ForEachHost(ipNet *net.IPNet, f func(ip net.IP) bool) {
+	ones, bits := ipNet.Mask.Size()
+	if bits == 0 {
+		return
+	}
+	first := networkIP(ipNet)
+	last := make(net.IP, len(first))
+	for i := range first {
+		last[i] = first[i] | ^ipNet.Mask[i]
+	}
+	if bits-ones > 1 {
+		incIP(first)
+		if bits == 32 {
+			decIP(last)
+		}
+	}
+	for ip := first; ; {
+		if !f(ip) || bytes.Equal(ip, last) {
+			return
+		}
+		next := make(net.IP, len(ip))
+		copy(next, ip)
+		incIP(next)
+		ip = next
+	}
+}
+
+// networkIP returns the first address of ipNet, with the same length
+// as its mask.
+func networkIP(ipNet *net.IPNet) net.IP {
+	ip := ipNet.IP
+	if len(ipNet.Mask) == net.IPv4len {
+		ip = ip.To4()
+	}
+	return ip.Mask(ipNet.Mask)
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(ip)
+}
+
+// intToIP returns the IP address of the given length with the value n.
+func intToIP(n *big.Int, length int) net.IP {
+	b := n.Bytes()
+	ip := make(net.IP, length)
+	copy(ip[length-len(b):], b)
+	return ip
+}
+
+// incIP adds one to ip in place.
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
+
+// decIP subtracts one from ip in place.
+func decIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]--
+		if ip[i] != 0xff {
+			return
+		}
+	}
+}
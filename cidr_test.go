@@ -0,0 +1,177 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"net"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type cidrSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&cidrSuite{})
+
+func mustParseCIDR(c *gc.C, value string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(value)
+	c.Assert(err, jc.ErrorIsNil)
+	return ipNet
+}
+
+func cidrStrings(cidrs []*net.IPNet) []string {
+	values := make([]string, len(cidrs))
+	for i, ipNet := range cidrs {
+		values[i] = ipNet.String()
+	}
+	return values
+}
+
+func (*cidrSuite) TestParseCIDRs(c *gc.C) {
+	cidrs, err := utils.ParseCIDRs("10.0.0.1/8", "2001:db8::/32")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cidrStrings(cidrs), jc.DeepEquals, []string{"10.0.0.0/8", "2001:db8::/32"})
+
+	_, err = utils.ParseCIDRs("10.0.0.0/8", "10.0.0.1")
+	c.Assert(err, gc.ErrorMatches, "invalid CIDR address: 10.0.0.1")
+}
+
+func (*cidrSuite) TestIPInCIDRs(c *gc.C) {
+	cidrs, err := utils.ParseCIDRs("10.0.0.0/8", "192.168.1.0/24", "2001:db8::/32")
+	c.Assert(err, jc.ErrorIsNil)
+	for i, test := range []struct {
+		ip       string
+		expected bool
+	}{
+		{"10.1.2.3", true},
+		{"11.1.2.3", false},
+		{"192.168.1.255", true},
+		{"192.168.2.1", false},
+		{"::ffff:10.0.0.1", true},
+		{"2001:db8:1::1", true},
+		{"2001:db9::1", false},
+	} {
+		c.Logf("test %d: %s", i, test.ip)
+		c.Check(utils.IPInCIDRs(net.ParseIP(test.ip), cidrs), gc.Equals, test.expected)
+	}
+	c.Check(utils.IPInCIDRs(net.ParseIP("10.0.0.1"), nil), jc.IsFalse)
+}
+
+func (*cidrSuite) TestCIDRsOverlap(c *gc.C) {
+	for i, test := range []struct {
+		a, b     string
+		expected bool
+	}{
+		{"10.0.0.0/8", "10.1.0.0/16", true},
+		{"10.1.0.0/16", "10.0.0.0/8", true},
+		{"10.0.0.0/24", "10.0.0.0/24", true},
+		{"10.0.0.0/24", "10.0.1.0/24", false},
+		{"10.0.0.0/23", "10.0.1.128/25", true},
+		{"0.0.0.0/0", "192.168.0.0/16", true},
+		{"2001:db8::/32", "2001:db8:1::/48", true},
+		{"2001:db8::/32", "2001:db9::/32", false},
+		{"0.0.0.0/0", "::/0", false},
+		{"10.0.0.0/8", "::ffff:a00:0/104", false},
+	} {
+		c.Logf("test %d: %s %s", i, test.a, test.b)
+		c.Check(utils.CIDRsOverlap(mustParseCIDR(c, test.a), mustParseCIDR(c, test.b)), gc.Equals, test.expected)
+	}
+}
+
+func (*cidrSuite) TestSplitCIDR(c *gc.C) {
+	for i, test := range []struct {
+		cidr      string
+		prefixLen int
+		expected  []string
+	}{
+		{"10.0.0.0/23", 24, []string{"10.0.0.0/24", "10.0.1.0/24"}},
+		{"10.0.0.0/24", 24, []string{"10.0.0.0/24"}},
+		{"10.0.255.0/24", 26, []string{"10.0.255.0/26", "10.0.255.64/26", "10.0.255.128/26", "10.0.255.192/26"}},
+		{"192.168.1.252/30", 32, []string{"192.168.1.252/32", "192.168.1.253/32", "192.168.1.254/32", "192.168.1.255/32"}},
+		{"2001:db8::/47", 48, []string{"2001:db8::/48", "2001:db8:1::/48"}},
+		{"::/0", 2, []string{"::/2", "4000::/2", "8000::/2", "c000::/2"}},
+	} {
+		c.Logf("test %d: %s into /%d", i, test.cidr, test.prefixLen)
+		subnets, err := utils.SplitCIDR(mustParseCIDR(c, test.cidr), test.prefixLen)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(cidrStrings(subnets), jc.DeepEquals, test.expected)
+	}
+}
+
+func (*cidrSuite) TestSplitCIDRLarge(c *gc.C) {
+	subnets, err := utils.SplitCIDR(mustParseCIDR(c, "10.0.0.0/8"), 24)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(subnets, gc.HasLen, 65536)
+	c.Assert(subnets[65535].String(), gc.Equals, "10.255.255.0/24")
+}
+
+func (*cidrSuite) TestSplitCIDRErrors(c *gc.C) {
+	ipNet := mustParseCIDR(c, "10.0.0.0/16")
+	_, err := utils.SplitCIDR(ipNet, 15)
+	c.Assert(err, gc.ErrorMatches, "prefix length 15 for 10.0.0.0/16 not valid")
+	c.Assert(errors.IsNotValid(err), jc.IsTrue)
+
+	_, err = utils.SplitCIDR(ipNet, 33)
+	c.Assert(err, gc.ErrorMatches, "prefix length 33 for 10.0.0.0/16 not valid")
+
+	_, err = utils.SplitCIDR(mustParseCIDR(c, "10.0.0.0/8"), 25)
+	c.Assert(err, gc.ErrorMatches, `splitting 10.0.0.0/8 into /25 subnets would give more than 65536 subnets`)
+
+	_, err = utils.SplitCIDR(&net.IPNet{
+		IP:   net.ParseIP("10.0.0.0"),
+		Mask: net.IPv4Mask(255, 0, 255, 0),
+	}, 24)
+	c.Assert(err, gc.ErrorMatches, "non-canonical mask in .* not valid")
+}
+
+func hosts(ipNet *net.IPNet, max int) []string {
+	var result []string
+	utils.ForEachHost(ipNet, func(ip net.IP) bool {
+		result = append(result, ip.String())
+		return len(result) < max
+	})
+	return result
+}
+
+func (*cidrSuite) TestForEachHost(c *gc.C) {
+	for i, test := range []struct {
+		cidr     string
+		expected []string
+	}{
+		{"192.168.1.0/30", []string{"192.168.1.1", "192.168.1.2"}},
+		{"192.168.1.8/29", []string{"192.168.1.9", "192.168.1.10", "192.168.1.11", "192.168.1.12", "192.168.1.13", "192.168.1.14"}},
+		{"10.0.0.4/31", []string{"10.0.0.4", "10.0.0.5"}},
+		{"10.0.0.4/32", []string{"10.0.0.4"}},
+		{"2001:db8::/126", []string{"2001:db8::1", "2001:db8::2", "2001:db8::3"}},
+		{"2001:db8::/127", []string{"2001:db8::", "2001:db8::1"}},
+		{"2001:db8::1/128", []string{"2001:db8::1"}},
+	} {
+		c.Logf("test %d: %s", i, test.cidr)
+		c.Check(hosts(mustParseCIDR(c, test.cidr), 100), jc.DeepEquals, test.expected)
+	}
+}
+
+func (*cidrSuite) TestForEachHostStops(c *gc.C) {
+	c.Check(hosts(mustParseCIDR(c, "10.0.0.0/8"), 3), jc.DeepEquals, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"})
+	c.Check(hosts(mustParseCIDR(c, "2001:db8::/32"), 2), jc.DeepEquals, []string{"2001:db8::1", "2001:db8::2"})
+}
+
+func (*cidrSuite) TestForEachHostCarries(c *gc.C) {
+	ipNet := mustParseCIDR(c, "10.0.0.0/23")
+	var all []net.IP
+	utils.ForEachHost(ipNet, func(ip net.IP) bool {
+		all = append(all, ip)
+		return true
+	})
+	c.Assert(all, gc.HasLen, 510)
+	c.Assert(all[254].String(), gc.Equals, "10.0.0.255")
+	c.Assert(all[255].String(), gc.Equals, "10.0.1.0")
+	c.Assert(all[509].String(), gc.Equals, "10.0.1.254")
+}
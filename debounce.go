@@ -0,0 +1,228 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/clock"
+)
+
+// Debouncer collapses bursts of triggers into a single call of a
+// function, made once no trigger has arrived for a given window. It is
+// useful for reacting to filesystem or configuration change events,
+// which tend to arrive in bursts.
+type Debouncer struct {
+	clock  clock.Clock
+	fn     func()
+	window time.Duration
+	run    funcRunner
+
+	// mu guards the fields below it.
+	mu      sync.Mutex
+	timer   clock.Timer
+	gen     int
+	stopped bool
+}
+
+// Debounce returns a Debouncer that calls fn once window has passed
+// since the most recent call to Trigger. If clk is nil, clock.WallClock
+// is used.
+func Debounce(fn func(), window time.Duration, clk clock.Clock) *Debouncer {
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	return &Debouncer{
+		clock:  clk,
+		fn:     fn,
+		window: window,
+	}
+}
+
+// Trigger schedules a call of the function, postponing any call that
+// was already scheduled. It does nothing once Stop has been called.
+func (d *Debouncer) Trigger() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return
+	}
+	d.cancel()
+	gen := d.gen
+	d.timer = d.clock.AfterFunc(d.window, func() {
+		d.fire(gen)
+	})
+}
+
+// Pending reports whether a call of the function is scheduled.
+func (d *Debouncer) Pending() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.timer != nil
+}
+
+// Flush calls the function immediately if a call is scheduled,
+// instead of waiting for the window to pass.
+func (d *Debouncer) Flush() {
+	d.mu.Lock()
+	if d.timer == nil {
+		d.mu.Unlock()
+		return
+	}
+	d.cancel()
+	d.mu.Unlock()
+	d.run.call(d.fn)
+}
+
+// Stop cancels any scheduled call of the function and stops
+// the Debouncer from scheduling any more.
+func (d *Debouncer) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+	d.cancel()
+}
+
+// cancel cancels any scheduled call. Called with d.mu held.
+func (d *Debouncer) cancel() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	// The timer may already have fired, so make sure that its
+	// callback does nothing.
+	d.gen++
+}
+
+func (d *Debouncer) fire(gen int) {
+	d.mu.Lock()
+	if gen != d.gen {
+		d.mu.Unlock()
+		return
+	}
+	d.timer = nil
+	d.mu.Unlock()
+	d.run.call(d.fn)
+}
+
+// Throttler limits the rate at which a function is called in response
+// to triggers. The first trigger calls the function immediately; further
+// triggers within the minimum interval are collapsed into a single call
+// made when the interval has passed.
+type Throttler struct {
+	clock       clock.Clock
+	fn          func()
+	minInterval time.Duration
+	run         funcRunner
+
+	// mu guards the fields below it.
+	mu      sync.Mutex
+	last    time.Time
+	timer   clock.Timer
+	gen     int
+	stopped bool
+}
+
+// Throttle returns a Throttler that calls fn at most once every
+// minInterval. If clk is nil, clock.WallClock is used.
+func Throttle(fn func(), minInterval time.Duration, clk clock.Clock) *Throttler {
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	return &Throttler{
+		clock:       clk,
+		fn:          fn,
+		minInterval: minInterval,
+	}
+}
+
+// Trigger calls the function if it hasn't been called within the
+// minimum interval, in which case Trigger returns when the call has
+// finished. Otherwise it schedules a call for when the interval has
+// passed, if one isn't already scheduled. It does nothing once Stop has
+// been called.
+func (t *Throttler) Trigger() {
+	t.mu.Lock()
+	if t.stopped || t.timer != nil {
+		t.mu.Unlock()
+		return
+	}
+	now := t.clock.Now()
+	next := t.last.Add(t.minInterval)
+	if t.last.IsZero() || !now.Before(next) {
+		t.last = now
+		t.mu.Unlock()
+		t.run.call(t.fn)
+		return
+	}
+	gen := t.gen
+	t.timer = t.clock.AfterFunc(next.Sub(now), func() {
+		t.fire(gen)
+	})
+	t.mu.Unlock()
+}
+
+// Pending reports whether a call of the function is scheduled.
+func (t *Throttler) Pending() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timer != nil
+}
+
+// Flush calls the function immediately if a call is scheduled,
+// instead of waiting for the interval to pass.
+func (t *Throttler) Flush() {
+	t.mu.Lock()
+	if t.timer == nil {
+		t.mu.Unlock()
+		return
+	}
+	t.cancel()
+	t.last = t.clock.Now()
+	t.mu.Unlock()
+	t.run.call(t.fn)
+}
+
+// Stop cancels any scheduled call of the function and stops
+// the Throttler from calling it again.
+func (t *Throttler) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	t.cancel()
+}
+
+// cancel cancels any scheduled call. Called with t.mu held.
+func (t *Throttler) cancel() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.gen++
+}
+
+func (t *Throttler) fire(gen int) {
+	t.mu.Lock()
+	if gen != t.gen {
+		t.mu.Unlock()
+		return
+	}
+	t.timer = nil
+	t.last = t.clock.Now()
+	t.mu.Unlock()
+	t.run.call(t.fn)
+}
+
+// funcRunner makes sure that calls of a function made from different
+// goroutines never overlap.
+type funcRunner struct {
+	mu sync.Mutex
+}
+
+func (r *funcRunner) call(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn()
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type debounceSuite struct {
+	testing.IsolationSuite
+	clock *testclock.Clock
+	calls chan struct{}
+}
+
+var _ = gc.Suite(&debounceSuite{})
+
+func (s *debounceSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.clock = testclock.NewClock(time.Now())
+	s.calls = make(chan struct{}, 10)
+}
+
+func (s *debounceSuite) fn() {
+	s.calls <- struct{}{}
+}
+
+func (s *debounceSuite) assertCalled(c *gc.C) {
+	select {
+	case <-s.calls:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("function not called")
+	}
+}
+
+func (s *debounceSuite) assertNotCalled(c *gc.C) {
+	select {
+	case <-s.calls:
+		c.Fatalf("function called unexpectedly")
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+func (s *debounceSuite) TestDebounce(c *gc.C) {
+	d := utils.Debounce(s.fn, time.Second, s.clock)
+	d.Trigger()
+	c.Assert(d.Pending(), jc.IsTrue)
+	s.clock.Advance(900 * time.Millisecond)
+	d.Trigger()
+	s.clock.Advance(900 * time.Millisecond)
+	s.assertNotCalled(c)
+	s.clock.Advance(100 * time.Millisecond)
+	s.assertCalled(c)
+	s.assertNotCalled(c)
+	c.Assert(d.Pending(), jc.IsFalse)
+}
+
+func (s *debounceSuite) TestDebounceFlush(c *gc.C) {
+	d := utils.Debounce(s.fn, time.Second, s.clock)
+	d.Flush()
+	s.assertNotCalled(c)
+
+	d.Trigger()
+	d.Flush()
+	s.assertCalled(c)
+	c.Assert(d.Pending(), jc.IsFalse)
+	s.clock.Advance(time.Second)
+	s.assertNotCalled(c)
+}
+
+func (s *debounceSuite) TestDebounceStop(c *gc.C) {
+	d := utils.Debounce(s.fn, time.Second, s.clock)
+	d.Trigger()
+	d.Stop()
+	c.Assert(d.Pending(), jc.IsFalse)
+	d.Trigger()
+	s.clock.Advance(time.Second)
+	s.assertNotCalled(c)
+}
+
+func (s *debounceSuite) TestThrottle(c *gc.C) {
+	t := utils.Throttle(s.fn, time.Second, s.clock)
+	t.Trigger()
+	s.assertCalled(c)
+	c.Assert(t.Pending(), jc.IsFalse)
+
+	s.clock.Advance(200 * time.Millisecond)
+	t.Trigger()
+	t.Trigger()
+	c.Assert(t.Pending(), jc.IsTrue)
+	s.clock.Advance(700 * time.Millisecond)
+	s.assertNotCalled(c)
+	s.clock.Advance(100 * time.Millisecond)
+	s.assertCalled(c)
+	s.assertNotCalled(c)
+
+	// A trigger after the interval has passed calls the
+	// function immediately.
+	s.clock.Advance(time.Second)
+	t.Trigger()
+	s.assertCalled(c)
+}
+
+func (s *debounceSuite) TestThrottleFlush(c *gc.C) {
+	t := utils.Throttle(s.fn, time.Second, s.clock)
+	t.Trigger()
+	s.assertCalled(c)
+	t.Trigger()
+	t.Flush()
+	s.assertCalled(c)
+	s.clock.Advance(time.Second)
+	s.assertNotCalled(c)
+}
+
+func (s *debounceSuite) TestThrottleStop(c *gc.C) {
+	t := utils.Throttle(s.fn, time.Second, s.clock)
+	t.Trigger()
+	s.assertCalled(c)
+	t.Trigger()
+	t.Stop()
+	s.clock.Advance(time.Second)
+	t.Trigger()
+	s.assertNotCalled(c)
+}
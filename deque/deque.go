@@ -45,7 +45,7 @@ func New() *Deque {
 	return NewWithMaxLen(0)
 }
 
-// New returns a new Deque instance which is limited to a certain
+// NewWithMaxLen returns a new Deque instance which is limited to a certain
 // length. Pushes which cause the length to exceed the specified size
 // will cause an item to be dropped from the opposing side.
 //
@@ -74,6 +74,38 @@ func (d *Deque) Len() int {
 	return d.len
 }
 
+// MaxLen returns the maximum length of the queue, or 0 if it is not
+// limited.
+func (d *Deque) MaxLen() int {
+	return d.maxLen
+}
+
+// PeekFront returns the item at the front of the queue without
+// removing it. The returned flag is true unless the queue is empty.
+func (d *Deque) PeekFront() (interface{}, bool) {
+	if d.len < 1 {
+		return nil, false
+	}
+	return d.blocks.Front().Value.(blockT)[d.frontIdx], true
+}
+
+// PeekBack returns the item at the back of the queue without removing
+// it. The returned flag is true unless the queue is empty.
+func (d *Deque) PeekBack() (interface{}, bool) {
+	if d.len < 1 {
+		return nil, false
+	}
+	return d.blocks.Back().Value.(blockT)[d.backIdx], true
+}
+
+// Clear removes all the items from the queue.
+func (d *Deque) Clear() {
+	d.blocks.Init()
+	d.blocks.PushBack(newBlock())
+	d.recenter()
+	d.len = 0
+}
+
 // PushBack adds an item to the back of the queue.
 func (d *Deque) PushBack(item interface{}) {
 	var block blockT
@@ -171,6 +171,55 @@ func (s *suite) TestMaxLenBack(c *gc.C) {
 	c.Assert(v.(int), gc.Equals, 3)
 }
 
+func (s *suite) TestPeek(c *gc.C) {
+	_, ok := s.deque.PeekFront()
+	c.Assert(ok, jc.IsFalse)
+	_, ok = s.deque.PeekBack()
+	c.Assert(ok, jc.IsFalse)
+
+	for i := 0; i < testLen; i++ {
+		s.deque.PushBack(i)
+		v, ok := s.deque.PeekFront()
+		c.Assert(ok, jc.IsTrue)
+		c.Assert(v.(int), gc.Equals, 0)
+		v, ok = s.deque.PeekBack()
+		c.Assert(ok, jc.IsTrue)
+		c.Assert(v.(int), gc.Equals, i)
+	}
+	c.Assert(s.deque.Len(), gc.Equals, testLen)
+
+	for i := 0; i < testLen; i++ {
+		v, ok := s.deque.PeekFront()
+		c.Assert(ok, jc.IsTrue)
+		c.Assert(v.(int), gc.Equals, i)
+		s.deque.PopFront()
+	}
+	s.checkEmpty(c)
+}
+
+func (s *suite) TestClear(c *gc.C) {
+	for i := 0; i < testLen; i++ {
+		s.deque.PushFront(i)
+	}
+	s.deque.Clear()
+	s.checkEmpty(c)
+	c.Assert(deque.GetDequeBlocks(s.deque), gc.Equals, 1)
+
+	s.deque.PushFront(1)
+	s.deque.PushBack(2)
+	v, ok := s.deque.PopFront()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(v.(int), gc.Equals, 1)
+	v, ok = s.deque.PopFront()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(v.(int), gc.Equals, 2)
+}
+
+func (s *suite) TestMaxLen(c *gc.C) {
+	c.Assert(s.deque.MaxLen(), gc.Equals, 0)
+	c.Assert(deque.NewWithMaxLen(5).MaxLen(), gc.Equals, 5)
+}
+
 func (s *suite) TestBlockAllocation(c *gc.C) {
 	// This test confirms that the Deque allocates and deallocates
 	// blocks as expected.
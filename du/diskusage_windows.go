@@ -28,9 +28,9 @@ func NewDiskUsage(volumePath string) *DiskUsage {
 
 	c.Call(
 		uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(volumePath))),
-		uintptr(unsafe.Pointer(&du.freeBytes)),
+		uintptr(unsafe.Pointer(&du.availBytes)),
 		uintptr(unsafe.Pointer(&du.totalBytes)),
-		uintptr(unsafe.Pointer(&du.availBytes)))
+		uintptr(unsafe.Pointer(&du.freeBytes)))
 
 	return du
 }
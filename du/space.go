@@ -0,0 +1,27 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package du
+
+import "github.com/juju/errors"
+
+// AvailableSpace returns the number of bytes available to an
+// unprivileged user on the filesystem containing path, so that callers
+// can check there is room before downloading or unpacking.
+func AvailableSpace(path string) (uint64, error) {
+	available, _, err := diskSpace(path)
+	if err != nil {
+		return 0, errors.Annotatef(err, "cannot get available space for %q", path)
+	}
+	return available, nil
+}
+
+// TotalSpace returns the size in bytes of the filesystem containing
+// path.
+func TotalSpace(path string) (uint64, error) {
+	_, total, err := diskSpace(path)
+	if err != nil {
+		return 0, errors.Annotatef(err, "cannot get total space for %q", path)
+	}
+	return total, nil
+}
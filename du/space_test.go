@@ -0,0 +1,35 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package du_test
+
+import (
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/du"
+)
+
+type spaceSuite struct{}
+
+var _ = gc.Suite(&spaceSuite{})
+
+func (*spaceSuite) TestSpace(c *gc.C) {
+	dir := c.MkDir()
+	available, err := du.AvailableSpace(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	total, err := du.TotalSpace(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(total > 0, jc.IsTrue)
+	c.Assert(available <= total, jc.IsTrue, gc.Commentf("available %d, total %d", available, total))
+}
+
+func (*spaceSuite) TestSpaceMissingPath(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "missing")
+	_, err := du.AvailableSpace(path)
+	c.Assert(err, gc.ErrorMatches, `cannot get available space for ".*missing": .*`)
+	_, err = du.TotalSpace(path)
+	c.Assert(err, gc.ErrorMatches, `cannot get total space for ".*missing": .*`)
+}
@@ -0,0 +1,38 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package du
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procGetDiskFreeSpaceExW = modkernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// diskSpace returns the bytes available to an unprivileged user and
+// the total size of the filesystem containing path.
+func diskSpace(path string) (available, total uint64, err error) {
+	pathp, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var free uint64
+	r1, _, e1 := procGetDiskFreeSpaceExW.Call(
+		uintptr(unsafe.Pointer(pathp)),
+		uintptr(unsafe.Pointer(&available)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)),
+	)
+	if r1 == 0 {
+		if e1 != nil && e1 != syscall.Errno(0) {
+			return 0, 0, e1
+		}
+		return 0, 0, syscall.EINVAL
+	}
+	return available, total, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package du
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// TreeSize holds the sizes of a directory tree.
+type TreeSize struct {
+	// Apparent is the total length of the files in the tree.
+	Apparent int64
+
+	// OnDisk is the space allocated to the files and directories in
+	// the tree. Sparse files may use less space than their length,
+	// and small files more. On Windows OnDisk is the same as
+	// Apparent.
+	OnDisk int64
+
+	// Files and Dirs hold the number of files and directories
+	// counted, including the root.
+	Files int64
+	Dirs  int64
+}
+
+// TreeSizeOptions holds the options for DirSize.
+type TreeSizeOptions struct {
+	// FollowSymlinks causes symbolic links to be followed. Links
+	// that lead back to a directory containing them are not followed,
+	// so loops do not cause problems. Otherwise, and for dangling
+	// links, the links themselves are counted.
+	FollowSymlinks bool
+
+	// Exclude, if not nil, is called for each entry below the root;
+	// if it returns true the entry, and everything below it, is left
+	// out.
+	Exclude func(path string, info os.FileInfo) bool
+
+	// Concurrency is the maximum number of directories read at once.
+	// If it is zero, the number of CPUs is used.
+	Concurrency int
+}
+
+// DirSize returns the sizes of the directory tree rooted at root, for
+// example so that quotas can be checked before copying or backing it
+// up. Entries that cannot be read cause an error.
+func DirSize(root string, opts TreeSizeOptions) (TreeSize, error) {
+	if opts.Concurrency < 0 {
+		return TreeSize{}, errors.NotValidf("negative Concurrency")
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = runtime.NumCPU()
+	}
+	info, err := os.Lstat(root)
+	if err != nil {
+		return TreeSize{}, errors.Trace(err)
+	}
+	w := &treeWalker{
+		opts: opts,
+		// The calling goroutine counts as one reader.
+		sem: make(chan struct{}, opts.Concurrency-1),
+	}
+	w.visit(root, info, nil)
+	w.wg.Wait()
+	if w.err != nil {
+		return TreeSize{}, errors.Annotatef(w.err, "cannot get size of %q", root)
+	}
+	return w.size, nil
+}
+
+// treeWalker holds the state of a DirSize call.
+type treeWalker struct {
+	opts TreeSizeOptions
+	sem  chan struct{}
+	wg   sync.WaitGroup
+
+	mu   sync.Mutex
+	size TreeSize
+	err  error
+}
+
+// visit counts the entry at path, with the given Lstat info, and
+// everything below it. The ancestors hold the directories above it.
+func (w *treeWalker) visit(path string, info os.FileInfo, ancestors []os.FileInfo) {
+	if info.Mode()&os.ModeSymlink != 0 && w.opts.FollowSymlinks {
+		if target, err := os.Stat(path); err == nil {
+			info = target
+		}
+	}
+	if !info.IsDir() {
+		w.add(info, false)
+		return
+	}
+	for _, ancestor := range ancestors {
+		if os.SameFile(ancestor, info) {
+			return
+		}
+	}
+	w.add(info, true)
+
+	dir, err := os.Open(path)
+	if err != nil {
+		w.setError(err)
+		return
+	}
+	entries, err := dir.Readdir(-1)
+	dir.Close()
+	if err != nil {
+		w.setError(err)
+		return
+	}
+	// Copy the ancestors, as other goroutines may be appending to
+	// the same slice.
+	ancestors = append(ancestors[:len(ancestors):len(ancestors)], info)
+	for _, entry := range entries {
+		entryPath := filepath.Join(path, entry.Name())
+		if w.opts.Exclude != nil && w.opts.Exclude(entryPath, entry) {
+			continue
+		}
+		if !entry.IsDir() && (entry.Mode()&os.ModeSymlink == 0 || !w.opts.FollowSymlinks) {
+			w.add(entry, false)
+			continue
+		}
+		select {
+		case w.sem <- struct{}{}:
+			w.wg.Add(1)
+			go func(entryPath string, entry os.FileInfo) {
+				defer func() {
+					<-w.sem
+					w.wg.Done()
+				}()
+				w.visit(entryPath, entry, ancestors)
+			}(entryPath, entry)
+		default:
+			// Enough directories are already being read
+			// concurrently, so do this one ourselves.
+			w.visit(entryPath, entry, ancestors)
+		}
+	}
+}
+
+func (w *treeWalker) add(info os.FileInfo, isDir bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if isDir {
+		w.size.Dirs++
+	} else {
+		w.size.Files++
+		w.size.Apparent += info.Size()
+	}
+	w.size.OnDisk += allocatedSize(info)
+}
+
+func (w *treeWalker) setError(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package du
+
+import (
+	"os"
+	"syscall"
+)
+
+// allocatedSize returns the space allocated on disk to the file
+// described by info.
+func allocatedSize(info os.FileInfo) int64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		// Blocks are always counted in 512 byte units.
+		return int64(stat.Blocks) * 512
+	}
+	return info.Size()
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package du_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/du"
+)
+
+type treeSizeSuite struct {
+	dir string
+}
+
+var _ = gc.Suite(&treeSizeSuite{})
+
+func (s *treeSizeSuite) SetUpTest(c *gc.C) {
+	s.dir = c.MkDir()
+	for _, dir := range []string{"a", "a/b", "c"} {
+		err := os.Mkdir(filepath.Join(s.dir, dir), 0755)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	for path, size := range map[string]int{
+		"top":      10,
+		"a/one":    100,
+		"a/b/two":  1000,
+		"c/three":  10000,
+		"c/ignore": 100000,
+	} {
+		err := ioutil.WriteFile(filepath.Join(s.dir, path), make([]byte, size), 0644)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+func (s *treeSizeSuite) TestDirSize(c *gc.C) {
+	for _, concurrency := range []int{0, 1, 3} {
+		c.Logf("concurrency %d", concurrency)
+		size, err := du.DirSize(s.dir, du.TreeSizeOptions{Concurrency: concurrency})
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(size.Apparent, gc.Equals, int64(111110))
+		c.Assert(size.Files, gc.Equals, int64(5))
+		c.Assert(size.Dirs, gc.Equals, int64(4))
+		c.Assert(size.OnDisk > 0, jc.IsTrue)
+	}
+}
+
+func (s *treeSizeSuite) TestExclude(c *gc.C) {
+	var excluded []string
+	size, err := du.DirSize(s.dir, du.TreeSizeOptions{
+		Concurrency: 1,
+		Exclude: func(path string, info os.FileInfo) bool {
+			if info.Name() == "ignore" || info.Name() == "b" {
+				excluded = append(excluded, strings.TrimPrefix(path, s.dir))
+				return true
+			}
+			return false
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(size.Apparent, gc.Equals, int64(10110))
+	c.Assert(size.Dirs, gc.Equals, int64(3))
+	c.Assert(excluded, jc.SameContents, []string{
+		string(filepath.Separator) + filepath.Join("a", "b"),
+		string(filepath.Separator) + filepath.Join("c", "ignore"),
+	})
+}
+
+func (s *treeSizeSuite) TestSymlinks(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("non-windows only test")
+	}
+	err := os.Symlink("..", filepath.Join(s.dir, "a", "parent"))
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Symlink(filepath.Join("..", "c"), filepath.Join(s.dir, "a", "c"))
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Symlink("missing", filepath.Join(s.dir, "dangling"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	size, err := du.DirSize(s.dir, du.TreeSizeOptions{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(size.Files, gc.Equals, int64(8))
+	c.Assert(size.Dirs, gc.Equals, int64(4))
+
+	// The loop back to the root is not followed, but the link to c is.
+	size, err = du.DirSize(s.dir, du.TreeSizeOptions{FollowSymlinks: true})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(size.Apparent, gc.Equals, int64(111110+110000+int64(len("missing"))))
+	c.Assert(size.Files, gc.Equals, int64(8))
+	c.Assert(size.Dirs, gc.Equals, int64(5))
+}
+
+func (s *treeSizeSuite) TestErrors(c *gc.C) {
+	_, err := du.DirSize(filepath.Join(s.dir, "missing"), du.TreeSizeOptions{})
+	c.Assert(err, gc.ErrorMatches, ".*missing.*")
+	_, err = du.DirSize(s.dir, du.TreeSizeOptions{Concurrency: -1})
+	c.Assert(err, gc.ErrorMatches, "negative Concurrency not valid")
+}
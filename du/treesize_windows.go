@@ -0,0 +1,16 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package du
+
+import "os"
+
+// allocatedSize returns the space allocated on disk to the file
+// described by info. Finding the real value is expensive on Windows,
+// so the apparent size is used.
+func allocatedSize(info os.FileInfo) int64 {
+	if info.IsDir() {
+		return 0
+	}
+	return info.Size()
+}
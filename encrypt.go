@@ -0,0 +1,84 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+
+	"github.com/juju/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// EncryptionKeyLength is the length of the keys used by Encrypt and
+// Decrypt, which select AES-256.
+const EncryptionKeyLength = 32
+
+// keyDerivationIterations is the number of PBKDF2 iterations used by
+// DeriveEncryptionKey.
+const keyDerivationIterations = 100000
+
+// Encrypt encrypts and authenticates plaintext with AES-GCM, using the
+// given key of length EncryptionKeyLength. The additional data is
+// authenticated but not encrypted, and the same additional data must be
+// passed to Decrypt; it may be nil. A random nonce is generated for each
+// call and prepended to the returned ciphertext, so encrypting the same
+// plaintext twice gives different results.
+//
+// Encrypt is intended for small secrets, such as passwords and API
+// keys, stored at rest.
+func Encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	nonce, err := RandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Decrypt decrypts ciphertext returned by Encrypt, checking that
+// neither it nor the additional data have been tampered with.
+func Decrypt(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, errors.New("cannot decrypt: message authentication failed")
+	}
+	return plaintext, nil
+}
+
+// DeriveEncryptionKey derives a key suitable for Encrypt and Decrypt
+// from a passphrase, using PBKDF2 with SHA-256. The salt should be
+// random (see RandomBytes), at least 16 bytes long and stored alongside
+// the ciphertext.
+func DeriveEncryptionKey(passphrase string, salt []byte) ([]byte, error) {
+	if len(salt) == 0 {
+		return nil, errors.New("salt is not allowed to be empty")
+	}
+	pw := []byte(passphrase)
+	defer ZeroBytes(pw)
+	return pbkdf2.Key(pw, salt, keyDerivationIterations, EncryptionKeyLength, sha256.New), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != EncryptionKeyLength {
+		return nil, errors.NotValidf("key length %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"bytes"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type encryptSuite struct {
+	testing.IsolationSuite
+	key []byte
+}
+
+var _ = gc.Suite(&encryptSuite{})
+
+func (s *encryptSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	key, err := utils.RandomBytes(utils.EncryptionKeyLength)
+	c.Assert(err, jc.ErrorIsNil)
+	s.key = key
+}
+
+func (s *encryptSuite) TestRoundTrip(c *gc.C) {
+	plaintext := []byte("hunter2")
+	ciphertext, err := utils.Encrypt(s.key, plaintext, []byte("user:admin"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(bytes.Contains(ciphertext, plaintext), jc.IsFalse)
+
+	result, err := utils.Decrypt(s.key, ciphertext, []byte("user:admin"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, plaintext)
+}
+
+func (s *encryptSuite) TestRandomNonce(c *gc.C) {
+	first, err := utils.Encrypt(s.key, []byte("secret"), nil)
+	c.Assert(err, jc.ErrorIsNil)
+	second, err := utils.Encrypt(s.key, []byte("secret"), nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(first, gc.Not(jc.DeepEquals), second)
+}
+
+func (s *encryptSuite) TestDecryptTampered(c *gc.C) {
+	ciphertext, err := utils.Encrypt(s.key, []byte("secret"), []byte("a"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = utils.Decrypt(s.key, ciphertext, []byte("b"))
+	c.Assert(err, gc.ErrorMatches, "cannot decrypt: message authentication failed")
+
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = utils.Decrypt(s.key, ciphertext, []byte("a"))
+	c.Assert(err, gc.ErrorMatches, "cannot decrypt: message authentication failed")
+
+	_, err = utils.Decrypt(s.key, ciphertext[:4], []byte("a"))
+	c.Assert(err, gc.ErrorMatches, "ciphertext too short")
+}
+
+func (s *encryptSuite) TestDecryptWrongKey(c *gc.C) {
+	ciphertext, err := utils.Encrypt(s.key, []byte("secret"), nil)
+	c.Assert(err, jc.ErrorIsNil)
+	otherKey, err := utils.RandomBytes(utils.EncryptionKeyLength)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = utils.Decrypt(otherKey, ciphertext, nil)
+	c.Assert(err, gc.ErrorMatches, "cannot decrypt: message authentication failed")
+}
+
+func (s *encryptSuite) TestInvalidKey(c *gc.C) {
+	_, err := utils.Encrypt([]byte("short"), []byte("secret"), nil)
+	c.Assert(err, gc.ErrorMatches, "key length 5 not valid")
+	_, err = utils.Decrypt([]byte("short"), []byte("secret"), nil)
+	c.Assert(err, gc.ErrorMatches, "key length 5 not valid")
+}
+
+func (s *encryptSuite) TestDeriveEncryptionKey(c *gc.C) {
+	salt := []byte("0123456789abcdef")
+	key, err := utils.DeriveEncryptionKey("correct horse", salt)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(key, gc.HasLen, utils.EncryptionKeyLength)
+
+	again, err := utils.DeriveEncryptionKey("correct horse", salt)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(again, jc.DeepEquals, key)
+
+	other, err := utils.DeriveEncryptionKey("battery staple", salt)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(other, gc.Not(jc.DeepEquals), key)
+
+	ciphertext, err := utils.Encrypt(key, []byte("secret"), nil)
+	c.Assert(err, jc.ErrorIsNil)
+	plaintext, err := utils.Decrypt(again, ciphertext, nil)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(plaintext), gc.Equals, "secret")
+}
+
+func (s *encryptSuite) TestDeriveEncryptionKeyEmptySalt(c *gc.C) {
+	_, err := utils.DeriveEncryptionKey("correct horse", nil)
+	c.Assert(err, gc.ErrorMatches, "salt is not allowed to be empty")
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// ExpandEnvWithDefaults is like os.ExpandEnv, but also understands the
+// shell's default value forms:
+//
+//	${VAR:-default}  default if VAR is unset or empty
+//	${VAR-default}   default if VAR is unset
+//
+// Variables in the default are expanded too, but defaults may not
+// contain a closing brace, so ${A:-${B}} is not supported; use
+// ${A:-$B} instead.
+func ExpandEnvWithDefaults(s string) string {
+	return ExpandWithDefaults(s, os.LookupEnv)
+}
+
+// ExpandWithDefaults is like ExpandEnvWithDefaults, but looks up
+// variables with the given function rather than in the environment.
+func ExpandWithDefaults(s string, lookup func(string) (string, bool)) string {
+	var mapping func(string) string
+	mapping = func(name string) string {
+		var def string
+		emptyIsUnset, hasDefault := false, false
+		if i := strings.Index(name, ":-"); i >= 0 {
+			name, def = name[:i], name[i+2:]
+			emptyIsUnset, hasDefault = true, true
+		} else if i := strings.Index(name, "-"); i >= 0 {
+			name, def = name[:i], name[i+1:]
+			hasDefault = true
+		}
+		value, ok := lookup(name)
+		if hasDefault && (!ok || emptyIsUnset && value == "") {
+			return os.Expand(def, mapping)
+		}
+		return value
+	}
+	return os.Expand(s, mapping)
+}
+
+// GetenvString returns the value of the named environment variable, or
+// def if it is unset or empty.
+func GetenvString(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// GetenvInt returns the value of the named environment variable as an
+// integer, or def if it is unset or empty.
+func GetenvInt(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.NotValidf("value %q for $%s", value, name)
+	}
+	return i, nil
+}
+
+// GetenvBool returns the value of the named environment variable as a
+// boolean, as parsed by strconv.ParseBool, or def if it is unset or
+// empty.
+func GetenvBool(name string, def bool) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.NotValidf("value %q for $%s", value, name)
+	}
+	return b, nil
+}
+
+// GetenvDuration returns the value of the named environment variable as
+// a duration, as parsed by time.ParseDuration, or def if it is unset or
+// empty.
+func GetenvDuration(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.NotValidf("value %q for $%s", value, name)
+	}
+	return d, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type envSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&envSuite{})
+
+var expandWithDefaultsTests = []struct {
+	about  string
+	s      string
+	expect string
+}{{
+	about:  "plain variables",
+	s:      "$FOO and ${FOO}",
+	expect: "foo and foo",
+}, {
+	about:  "unset variable",
+	s:      "[$UNSET]",
+	expect: "[]",
+}, {
+	about:  "default for set variable",
+	s:      "${FOO:-bar} ${FOO-bar}",
+	expect: "foo foo",
+}, {
+	about:  "default for unset variable",
+	s:      "${UNSET:-bar} ${UNSET-bar}",
+	expect: "bar bar",
+}, {
+	about:  "default for empty variable",
+	s:      "[${EMPTY:-bar}] [${EMPTY-bar}]",
+	expect: "[bar] []",
+}, {
+	about:  "empty default",
+	s:      "[${UNSET:-}]",
+	expect: "[]",
+}, {
+	about:  "default containing variables",
+	s:      "${UNSET:-$FOO/bin}",
+	expect: "foo/bin",
+}, {
+	about:  "default referring to unset variable",
+	s:      "${UNSET:-$OTHER}",
+	expect: "",
+}, {
+	about:  "default containing separators",
+	s:      "${UNSET:-a-b:-c}",
+	expect: "a-b:-c",
+}}
+
+func (s *envSuite) TestExpandWithDefaults(c *gc.C) {
+	env := map[string]string{
+		"FOO":   "foo",
+		"EMPTY": "",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	for i, test := range expandWithDefaultsTests {
+		c.Logf("test %d: %s", i, test.about)
+		c.Check(utils.ExpandWithDefaults(test.s, lookup), gc.Equals, test.expect)
+	}
+}
+
+func (s *envSuite) TestExpandEnvWithDefaults(c *gc.C) {
+	s.PatchEnvironment("JUJU_TEST_FOO", "foo")
+	s.PatchEnvironment("JUJU_TEST_UNSET", "")
+	c.Assert(utils.ExpandEnvWithDefaults("${JUJU_TEST_FOO}/${JUJU_TEST_UNSET:-bar}"), gc.Equals, "foo/bar")
+}
+
+func (s *envSuite) TestGetenvString(c *gc.C) {
+	s.PatchEnvironment("JUJU_TEST_VALUE", "")
+	c.Assert(utils.GetenvString("JUJU_TEST_VALUE", "def"), gc.Equals, "def")
+	s.PatchEnvironment("JUJU_TEST_VALUE", "value")
+	c.Assert(utils.GetenvString("JUJU_TEST_VALUE", "def"), gc.Equals, "value")
+}
+
+func (s *envSuite) TestGetenvInt(c *gc.C) {
+	s.PatchEnvironment("JUJU_TEST_VALUE", "")
+	i, err := utils.GetenvInt("JUJU_TEST_VALUE", 42)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(i, gc.Equals, 42)
+
+	s.PatchEnvironment("JUJU_TEST_VALUE", "-7")
+	i, err = utils.GetenvInt("JUJU_TEST_VALUE", 42)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(i, gc.Equals, -7)
+
+	s.PatchEnvironment("JUJU_TEST_VALUE", "seven")
+	_, err = utils.GetenvInt("JUJU_TEST_VALUE", 42)
+	c.Assert(err, gc.ErrorMatches, `value "seven" for \$JUJU_TEST_VALUE not valid`)
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+}
+
+func (s *envSuite) TestGetenvBool(c *gc.C) {
+	s.PatchEnvironment("JUJU_TEST_VALUE", "")
+	b, err := utils.GetenvBool("JUJU_TEST_VALUE", true)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(b, jc.IsTrue)
+
+	s.PatchEnvironment("JUJU_TEST_VALUE", "false")
+	b, err = utils.GetenvBool("JUJU_TEST_VALUE", true)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(b, jc.IsFalse)
+
+	s.PatchEnvironment("JUJU_TEST_VALUE", "maybe")
+	_, err = utils.GetenvBool("JUJU_TEST_VALUE", true)
+	c.Assert(err, gc.ErrorMatches, `value "maybe" for \$JUJU_TEST_VALUE not valid`)
+}
+
+func (s *envSuite) TestGetenvDuration(c *gc.C) {
+	s.PatchEnvironment("JUJU_TEST_VALUE", "")
+	d, err := utils.GetenvDuration("JUJU_TEST_VALUE", time.Second)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(d, gc.Equals, time.Second)
+
+	s.PatchEnvironment("JUJU_TEST_VALUE", "1m30s")
+	d, err = utils.GetenvDuration("JUJU_TEST_VALUE", time.Second)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(d, gc.Equals, 90*time.Second)
+
+	s.PatchEnvironment("JUJU_TEST_VALUE", "soon")
+	_, err = utils.GetenvDuration("JUJU_TEST_VALUE", time.Second)
+	c.Assert(err, gc.ErrorMatches, `value "soon" for \$JUJU_TEST_VALUE not valid`)
+}
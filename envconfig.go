@@ -0,0 +1,201 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LoadEnvConfig sets the fields of the struct pointed to by cfg from
+// environment variables, as described by the fields' struct tags. For
+// example:
+//
+//	type Config struct {
+//		Addr      string        `env:"MYD_ADDR,required"`
+//		Timeout   time.Duration `env:"MYD_TIMEOUT" default:"30s"`
+//		CacheSize uint64        `env:"MYD_CACHE_SIZE,bytes" default:"64MiB"`
+//		Debug     bool          `env:"MYD_DEBUG"`
+//	}
+//
+// The env tag holds the name of the variable, optionally followed by
+// these options:
+//
+//	required  the variable must be set to a non-empty value
+//	size      the value is a size in mebibytes, as parsed by ParseSize
+//	bytes     the value is a size in bytes, as parsed by ParseBytes
+//
+// If the variable is unset or empty, the value in the default tag, if
+// any and non-empty, is used; otherwise the field is left unchanged.
+// Fields may be strings, booleans, integers, floats, durations (as
+// parsed by time.ParseDuration) or string slices (comma separated).
+// Struct fields without an env tag, or with an empty one, are set
+// recursively.
+//
+// Invalid values result in an error satisfying errors.IsNotValid, and
+// missing required variables in an error satisfying errors.IsNotFound.
+func LoadEnvConfig(cfg interface{}) error {
+	return LoadConfig(cfg, lookupEnv)
+}
+
+// lookupEnv is like os.LookupEnv, which isn't available before Go 1.5,
+// except that it reports empty variables as unset. LoadConfig treats
+// them the same way.
+func lookupEnv(name string) (string, bool) {
+	value := os.Getenv(name)
+	return value, value != ""
+}
+
+// LoadConfig is like LoadEnvConfig, but looks up variables with the
+// given function rather than in the environment.
+func LoadConfig(cfg interface{}, lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("expected pointer to struct, got %T", cfg)
+	}
+	return errors.Trace(loadStruct(v.Elem(), lookup))
+}
+
+func loadStruct(v reflect.Value, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			// Unexported field.
+			continue
+		}
+		// An empty tag is treated as absent, as StructTag.Lookup
+		// isn't available before Go 1.7.
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+				if err := loadStruct(v.Field(i), lookup); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		var required, size, inBytes bool
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "required":
+				required = true
+			case "size":
+				size = true
+			case "bytes":
+				inBytes = true
+			default:
+				return errors.Errorf("unknown option %q in env tag of field %s", opt, field.Name)
+			}
+		}
+		value, _ := lookup(name)
+		if value == "" {
+			if required {
+				return errors.NotFoundf("environment variable $%s", name)
+			}
+			value = field.Tag.Get("default")
+			if value == "" {
+				continue
+			}
+		}
+		if err := setField(v.Field(i), value, size, inBytes); err != nil {
+			if errors.IsNotValid(err) {
+				return errors.NewNotValid(err, "value "+strconv.Quote(value)+" for $"+name)
+			}
+			return errors.Annotatef(err, "field %s", field.Name)
+		}
+	}
+	return nil
+}
+
+// setField sets v from the string value, returning an error satisfying
+// errors.IsNotValid if the value can't be parsed.
+func setField(v reflect.Value, value string, size, inBytes bool) error {
+	if size || inBytes {
+		var n uint64
+		var err error
+		if size {
+			n, err = ParseSize(value)
+		} else {
+			n, err = ParseBytes(value)
+		}
+		if err != nil {
+			return errors.NewNotValid(err, "")
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if int64(n) < 0 || v.OverflowInt(int64(n)) {
+				return errors.NewNotValid(nil, "size out of range")
+			}
+			v.SetInt(int64(n))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v.OverflowUint(n) {
+				return errors.NewNotValid(nil, "size out of range")
+			}
+			v.SetUint(n)
+		default:
+			return errors.Errorf("size in field of type %s", v.Type())
+		}
+		return nil
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.NewNotValid(err, "")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.NewNotValid(err, "")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 0, v.Type().Bits())
+		if err != nil {
+			return errors.NewNotValid(err, "")
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 0, v.Type().Bits())
+		if err != nil {
+			return errors.NewNotValid(err, "")
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return errors.NewNotValid(err, "")
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return errors.Errorf("unsupported field type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+	default:
+		return errors.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
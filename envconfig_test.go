@@ -0,0 +1,156 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type envConfigSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&envConfigSuite{})
+
+type logConfig struct {
+	Level string `env:"TEST_LOG_LEVEL" default:"INFO"`
+}
+
+type daemonConfig struct {
+	Addr      string        `env:"TEST_ADDR,required"`
+	Timeout   time.Duration `env:"TEST_TIMEOUT" default:"30s"`
+	CacheSize uint64        `env:"TEST_CACHE_SIZE,bytes" default:"1KiB"`
+	DiskMB    int           `env:"TEST_DISK,size"`
+	Debug     bool          `env:"TEST_DEBUG"`
+	Workers   int           `env:"TEST_WORKERS" default:"4"`
+	Ratio     float64       `env:"TEST_RATIO"`
+	Peers     []string      `env:"TEST_PEERS"`
+	Untagged  string
+	Log       logConfig
+}
+
+func lookupIn(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func (*envConfigSuite) TestDefaults(c *gc.C) {
+	cfg := daemonConfig{Untagged: "kept"}
+	err := utils.LoadConfig(&cfg, lookupIn(map[string]string{
+		"TEST_ADDR":    "localhost:80",
+		"TEST_WORKERS": "",
+	}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg, jc.DeepEquals, daemonConfig{
+		Addr:      "localhost:80",
+		Timeout:   30 * time.Second,
+		CacheSize: 1024,
+		Workers:   4,
+		Untagged:  "kept",
+		Log:       logConfig{Level: "INFO"},
+	})
+}
+
+func (*envConfigSuite) TestAllSet(c *gc.C) {
+	var cfg daemonConfig
+	err := utils.LoadConfig(&cfg, lookupIn(map[string]string{
+		"TEST_ADDR":       "localhost:80",
+		"TEST_TIMEOUT":    "1m",
+		"TEST_CACHE_SIZE": "2MB",
+		"TEST_DISK":       "2G",
+		"TEST_DEBUG":      "true",
+		"TEST_WORKERS":    "0x10",
+		"TEST_RATIO":      "0.5",
+		"TEST_PEERS":      "a, b,,c",
+		"TEST_LOG_LEVEL":  "DEBUG",
+	}))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg, jc.DeepEquals, daemonConfig{
+		Addr:      "localhost:80",
+		Timeout:   time.Minute,
+		CacheSize: 2000000,
+		DiskMB:    2048,
+		Debug:     true,
+		Workers:   16,
+		Ratio:     0.5,
+		Peers:     []string{"a", "b", "c"},
+		Log:       logConfig{Level: "DEBUG"},
+	})
+}
+
+func (*envConfigSuite) TestRequired(c *gc.C) {
+	var cfg daemonConfig
+	err := utils.LoadConfig(&cfg, lookupIn(nil))
+	c.Assert(err, gc.ErrorMatches, `environment variable \$TEST_ADDR not found`)
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+}
+
+func (*envConfigSuite) TestInvalidValues(c *gc.C) {
+	for i, test := range []struct {
+		name  string
+		value string
+		err   string
+	}{{
+		name:  "TEST_TIMEOUT",
+		value: "soon",
+		err:   `value "soon" for \$TEST_TIMEOUT: .*`,
+	}, {
+		name:  "TEST_CACHE_SIZE",
+		value: "lots",
+		err:   `value "lots" for \$TEST_CACHE_SIZE: .*`,
+	}, {
+		name:  "TEST_DEBUG",
+		value: "maybe",
+		err:   `value "maybe" for \$TEST_DEBUG: .*`,
+	}, {
+		name:  "TEST_WORKERS",
+		value: "99999999999999999999",
+		err:   `value "99999999999999999999" for \$TEST_WORKERS: .*`,
+	}} {
+		c.Logf("test %d: $%s=%s", i, test.name, test.value)
+		var cfg daemonConfig
+		err := utils.LoadConfig(&cfg, lookupIn(map[string]string{
+			"TEST_ADDR": "localhost:80",
+			test.name:   test.value,
+		}))
+		c.Check(err, gc.ErrorMatches, test.err)
+		c.Check(errors.IsNotValid(err), jc.IsTrue)
+	}
+}
+
+func (*envConfigSuite) TestUnsupported(c *gc.C) {
+	var cfg struct {
+		Chan chan int `env:"TEST_CHAN"`
+	}
+	err := utils.LoadConfig(&cfg, lookupIn(map[string]string{"TEST_CHAN": "x"}))
+	c.Assert(err, gc.ErrorMatches, `field Chan: unsupported field type chan int`)
+
+	var opt struct {
+		S string `env:"TEST_S,secret"`
+	}
+	err = utils.LoadConfig(&opt, lookupIn(nil))
+	c.Assert(err, gc.ErrorMatches, `unknown option "secret" in env tag of field S`)
+
+	err = utils.LoadConfig(cfg, lookupIn(nil))
+	c.Assert(err, gc.ErrorMatches, `expected pointer to struct, got struct .*`)
+}
+
+func (s *envConfigSuite) TestLoadEnvConfig(c *gc.C) {
+	s.PatchEnvironment("TEST_ADDR", "example.com:443")
+	s.PatchEnvironment("TEST_DEBUG", "1")
+	var cfg daemonConfig
+	err := utils.LoadEnvConfig(&cfg)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg.Addr, gc.Equals, "example.com:443")
+	c.Assert(cfg.Debug, jc.IsTrue)
+}
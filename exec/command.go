@@ -0,0 +1,153 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package exec
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
+)
+
+// ErrTimeout is returned by RunCommand when the command is killed
+// because its timeout or its context's deadline has passed.
+var ErrTimeout = errors.New("command timed out")
+
+// CommandSpec describes a command to be run by RunCommand. Unlike
+// RunParams, the command is run directly rather than by a shell.
+type CommandSpec struct {
+	// Path holds the program to run. If it contains no path
+	// separators, it is looked up in $PATH.
+	Path string
+
+	// Args holds the arguments to pass to the program, not including
+	// the program name itself.
+	Args []string
+
+	// Dir, if not empty, holds the working directory of the command.
+	Dir string
+
+	// Env, if not nil, holds the environment of the command in
+	// "key=value" form. Otherwise the current environment is used.
+	Env []string
+
+	// Stdin, if not nil, is used as the standard input of the
+	// command.
+	Stdin io.Reader
+
+	// Timeout, if positive, holds the maximum time the command may
+	// run for.
+	Timeout time.Duration
+
+	// MaxOutput, if positive, holds the maximum number of bytes of
+	// each of stdout and stderr to capture. Any further output is
+	// discarded.
+	MaxOutput int
+}
+
+// RunCommand runs the command described by spec, capturing its stdout
+// and stderr. If the command's timeout passes or ctx is done before
+// the command exits, the command and any processes it has started are
+// killed - by killing its process group on POSIX systems or its job
+// object on Windows - and RunCommand returns the output captured so far
+// along with ErrTimeout or ErrCancelled.
+//
+// As with RunCommands, a non-zero exit code is recorded in the response
+// and is not considered an error.
+func RunCommand(ctx context.Context, spec CommandSpec) (*ExecResponse, error) {
+	if spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.Timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+	stdout := &cappedBuffer{max: spec.MaxOutput}
+	stderr := &cappedBuffer{max: spec.MaxOutput}
+	cmd := exec.Command(spec.Path, spec.Args...)
+	cmd.Dir = spec.Dir
+	cmd.Env = spec.Env
+	cmd.Stdin = spec.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Annotatef(err, "cannot start %q", spec.Path)
+	}
+	tree, err := newProcessTree(cmd.Process)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, errors.Trace(err)
+	}
+	defer tree.close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	var ctxErr error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		ctxErr = ctx.Err()
+		logger.Debugf("killing %q: %v", spec.Path, ctxErr)
+		if err := tree.kill(); err != nil {
+			logger.Warningf("cannot kill %q: %v", spec.Path, err)
+		}
+		err = <-done
+	}
+
+	result := &ExecResponse{
+		Stdout:          stdout.Bytes(),
+		Stderr:          stderr.Bytes(),
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+	}
+	result.Code, err = exitCode(err)
+	if ctxErr != nil {
+		return result, contextError(ctxErr)
+	}
+	return result, errors.Trace(err)
+}
+
+// contextError returns the error from RunCommand for the given context
+// error.
+func contextError(err error) error {
+	if err == context.DeadlineExceeded {
+		return ErrTimeout
+	}
+	return ErrCancelled
+}
+
+// cappedBuffer is an io.Writer that keeps at most max bytes written to
+// it, or all of them if max is not positive.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+// Write implements io.Writer. It always reports that all of p has been
+// written, so that the command doesn't see write errors.
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if b.max > 0 {
+		if room := b.max - b.buf.Len(); len(p) > room {
+			b.truncated = true
+			p = p[:room]
+		}
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// Bytes returns the bytes kept.
+func (b *cappedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package exec_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/exec"
+)
+
+type commandSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&commandSuite{})
+
+func shellSpec(script string) exec.CommandSpec {
+	return exec.CommandSpec{
+		Path: "/bin/sh",
+		Args: []string{"-c", script},
+	}
+}
+
+func (*commandSuite) TestRunCommand(c *gc.C) {
+	spec := shellSpec("echo out; echo err >&2; exit 3")
+	result, err := exec.RunCommand(context.Background(), spec)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, jc.DeepEquals, &exec.ExecResponse{
+		Code:   3,
+		Stdout: []byte("out\n"),
+		Stderr: []byte("err\n"),
+	})
+}
+
+func (*commandSuite) TestRunCommandDirEnvStdin(c *gc.C) {
+	dir := c.MkDir()
+	spec := shellSpec(`echo "$(pwd) $FOO $(cat)"`)
+	spec.Dir = dir
+	spec.Env = []string{"FOO=bar"}
+	spec.Stdin = strings.NewReader("input")
+	result, err := exec.RunCommand(context.Background(), spec)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(result.Stdout), gc.Equals, dir+" bar input\n")
+}
+
+func (*commandSuite) TestRunCommandMaxOutput(c *gc.C) {
+	spec := shellSpec("echo 0123456789; echo short >&2")
+	spec.MaxOutput = 6
+	result, err := exec.RunCommand(context.Background(), spec)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(string(result.Stdout), gc.Equals, "012345")
+	c.Check(result.StdoutTruncated, jc.IsTrue)
+	c.Check(string(result.Stderr), gc.Equals, "short\n")
+	c.Check(result.StderrTruncated, jc.IsFalse)
+}
+
+func (*commandSuite) TestRunCommandTimeout(c *gc.C) {
+	// The background sleep holds stdout open, so RunCommand would
+	// not return until it exited if only the shell was killed.
+	spec := shellSpec("echo started; sleep 100 & sleep 100")
+	spec.Timeout = 500 * time.Millisecond
+	start := time.Now()
+	result, err := exec.RunCommand(context.Background(), spec)
+	c.Assert(err, gc.Equals, exec.ErrTimeout)
+	c.Assert(time.Since(start) < 50*time.Second, jc.IsTrue)
+	c.Assert(string(result.Stdout), gc.Equals, "started\n")
+}
+
+func (*commandSuite) TestRunCommandCancelled(c *gc.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	result, err := exec.RunCommand(ctx, shellSpec("sleep 100"))
+	c.Assert(err, gc.Equals, exec.ErrCancelled)
+	c.Assert(result, gc.NotNil)
+}
+
+func (*commandSuite) TestRunCommandAlreadyCancelled(c *gc.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := exec.RunCommand(ctx, shellSpec("exit 0"))
+	c.Assert(err, gc.Equals, exec.ErrCancelled)
+	c.Assert(result, gc.IsNil)
+}
+
+func (*commandSuite) TestRunCommandNotFound(c *gc.C) {
+	_, err := exec.RunCommand(context.Background(), exec.CommandSpec{
+		Path: "/no/such/command",
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot start "/no/such/command": .*`)
+}
@@ -49,6 +49,11 @@ type ExecResponse struct {
 	Code   int
 	Stdout []byte
 	Stderr []byte
+
+	// StdoutTruncated and StderrTruncated report whether any output
+	// was discarded because it exceeded CommandSpec.MaxOutput.
+	StdoutTruncated bool
+	StderrTruncated bool
 }
 
 // mergeEnvironment takes in a string array representing the desired environment
@@ -198,17 +203,22 @@ func (r *RunParams) Wait() (*ExecResponse, error) {
 		Stdout: r.stdout.Bytes(),
 		Stderr: r.stderr.Bytes(),
 	}
+	result.Code, err = exitCode(err)
+	return result, err
+}
 
+// exitCode returns the exit code of a process given the error from
+// waiting for it. A non-zero return code isn't considered an error, so
+// the error is only returned if the process didn't exit normally.
+func exitCode(err error) (int, error) {
 	if ee, ok := err.(*exec.ExitError); ok && err != nil {
+		logger.Infof("run result: %v", ee)
 		status := ee.ProcessState.Sys().(syscall.WaitStatus)
 		if status.Exited() {
-			// A non-zero return code isn't considered an error here.
-			result.Code = status.ExitStatus()
-			err = nil
+			return status.ExitStatus(), nil
 		}
-		logger.Infof("run result: %v", ee)
 	}
-	return result, err
+	return 0, err
 }
 
 // ErrCancelled is returned by WaitWithCancel in case it successfully manages to kill
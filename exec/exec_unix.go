@@ -8,6 +8,7 @@ package exec
 
 import (
 	"os"
+	"os/exec"
 	"syscall"
 )
 
@@ -29,3 +30,32 @@ func KillProcess(proc *os.Process) error {
 func (r *RunParams) populateSysProcAttr() {
 	r.ps.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
+
+// setProcessGroup makes cmd start in a new process group so that
+// RunCommand can kill it along with any processes it starts.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// processTree represents a process and its descendants.
+type processTree struct {
+	pgid int
+}
+
+func newProcessTree(proc *os.Process) (*processTree, error) {
+	// The process is the leader of its own process group.
+	return &processTree{pgid: proc.Pid}, nil
+}
+
+// kill kills all the processes in the tree.
+func (t *processTree) kill() error {
+	err := syscall.Kill(-t.pgid, syscall.SIGKILL)
+	if err == syscall.ESRCH {
+		// They've all gone already.
+		return nil
+	}
+	return err
+}
+
+// close releases any resources held by the tree.
+func (t *processTree) close() {}
@@ -0,0 +1,112 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"github.com/juju/errors"
+)
+
+//sys createJobObject(attrs *syscall.SecurityAttributes, name *uint16) (handle syscall.Handle, err error) = CreateJobObjectW
+//sys setInformationJobObject(job syscall.Handle, class uint32, info uintptr, length uint32) (err error) = SetInformationJobObject
+//sys assignProcessToJobObject(job syscall.Handle, process syscall.Handle) (err error) = AssignProcessToJobObject
+//sys terminateJobObject(job syscall.Handle, exitCode uint32) (err error) = TerminateJobObject
+
+const (
+	jobObjectExtendedLimitInformationClass = 9
+	jobObjectLimitKillOnJobClose           = 0x2000
+
+	processSetQuota  = 0x0100
+	processTerminate = 0x0001
+)
+
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// setProcessGroup does nothing on Windows; the process is added to a
+// job object once it has started.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// processTree represents a process and its descendants, using a job
+// object. Processes started by a process in a job are also in the job,
+// so they can all be killed together.
+type processTree struct {
+	job syscall.Handle
+}
+
+func newProcessTree(proc *os.Process) (*processTree, error) {
+	job, err := createJobObject(nil, nil)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create job object")
+	}
+	// Make sure that the processes are killed if we go away
+	// without killing them.
+	info := jobObjectExtendedLimitInformation{
+		BasicLimitInformation: jobObjectBasicLimitInformation{
+			LimitFlags: jobObjectLimitKillOnJobClose,
+		},
+	}
+	if err := setInformationJobObject(
+		job,
+		jobObjectExtendedLimitInformationClass,
+		uintptr(unsafe.Pointer(&info)),
+		uint32(unsafe.Sizeof(info)),
+	); err != nil {
+		syscall.CloseHandle(job)
+		return nil, errors.Annotate(err, "cannot set job object limits")
+	}
+	handle, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(proc.Pid))
+	if err != nil {
+		syscall.CloseHandle(job)
+		return nil, errors.Annotate(err, "cannot open process")
+	}
+	defer syscall.CloseHandle(handle)
+	if err := assignProcessToJobObject(job, handle); err != nil {
+		syscall.CloseHandle(job)
+		return nil, errors.Annotate(err, "cannot assign process to job object")
+	}
+	return &processTree{job: job}, nil
+}
+
+// kill kills all the processes in the tree.
+func (t *processTree) kill() error {
+	return terminateJobObject(t.job, 1)
+}
+
+// close releases the job object. Any processes still in it are
+// killed.
+func (t *processTree) close() {
+	syscall.CloseHandle(t.job)
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package exec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+)
+
+// maxPartialLine holds the number of bytes of an incomplete line that
+// a LineWriter buffers before writing them anyway.
+const maxPartialLine = 64 * 1024
+
+// LineWriter is an io.WriteCloser that passes data on to another writer
+// only in whole lines, so that the output of several commands written
+// to the same destination, such as their stdout and stderr, isn't
+// interleaved in the middle of lines. An incomplete line is written
+// once it has been buffered for the LineWriter's timeout, so a command
+// that prints a prompt without a newline isn't hidden indefinitely.
+//
+// Each chunk of lines is passed on in a single Write call; when several
+// LineWriters share a destination, it must be safe for concurrent use.
+// A LineWriter is itself safe for concurrent use.
+type LineWriter struct {
+	w       io.Writer
+	clock   clock.Clock
+	timeout time.Duration
+
+	mu     sync.Mutex
+	buf    []byte
+	timer  clock.Timer
+	timerN int
+	err    error
+}
+
+// NewLineWriter returns a LineWriter that writes lines to w. If timeout
+// is positive, an incomplete line is written after it has been buffered
+// for that long, measured by the given clock; otherwise it is only
+// written when the line is completed, when it grows too long, or when
+// the LineWriter is flushed or closed. If clk is nil, clock.WallClock is
+// used.
+func NewLineWriter(w io.Writer, clk clock.Clock, timeout time.Duration) *LineWriter {
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	return &LineWriter{
+		w:       w,
+		clock:   clk,
+		timeout: timeout,
+	}
+}
+
+// Write implements io.Writer. If a previous write to the underlying
+// writer failed, Write returns that error.
+func (l *LineWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return 0, l.err
+	}
+	l.buf = append(l.buf, p...)
+	end := bytes.LastIndexByte(l.buf, '\n') + 1
+	if len(l.buf)-end > maxPartialLine {
+		end = len(l.buf)
+	}
+	if end > 0 {
+		if err := l.write(end); err != nil {
+			return 0, err
+		}
+	}
+	if len(l.buf) > 0 && l.timer == nil && l.timeout > 0 {
+		l.startTimer()
+	}
+	return len(p), nil
+}
+
+// Flush writes any buffered incomplete line.
+func (l *LineWriter) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.flush()
+}
+
+// Close implements io.Closer by flushing any buffered incomplete line.
+// It does not close the underlying writer.
+func (l *LineWriter) Close() error {
+	return l.Flush()
+}
+
+// flush writes all the buffered data. It must be called with l.mu
+// held.
+func (l *LineWriter) flush() error {
+	if l.err != nil {
+		return l.err
+	}
+	if len(l.buf) == 0 {
+		return nil
+	}
+	return l.write(len(l.buf))
+}
+
+// write writes the first n bytes of the buffer to the underlying
+// writer and stops the timer, since any data left is the start of a
+// new line. It must be called with l.mu held.
+func (l *LineWriter) write(n int) error {
+	_, err := l.w.Write(l.buf[:n])
+	l.buf = l.buf[:copy(l.buf, l.buf[n:])]
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if err != nil {
+		l.err = errors.Trace(err)
+	}
+	return l.err
+}
+
+// startTimer starts the timer for flushing the incomplete line. It
+// must be called with l.mu held.
+func (l *LineWriter) startTimer() {
+	// Count the timers, so that a timer that fires after it has
+	// been stopped doesn't flush a line that was started later.
+	l.timerN++
+	n := l.timerN
+	l.timer = l.clock.AfterFunc(l.timeout, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.timerN != n || l.timer == nil {
+			return
+		}
+		l.timer = nil
+		if err := l.flush(); err != nil {
+			logger.Debugf("cannot write incomplete line: %v", err)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package exec_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/exec"
+)
+
+type lineWriterSuite struct {
+	testing.IsolationSuite
+	clock *testclock.Clock
+	dest  *recordingWriter
+}
+
+var _ = gc.Suite(&lineWriterSuite{})
+
+func (s *lineWriterSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.clock = testclock.NewClock(time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC))
+	s.dest = &recordingWriter{}
+}
+
+// recordingWriter records each call to Write.
+type recordingWriter struct {
+	mu     sync.Mutex
+	writes []string
+	err    error
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func (w *recordingWriter) Writes() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.writes...)
+}
+
+func (s *lineWriterSuite) write(c *gc.C, w *exec.LineWriter, data string) {
+	n, err := w.Write([]byte(data))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(n, gc.Equals, len(data))
+}
+
+func (s *lineWriterSuite) TestWholeLines(c *gc.C) {
+	w := exec.NewLineWriter(s.dest, s.clock, 0)
+	s.write(c, w, "one\ntw")
+	s.write(c, w, "o\nthree\nfo")
+	s.write(c, w, "ur")
+	c.Assert(s.dest.Writes(), jc.DeepEquals, []string{"one\n", "two\nthree\n"})
+	c.Assert(w.Close(), jc.ErrorIsNil)
+	c.Assert(s.dest.Writes(), jc.DeepEquals, []string{"one\n", "two\nthree\n", "four"})
+}
+
+func (s *lineWriterSuite) TestFlushEmpty(c *gc.C) {
+	w := exec.NewLineWriter(s.dest, s.clock, 0)
+	s.write(c, w, "line\n")
+	c.Assert(w.Flush(), jc.ErrorIsNil)
+	c.Assert(s.dest.Writes(), jc.DeepEquals, []string{"line\n"})
+}
+
+func (s *lineWriterSuite) TestTimeout(c *gc.C) {
+	w := exec.NewLineWriter(s.dest, s.clock, time.Second)
+	s.write(c, w, "prompt: ")
+	err := s.clock.WaitAdvance(time.Second, testing.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	s.waitForWrites(c, []string{"prompt: "})
+
+	s.write(c, w, "answer\n")
+	c.Assert(s.dest.Writes(), jc.DeepEquals, []string{"prompt: ", "answer\n"})
+}
+
+func (s *lineWriterSuite) TestNilClock(c *gc.C) {
+	w := exec.NewLineWriter(s.dest, nil, time.Millisecond)
+	s.write(c, w, "prompt: ")
+	s.waitForWrites(c, []string{"prompt: "})
+}
+
+func (s *lineWriterSuite) TestTimeoutNotExtendedByWrites(c *gc.C) {
+	w := exec.NewLineWriter(s.dest, s.clock, time.Second)
+	s.write(c, w, "a")
+	s.clock.Advance(500 * time.Millisecond)
+	s.write(c, w, "b")
+	s.clock.Advance(500 * time.Millisecond)
+	s.waitForWrites(c, []string{"ab"})
+}
+
+func (s *lineWriterSuite) TestTimerRestartedForNewLine(c *gc.C) {
+	w := exec.NewLineWriter(s.dest, s.clock, time.Second)
+	s.write(c, w, "a")
+	s.clock.Advance(500 * time.Millisecond)
+	s.write(c, w, "b\nc")
+	// The timer started for "a" has been stopped, so "c" waits a
+	// full second of its own.
+	s.clock.Advance(500 * time.Millisecond)
+	time.Sleep(testing.ShortWait)
+	c.Assert(s.dest.Writes(), jc.DeepEquals, []string{"ab\n"})
+	s.clock.Advance(500 * time.Millisecond)
+	s.waitForWrites(c, []string{"ab\n", "c"})
+}
+
+func (s *lineWriterSuite) TestLongLine(c *gc.C) {
+	w := exec.NewLineWriter(s.dest, s.clock, 0)
+	long := strings.Repeat("x", 64*1024+1)
+	s.write(c, w, long)
+	c.Assert(s.dest.Writes(), jc.DeepEquals, []string{long})
+}
+
+func (s *lineWriterSuite) TestWriteError(c *gc.C) {
+	s.dest.err = errors.New("boom")
+	w := exec.NewLineWriter(s.dest, s.clock, 0)
+	_, err := w.Write([]byte("line\n"))
+	c.Assert(err, gc.ErrorMatches, "boom")
+	s.dest.err = nil
+	_, err = w.Write([]byte("another\n"))
+	c.Assert(err, gc.ErrorMatches, "boom")
+	c.Assert(w.Close(), gc.ErrorMatches, "boom")
+}
+
+func (s *lineWriterSuite) waitForWrites(c *gc.C, expected []string) {
+	deadline := time.Now().Add(testing.LongWait)
+	for len(s.dest.Writes()) < len(expected) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(s.dest.Writes(), jc.DeepEquals, expected)
+}
@@ -0,0 +1,42 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package exec
+
+import (
+	"path/filepath"
+
+	"github.com/juju/errors"
+)
+
+// FindExecutable returns the path of the executable that would be run
+// for name, following the conventions of the host platform. If name
+// contains a path separator, it is checked directly; otherwise it is
+// searched for in the directories listed in the PATH environment
+// variable. On Windows each of the extensions listed in PATHEXT is
+// tried in turn. Unlike the Windows command interpreter, the current
+// directory is not searched unless it is listed in PATH, so that an
+// executable planted there cannot be found by mistake.
+//
+// If no executable is found, an error satisfying errors.IsNotFound is
+// returned.
+func FindExecutable(name string) (string, error) {
+	if name == "" {
+		return "", errors.NotValidf("empty executable name")
+	}
+	for _, path := range executableCandidates(name) {
+		if IsExecutable(path) {
+			return path, nil
+		}
+	}
+	return "", errors.NotFoundf("executable %q", name)
+}
+
+// joinPath joins dir and name like filepath.Join, but keeps a leading
+// "." so that the result is never mistaken for a bare command name.
+func joinPath(dir, name string) string {
+	if dir == "." {
+		return "." + string(filepath.Separator) + name
+	}
+	return filepath.Join(dir, name)
+}
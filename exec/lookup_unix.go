@@ -0,0 +1,39 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsExecutable reports whether path names a regular file, or a
+// symbolic link to one, with at least one execute permission bit set.
+func IsExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
+
+// executableCandidates returns the paths FindExecutable should check
+// for name, in order.
+func executableCandidates(name string) []string {
+	if strings.Contains(name, "/") {
+		return []string{name}
+	}
+	var paths []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			// POSIX treats an empty entry as the current directory.
+			dir = "."
+		}
+		paths = append(paths, joinPath(dir, name))
+	}
+	return paths
+}
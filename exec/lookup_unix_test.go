@@ -0,0 +1,109 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package exec_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/exec"
+)
+
+type lookupSuite struct {
+	testing.IsolationSuite
+	dir1, dir2 string
+}
+
+var _ = gc.Suite(&lookupSuite{})
+
+func (s *lookupSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.dir1 = c.MkDir()
+	s.dir2 = c.MkDir()
+	s.PatchEnvironment("PATH", s.dir1+":"+s.dir2)
+}
+
+func writeFile(c *gc.C, path string, perm os.FileMode) {
+	err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"), perm)
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Chmod(path, perm)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *lookupSuite) TestFindExecutable(c *gc.C) {
+	// The first match isn't executable, so is skipped.
+	writeFile(c, filepath.Join(s.dir1, "tool"), 0644)
+	writeFile(c, filepath.Join(s.dir2, "tool"), 0755)
+	path, err := exec.FindExecutable("tool")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(path, gc.Equals, filepath.Join(s.dir2, "tool"))
+}
+
+func (s *lookupSuite) TestFindExecutableNotFound(c *gc.C) {
+	err := os.Mkdir(filepath.Join(s.dir1, "tool"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = exec.FindExecutable("tool")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	c.Assert(err, gc.ErrorMatches, `executable "tool" not found`)
+}
+
+func (s *lookupSuite) TestFindExecutableWithPath(c *gc.C) {
+	dir := c.MkDir()
+	writeFile(c, filepath.Join(dir, "tool"), 0700)
+	path, err := exec.FindExecutable(filepath.Join(dir, "tool"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(path, gc.Equals, filepath.Join(dir, "tool"))
+
+	// Names with a slash are not looked up in $PATH.
+	writeFile(c, filepath.Join(s.dir1, "tool"), 0755)
+	_, err = exec.FindExecutable("sub/tool")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *lookupSuite) TestFindExecutableEmptyPathEntry(c *gc.C) {
+	dir := c.MkDir()
+	writeFile(c, filepath.Join(dir, "tool"), 0755)
+	cwd, err := os.Getwd()
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Chdir(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(*gc.C) { os.Chdir(cwd) })
+
+	s.PatchEnvironment("PATH", s.dir1+"::"+s.dir2)
+	path, err := exec.FindExecutable("tool")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(path, gc.Equals, "./tool")
+}
+
+func (s *lookupSuite) TestFindExecutableEmptyName(c *gc.C) {
+	_, err := exec.FindExecutable("")
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+}
+
+func (s *lookupSuite) TestIsExecutable(c *gc.C) {
+	for _, perm := range []os.FileMode{0755, 0700, 0710, 0701} {
+		path := filepath.Join(s.dir1, "tool")
+		writeFile(c, path, perm)
+		c.Check(exec.IsExecutable(path), jc.IsTrue, gc.Commentf("%o", perm))
+	}
+	path := filepath.Join(s.dir1, "data")
+	writeFile(c, path, 0644)
+	c.Check(exec.IsExecutable(path), jc.IsFalse)
+
+	link := filepath.Join(s.dir1, "link")
+	err := os.Symlink(filepath.Join(s.dir1, "tool"), link)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(exec.IsExecutable(link), jc.IsTrue)
+
+	c.Check(exec.IsExecutable(s.dir1), jc.IsFalse)
+	c.Check(exec.IsExecutable(filepath.Join(s.dir1, "missing")), jc.IsFalse)
+}
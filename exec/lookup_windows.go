@@ -0,0 +1,85 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build windows
+
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultPathExts is used when PATHEXT is not set.
+var defaultPathExts = []string{".com", ".exe", ".bat", ".cmd"}
+
+// IsExecutable reports whether path names a regular file whose
+// extension is listed in the PATHEXT environment variable.
+func IsExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return info.Mode().IsRegular() && hasPathExt(path, pathExts())
+}
+
+// executableCandidates returns the paths FindExecutable should check
+// for name, in order.
+func executableCandidates(name string) []string {
+	exts := pathExts()
+	var paths []string
+	add := func(path string) {
+		if hasPathExt(path, exts) {
+			paths = append(paths, path)
+		}
+		for _, ext := range exts {
+			paths = append(paths, path+ext)
+		}
+	}
+	if strings.ContainsAny(name, `\/:`) {
+		add(name)
+		return paths
+	}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" {
+			add(joinPath(dir, name))
+		}
+	}
+	return paths
+}
+
+// pathExts returns the lower case extensions listed in PATHEXT, each
+// with a leading dot.
+func pathExts() []string {
+	var exts []string
+	for _, ext := range strings.Split(strings.ToLower(os.Getenv("PATHEXT")), ";") {
+		ext = strings.TrimSpace(ext)
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		exts = append(exts, ext)
+	}
+	if len(exts) == 0 {
+		return defaultPathExts
+	}
+	return exts
+}
+
+// hasPathExt reports whether the extension of path is one of exts,
+// ignoring case.
+func hasPathExt(path string, exts []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build windows
+
+package exec_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/exec"
+)
+
+type lookupSuite struct {
+	testing.IsolationSuite
+	dir string
+}
+
+var _ = gc.Suite(&lookupSuite{})
+
+func (s *lookupSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.dir = c.MkDir()
+	s.PatchEnvironment("PATH", s.dir)
+	s.PatchEnvironment("PATHEXT", ".COM;.EXE;.BAT")
+}
+
+func (s *lookupSuite) writeFile(c *gc.C, name string) string {
+	path := filepath.Join(s.dir, name)
+	err := ioutil.WriteFile(path, nil, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	return path
+}
+
+func (s *lookupSuite) TestFindExecutable(c *gc.C) {
+	s.writeFile(c, "tool")
+	want := s.writeFile(c, "tool.bat")
+	path, err := exec.FindExecutable("tool")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(path, gc.Equals, want)
+
+	// An earlier extension in PATHEXT wins.
+	want = s.writeFile(c, "tool.exe")
+	path, err = exec.FindExecutable("tool")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(path, gc.Equals, want)
+}
+
+func (s *lookupSuite) TestFindExecutableWithExtension(c *gc.C) {
+	want := s.writeFile(c, "tool.Exe")
+	path, err := exec.FindExecutable("tool.Exe")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(path, gc.Equals, want)
+}
+
+func (s *lookupSuite) TestFindExecutableNotFound(c *gc.C) {
+	s.writeFile(c, "tool.txt")
+	_, err := exec.FindExecutable("tool")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = exec.FindExecutable("tool.txt")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *lookupSuite) TestFindExecutableIgnoresCurrentDirectory(c *gc.C) {
+	dir := c.MkDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "planted.exe"), nil, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	cwd, err := os.Getwd()
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Chdir(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(*gc.C) { os.Chdir(cwd) })
+
+	_, err = exec.FindExecutable("planted")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *lookupSuite) TestIsExecutable(c *gc.C) {
+	c.Check(exec.IsExecutable(s.writeFile(c, "tool.exe")), jc.IsTrue)
+	c.Check(exec.IsExecutable(s.writeFile(c, "tool.txt")), jc.IsFalse)
+	c.Check(exec.IsExecutable(s.dir), jc.IsFalse)
+
+	s.PatchEnvironment("PATHEXT", "")
+	c.Check(exec.IsExecutable(s.writeFile(c, "tool.cmd")), jc.IsTrue)
+}
@@ -4,12 +4,15 @@
 package utils
 
 import (
+	"sync/atomic"
 	"time"
 )
 
 var (
 	GOMAXPROCS        = &gomaxprocs
 	NumCPU            = &numCPU
+	CgroupRoot        = &cgroupRoot
+	ProcSelfCgroup    = &procSelfCgroup
 	ResolveSudoByFunc = resolveSudo
 )
 
@@ -18,3 +21,19 @@ func ExposeBackoffTimerDuration(bot *BackoffTimer) time.Duration {
 }
 
 var IsLocalAddr = isLocalAddr
+
+var UUIDNow = &uuidNow
+
+// LimiterQueued returns the number of callers waiting in
+// AcquireContext on the given limiter.
+func LimiterQueued(l Limiter) int64 {
+	return atomic.LoadInt64(l.(limiter).queued)
+}
+
+var (
+	NetInterfaces   = &netInterfaces
+	InterfaceByName = &interfaceByName
+	InterfaceAddrs  = &interfaceAddrs
+)
+
+var RemoveAll = &removeAll
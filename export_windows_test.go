@@ -0,0 +1,9 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+var (
+	IsOwnerSID = isOwnerSID
+	OwnerSID   = ownerSID
+)
@@ -10,7 +10,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
+	"strings"
 
 	"github.com/juju/errors"
 )
@@ -35,24 +35,36 @@ func UserHomeDir(userName string) (hDir string, err error) {
 	return hDir, nil
 }
 
-// Only match paths starting with ~ (~user/test, ~/test). This will prevent
-// accidental expansion on Windows when short form paths are present (C:\users\ADMINI~1\test)
-var userHomePathRegexp = regexp.MustCompile("(^~(?P<user>[^/]*))(?P<path>.*)")
-
 // NormalizePath expands a path containing ~ to its absolute form,
 // and removes any .. or . path elements.
 func NormalizePath(dir string) (string, error) {
-	if userHomePathRegexp.MatchString(dir) {
-		user := userHomePathRegexp.ReplaceAllString(dir, "$user")
-		userHomeDir, err := UserHomeDir(user)
-		if err != nil {
-			return "", err
-		}
-		dir = userHomePathRegexp.ReplaceAllString(dir, fmt.Sprintf("%s$path", userHomeDir))
+	dir, err := ExpandHome(dir)
+	if err != nil {
+		return "", err
 	}
 	return filepath.Clean(dir), nil
 }
 
+// ExpandHome replaces a leading ~ in path with the home directory of
+// the current user (see Home), and a leading ~user with the home
+// directory of the named user. Other paths, including those with a ~
+// elsewhere, such as Windows short form paths
+// (C:\users\ADMINI~1\test), are returned unchanged.
+func ExpandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	end := 1
+	for end < len(path) && !os.IsPathSeparator(path[end]) {
+		end++
+	}
+	userHomeDir, err := UserHomeDir(path[1:end])
+	if err != nil {
+		return "", err
+	}
+	return userHomeDir + path[end:], nil
+}
+
 // ExpandPath normalises (via Normalize) a path returning an absolute path.
 func ExpandPath(path string) (string, error) {
 	normPath, err := NormalizePath(path)
@@ -105,13 +117,128 @@ func CopyFile(dest, source string) error {
 	if err != nil {
 		return err
 	}
+	defer df.Close()
 	f, err := os.Open(source)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	_, err = io.Copy(df, f)
-	return err
+	if _, err = io.Copy(df, f); err != nil {
+		return err
+	}
+	return df.Close()
+}
+
+// CopyFileOptions holds the options for CopyFileMode.
+type CopyFileOptions struct {
+	// PreserveOwner causes the destination to be given the owner and
+	// group of the source. It is ignored on Windows.
+	PreserveOwner bool
+
+	// PreserveTimes causes the destination to be given the
+	// modification time of the source.
+	PreserveTimes bool
+}
+
+// sparseBlockSize is the size of the blocks that CopyFileMode checks
+// for zeros.
+const sparseBlockSize = 4096
+
+// CopyFileMode copies the regular file at source to dest, giving dest
+// the permissions of source, and returns the number of bytes copied.
+// Blocks of zeros are skipped rather than written, so that sparse
+// files stay sparse on filesystems that support them. The destination
+// is synced to disk before CopyFileMode returns.
+func CopyFileMode(dest, source string, opts CopyFileOptions) (_ int64, err error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if !info.Mode().IsRegular() {
+		return 0, errors.NotValidf("copying %q with mode %v", source, info.Mode())
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	defer f.Close()
+	df, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	defer df.Close()
+
+	n, err := copySparse(df, f)
+	if err != nil {
+		return n, errors.Annotatef(err, "cannot copy %q to %q", source, dest)
+	}
+	// FileMod.Chmod() is not implemented on Windows, however, os.Chmod() is.
+	// This also makes the permissions match in the presence of umask.
+	if err := os.Chmod(dest, info.Mode().Perm()); err != nil {
+		return n, errors.Trace(err)
+	}
+	if opts.PreserveOwner {
+		if err := chownAs(dest, info); err != nil {
+			return n, errors.Annotatef(err, "cannot set owner of %q", dest)
+		}
+	}
+	if err := df.Sync(); err != nil {
+		return n, errors.Trace(err)
+	}
+	if err := df.Close(); err != nil {
+		return n, errors.Trace(err)
+	}
+	if opts.PreserveTimes {
+		if err := os.Chtimes(dest, info.ModTime(), info.ModTime()); err != nil {
+			return n, errors.Trace(err)
+		}
+	}
+	return n, nil
+}
+
+// copySparse copies src to dst, seeking over blocks of zeros instead
+// of writing them.
+func copySparse(dst *os.File, src io.Reader) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var written int64
+	for {
+		n, err := io.ReadFull(src, buf)
+		for offset := 0; offset < n; offset += sparseBlockSize {
+			end := offset + sparseBlockSize
+			if end > n {
+				end = n
+			}
+			block := buf[offset:end]
+			if isZeros(block) {
+				if _, err := dst.Seek(int64(len(block)), os.SEEK_CUR); err != nil {
+					return written, err
+				}
+			} else if _, err := dst.Write(block); err != nil {
+				return written, err
+			}
+			written += int64(len(block))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	// Any zeros at the end were skipped, so set the full length.
+	if err := dst.Truncate(written); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+func isZeros(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
 }
 
 // AtomicWriteFileAndChange atomically writes the filename with the
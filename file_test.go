@@ -9,6 +9,8 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"runtime"
+	"time"
 
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
@@ -73,6 +75,46 @@ func (*fileSuite) TestNormalizePath(c *gc.C) {
 	}
 }
 
+func (*fileSuite) TestExpandHome(c *gc.C) {
+	home := filepath.FromSlash(c.MkDir())
+	err := utils.SetHome(home)
+	c.Assert(err, gc.IsNil)
+	currentUser, err := user.Current()
+	c.Assert(err, gc.IsNil)
+	for i, test := range []struct {
+		path     string
+		expected string
+		err      string
+	}{{
+		path:     "~",
+		expected: home,
+	}, {
+		path:     "~/foo/../bar",
+		expected: home + "/foo/../bar",
+	}, {
+		path:     "~" + currentUser.Username + "/foo",
+		expected: currentUser.HomeDir + "/foo",
+	}, {
+		path:     "foo/~/bar",
+		expected: "foo/~/bar",
+	}, {
+		path:     "",
+		expected: "",
+	}, {
+		path: "~foobar/path",
+		err:  ".*" + utils.NoSuchUserErrRegexp,
+	}} {
+		c.Logf("test %d: %s", i, test.path)
+		actual, err := utils.ExpandHome(test.path)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Check(err, gc.IsNil)
+			c.Check(actual, gc.Equals, test.expected)
+		}
+	}
+}
+
 func (*fileSuite) TestExpandPath(c *gc.C) {
 	home := filepath.FromSlash(c.MkDir())
 	err := utils.SetHome(home)
@@ -150,6 +192,63 @@ func (*fileSuite) TestCopyFile(c *gc.C) {
 	c.Assert(string(data), gc.Equals, "hello world")
 }
 
+func (*fileSuite) TestCopyFileMode(c *gc.C) {
+	dir := c.MkDir()
+	source := filepath.Join(dir, "source")
+	// Include zero blocks in the middle and at the end, which are
+	// skipped when copying.
+	contents := append([]byte("hello"), make([]byte, 3*4096)...)
+	contents = append(contents, "world"...)
+	contents = append(contents, make([]byte, 5000)...)
+	err := ioutil.WriteFile(source, contents, 0600)
+	c.Assert(err, gc.IsNil)
+	err = os.Chmod(source, 0751)
+	c.Assert(err, gc.IsNil)
+	mtime := time.Date(2018, 9, 1, 12, 0, 0, 0, time.UTC)
+	err = os.Chtimes(source, mtime, mtime)
+	c.Assert(err, gc.IsNil)
+
+	dest := filepath.Join(dir, "dest")
+	n, err := utils.CopyFileMode(dest, source, utils.CopyFileOptions{
+		PreserveOwner: true,
+		PreserveTimes: true,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(n, gc.Equals, int64(len(contents)))
+
+	data, err := ioutil.ReadFile(dest)
+	c.Assert(err, gc.IsNil)
+	c.Assert(data, jc.DeepEquals, contents)
+	info, err := os.Stat(dest)
+	c.Assert(err, gc.IsNil)
+	if runtime.GOOS != "windows" {
+		c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0751))
+	}
+	c.Assert(info.ModTime().Equal(mtime), jc.IsTrue)
+}
+
+func (*fileSuite) TestCopyFileModeOverwrites(c *gc.C) {
+	dir := c.MkDir()
+	source := filepath.Join(dir, "source")
+	dest := filepath.Join(dir, "dest")
+	err := ioutil.WriteFile(source, []byte("new"), 0644)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(dest, []byte("old contents"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	_, err = utils.CopyFileMode(dest, source, utils.CopyFileOptions{})
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := ioutil.ReadFile(dest)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "new")
+}
+
+func (*fileSuite) TestCopyFileModeNotRegular(c *gc.C) {
+	dir := c.MkDir()
+	_, err := utils.CopyFileMode(filepath.Join(dir, "dest"), dir, utils.CopyFileOptions{})
+	c.Assert(err, gc.ErrorMatches, `copying ".*" with mode d.* not valid`)
+}
+
 var atomicWriteFileTests = []struct {
 	summary   string
 	change    func(filename string, contents []byte) error
@@ -267,3 +366,29 @@ func (*fileSuite) TestMoveFile(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(contents, gc.DeepEquals, []byte("macaroni"))
 }
+
+func (*fileSuite) TestReplaceFile(c *gc.C) {
+	d := c.MkDir()
+	dest := filepath.Join(d, "foo")
+	f1Name := filepath.Join(d, ".foo1")
+	f2Name := filepath.Join(d, ".foo2")
+	err := ioutil.WriteFile(f1Name, []byte("macaroni"), 0644)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(f2Name, []byte("cheese"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	err = utils.ReplaceFile(f1Name, dest)
+	c.Assert(err, gc.IsNil)
+	err = utils.ReplaceFile(f2Name, dest)
+	c.Assert(err, gc.IsNil)
+
+	contents, err := ioutil.ReadFile(dest)
+	c.Assert(err, gc.IsNil)
+	c.Assert(contents, gc.DeepEquals, []byte("cheese"))
+	_, err = os.Stat(f2Name)
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+
+	err = utils.ReplaceFile(f2Name, dest)
+	c.Assert(err, gc.FitsTypeOf, &os.LinkError{})
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+}
@@ -75,6 +75,15 @@ func ChownPath(path, username string) error {
 	return os.Chown(path, uid, gid)
 }
 
+// chownAs sets the owner and group of path to those in info.
+func chownAs(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("cannot get owner of %q", info.Name())
+	}
+	return os.Chown(path, int(stat.Uid), int(stat.Gid))
+}
+
 // IsFileOwner checks to see if the ownership of the file corresponds to
 // the same username
 func IsFileOwner(path, username string) (bool, error) {
@@ -93,3 +102,15 @@ func IsFileOwner(path, username string) (bool, error) {
 	return (strconv.Itoa(int(stat.Uid)) == u.Uid &&
 		strconv.Itoa(int(stat.Gid)) == u.Gid), nil
 }
+
+// HasRestrictivePerms reports whether the file at path is inaccessible
+// to anyone but its owner, that is, whether it has no group or other
+// permission bits set. Code handling key material can use it to refuse
+// to proceed with files that others could read or change.
+func HasRestrictivePerms(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return info.Mode().Perm()&0077 == 0, nil
+}
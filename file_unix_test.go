@@ -7,6 +7,7 @@ package utils_test
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
@@ -70,3 +71,30 @@ func (s *unixFileSuite) TestFileOwnerWithInvalidUsername(c *gc.C) {
 	c.Assert(errors.Cause(err), gc.ErrorMatches, "user: unknown user invalid")
 	c.Assert(ok, gc.Equals, false)
 }
+
+func (s *unixFileSuite) TestHasRestrictivePerms(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "key")
+	err := ioutil.WriteFile(path, nil, 0600)
+	c.Assert(err, gc.IsNil)
+
+	for _, perm := range []os.FileMode{0600, 0400, 0700} {
+		err = os.Chmod(path, perm)
+		c.Assert(err, gc.IsNil)
+		ok, err := utils.HasRestrictivePerms(path)
+		c.Assert(err, gc.IsNil)
+		c.Assert(ok, gc.Equals, true, gc.Commentf("%o", perm))
+	}
+	for _, perm := range []os.FileMode{0640, 0604, 0660, 0666} {
+		err = os.Chmod(path, perm)
+		c.Assert(err, gc.IsNil)
+		ok, err := utils.HasRestrictivePerms(path)
+		c.Assert(err, gc.IsNil)
+		c.Assert(ok, gc.Equals, false, gc.Commentf("%o", perm))
+	}
+}
+
+func (s *unixFileSuite) TestHasRestrictivePermsWithInvalidPath(c *gc.C) {
+	ok, err := utils.HasRestrictivePerms(filepath.Join(c.MkDir(), "missing"))
+	c.Assert(errors.Cause(err), gc.ErrorMatches, "stat .*: no such file or directory")
+	c.Assert(ok, gc.Equals, false)
+}
@@ -141,7 +141,225 @@ func ChownPath(path, username string) error {
 	return nil
 }
 
-// IsFileOwner is not implemented for Windows.
+// chownAs is not implemented for Windows, for the same reasons as
+// ChownPath.
+func chownAs(path string, info os.FileInfo) error {
+	return nil
+}
+
+// IsFileOwner checks to see if the file is owned by the named user, or
+// by the user with the given SID. If the user is the one running the
+// current process, files owned by the default owner of its token, or by
+// one of its groups that may be assigned as the owner of new files, are
+// also considered to be owned by the user; files created by an elevated
+// administrator, for example, are owned by BUILTIN\Administrators.
+// Other groups, such as BUILTIN\Users, don't count even if the user
+// belongs to them.
 func IsFileOwner(path, username string) (bool, error) {
+	sid, err := ownerSID(username)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	sd, err := getFileSecurity(path, owner_security_information)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer sd.free()
+	owner, err := sd.owner.String()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return isOwnerSID(owner, sid)
+}
+
+// isOwnerSID reports whether a file owned by owner is considered to be
+// owned by the user with the given SID.
+func isOwnerSID(owner, sid string) (bool, error) {
+	if owner == sid {
+		return true, nil
+	}
+	user, owners, err := currentUserOwnerSIDs()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return user == sid && owners[owner], nil
+}
+
+const se_group_owner = 0x8
+
+// tokenOwner mirrors the Windows TOKEN_OWNER structure.
+type tokenOwner struct {
+	owner *syscall.SID
+}
+
+// tokenGroups mirrors the Windows TOKEN_GROUPS structure; the groups
+// run on past the end of the struct.
+type tokenGroups struct {
+	groupCount uint32
+	groups     [1]syscall.SIDAndAttributes
+}
+
+// currentUserOwnerSIDs returns the SID of the user running the current
+// process, and the SIDs that may own files it creates: the default
+// owner of its token and the groups it belongs to that may be assigned
+// as owners.
+func currentUserOwnerSIDs() (string, map[string]bool, error) {
+	token, err := syscall.OpenCurrentProcessToken()
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	defer token.Close()
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	userSID, err := user.User.Sid.String()
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	owners := map[string]bool{userSID: true}
+
+	info, err := tokenInformation(token, syscall.TokenOwner)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	defaultOwner, err := (*tokenOwner)(unsafe.Pointer(&info[0])).owner.String()
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	owners[defaultOwner] = true
+
+	if info, err = tokenInformation(token, syscall.TokenGroups); err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	tg := (*tokenGroups)(unsafe.Pointer(&info[0]))
+	groups := (*[1 << 20]syscall.SIDAndAttributes)(unsafe.Pointer(&tg.groups[0]))[:tg.groupCount:tg.groupCount]
+	for _, group := range groups {
+		if group.Attributes&se_group_owner == 0 {
+			continue
+		}
+		sid, err := group.Sid.String()
+		if err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		owners[sid] = true
+	}
+	return userSID, owners, nil
+}
+
+// tokenInformation returns the information of the given class about
+// token.
+func tokenInformation(token syscall.Token, class uint32) ([]byte, error) {
+	n := uint32(256)
+	for {
+		buf := make([]byte, n)
+		err := syscall.GetTokenInformation(token, class, &buf[0], uint32(len(buf)), &n)
+		if err == nil {
+			return buf, nil
+		}
+		if err != syscall.ERROR_INSUFFICIENT_BUFFER {
+			return nil, os.NewSyscallError("GetTokenInformation", err)
+		}
+	}
+}
+
+// HasRestrictivePerms reports whether the file at path is inaccessible
+// to anyone but its owner, LocalSystem and the Administrators group,
+// that is, whether its access control list grants nobody else any
+// access. Code handling key material can use it to refuse to proceed
+// with files that others could read or change.
+func HasRestrictivePerms(path string) (bool, error) {
+	sd, err := getFileSecurity(path, owner_security_information|dacl_security_information)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer sd.free()
+	if sd.dacl == nil {
+		// A missing DACL grants everyone full access.
+		return false, nil
+	}
+	owner, err := sd.owner.String()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	trusted := map[string]bool{
+		owner:          true,
+		"S-1-5-18":     true, // LocalSystem
+		"S-1-5-32-544": true, // Administrators
+	}
+	for i := uint32(0); i < uint32(sd.dacl.aceCount); i++ {
+		var a *accessAllowedAce
+		if err := getAce(sd.dacl, i, &a); err != nil {
+			return false, os.NewSyscallError("GetAce", err)
+		}
+		if a.header.aceType != access_allowed_ace_type || a.header.aceFlags&inherit_only_ace != 0 {
+			// Denied access, and entries that only apply to
+			// new files inside a directory, don't matter.
+			continue
+		}
+		sid, err := (*syscall.SID)(unsafe.Pointer(&a.sidStart)).String()
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if !trusted[sid] {
+			return false, nil
+		}
+	}
 	return true, nil
 }
+
+const (
+	se_file_object             = 1
+	owner_security_information = 0x1
+	access_allowed_ace_type    = 0
+	inherit_only_ace           = 0x8
+)
+
+// acl mirrors the Windows ACL header.
+type acl struct {
+	aclRevision byte
+	sbz1        byte
+	aclSize     uint16
+	aceCount    uint16
+	sbz2        uint16
+}
+
+// accessAllowedAce mirrors the Windows ACCESS_ALLOWED_ACE structure;
+// the SID starts at sidStart and runs on past the end of the struct.
+type accessAllowedAce struct {
+	header struct {
+		aceType  byte
+		aceFlags byte
+		aceSize  uint16
+	}
+	mask     uint32
+	sidStart uint32
+}
+
+//sys getNamedSecurityInfo(objectName *uint16, objectType uint32, securityInformation uint32, owner **syscall.SID, group **syscall.SID, dacl **acl, sacl **acl, sd *uintptr) (ret error) = advapi32.GetNamedSecurityInfoW
+//sys getAce(acl *acl, index uint32, ace **accessAllowedAce) (err error) = advapi32.GetAce
+
+// fileSecurity holds the parts of a file's security descriptor asked
+// for from getFileSecurity. The owner and dacl point into the
+// descriptor, which must be released with free.
+type fileSecurity struct {
+	owner *syscall.SID
+	dacl  *acl
+	sd    uintptr
+}
+
+func getFileSecurity(path string, info uint32) (*fileSecurity, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var fs fileSecurity
+	if err := getNamedSecurityInfo(name, se_file_object, info, &fs.owner, nil, &fs.dacl, nil, &fs.sd); err != nil {
+		return nil, &os.PathError{Op: "GetNamedSecurityInfo", Path: path, Err: err}
+	}
+	return &fs, nil
+}
+
+func (fs *fileSecurity) free() {
+	localFree(fs.sd)
+}
@@ -7,6 +7,10 @@
 package utils_test
 
 import (
+	"io/ioutil"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/utils"
@@ -50,5 +54,50 @@ func (s *windowsFileSuite) TestEnsureBaseDir(c *gc.C) {
 }
 
 func (s *windowsFileSuite) TestFileOwner(c *gc.C) {
-	c.Assert(utils.IsFileOwner("file://C:\\foo\\baz", "timmy"), gc.Equals, true)
+	username, err := utils.LocalUsername()
+	c.Assert(err, jc.ErrorIsNil)
+	path := filepath.Join(c.MkDir(), "file")
+	err = ioutil.WriteFile(path, nil, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+
+	ok, err := utils.IsFileOwner(path, username)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ok, jc.IsTrue)
+
+	// Everyone is a group, not a user, so it doesn't own the file even
+	// though the user running the tests belongs to it.
+	ok, err = utils.IsFileOwner(path, "S-1-1-0")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ok, jc.IsFalse)
+}
+
+func (s *windowsFileSuite) TestFileOwnedByBroadGroup(c *gc.C) {
+	sid, err := utils.OwnerSID("")
+	c.Assert(err, jc.ErrorIsNil)
+	// The user running the tests belongs to BUILTIN\Users and
+	// Authenticated Users, but files they own aren't the user's.
+	for _, group := range []string{"S-1-5-32-545", "S-1-5-11"} {
+		ok, err := utils.IsOwnerSID(group, sid)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(ok, jc.IsFalse, gc.Commentf("owner %s", group))
+	}
+	ok, err := utils.IsOwnerSID(sid, sid)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(ok, jc.IsTrue)
+}
+
+func (s *windowsFileSuite) TestHasRestrictivePerms(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "private")
+	err := utils.MkdirAllForUser(path, 0700, "")
+	c.Assert(err, jc.ErrorIsNil)
+	ok, err := utils.HasRestrictivePerms(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ok, jc.IsTrue)
+
+	path = filepath.Join(c.MkDir(), "public")
+	err = utils.MkdirAllForUser(path, 0755, "")
+	c.Assert(err, jc.ErrorIsNil)
+	ok, err = utils.HasRestrictivePerms(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ok, jc.IsFalse)
 }
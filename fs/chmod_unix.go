@@ -0,0 +1,43 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package fs
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/juju/errors"
+)
+
+// chmodNoFollow changes the mode of the file at path, which was
+// described by info when the tree was walked. Regular files and
+// directories are opened without following symbolic links and changed
+// through the open file, so that replacing the entry with a link cannot
+// redirect the change to the link's target.
+func chmodNoFollow(path string, info os.FileInfo, mode os.FileMode) error {
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		// Opening devices, named pipes and sockets may block or have
+		// side effects.
+		return chmodIfSame(path, info, mode)
+	}
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+	if os.IsPermission(err) {
+		// We may change the mode of files we cannot read.
+		return chmodIfSame(path, info, mode)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	current, err := f.Stat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !os.SameFile(current, info) {
+		return errors.Errorf("%q was replaced while changing its mode", path)
+	}
+	return f.Chmod(mode)
+}
@@ -0,0 +1,17 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build windows
+
+package fs
+
+import (
+	"os"
+)
+
+// chmodNoFollow changes the mode of the file at path, which was
+// described by info when the tree was walked, if it has not been
+// replaced since.
+func chmodNoFollow(path string, info os.FileInfo, mode os.FileMode) error {
+	return chmodIfSame(path, info, mode)
+}
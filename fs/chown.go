@@ -0,0 +1,83 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+)
+
+// RecursiveOptions holds the options for ChownRecursive and
+// ChmodRecursive.
+type RecursiveOptions struct {
+	// Filter, if not nil, is called with the path and information of
+	// each entry in the tree, including the root. Entries for which it
+	// returns false are left unchanged; the contents of directories are
+	// still visited.
+	Filter func(path string, info os.FileInfo) bool
+}
+
+// ChownRecursive sets the owner and group of root and everything
+// beneath it to uid and gid. Symbolic links are never followed: their
+// own ownership is changed instead, so a link inside the tree cannot
+// be used to change files outside it.
+//
+// It is not supported on Windows.
+func ChownRecursive(root string, uid, gid int, opts RecursiveOptions) error {
+	return walkNoFollow(root, opts, func(path string, info os.FileInfo) error {
+		return os.Lchown(path, uid, gid)
+	})
+}
+
+// ChmodRecursive sets the permissions of the directories in the tree
+// rooted at root to dirMode, and of all other files to fileMode.
+// Symbolic links are left alone and never followed, since changing the
+// mode of a link changes the mode of its target. An entry that is
+// replaced while the tree is being walked, for example by a symbolic
+// link, is not changed and an error is returned.
+func ChmodRecursive(root string, fileMode, dirMode os.FileMode, opts RecursiveOptions) error {
+	return walkNoFollow(root, opts, func(path string, info os.FileInfo) error {
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			return nil
+		case info.IsDir():
+			return chmodNoFollow(path, info, dirMode)
+		default:
+			return chmodNoFollow(path, info, fileMode)
+		}
+	})
+}
+
+// chmodIfSame changes the mode of the file at path, if it is still the
+// file described by info. There is a small window between the check
+// and the change, so chmodNoFollow uses it only when it cannot change
+// the mode through an open file.
+func chmodIfSame(path string, info os.FileInfo, mode os.FileMode) error {
+	current, err := os.Lstat(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !os.SameFile(current, info) {
+		return errors.Errorf("%q was replaced while changing its mode", path)
+	}
+	return os.Chmod(path, mode)
+}
+
+// walkNoFollow calls change for each entry in the tree rooted at root
+// that passes the filter in opts, without following symbolic links,
+// even when root is one.
+func walkNoFollow(root string, opts RecursiveOptions, change func(path string, info os.FileInfo) error) error {
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if opts.Filter != nil && !opts.Filter(path, info) {
+			return nil
+		}
+		return change(path, info)
+	})
+	return errors.Trace(err)
+}
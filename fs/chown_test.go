@@ -0,0 +1,131 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	ft "github.com/juju/testing/filetesting"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/fs"
+)
+
+type recursiveSuite struct{}
+
+var _ = gc.Suite(&recursiveSuite{})
+
+func (*recursiveSuite) SetUpTest(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("file modes and ownership are not supported on windows")
+	}
+}
+
+var recursiveTree = ft.Entries{
+	ft.Dir{"root", 0700},
+	ft.File{"root/foo", "foodata", 0600},
+	ft.Dir{"root/sub", 0700},
+	ft.File{"root/sub/bar", "bardata", 0600},
+	ft.File{"outside", "outsidedata", 0600},
+	ft.Symlink{"root/link", "../outside"},
+}
+
+func (*recursiveSuite) TestChmodRecursive(c *gc.C) {
+	dir := c.MkDir()
+	recursiveTree.Create(c, dir)
+
+	err := fs.ChmodRecursive(filepath.Join(dir, "root"), 0644, 0755, fs.RecursiveOptions{})
+	c.Assert(err, jc.ErrorIsNil)
+	ft.Entries{
+		ft.Dir{"root", 0755},
+		ft.File{"root/foo", "foodata", 0644},
+		ft.Dir{"root/sub", 0755},
+		ft.File{"root/sub/bar", "bardata", 0644},
+		ft.File{"outside", "outsidedata", 0600},
+		ft.Symlink{"root/link", "../outside"},
+	}.Check(c, dir)
+}
+
+func (*recursiveSuite) TestChmodRecursiveFilter(c *gc.C) {
+	dir := c.MkDir()
+	recursiveTree.Create(c, dir)
+
+	var visited []string
+	err := fs.ChmodRecursive(filepath.Join(dir, "root"), 0640, 0750, fs.RecursiveOptions{
+		Filter: func(path string, info os.FileInfo) bool {
+			rel, err := filepath.Rel(dir, path)
+			c.Assert(err, jc.ErrorIsNil)
+			visited = append(visited, filepath.ToSlash(rel))
+			return info.Name() != "sub"
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(visited, jc.SameContents, []string{
+		"root", "root/foo", "root/sub", "root/sub/bar", "root/link",
+	})
+	ft.Entries{
+		ft.Dir{"root", 0750},
+		ft.File{"root/foo", "foodata", 0640},
+		ft.Dir{"root/sub", 0700},
+		ft.File{"root/sub/bar", "bardata", 0640},
+		ft.File{"outside", "outsidedata", 0600},
+	}.Check(c, dir)
+}
+
+func (*recursiveSuite) TestChmodRecursiveSymlinkRoot(c *gc.C) {
+	dir := c.MkDir()
+	recursiveTree.Create(c, dir)
+
+	err := fs.ChmodRecursive(filepath.Join(dir, "root/link"), 0644, 0755, fs.RecursiveOptions{})
+	c.Assert(err, jc.ErrorIsNil)
+	ft.File{"outside", "outsidedata", 0600}.Check(c, dir)
+}
+
+func (*recursiveSuite) TestChmodRecursiveReplacedBySymlink(c *gc.C) {
+	dir := c.MkDir()
+	recursiveTree.Create(c, dir)
+
+	// Replace root/foo with a link to a file outside the tree after
+	// it has been walked but before its mode is changed.
+	err := fs.ChmodRecursive(filepath.Join(dir, "root"), 0644, 0755, fs.RecursiveOptions{
+		Filter: func(path string, info os.FileInfo) bool {
+			if info.Name() == "foo" {
+				err := os.Remove(path)
+				c.Assert(err, jc.ErrorIsNil)
+				err = os.Symlink("../outside", path)
+				c.Assert(err, jc.ErrorIsNil)
+			}
+			return true
+		},
+	})
+	c.Assert(err, gc.NotNil)
+	ft.File{"outside", "outsidedata", 0600}.Check(c, dir)
+}
+
+func (*recursiveSuite) TestChownRecursive(c *gc.C) {
+	dir := c.MkDir()
+	recursiveTree.Create(c, dir)
+
+	// Only root can give files away, but anyone can chown their own
+	// files to themselves.
+	uid, gid := os.Getuid(), os.Getgid()
+	var changed []string
+	err := fs.ChownRecursive(filepath.Join(dir, "root"), uid, gid, fs.RecursiveOptions{
+		Filter: func(path string, info os.FileInfo) bool {
+			changed = append(changed, filepath.Base(path))
+			return true
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(changed, jc.SameContents, []string{"root", "foo", "sub", "bar", "link"})
+}
+
+func (*recursiveSuite) TestChmodRecursiveMissing(c *gc.C) {
+	err := fs.ChmodRecursive(filepath.Join(c.MkDir(), "missing"), 0644, 0755, fs.RecursiveOptions{})
+	c.Assert(errors.Cause(err), jc.Satisfies, os.IsNotExist)
+}
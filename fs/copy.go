@@ -35,7 +35,8 @@ func Copy(src, dst string) error {
 	case os.ModeDir:
 		return copyDir(src, dst, mode)
 	case 0:
-		return copyFile(src, dst, mode)
+		_, err := copyFile(src, dst, mode)
+		return err
 	default:
 		return fmt.Errorf("cannot copy file with mode %v", mode)
 	}
@@ -49,26 +50,27 @@ func copySymLink(src, dst string) error {
 	return os.Symlink(target, dst)
 }
 
-func copyFile(src, dst string, mode os.FileMode) error {
+func copyFile(src, dst string, mode os.FileMode) (int64, error) {
 	srcf, err := os.Open(src)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer srcf.Close()
 	dstf, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer dstf.Close()
 	// Make the actual permissions match the source permissions
 	// even in the presence of umask.
 	if err := os.Chmod(dstf.Name(), mode.Perm()); err != nil {
-		return err
+		return 0, err
 	}
-	if _, err := io.Copy(dstf, srcf); err != nil {
-		return fmt.Errorf("cannot copy %q to %q: %v", src, dst, err)
+	n, err := io.Copy(dstf, srcf)
+	if err != nil {
+		return n, fmt.Errorf("cannot copy %q to %q: %v", src, dst, err)
 	}
-	return nil
+	return n, nil
 }
 
 func copyDir(src, dst string, mode os.FileMode) error {
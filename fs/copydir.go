@@ -0,0 +1,189 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/juju/errors"
+)
+
+// SymlinkPolicy determines how CopyDir treats symbolic links.
+type SymlinkPolicy int
+
+const (
+	// CopySymlinks copies symbolic links as links, with the same
+	// target. This is the default.
+	CopySymlinks SymlinkPolicy = iota
+
+	// FollowSymlinks copies whatever symbolic links point to.
+	// Dangling links cause an error, and links to a directory that
+	// is already being copied are not followed a second time.
+	FollowSymlinks
+
+	// SkipSymlinks leaves symbolic links out of the copy.
+	SkipSymlinks
+)
+
+// CopyDirOptions holds the options for CopyDir.
+type CopyDirOptions struct {
+	// Symlinks determines how symbolic links are treated.
+	Symlinks SymlinkPolicy
+
+	// Exclude holds glob patterns, in the syntax of MatchGlob, for
+	// entries that are left out of the copy. Each pattern is matched
+	// both against the path of an entry relative to the source
+	// directory and against its base name, so "*.pyc" excludes
+	// matching files in every directory.
+	Exclude []string
+
+	// Progress, if not nil, is called after each file, directory and
+	// symbolic link is copied, with its path relative to the source
+	// directory and the number of bytes copied.
+	Progress func(path string, bytes int64)
+}
+
+// CopyDir recursively copies the directory at src to dst, which must
+// not exist, as configured by opts.
+//
+// If the copy fails half way through, the destination might be left
+// partially written.
+func CopyDir(src, dst string, opts CopyDirOptions) error {
+	for _, pattern := range opts.Exclude {
+		if err := validateGlob(filepath.ToSlash(pattern)); err != nil {
+			return errors.NotValidf("exclude pattern %q", pattern)
+		}
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !info.IsDir() {
+		return errors.NotValidf("copying non-directory %q", src)
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("will not overwrite %q", dst)
+	} else if !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+	c := &dirCopier{opts: opts}
+	return c.copyDir(src, dst, "", info)
+}
+
+// dirCopier holds the state of a CopyDir call.
+type dirCopier struct {
+	opts CopyDirOptions
+
+	// parents holds the directories being copied, outermost first,
+	// to stop followed symbolic links from causing loops.
+	parents []os.FileInfo
+}
+
+func (c *dirCopier) copyEntry(src, dst, rel string) error {
+	if c.excluded(rel) {
+		return nil
+	}
+	info, err := os.Lstat(src)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		switch c.opts.Symlinks {
+		case SkipSymlinks:
+			return nil
+		case FollowSymlinks:
+			if info, err = os.Stat(src); err != nil {
+				return errors.Annotatef(err, "cannot follow symbolic link %q", src)
+			}
+		default:
+			if err := copySymLink(src, dst); err != nil {
+				return errors.Trace(err)
+			}
+			c.progress(rel, 0)
+			return nil
+		}
+	}
+	switch mode := info.Mode(); mode & os.ModeType {
+	case os.ModeDir:
+		return c.copyDir(src, dst, rel, info)
+	case 0:
+		n, err := copyFile(src, dst, mode)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c.progress(rel, n)
+		return nil
+	default:
+		return fmt.Errorf("cannot copy file with mode %v", mode)
+	}
+}
+
+func (c *dirCopier) copyDir(src, dst, rel string, info os.FileInfo) error {
+	for _, parent := range c.parents {
+		if os.SameFile(parent, info) {
+			// A followed symbolic link leads back to a directory
+			// we're already copying.
+			return nil
+		}
+	}
+	c.parents = append(c.parents, info)
+	defer func() {
+		c.parents = c.parents[:len(c.parents)-1]
+	}()
+
+	srcf, err := os.Open(src)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	names, err := srcf.Readdirnames(-1)
+	srcf.Close()
+	if err != nil {
+		return fmt.Errorf("error reading directory %q: %v", src, err)
+	}
+	sort.Strings(names)
+
+	mode := info.Mode()
+	// As in Copy, make sure we can create the contents of the new
+	// directory, and make the permissions match at the end.
+	if err := os.Mkdir(dst, mode.Perm()|0700); err != nil {
+		return errors.Trace(err)
+	}
+	for _, name := range names {
+		if err := c.copyEntry(filepath.Join(src, name), filepath.Join(dst, name), filepath.Join(rel, name)); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod(dst, mode.Perm()); err != nil {
+		return errors.Trace(err)
+	}
+	if rel != "" {
+		c.progress(rel, 0)
+	}
+	return nil
+}
+
+func (c *dirCopier) excluded(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	base := path.Base(rel)
+	for _, pattern := range c.opts.Exclude {
+		pattern = filepath.ToSlash(pattern)
+		if ok, _ := MatchGlob(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := MatchGlob(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *dirCopier) progress(rel string, n int64) {
+	if c.opts.Progress != nil {
+		c.opts.Progress(rel, n)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	ft "github.com/juju/testing/filetesting"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/fs"
+)
+
+type copyDirSuite struct{}
+
+var _ = gc.Suite(&copyDirSuite{})
+
+var copyDirSource = ft.Entries{
+	ft.Dir{"src", 0755},
+	ft.File{"src/foo", "foodata", 0644},
+	ft.File{"src/foo.pyc", "compiled", 0644},
+	ft.Dir{"src/sub", 0750},
+	ft.File{"src/sub/bar", "bardata", 0600},
+	ft.File{"src/sub/bar.pyc", "compiled", 0600},
+	ft.Symlink{"src/link", "foo"},
+	ft.Symlink{"src/sublink", "sub"},
+}
+
+var copyDirTests = []struct {
+	about    string
+	opts     fs.CopyDirOptions
+	expected ft.Entries
+}{{
+	about: "copy symlinks",
+	expected: ft.Entries{
+		ft.Dir{"dst", 0755},
+		ft.File{"dst/foo", "foodata", 0644},
+		ft.File{"dst/foo.pyc", "compiled", 0644},
+		ft.Dir{"dst/sub", 0750},
+		ft.File{"dst/sub/bar", "bardata", 0600},
+		ft.File{"dst/sub/bar.pyc", "compiled", 0600},
+		ft.Symlink{"dst/link", "foo"},
+		ft.Symlink{"dst/sublink", "sub"},
+	},
+}, {
+	about: "follow symlinks",
+	opts:  fs.CopyDirOptions{Symlinks: fs.FollowSymlinks},
+	expected: ft.Entries{
+		ft.File{"dst/link", "foodata", 0644},
+		ft.Dir{"dst/sublink", 0750},
+		ft.File{"dst/sublink/bar", "bardata", 0600},
+	},
+}, {
+	about: "skip symlinks",
+	opts:  fs.CopyDirOptions{Symlinks: fs.SkipSymlinks},
+	expected: ft.Entries{
+		ft.File{"dst/foo", "foodata", 0644},
+		ft.Removed{"dst/link"},
+		ft.Removed{"dst/sublink"},
+	},
+}, {
+	about: "exclude base names and relative paths",
+	opts:  fs.CopyDirOptions{Exclude: []string{"*.pyc", filepath.Join("sub", "bar")}},
+	expected: ft.Entries{
+		ft.File{"dst/foo", "foodata", 0644},
+		ft.Removed{"dst/foo.pyc"},
+		ft.Dir{"dst/sub", 0750},
+		ft.Removed{"dst/sub/bar"},
+		ft.Removed{"dst/sub/bar.pyc"},
+	},
+}, {
+	about: "exclude with doublestar and braces",
+	opts:  fs.CopyDirOptions{Exclude: []string{"sub/**/*.{pyc,pyo}", "foo"}},
+	expected: ft.Entries{
+		ft.Removed{"dst/foo"},
+		ft.File{"dst/foo.pyc", "compiled", 0644},
+		ft.File{"dst/sub/bar", "bardata", 0600},
+		ft.Removed{"dst/sub/bar.pyc"},
+	},
+}}
+
+func (*copyDirSuite) TestCopyDir(c *gc.C) {
+	for i, test := range copyDirTests {
+		c.Logf("test %d: %v", i, test.about)
+		dir := c.MkDir()
+		copyDirSource.Create(c, dir)
+		err := fs.CopyDir(filepath.Join(dir, "src"), filepath.Join(dir, "dst"), test.opts)
+		c.Assert(err, jc.ErrorIsNil)
+		test.expected.Check(c, dir)
+	}
+}
+
+func (*copyDirSuite) TestProgress(c *gc.C) {
+	dir := c.MkDir()
+	copyDirSource.Create(c, dir)
+	progress := make(map[string]int64)
+	err := fs.CopyDir(filepath.Join(dir, "src"), filepath.Join(dir, "dst"), fs.CopyDirOptions{
+		Exclude: []string{"*.pyc"},
+		Progress: func(path string, n int64) {
+			progress[path] = n
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(progress, jc.DeepEquals, map[string]int64{
+		"foo":                       7,
+		"link":                      0,
+		"sub":                       0,
+		filepath.Join("sub", "bar"): 7,
+		"sublink":                   0,
+	})
+}
+
+func (*copyDirSuite) TestFollowSymlinkLoop(c *gc.C) {
+	dir := c.MkDir()
+	ft.Entries{
+		ft.Dir{"src", 0755},
+		ft.Dir{"src/sub", 0755},
+		ft.Symlink{"src/sub/parent", ".."},
+	}.Create(c, dir)
+	err := fs.CopyDir(filepath.Join(dir, "src"), filepath.Join(dir, "dst"), fs.CopyDirOptions{
+		Symlinks: fs.FollowSymlinks,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	ft.Entries{
+		ft.Dir{"dst/sub", 0755},
+		ft.Removed{"dst/sub/parent"},
+	}.Check(c, dir)
+}
+
+func (*copyDirSuite) TestFollowDanglingSymlink(c *gc.C) {
+	dir := c.MkDir()
+	ft.Entries{
+		ft.Dir{"src", 0755},
+		ft.Symlink{"src/link", "missing"},
+	}.Create(c, dir)
+	err := fs.CopyDir(filepath.Join(dir, "src"), filepath.Join(dir, "dst"), fs.CopyDirOptions{
+		Symlinks: fs.FollowSymlinks,
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot follow symbolic link ".*link": .*`)
+}
+
+func (*copyDirSuite) TestErrors(c *gc.C) {
+	dir := c.MkDir()
+	copyDirSource.Create(c, dir)
+	src := filepath.Join(dir, "src")
+
+	err := fs.CopyDir(src, filepath.Join(dir, "dst"), fs.CopyDirOptions{Exclude: []string{"["}})
+	c.Assert(err, gc.ErrorMatches, `exclude pattern "\[" not valid`)
+
+	err = fs.CopyDir(filepath.Join(src, "foo"), filepath.Join(dir, "dst"), fs.CopyDirOptions{})
+	c.Assert(err, gc.ErrorMatches, `copying non-directory ".*foo" not valid`)
+
+	err = fs.CopyDir(src, filepath.Join(src, "sub"), fs.CopyDirOptions{})
+	c.Assert(err, gc.ErrorMatches, `will not overwrite ".*sub"`)
+
+	_, err = os.Stat(filepath.Join(dir, "dst"))
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+}
@@ -0,0 +1,232 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fs
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// MatchGlob reports whether name, a slash-separated relative path,
+// matches pattern. Patterns use the syntax of path.Match within each
+// path element, with two additions:
+//
+//	**      as a whole path element, matches zero or more elements
+//	{a,b}   matches either alternative; alternatives may be nested
+//
+// So "**/*.{go,s}" matches every Go and assembly file beneath the
+// root, and "a/**" matches a and everything beneath it.
+func MatchGlob(pattern, name string) (bool, error) {
+	patterns, err := ExpandBraces(pattern)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	nameElems := splitPath(name)
+	for _, p := range patterns {
+		ok, err := matchElems(splitPath(p), nameElems)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// ExpandBraces returns the patterns produced by expanding the brace
+// alternatives in pattern, in order. For example, "a{b,c{d,e}}"
+// expands to "ab", "acd" and "ace". Braces within character classes
+// and those escaped with a backslash are left alone.
+func ExpandBraces(pattern string) ([]string, error) {
+	start, end, alts, err := findBraces(pattern)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if start < 0 {
+		return []string{pattern}, nil
+	}
+	var expanded []string
+	for _, alt := range alts {
+		more, err := ExpandBraces(pattern[:start] + alt + pattern[end+1:])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		expanded = append(expanded, more...)
+	}
+	return expanded, nil
+}
+
+// findBraces finds the first top-level brace group in pattern,
+// returning the positions of its braces and its alternatives. If
+// there is none, start is -1.
+func findBraces(pattern string) (start, end int, alts []string, err error) {
+	start = -1
+	depth := 0
+	altStart := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			// Skip the character class.
+			j := strings.IndexByte(pattern[i+1:], ']')
+			if j < 0 {
+				return -1, -1, nil, path.ErrBadPattern
+			}
+			i += j + 1
+		case '{':
+			if depth == 0 {
+				start = i
+				altStart = i + 1
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				alts = append(alts, pattern[altStart:i])
+				altStart = i + 1
+			}
+		case '}':
+			if depth == 0 {
+				return -1, -1, nil, path.ErrBadPattern
+			}
+			depth--
+			if depth == 0 {
+				alts = append(alts, pattern[altStart:i])
+				return start, i, alts, nil
+			}
+		}
+	}
+	if depth > 0 {
+		return -1, -1, nil, path.ErrBadPattern
+	}
+	return -1, -1, nil, nil
+}
+
+// matchElems reports whether the path elements in name match the
+// pattern elements in pattern.
+func matchElems(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true, nil
+			}
+			for i := 0; i <= len(name); i++ {
+				ok, err := matchElems(pattern, name[i:])
+				if ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if !ok || err != nil {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// validateGlob returns an error if pattern is malformed.
+func validateGlob(pattern string) error {
+	patterns, err := ExpandBraces(pattern)
+	if err != nil {
+		return err
+	}
+	for _, p := range patterns {
+		for _, elem := range splitPath(p) {
+			if _, err := path.Match(elem, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Glob returns the paths, relative to root, of the files and
+// directories beneath root that match patterns, in lexical order.
+// Patterns are slash-separated and use the syntax of MatchGlob. A
+// pattern starting with "!" excludes the entries it matches instead.
+// When several patterns match an entry, the last one wins, so
+//
+//	Glob(root, "**/*.go", "!vendor/**", "vendor/github.com/juju/**/*.go")
+//
+// finds the Go files outside the vendor directory and those in the
+// vendored juju packages. The contents of a directory that is
+// excluded are not searched unless a later pattern could include
+// them. Symbolic links are not followed.
+func Glob(root string, patterns ...string) ([]string, error) {
+	for _, pattern := range patterns {
+		if err := validateGlob(strings.TrimPrefix(pattern, "!")); err != nil {
+			return nil, errors.NotValidf("glob pattern %q", pattern)
+		}
+	}
+	var matches []string
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		included, last := globIncluded(patterns, filepath.ToSlash(rel))
+		if included {
+			matches = append(matches, rel)
+		} else if info.IsDir() && last >= 0 && !includesAfter(patterns, last) {
+			// Nothing beneath the directory can be included.
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return matches, nil
+}
+
+// globIncluded reports whether name is included by patterns, and the
+// index of the last pattern that matched it, or -1 if none did.
+func globIncluded(patterns []string, name string) (included bool, last int) {
+	last = -1
+	for i, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		// The patterns have already been validated.
+		if ok, _ := MatchGlob(strings.TrimPrefix(pattern, "!"), name); ok {
+			included = !exclude
+			last = i
+		}
+	}
+	return included, last
+}
+
+// includesAfter reports whether any pattern after patterns[i] includes
+// entries.
+func includesAfter(patterns []string, i int) bool {
+	for _, pattern := range patterns[i+1:] {
+		if !strings.HasPrefix(pattern, "!") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fs_test
+
+import (
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	ft "github.com/juju/testing/filetesting"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/fs"
+)
+
+type globSuite struct{}
+
+var _ = gc.Suite(&globSuite{})
+
+var matchGlobTests = []struct {
+	pattern string
+	name    string
+	match   bool
+	err     string
+}{
+	{pattern: "*.go", name: "main.go", match: true},
+	{pattern: "*.go", name: "cmd/main.go"},
+	{pattern: "**/*.go", name: "main.go", match: true},
+	{pattern: "**/*.go", name: "cmd/juju/main.go", match: true},
+	{pattern: "**/*.go", name: "cmd/juju/main.c"},
+	{pattern: "cmd/**/main.go", name: "cmd/main.go", match: true},
+	{pattern: "cmd/**/main.go", name: "cmd/a/b/c/main.go", match: true},
+	{pattern: "cmd/**/main.go", name: "other/a/main.go"},
+	{pattern: "cmd/**", name: "cmd", match: true},
+	{pattern: "cmd/**", name: "cmd/a/b", match: true},
+	{pattern: "**", name: "anything/at/all", match: true},
+	{pattern: "**/**/x", name: "x", match: true},
+	{pattern: "a/?/c", name: "a/b/c", match: true},
+	{pattern: "a/[a-c]/c", name: "a/d/c"},
+	{pattern: "**/*.{go,s}", name: "runtime/asm.s", match: true},
+	{pattern: "**/*.{go,s}", name: "runtime/asm.c"},
+	{pattern: "{cmd,pkg}/**/*_test.go", name: "pkg/x/x_test.go", match: true},
+	{pattern: "{cmd,pkg}/**/*_test.go", name: "internal/x_test.go"},
+	{pattern: `\{a,b\}`, name: "{a,b}", match: true},
+	{pattern: "a{b", name: "ab", err: "syntax error in pattern"},
+	{pattern: "[a", name: "a", err: "syntax error in pattern"},
+}
+
+func (*globSuite) TestMatchGlob(c *gc.C) {
+	for i, test := range matchGlobTests {
+		c.Logf("test %d: %q %q", i, test.pattern, test.name)
+		match, err := fs.MatchGlob(test.pattern, test.name)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(match, gc.Equals, test.match)
+	}
+}
+
+var expandBracesTests = []struct {
+	pattern  string
+	expected []string
+	err      string
+}{
+	{pattern: "abc", expected: []string{"abc"}},
+	{pattern: "a{b,c}d", expected: []string{"abd", "acd"}},
+	{pattern: "a{b,c{d,e}}", expected: []string{"ab", "acd", "ace"}},
+	{pattern: "{a,b}{c,d}", expected: []string{"ac", "ad", "bc", "bd"}},
+	{pattern: "x{,y}", expected: []string{"x", "xy"}},
+	{pattern: "[{]{a,b}", expected: []string{"[{]a", "[{]b"}},
+	{pattern: `\{a,b}`, err: "syntax error in pattern"},
+	{pattern: "{a,b", err: "syntax error in pattern"},
+}
+
+func (*globSuite) TestExpandBraces(c *gc.C) {
+	for i, test := range expandBracesTests {
+		c.Logf("test %d: %q", i, test.pattern)
+		expanded, err := fs.ExpandBraces(test.pattern)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(expanded, jc.DeepEquals, test.expected)
+	}
+}
+
+var globTree = ft.Entries{
+	ft.File{"main.go", "", 0644},
+	ft.File{"README.md", "", 0644},
+	ft.Dir{"cmd", 0755},
+	ft.File{"cmd/run.go", "", 0644},
+	ft.File{"cmd/run_test.go", "", 0644},
+	ft.Dir{"vendor", 0755},
+	ft.Dir{"vendor/other", 0755},
+	ft.File{"vendor/other/x.go", "", 0644},
+	ft.Dir{"vendor/juju", 0755},
+	ft.File{"vendor/juju/y.go", "", 0644},
+	ft.File{"vendor/juju/y.md", "", 0644},
+}
+
+var globTests = []struct {
+	patterns []string
+	expected []string
+}{{
+	patterns: []string{"*.go"},
+	expected: []string{"main.go"},
+}, {
+	patterns: []string{"**/*.go"},
+	expected: []string{"cmd/run.go", "cmd/run_test.go", "main.go", "vendor/juju/y.go", "vendor/other/x.go"},
+}, {
+	patterns: []string{"**/*.go", "!**/*_test.go", "!vendor"},
+	expected: []string{"cmd/run.go", "main.go"},
+}, {
+	patterns: []string{"**/*.go", "!vendor/**", "vendor/juju/*.go"},
+	expected: []string{"cmd/run.go", "cmd/run_test.go", "main.go", "vendor/juju/y.go"},
+}, {
+	patterns: []string{"**", "!**/*.{go,md}"},
+	expected: []string{"cmd", "vendor", "vendor/juju", "vendor/other"},
+}, {
+	patterns: []string{"nothing/**"},
+}}
+
+func (*globSuite) TestGlob(c *gc.C) {
+	root := c.MkDir()
+	globTree.Create(c, root)
+	for i, test := range globTests {
+		c.Logf("test %d: %q", i, test.patterns)
+		matches, err := fs.Glob(root, test.patterns...)
+		c.Check(err, jc.ErrorIsNil)
+		var expected []string
+		for _, p := range test.expected {
+			expected = append(expected, filepath.FromSlash(p))
+		}
+		c.Check(matches, jc.DeepEquals, expected)
+	}
+}
+
+func (*globSuite) TestGlobBadPattern(c *gc.C) {
+	_, err := fs.Glob(c.MkDir(), "**/*.go", "!{a")
+	c.Assert(err, gc.ErrorMatches, `glob pattern "!{a" not valid`)
+}
+
+func (*globSuite) TestGlobMissingRoot(c *gc.C) {
+	_, err := fs.Glob(filepath.Join(c.MkDir(), "missing"), "**")
+	c.Assert(err, gc.ErrorMatches, `.*no such file or directory`)
+}
@@ -0,0 +1,334 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// Package fslock provides an advisory file lock that can be used to
+// serialise access to shared state, such as a directory, between
+// processes.
+//
+// The lock is held using flock on POSIX systems and LockFileEx on
+// Windows, so the operating system releases it if the holder exits.
+// While the lock is held the lock file records the holder's process
+// ID and the time it acquired the lock. A lock whose holder is no
+// longer running, or that has been held for longer than the
+// configured StaleAge, is considered stale and is broken by removing
+// the lock file. Process IDs are only meaningful on a single machine,
+// so lock files should not be shared between machines. On Windows a
+// lock file cannot be removed while its holder has it open, so stale
+// locks cannot be broken there; they are still released when their
+// holder exits.
+package fslock
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+)
+
+var logger = loggo.GetLogger("juju.utils.fslock")
+
+var (
+	// ErrLocked is returned by TryLock when the lock is held by
+	// someone else.
+	ErrLocked = errors.New("lock held by another process")
+
+	// ErrTimeout is returned by LockWithTimeout when the lock could
+	// not be acquired in time.
+	ErrTimeout = errors.New("lock timeout exceeded")
+)
+
+// defaultDelay is the default time LockWithTimeout waits between
+// attempts to acquire the lock.
+const defaultDelay = 100 * time.Millisecond
+
+// LockConfig holds the configuration for a Lock.
+type LockConfig struct {
+	// Clock is used to record acquisition times and to wait between
+	// attempts. If it is nil, clock.WallClock is used.
+	Clock clock.Clock
+
+	// Delay is the time LockWithTimeout waits between attempts to
+	// acquire the lock. If it is zero, 100ms is used.
+	Delay time.Duration
+
+	// StaleAge, if positive, is the time after which a held lock is
+	// considered stale and may be broken, even if its holder is still
+	// running. This allows recovery from holders that have hung.
+	StaleAge time.Duration
+}
+
+// Lock is an advisory lock on a file. A Lock is safe for concurrent
+// use, but is not reentrant: it must be unlocked before it can be
+// locked again.
+type Lock struct {
+	path   string
+	config LockConfig
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewLock returns a Lock that uses the file at the given path, which
+// is created if necessary. The directory containing it must exist.
+func NewLock(path string, config LockConfig) (*Lock, error) {
+	if path == "" {
+		return nil, errors.NotValidf("empty lock path")
+	}
+	if config.Delay < 0 {
+		return nil, errors.NotValidf("negative Delay")
+	}
+	if config.StaleAge < 0 {
+		return nil, errors.NotValidf("negative StaleAge")
+	}
+	if config.Clock == nil {
+		config.Clock = clock.WallClock
+	}
+	if config.Delay == 0 {
+		config.Delay = defaultDelay
+	}
+	return &Lock{
+		path:   path,
+		config: config,
+	}, nil
+}
+
+// Path returns the path of the lock file.
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// IsLocked reports whether the lock is held by l.
+func (l *Lock) IsLocked() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file != nil
+}
+
+// TryLock attempts to acquire the lock without waiting. If the lock
+// is held elsewhere and is not stale, it returns an error satisfying
+// IsLocked.
+func (l *Lock) TryLock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		return errors.Errorf("lock %q already held", l.path)
+	}
+	broken := false
+	for {
+		file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return errors.Annotate(err, "cannot open lock file")
+		}
+		if err := lockFile(file); err == errWouldBlock {
+			holder, err := readHolder(file)
+			if err == nil && !broken && l.isStale(holder) {
+				logger.Infof("breaking stale lock %q held by %s", l.path, holder)
+				err := breakLock(file, l.path)
+				file.Close()
+				if isFileInUse(errors.Cause(err)) {
+					// The holder still has the file open, so
+					// we must wait for it to exit.
+					return errors.Annotatef(ErrLocked, "lock %q (holder: %s)", l.path, holder)
+				}
+				if err != nil {
+					return errors.Annotate(err, "cannot break stale lock")
+				}
+				broken = true
+				continue
+			}
+			file.Close()
+			if err == nil {
+				return errors.Annotatef(ErrLocked, "lock %q (holder: %s)", l.path, holder)
+			}
+			return errors.Annotatef(ErrLocked, "lock %q", l.path)
+		} else if err != nil {
+			file.Close()
+			return errors.Annotate(err, "cannot lock file")
+		}
+		// The lock file may have been removed, by someone breaking
+		// a stale lock, between opening and locking it. If so, our
+		// lock on the old file is worthless, so start again.
+		if same, err := isCurrentFile(file, l.path); err != nil {
+			unlockFile(file)
+			file.Close()
+			return errors.Trace(err)
+		} else if !same {
+			unlockFile(file)
+			file.Close()
+			continue
+		}
+		if err := writeHolder(file, lockHolder{
+			pid:      os.Getpid(),
+			acquired: l.config.Clock.Now(),
+		}); err != nil {
+			unlockFile(file)
+			file.Close()
+			return errors.Annotate(err, "cannot write lock file")
+		}
+		l.file = file
+		return nil
+	}
+}
+
+// LockWithTimeout attempts to acquire the lock, waiting for up to
+// the given duration for it to become available. If the lock is not
+// acquired in time, it returns ErrTimeout.
+func (l *Lock) LockWithTimeout(timeout time.Duration) error {
+	deadline := l.config.Clock.Now().Add(timeout)
+	for {
+		err := l.TryLock()
+		if !IsLocked(err) {
+			return errors.Trace(err)
+		}
+		remaining := deadline.Sub(l.config.Clock.Now())
+		if remaining <= 0 {
+			return ErrTimeout
+		}
+		delay := l.config.Delay
+		if delay > remaining {
+			delay = remaining
+		}
+		<-l.config.Clock.After(delay)
+	}
+}
+
+// Unlock releases the lock. It returns an error if the lock is not
+// held by l.
+func (l *Lock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return errors.Errorf("lock %q not held", l.path)
+	}
+	file := l.file
+	l.file = nil
+	// Clear the holder so that nobody mistakes it for a current one.
+	// The file itself is left in place, as removing it could race
+	// with another process that has just opened it.
+	if err := file.Truncate(0); err != nil {
+		logger.Warningf("cannot clear lock file %q: %v", l.path, err)
+	}
+	if err := unlockFile(file); err != nil {
+		file.Close()
+		return errors.Annotate(err, "cannot unlock file")
+	}
+	return errors.Trace(file.Close())
+}
+
+// IsLocked reports whether err was returned because a lock is held by
+// someone else.
+func IsLocked(err error) bool {
+	return errors.Cause(err) == ErrLocked
+}
+
+func (l *Lock) isStale(holder lockHolder) bool {
+	if !processExists(holder.pid) {
+		return true
+	}
+	if l.config.StaleAge > 0 && l.config.Clock.Now().Sub(holder.acquired) > l.config.StaleAge {
+		return true
+	}
+	return false
+}
+
+// lockHolder records who holds a lock, and since when.
+type lockHolder struct {
+	pid      int
+	acquired time.Time
+}
+
+// String implements fmt.Stringer.
+func (h lockHolder) String() string {
+	return fmt.Sprintf("process %d since %s", h.pid, h.acquired.UTC().Format(time.RFC3339))
+}
+
+func writeHolder(file *os.File, holder lockHolder) error {
+	if err := file.Truncate(0); err != nil {
+		return errors.Trace(err)
+	}
+	data := fmt.Sprintf("%d %d\n", holder.pid, holder.acquired.UnixNano())
+	if _, err := file.WriteAt([]byte(data), 0); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(file.Sync())
+}
+
+// maxHolderSize holds the most that readHolder reads from a lock file;
+// a valid holder is much smaller.
+const maxHolderSize = 1024
+
+func readHolder(file *os.File) (lockHolder, error) {
+	data, err := ioutil.ReadAll(io.NewSectionReader(file, 0, maxHolderSize))
+	if err != nil {
+		return lockHolder{}, errors.Trace(err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return lockHolder{}, errors.NotValidf("lock file content %q", data)
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return lockHolder{}, errors.NotValidf("lock file pid %q", fields[0])
+	}
+	nanos, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return lockHolder{}, errors.NotValidf("lock file timestamp %q", fields[1])
+	}
+	return lockHolder{
+		pid:      pid,
+		acquired: time.Unix(0, nanos),
+	}, nil
+}
+
+// breakLock removes the lock file at path, which file was opened from
+// and found to have a stale holder. If the file at path is no longer
+// that file, the stale lock has already been broken by someone else,
+// who may now hold the lock in a new file, so it is left alone.
+//
+// Checking the file and then removing it would leave a window in which
+// the new file could be removed instead, so the file at path is first
+// moved aside to a name of our own, where no one else can replace it,
+// and checked there. A file that turns out not to be the stale one is
+// linked back into place, which fails rather than replacing any file
+// created at path in the meantime.
+func breakLock(file *os.File, path string) error {
+	aside := fmt.Sprintf("%s.%d.%d.stale", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, aside); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	same, err := isCurrentFile(file, aside)
+	if err != nil || !same {
+		if err := os.Link(aside, path); err != nil {
+			return errors.Annotate(err, "cannot restore lock file")
+		}
+	}
+	if err := os.Remove(aside); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(err)
+}
+
+// isCurrentFile reports whether file is the file currently at path.
+func isCurrentFile(file *os.File, path string) (bool, error) {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	pathInfo, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Trace(err)
+	}
+	return os.SameFile(fileInfo, pathInfo), nil
+}
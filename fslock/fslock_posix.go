@@ -0,0 +1,40 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package fslock
+
+import (
+	"os"
+	"syscall"
+)
+
+// errWouldBlock is returned by lockFile when the file is locked by
+// someone else.
+var errWouldBlock = syscall.EWOULDBLOCK
+
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EAGAIN {
+		return errWouldBlock
+	}
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
+
+// isFileInUse reports whether err was returned when removing a file
+// because it is open. POSIX systems allow open files to be removed.
+func isFileInUse(err error) bool {
+	return false
+}
+
+// processExists reports whether a process with the given ID is
+// running.
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
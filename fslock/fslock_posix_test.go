@@ -0,0 +1,10 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package fslock_test
+
+// deadPID is a process ID that is above the maximum on Linux and
+// macOS, so cannot be running.
+const deadPID = 1 << 30
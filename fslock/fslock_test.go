@@ -0,0 +1,192 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fslock_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/fslock"
+)
+
+type fslockSuite struct {
+	testing.IsolationSuite
+	path string
+}
+
+var _ = gc.Suite(&fslockSuite{})
+
+func (s *fslockSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.path = filepath.Join(c.MkDir(), "lock")
+}
+
+func (s *fslockSuite) newLock(c *gc.C, config fslock.LockConfig) *fslock.Lock {
+	lock, err := fslock.NewLock(s.path, config)
+	c.Assert(err, jc.ErrorIsNil)
+	return lock
+}
+
+func (s *fslockSuite) TestNewLockValidation(c *gc.C) {
+	_, err := fslock.NewLock("", fslock.LockConfig{})
+	c.Assert(err, gc.ErrorMatches, "empty lock path not valid")
+	_, err = fslock.NewLock(s.path, fslock.LockConfig{Delay: -1})
+	c.Assert(err, gc.ErrorMatches, "negative Delay not valid")
+	_, err = fslock.NewLock(s.path, fslock.LockConfig{StaleAge: -1})
+	c.Assert(err, gc.ErrorMatches, "negative StaleAge not valid")
+}
+
+func (s *fslockSuite) TestTryLock(c *gc.C) {
+	lock1 := s.newLock(c, fslock.LockConfig{})
+	lock2 := s.newLock(c, fslock.LockConfig{})
+
+	c.Assert(lock1.TryLock(), jc.ErrorIsNil)
+	c.Assert(lock1.IsLocked(), jc.IsTrue)
+	err := lock2.TryLock()
+	c.Assert(fslock.IsLocked(err), jc.IsTrue)
+	c.Assert(err, gc.ErrorMatches, fmt.Sprintf(`lock ".*" \(holder: process %d since .*\): lock held by another process`, os.Getpid()))
+	c.Assert(lock2.IsLocked(), jc.IsFalse)
+
+	c.Assert(lock1.Unlock(), jc.ErrorIsNil)
+	c.Assert(lock1.IsLocked(), jc.IsFalse)
+	c.Assert(lock2.TryLock(), jc.ErrorIsNil)
+	c.Assert(lock2.Unlock(), jc.ErrorIsNil)
+}
+
+func (s *fslockSuite) TestNotReentrant(c *gc.C) {
+	lock := s.newLock(c, fslock.LockConfig{})
+	c.Assert(lock.TryLock(), jc.ErrorIsNil)
+	c.Assert(lock.TryLock(), gc.ErrorMatches, `lock ".*" already held`)
+	c.Assert(lock.Unlock(), jc.ErrorIsNil)
+	c.Assert(lock.Unlock(), gc.ErrorMatches, `lock ".*" not held`)
+}
+
+func (s *fslockSuite) TestHolderRecorded(c *gc.C) {
+	now := time.Date(2018, 9, 1, 12, 0, 0, 0, time.UTC)
+	lock := s.newLock(c, fslock.LockConfig{Clock: testclock.NewClock(now)})
+	c.Assert(lock.TryLock(), jc.ErrorIsNil)
+	data, err := ioutil.ReadFile(s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, fmt.Sprintf("%d %d\n", os.Getpid(), now.UnixNano()))
+
+	c.Assert(lock.Unlock(), jc.ErrorIsNil)
+	data, err = ioutil.ReadFile(s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "")
+}
+
+func (s *fslockSuite) TestBreakDeadHolder(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		// Windows does not allow the lock file to be removed while
+		// the holder in this process has it open.
+		c.Skip("non-windows only test")
+	}
+	lock1 := s.newLock(c, fslock.LockConfig{})
+	lock2 := s.newLock(c, fslock.LockConfig{})
+	c.Assert(lock1.TryLock(), jc.ErrorIsNil)
+	defer lock1.Unlock()
+
+	// Pretend the holder is a process that has died without
+	// releasing the lock.
+	err := ioutil.WriteFile(s.path, []byte(fmt.Sprintf("%d %d\n", deadPID, time.Now().UnixNano())), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(lock2.TryLock(), jc.ErrorIsNil)
+	c.Assert(lock2.Unlock(), jc.ErrorIsNil)
+}
+
+func (s *fslockSuite) TestBreakStaleAge(c *gc.C) {
+	clock := testclock.NewClock(time.Now())
+	lock1 := s.newLock(c, fslock.LockConfig{Clock: clock})
+	lock2 := s.newLock(c, fslock.LockConfig{Clock: clock, StaleAge: time.Minute})
+	c.Assert(lock1.TryLock(), jc.ErrorIsNil)
+	defer lock1.Unlock()
+
+	clock.Advance(time.Minute)
+	c.Assert(fslock.IsLocked(lock2.TryLock()), jc.IsTrue)
+
+	clock.Advance(time.Second)
+	if runtime.GOOS == "windows" {
+		// Windows does not allow the lock file to be removed while
+		// the holder in this process has it open, so the lock is
+		// still held rather than broken.
+		c.Assert(fslock.IsLocked(lock2.TryLock()), jc.IsTrue)
+		return
+	}
+	c.Assert(lock2.TryLock(), jc.ErrorIsNil)
+	c.Assert(lock2.Unlock(), jc.ErrorIsNil)
+}
+
+func (s *fslockSuite) TestBreakLockReplaced(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("non-windows only test")
+	}
+	err := ioutil.WriteFile(s.path, []byte("stale"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	stale, err := os.Open(s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	defer stale.Close()
+
+	// Someone else breaks the stale lock and takes the lock in a new
+	// file before we get round to breaking it ourselves.
+	err = os.Remove(s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ioutil.WriteFile(s.path, []byte("live"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = fslock.BreakLock(stale, s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := ioutil.ReadFile(s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "live")
+	matches, err := filepath.Glob(s.path + ".*")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(matches, gc.HasLen, 0)
+
+	// The file we read is removed if it is still the lock file.
+	live, err := os.Open(s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	defer live.Close()
+	err = fslock.BreakLock(live, s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = os.Stat(s.path)
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *fslockSuite) TestLockWithTimeout(c *gc.C) {
+	lock1 := s.newLock(c, fslock.LockConfig{})
+	lock2 := s.newLock(c, fslock.LockConfig{Delay: time.Millisecond})
+	c.Assert(lock1.TryLock(), jc.ErrorIsNil)
+
+	err := lock2.LockWithTimeout(10 * time.Millisecond)
+	c.Assert(err, gc.Equals, fslock.ErrTimeout)
+
+	done := make(chan error)
+	go func() {
+		done <- lock2.LockWithTimeout(testing.LongWait)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(lock1.Unlock(), jc.ErrorIsNil)
+	select {
+	case err := <-done:
+		c.Assert(err, jc.ErrorIsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for lock")
+	}
+	c.Assert(lock2.Unlock(), jc.ErrorIsNil)
+}
+
+func (s *fslockSuite) TestMissingDirectory(c *gc.C) {
+	lock, err := fslock.NewLock(filepath.Join(c.MkDir(), "missing", "lock"), fslock.LockConfig{})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(lock.TryLock(), gc.ErrorMatches, "cannot open lock file: .*")
+}
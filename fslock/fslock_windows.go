@@ -0,0 +1,70 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fslock
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	lockfile_fail_immediately = 0x1
+	lockfile_exclusive_lock   = 0x2
+
+	error_access_denied     syscall.Errno = 5
+	error_sharing_violation syscall.Errno = 32
+	error_lock_violation    syscall.Errno = 33
+)
+
+// The locked region lies beyond the end of the file, so that the
+// holder's details can still be read by others.
+const (
+	lockOffsetHigh = 0x7fffffff
+	lockLength     = 1
+)
+
+//sys lockFileEx(handle syscall.Handle, flags uint32, reserved uint32, bytesLow uint32, bytesHigh uint32, overlapped *syscall.Overlapped) (err error) = LockFileEx
+//sys unlockFileEx(handle syscall.Handle, reserved uint32, bytesLow uint32, bytesHigh uint32, overlapped *syscall.Overlapped) (err error) = UnlockFileEx
+
+// errWouldBlock is returned by lockFile when the file is locked by
+// someone else.
+var errWouldBlock = error_lock_violation
+
+func lockFile(file *os.File) error {
+	overlapped := syscall.Overlapped{OffsetHigh: lockOffsetHigh}
+	err := lockFileEx(syscall.Handle(file.Fd()), lockfile_exclusive_lock|lockfile_fail_immediately, 0, lockLength, 0, &overlapped)
+	if err == error_lock_violation {
+		return errWouldBlock
+	}
+	return err
+}
+
+func unlockFile(file *os.File) error {
+	overlapped := syscall.Overlapped{OffsetHigh: lockOffsetHigh}
+	return unlockFileEx(syscall.Handle(file.Fd()), 0, lockLength, 0, &overlapped)
+}
+
+// isFileInUse reports whether err was returned when removing a file
+// because it is open. Windows does not allow a file to be removed while
+// it is open without delete sharing, as lock files are; a file that is
+// already being deleted gives an access denied error instead.
+func isFileInUse(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+	return err == error_sharing_violation || err == error_access_denied
+}
+
+// processExists reports whether a process with the given ID is
+// running.
+func processExists(pid int) bool {
+	// On Windows FindProcess opens a handle to the process, which
+	// fails if it does not exist.
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,8 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fslock_test
+
+// deadPID is a process ID that cannot be running, as Windows process
+// IDs are multiples of four.
+const deadPID = 1<<30 + 1
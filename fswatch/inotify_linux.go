@@ -0,0 +1,232 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/juju/errors"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MOVED_TO |
+	syscall.IN_MODIFY | syscall.IN_ATTRIB |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM |
+	syscall.IN_ONLYDIR
+
+// inotifyBackend implements backend using inotify. Reads are
+// multiplexed with a pipe using epoll, so that close can interrupt
+// them.
+type inotifyBackend struct {
+	fd       int
+	epfd     int
+	pipe     [2]int
+	out      chan Event
+	errs     chan error
+	done     chan struct{}
+	finished chan struct{}
+
+	// mu guards the fields below it.
+	mu    sync.Mutex
+	dirs  map[int]string
+	watch map[string]int
+}
+
+func newNativeBackend() (backend, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	b := &inotifyBackend{
+		fd:       fd,
+		epfd:     -1,
+		pipe:     [2]int{-1, -1},
+		out:      make(chan Event),
+		errs:     make(chan error, 1),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+		dirs:     make(map[int]string),
+		watch:    make(map[string]int),
+	}
+	if err := b.init(); err != nil {
+		b.closeFds()
+		return nil, errors.Trace(err)
+	}
+	go b.loop()
+	return b, nil
+}
+
+// init sets up the epoll instance used to wait for events.
+func (b *inotifyBackend) init() error {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return os.NewSyscallError("epoll_create1", err)
+	}
+	b.epfd = epfd
+	if err := syscall.Pipe2(b.pipe[:], syscall.O_NONBLOCK|syscall.O_CLOEXEC); err != nil {
+		return os.NewSyscallError("pipe2", err)
+	}
+	for _, fd := range []int{b.fd, b.pipe[0]} {
+		ev := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(fd)}
+		if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, fd, &ev); err != nil {
+			return os.NewSyscallError("epoll_ctl", err)
+		}
+	}
+	return nil
+}
+
+// add implements backend.
+func (b *inotifyBackend) add(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wd, err := syscall.InotifyAddWatch(b.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	// The same directory may be reached by different paths.
+	b.dirs[wd] = dir
+	b.watch[dir] = wd
+	return nil
+}
+
+// remove implements backend.
+func (b *inotifyBackend) remove(dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wd, ok := b.watch[dir]
+	if !ok {
+		return
+	}
+	delete(b.watch, dir)
+	delete(b.dirs, wd)
+	// This fails if the kernel has already removed the watch
+	// because the directory has gone, which is fine.
+	syscall.InotifyRmWatch(b.fd, uint32(wd))
+}
+
+// events implements backend.
+func (b *inotifyBackend) events() <-chan Event {
+	return b.out
+}
+
+// errors implements backend.
+func (b *inotifyBackend) errors() <-chan error {
+	return b.errs
+}
+
+// close implements backend.
+func (b *inotifyBackend) close() error {
+	close(b.done)
+	syscall.Write(b.pipe[1], []byte{0})
+	<-b.finished
+	return b.closeFds()
+}
+
+func (b *inotifyBackend) closeFds() error {
+	var firstErr error
+	for _, fd := range []int{b.fd, b.epfd, b.pipe[0], b.pipe[1]} {
+		if fd == -1 {
+			continue
+		}
+		if err := syscall.Close(fd); err != nil && firstErr == nil {
+			firstErr = os.NewSyscallError("close", err)
+		}
+	}
+	return firstErr
+}
+
+func (b *inotifyBackend) loop() {
+	defer close(b.finished)
+	if err := b.readEvents(); err != nil {
+		b.errs <- err
+	}
+}
+
+// readEvents delivers events until the backend is closed.
+func (b *inotifyBackend) readEvents() error {
+	var (
+		buf     [syscall.SizeofInotifyEvent * 4096]byte
+		epollEv [2]syscall.EpollEvent
+	)
+	for {
+		n, err := syscall.EpollWait(b.epfd, epollEv[:], -1)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return os.NewSyscallError("epoll_wait", err)
+		}
+		for _, ev := range epollEv[:n] {
+			if int(ev.Fd) == b.pipe[0] {
+				return nil
+			}
+		}
+		n, err = syscall.Read(b.fd, buf[:])
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return os.NewSyscallError("read", err)
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(raw.Len)]
+			offset += syscall.SizeofInotifyEvent + int(raw.Len)
+			if raw.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				return errors.New("inotify event queue overflowed")
+			}
+			ev, ok := b.convert(int(raw.Wd), raw.Mask, strings.TrimRight(string(nameBytes), "\x00"))
+			if !ok {
+				continue
+			}
+			select {
+			case <-b.done:
+				return nil
+			case b.out <- ev:
+			}
+		}
+	}
+}
+
+// convert returns the Event for an inotify event, if any.
+func (b *inotifyBackend) convert(wd int, mask uint32, name string) (Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	dir, ok := b.dirs[wd]
+	if !ok {
+		return Event{}, false
+	}
+	if mask&syscall.IN_IGNORED != 0 {
+		// The directory has gone, so the kernel has removed
+		// the watch.
+		delete(b.dirs, wd)
+		if b.watch[dir] == wd {
+			delete(b.watch, dir)
+		}
+		return Event{}, false
+	}
+	if name == "" {
+		// Changes to the directory itself are reported by its
+		// parent's watch, if it has one.
+		return Event{}, false
+	}
+	var op Op
+	if mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		op |= Create
+	}
+	if mask&syscall.IN_MODIFY != 0 {
+		op |= Write
+	}
+	if mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0 {
+		op |= Remove
+	}
+	if mask&syscall.IN_ATTRIB != 0 {
+		op |= Chmod
+	}
+	return Event{Path: filepath.Join(dir, name), Op: op}, op != 0
+}
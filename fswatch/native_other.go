@@ -0,0 +1,12 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !linux,!windows
+
+package fswatch
+
+import "github.com/juju/errors"
+
+func newNativeBackend() (backend, error) {
+	return nil, errors.NotSupportedf("native file system notifications")
+}
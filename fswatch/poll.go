@@ -0,0 +1,174 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+)
+
+// fileState holds what the poll backend knows about a directory entry.
+type fileState struct {
+	mode    os.FileMode
+	size    int64
+	modTime time.Time
+}
+
+// pollBackend implements backend by scanning the watched directories
+// periodically and comparing what it finds.
+type pollBackend struct {
+	clock    clock.Clock
+	interval time.Duration
+	out      chan Event
+	done     chan struct{}
+	finished chan struct{}
+
+	// mu guards dirs.
+	mu   sync.Mutex
+	dirs map[string]map[string]fileState
+}
+
+func newPollBackend(clk clock.Clock, interval time.Duration) *pollBackend {
+	b := &pollBackend{
+		clock:    clk,
+		interval: interval,
+		out:      make(chan Event),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+		dirs:     make(map[string]map[string]fileState),
+	}
+	go b.loop()
+	return b
+}
+
+// add implements backend.
+func (b *pollBackend) add(dir string) error {
+	entries, err := scanDir(dir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dirs[dir] = entries
+	return nil
+}
+
+// remove implements backend.
+func (b *pollBackend) remove(dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.dirs, dir)
+}
+
+// events implements backend.
+func (b *pollBackend) events() <-chan Event {
+	return b.out
+}
+
+// errors implements backend. Polling never fails: directories that
+// can't be read are treated as empty.
+func (b *pollBackend) errors() <-chan error {
+	return nil
+}
+
+// close implements backend.
+func (b *pollBackend) close() error {
+	close(b.done)
+	<-b.finished
+	return nil
+}
+
+func (b *pollBackend) loop() {
+	defer close(b.finished)
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.clock.After(b.interval):
+		}
+		for _, ev := range b.poll() {
+			select {
+			case <-b.done:
+				return
+			case b.out <- ev:
+			}
+		}
+	}
+}
+
+// poll scans the watched directories and returns the changes found
+// since the last scan.
+func (b *pollBackend) poll() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var events []Event
+	for dir, old := range b.dirs {
+		entries, err := scanDir(dir)
+		if err != nil {
+			// The directory has gone, so everything in it has too.
+			delete(b.dirs, dir)
+			entries = nil
+		} else {
+			b.dirs[dir] = entries
+		}
+		for name, state := range entries {
+			prev, existed := old[name]
+			if op := compareStates(prev, state, existed); op != 0 {
+				events = append(events, Event{Path: filepath.Join(dir, name), Op: op})
+			}
+		}
+		for name := range old {
+			if _, ok := entries[name]; !ok {
+				events = append(events, Event{Path: filepath.Join(dir, name), Op: Remove})
+			}
+		}
+	}
+	return events
+}
+
+// compareStates returns the change from prev to cur. If existed is
+// false, the entry is new.
+func compareStates(prev, cur fileState, existed bool) Op {
+	switch {
+	case !existed:
+		return Create
+	case prev.mode&os.ModeType != cur.mode&os.ModeType:
+		return Remove | Create
+	}
+	var op Op
+	if cur.mode.IsRegular() && (prev.size != cur.size || !prev.modTime.Equal(cur.modTime)) {
+		op |= Write
+	}
+	if prev.mode.Perm() != cur.mode.Perm() {
+		op |= Chmod
+	}
+	return op
+}
+
+// scanDir returns the state of each entry of dir.
+func scanDir(dir string) (map[string]fileState, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	infos, err := f.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]fileState, len(infos))
+	for _, info := range infos {
+		entries[info.Name()] = fileState{
+			mode:    info.Mode(),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,269 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/juju/errors"
+)
+
+const (
+	notifyFilter = syscall.FILE_NOTIFY_CHANGE_FILE_NAME |
+		syscall.FILE_NOTIFY_CHANGE_DIR_NAME |
+		syscall.FILE_NOTIFY_CHANGE_ATTRIBUTES |
+		syscall.FILE_NOTIFY_CHANGE_SIZE |
+		syscall.FILE_NOTIFY_CHANGE_LAST_WRITE
+
+	// changeBufferSize is the largest buffer ReadDirectoryChangesW
+	// accepts for directories on network shares.
+	changeBufferSize = 64 * 1024
+
+	error_notify_enum_dir syscall.Errno = 1022
+)
+
+// dirWatch holds a watch on one directory. The kernel fills in ov and
+// buf while a read is pending, so the dirWatch is kept in
+// readDirectoryChangesBackend.keys until the read completes.
+type dirWatch struct {
+	dir     string
+	key     uint32
+	handle  syscall.Handle
+	ov      syscall.Overlapped
+	buf     []byte
+	removed bool
+}
+
+// readDirectoryChangesBackend implements backend using
+// ReadDirectoryChangesW, with the reads completing on an I/O
+// completion port. Key 0 on the port is used to wake the loop when the
+// backend is closed.
+type readDirectoryChangesBackend struct {
+	port     syscall.Handle
+	out      chan Event
+	errs     chan error
+	done     chan struct{}
+	finished chan struct{}
+
+	// mu guards the fields below it, and the removed fields of the
+	// watches.
+	mu      sync.Mutex
+	lastKey uint32
+	keys    map[uint32]*dirWatch
+	watch   map[string]*dirWatch
+}
+
+func newNativeBackend() (backend, error) {
+	port, err := syscall.CreateIoCompletionPort(syscall.InvalidHandle, 0, 0, 1)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateIoCompletionPort", err)
+	}
+	b := &readDirectoryChangesBackend{
+		port:     port,
+		out:      make(chan Event),
+		errs:     make(chan error, 1),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+		keys:     make(map[uint32]*dirWatch),
+		watch:    make(map[string]*dirWatch),
+	}
+	go b.loop()
+	return b, nil
+}
+
+// add implements backend.
+func (b *readDirectoryChangesBackend) add(dir string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.watch[dir]; ok {
+		return nil
+	}
+	name, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return &os.PathError{Op: "CreateFile", Path: dir, Err: err}
+	}
+	// The directory is opened with FILE_SHARE_DELETE so that
+	// watching it doesn't stop it being removed or renamed.
+	handle, err := syscall.CreateFile(name,
+		syscall.FILE_LIST_DIRECTORY,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil,
+		syscall.OPEN_EXISTING,
+		syscall.FILE_FLAG_BACKUP_SEMANTICS|syscall.FILE_FLAG_OVERLAPPED,
+		0,
+	)
+	if err != nil {
+		return &os.PathError{Op: "CreateFile", Path: dir, Err: err}
+	}
+	b.lastKey++
+	w := &dirWatch{
+		dir:    dir,
+		key:    b.lastKey,
+		handle: handle,
+		buf:    make([]byte, changeBufferSize),
+	}
+	if _, err := syscall.CreateIoCompletionPort(handle, b.port, w.key, 0); err != nil {
+		syscall.CloseHandle(handle)
+		return &os.PathError{Op: "CreateIoCompletionPort", Path: dir, Err: err}
+	}
+	if err := w.read(); err != nil {
+		syscall.CloseHandle(handle)
+		return &os.PathError{Op: "ReadDirectoryChanges", Path: dir, Err: err}
+	}
+	b.keys[w.key] = w
+	b.watch[dir] = w
+	return nil
+}
+
+// remove implements backend.
+func (b *readDirectoryChangesBackend) remove(dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	w, ok := b.watch[dir]
+	if !ok {
+		return
+	}
+	delete(b.watch, dir)
+	w.removed = true
+	// The handle is closed by the loop when the cancelled read
+	// completes. This fails if the read has already completed, in
+	// which case the loop closes the handle instead of reading
+	// again.
+	syscall.CancelIoEx(w.handle, &w.ov)
+}
+
+// events implements backend.
+func (b *readDirectoryChangesBackend) events() <-chan Event {
+	return b.out
+}
+
+// errors implements backend.
+func (b *readDirectoryChangesBackend) errors() <-chan error {
+	return b.errs
+}
+
+// close implements backend.
+func (b *readDirectoryChangesBackend) close() error {
+	close(b.done)
+	syscall.PostQueuedCompletionStatus(b.port, 0, 0, nil)
+	<-b.finished
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var firstErr error
+	for _, w := range b.keys {
+		if err := syscall.CloseHandle(w.handle); err != nil && firstErr == nil {
+			firstErr = os.NewSyscallError("CloseHandle", err)
+		}
+	}
+	if err := syscall.CloseHandle(b.port); err != nil && firstErr == nil {
+		firstErr = os.NewSyscallError("CloseHandle", err)
+	}
+	return firstErr
+}
+
+func (b *readDirectoryChangesBackend) loop() {
+	defer close(b.finished)
+	if err := b.readEvents(); err != nil {
+		b.errs <- err
+	}
+}
+
+// readEvents delivers events until the backend is closed.
+func (b *readDirectoryChangesBackend) readEvents() error {
+	for {
+		var (
+			n, key uint32
+			ov     *syscall.Overlapped
+		)
+		err := syscall.GetQueuedCompletionStatus(b.port, &n, &key, &ov, syscall.INFINITE)
+		if ov == nil {
+			if err != nil {
+				return os.NewSyscallError("GetQueuedCompletionStatus", err)
+			}
+			// Woken by close.
+			return nil
+		}
+		b.mu.Lock()
+		w := b.keys[key]
+		b.mu.Unlock()
+		if w == nil {
+			continue
+		}
+		var events []Event
+		switch {
+		case err == error_notify_enum_dir, err == nil && n == 0:
+			return errors.Errorf("too many changes in %q", w.dir)
+		case err == nil:
+			events = w.convert(n)
+		}
+		// Any other error means the read was cancelled by remove,
+		// or the directory has gone. The removal of the directory
+		// is reported by its parent's watch, if it has one.
+		for _, ev := range events {
+			select {
+			case <-b.done:
+				return nil
+			case b.out <- ev:
+			}
+		}
+		b.mu.Lock()
+		if err == nil && !w.removed {
+			err = w.read()
+		}
+		if err != nil || w.removed {
+			syscall.CloseHandle(w.handle)
+			delete(b.keys, w.key)
+			if b.watch[w.dir] == w {
+				delete(b.watch, w.dir)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// read starts reading the next changes to the directory.
+func (w *dirWatch) read() error {
+	return syscall.ReadDirectoryChanges(
+		w.handle, &w.buf[0], uint32(len(w.buf)), false, notifyFilter, nil, &w.ov, 0,
+	)
+}
+
+// convert returns the Events for the n bytes of changes read into the
+// buffer. Windows reports attribute changes as modifications, so they
+// are delivered as Write rather than Chmod.
+func (w *dirWatch) convert(n uint32) []Event {
+	var events []Event
+	for offset := uint32(0); offset < n; {
+		raw := (*syscall.FileNotifyInformation)(unsafe.Pointer(&w.buf[offset]))
+		length := raw.FileNameLength / 2
+		name := syscall.UTF16ToString((*[changeBufferSize / 2]uint16)(unsafe.Pointer(&raw.FileName))[:length:length])
+		path := filepath.Join(w.dir, name)
+		var op Op
+		switch raw.Action {
+		case syscall.FILE_ACTION_ADDED, syscall.FILE_ACTION_RENAMED_NEW_NAME:
+			op = Create
+		case syscall.FILE_ACTION_REMOVED, syscall.FILE_ACTION_RENAMED_OLD_NAME:
+			op = Remove
+		case syscall.FILE_ACTION_MODIFIED:
+			// A directory is reported as modified when its
+			// entries change. Those changes are reported by the
+			// directory's own watch, if it has one.
+			if info, err := os.Lstat(path); err != nil || !info.IsDir() {
+				op = Write
+			}
+		}
+		if op != 0 {
+			events = append(events, Event{Path: path, Op: op})
+		}
+		if raw.NextEntryOffset == 0 {
+			break
+		}
+		offset += raw.NextEntryOffset
+	}
+	return events
+}
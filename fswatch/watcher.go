@@ -0,0 +1,410 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// Package fswatch watches files and directories for changes, so that
+// configuration files and certificates can be reloaded when they are
+// updated.
+//
+// On Linux, changes are reported by inotify, and on Windows by
+// ReadDirectoryChangesW. On other platforms, and when Config.Poll is
+// set, the watched directories are polled instead.
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"golang.org/x/net/context"
+	"gopkg.in/tomb.v1"
+)
+
+var logger = loggo.GetLogger("juju.utils.fswatch")
+
+// errStopped is used internally to stop the Watcher's loop when it is
+// asked to stop.
+var errStopped = errors.New("watcher stopped")
+
+// defaultPollInterval holds the default value of Config.PollInterval.
+const defaultPollInterval = time.Second
+
+// Op describes the kind of a change. The kinds of change seen during a
+// debounce window are combined.
+type Op uint32
+
+const (
+	// Create means a file or directory was created, or was renamed
+	// to the path.
+	Create Op = 1 << iota
+
+	// Write means a file was written.
+	Write
+
+	// Remove means a file or directory was removed, or was renamed
+	// away from the path.
+	Remove
+
+	// Chmod means the permissions or other attributes of a file or
+	// directory changed. Windows reports such changes as Write.
+	Chmod
+)
+
+var opNames = []string{"CREATE", "WRITE", "REMOVE", "CHMOD"}
+
+// String returns the names of the kinds of change in op, separated by
+// "|".
+func (op Op) String() string {
+	var names []string
+	for i, name := range opNames {
+		if op&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, "|")
+}
+
+// Event describes a change to a file or directory.
+type Event struct {
+	// Path holds the path of the file or directory that changed,
+	// joined to the watched path it was found through.
+	Path string
+
+	// Op holds the kinds of change seen.
+	Op Op
+}
+
+// Config holds the configuration for a Watcher.
+type Config struct {
+	// Paths holds the files and directories to watch. A file need
+	// not exist, but its directory must. Files are watched by name,
+	// so changes are still seen after a file is replaced by
+	// renaming another file over it, as AtomicWriteFile does. The
+	// files in a directory are watched, but not the directory
+	// itself.
+	Paths []string
+
+	// Recursive specifies that the subdirectories of the watched
+	// directories are watched too, including those created later.
+	Recursive bool
+
+	// Debounce holds how long to wait after a change for further
+	// changes before delivering them, so that a burst of changes,
+	// such as several files being written in turn, is delivered
+	// together. If it is zero, changes are delivered as soon as
+	// they are seen.
+	Debounce time.Duration
+
+	// Poll specifies that directories are polled even when the
+	// platform can report changes, as is needed on network file
+	// systems.
+	Poll bool
+
+	// PollInterval holds how often directories are polled. If it is
+	// zero, one second is used.
+	PollInterval time.Duration
+
+	// Clock is used for debouncing and polling. If it is nil,
+	// clock.WallClock is used.
+	Clock clock.Clock
+}
+
+// Validate returns an error if the configuration is not valid.
+func (config Config) Validate() error {
+	if len(config.Paths) == 0 {
+		return errors.NotValidf("empty Paths")
+	}
+	if config.Debounce < 0 {
+		return errors.NotValidf("negative Debounce")
+	}
+	if config.PollInterval < 0 {
+		return errors.NotValidf("negative PollInterval")
+	}
+	return nil
+}
+
+// backend is implemented by the sources of changes to directories.
+type backend interface {
+	// add starts watching the entries of the given directory, not
+	// including those of its subdirectories.
+	add(dir string) error
+
+	// remove stops watching the given directory. It does nothing if
+	// the directory isn't being watched.
+	remove(dir string)
+
+	// events returns the channel on which changes are delivered.
+	events() <-chan Event
+
+	// errors returns the channel on which a fatal error is delivered.
+	errors() <-chan error
+
+	// close stops the backend.
+	close() error
+}
+
+// Watcher delivers changes to watched files and directories.
+type Watcher struct {
+	tomb    tomb.Tomb
+	config  Config
+	backend backend
+	changes chan []Event
+
+	// watched holds the directories being watched. The value is
+	// true if all of the directory's entries are of interest, and
+	// false if it is only watched for the files in files.
+	watched map[string]bool
+
+	// files holds the files being watched by name.
+	files map[string]bool
+}
+
+// Watch starts watching the paths in config. The Watcher stops when ctx
+// is done, when Stop is called, or when watching fails.
+func Watch(ctx context.Context, config Config) (*Watcher, error) {
+	if err := config.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.Clock == nil {
+		config.Clock = clock.WallClock
+	}
+	w := &Watcher{
+		config:  config,
+		changes: make(chan []Event),
+		watched: make(map[string]bool),
+		files:   make(map[string]bool),
+	}
+	if err := w.start(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.changes)
+		defer w.backend.close()
+		err := w.loop(ctx)
+		if err == errStopped {
+			err = nil
+		}
+		w.tomb.Kill(err)
+	}()
+	return w, nil
+}
+
+// Changes returns the channel on which changes are delivered, sorted by
+// path. It is closed when the Watcher stops.
+func (w *Watcher) Changes() <-chan []Event {
+	return w.changes
+}
+
+// Stop stops the Watcher and returns any error it encountered.
+func (w *Watcher) Stop() error {
+	w.tomb.Kill(nil)
+	return w.tomb.Wait()
+}
+
+// Wait waits for the Watcher to stop and returns any error it
+// encountered. Stopping because the context is done is not an error.
+func (w *Watcher) Wait() error {
+	return w.tomb.Wait()
+}
+
+// start creates the backend and starts watching the configured paths.
+func (w *Watcher) start() error {
+	if !w.config.Poll {
+		b, err := newNativeBackend()
+		if err == nil {
+			w.backend = b
+		} else if !errors.IsNotSupported(err) {
+			logger.Warningf("cannot watch natively, falling back to polling: %v", err)
+		}
+	}
+	if w.backend == nil {
+		w.backend = newPollBackend(w.config.Clock, w.config.PollInterval)
+	}
+	for _, path := range w.config.Paths {
+		if err := w.addPath(filepath.Clean(path)); err != nil {
+			w.backend.close()
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// addPath starts watching a configured path.
+func (w *Watcher) addPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+	if err == nil && info.IsDir() {
+		return w.addDir(path, nil)
+	}
+	dir := filepath.Dir(path)
+	w.files[path] = true
+	if _, ok := w.watched[dir]; ok {
+		return nil
+	}
+	if err := w.backend.add(dir); err != nil {
+		return errors.Annotatef(err, "cannot watch %q", path)
+	}
+	w.watched[dir] = false
+	return nil
+}
+
+// addDir starts watching all the entries of dir, and its
+// subdirectories if the watch is recursive. If created is not nil,
+// entries found in the subdirectories are added to it, as they may have
+// been created before the subdirectories were watched.
+func (w *Watcher) addDir(dir string, created map[string]Op) error {
+	if err := w.backend.add(dir); err != nil {
+		if created != nil && os.IsNotExist(errors.Cause(err)) {
+			// It has gone again already.
+			return nil
+		}
+		return errors.Annotatef(err, "cannot watch %q", dir)
+	}
+	w.watched[dir] = true
+	if !w.config.Recursive {
+		return nil
+	}
+	entries, err := readDirNames(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	for _, name := range entries {
+		path := filepath.Join(dir, name)
+		if created != nil {
+			created[path] |= Create
+		}
+		info, err := os.Lstat(path)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		if err := w.addDir(path, created); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// removeDir stops watching dir and everything beneath it.
+func (w *Watcher) removeDir(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for path, all := range w.watched {
+		if all && (path == dir || strings.HasPrefix(path, prefix)) {
+			w.backend.remove(path)
+			delete(w.watched, path)
+		}
+	}
+}
+
+func (w *Watcher) loop(ctx context.Context) error {
+	var (
+		pending = make(map[string]Op)
+		due     bool
+		timer   clock.Timer
+		timeout <-chan time.Time
+		out     chan<- []Event
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		out = nil
+		var batch []Event
+		if due && len(pending) > 0 {
+			out = w.changes
+			batch = sortedEvents(pending)
+		}
+		select {
+		case <-ctx.Done():
+			return errStopped
+		case <-w.tomb.Dying():
+			return errStopped
+		case err := <-w.backend.errors():
+			return errors.Trace(err)
+		case ev := <-w.backend.events():
+			if err := w.handle(ev, pending); err != nil {
+				return errors.Trace(err)
+			}
+			if len(pending) == 0 || due {
+				break
+			}
+			if w.config.Debounce == 0 {
+				due = true
+				break
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = w.config.Clock.NewTimer(w.config.Debounce)
+			timeout = timer.Chan()
+		case <-timeout:
+			timer, timeout = nil, nil
+			due = true
+		case out <- batch:
+			pending = make(map[string]Op)
+			due = false
+		}
+	}
+}
+
+// handle records ev in pending if it is of interest, and starts
+// watching any directory it creates when watching recursively.
+func (w *Watcher) handle(ev Event, pending map[string]Op) error {
+	dir := filepath.Dir(ev.Path)
+	if !w.watched[dir] && !w.files[ev.Path] {
+		return nil
+	}
+	pending[ev.Path] |= ev.Op
+	if ev.Op&Remove != 0 {
+		w.removeDir(ev.Path)
+	}
+	if ev.Op&Create == 0 || !w.config.Recursive || !w.watched[dir] {
+		return nil
+	}
+	info, err := os.Lstat(ev.Path)
+	if err != nil || !info.IsDir() {
+		return nil
+	}
+	return errors.Trace(w.addDir(ev.Path, pending))
+}
+
+// sortedEvents returns the changes in pending, sorted by path.
+func sortedEvents(pending map[string]Op) []Event {
+	paths := make([]string, 0, len(pending))
+	for path := range pending {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	events := make([]Event, len(paths))
+	for i, path := range paths {
+		events[i] = Event{Path: path, Op: pending[path]}
+	}
+	return events
+}
+
+// readDirNames returns the names of the entries of dir.
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
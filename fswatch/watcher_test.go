@@ -0,0 +1,269 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package fswatch_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+	"github.com/juju/utils/fswatch"
+)
+
+type watcherSuite struct {
+	testing.IsolationSuite
+	dir string
+}
+
+var _ = gc.Suite(&watcherSuite{})
+
+func (s *watcherSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.dir = c.MkDir()
+}
+
+func (s *watcherSuite) watch(c *gc.C, config fswatch.Config) *fswatch.Watcher {
+	if config.PollInterval == 0 {
+		// Platforms without native notifications fall back to
+		// polling.
+		config.PollInterval = 10 * time.Millisecond
+	}
+	w, err := fswatch.Watch(context.Background(), config)
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(*gc.C) { w.Stop() })
+	return w
+}
+
+func (s *watcherSuite) path(names ...string) string {
+	return filepath.Join(append([]string{s.dir}, names...)...)
+}
+
+func writeFile(c *gc.C, path, data string) {
+	err := ioutil.WriteFile(path, []byte(data), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+// assertChanges waits until changes including each of the expected
+// kinds of change have been delivered, which may take several batches,
+// and checks that none of them were to the ignored paths.
+func assertChanges(c *gc.C, w *fswatch.Watcher, expected map[string]fswatch.Op, ignored ...string) {
+	seen := make(map[string]fswatch.Op)
+	timeout := time.After(testing.LongWait)
+	for {
+		done := true
+		for path, op := range expected {
+			if seen[path]&op != op {
+				done = false
+			}
+		}
+		if done {
+			break
+		}
+		select {
+		case events, ok := <-w.Changes():
+			c.Assert(ok, jc.IsTrue)
+			for _, ev := range events {
+				seen[ev.Path] |= ev.Op
+			}
+		case <-timeout:
+			c.Fatalf("timed out waiting for %v; saw %v", expected, seen)
+		}
+	}
+	for _, path := range ignored {
+		if op, ok := seen[path]; ok {
+			c.Errorf("unexpected change to %q: %v", path, op)
+		}
+	}
+}
+
+func assertNoChanges(c *gc.C, w *fswatch.Watcher) {
+	select {
+	case events := <-w.Changes():
+		c.Fatalf("unexpected changes %v", events)
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+func (s *watcherSuite) TestDirectory(c *gc.C) {
+	w := s.watch(c, fswatch.Config{Paths: []string{s.dir}})
+	path := s.path("a")
+
+	writeFile(c, path, "hello")
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Create})
+
+	// Make sure the modification time changes, for polling.
+	writeFile(c, path, "hello again")
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Write})
+
+	err := os.Remove(path)
+	c.Assert(err, jc.ErrorIsNil)
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Remove})
+}
+
+func (s *watcherSuite) TestFileReplaced(c *gc.C) {
+	path := s.path("cert.pem")
+	w := s.watch(c, fswatch.Config{Paths: []string{path}})
+
+	writeFile(c, s.path("other"), "ignored")
+	err := utils.AtomicWriteFile(path, []byte("one"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Create}, s.path("other"))
+
+	err = utils.AtomicWriteFile(path, []byte("two"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Create})
+}
+
+func (s *watcherSuite) TestNotRecursive(c *gc.C) {
+	err := os.Mkdir(s.path("sub"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	w := s.watch(c, fswatch.Config{Paths: []string{s.dir}})
+
+	writeFile(c, s.path("sub", "a"), "ignored")
+	writeFile(c, s.path("b"), "seen")
+	assertChanges(c, w, map[string]fswatch.Op{s.path("b"): fswatch.Create}, s.path("sub", "a"))
+}
+
+func (s *watcherSuite) TestRecursive(c *gc.C) {
+	s.testRecursive(c, false)
+}
+
+func (s *watcherSuite) TestRecursivePoll(c *gc.C) {
+	s.testRecursive(c, true)
+}
+
+func (s *watcherSuite) testRecursive(c *gc.C, poll bool) {
+	err := os.Mkdir(s.path("sub"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	w := s.watch(c, fswatch.Config{
+		Paths:     []string{s.dir},
+		Recursive: true,
+		Poll:      poll,
+	})
+
+	writeFile(c, s.path("sub", "a"), "hello")
+	assertChanges(c, w, map[string]fswatch.Op{s.path("sub", "a"): fswatch.Create})
+
+	// Directories created later are watched too, including anything
+	// created in them before they were seen.
+	err = os.MkdirAll(s.path("new", "deeper"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	writeFile(c, s.path("new", "deeper", "b"), "hello")
+	assertChanges(c, w, map[string]fswatch.Op{
+		s.path("new"):                fswatch.Create,
+		s.path("new", "deeper"):      fswatch.Create,
+		s.path("new", "deeper", "b"): fswatch.Create,
+	})
+
+	writeFile(c, s.path("new", "deeper", "c"), "hello")
+	assertChanges(c, w, map[string]fswatch.Op{s.path("new", "deeper", "c"): fswatch.Create})
+}
+
+func (s *watcherSuite) TestDebounce(c *gc.C) {
+	w := s.watch(c, fswatch.Config{
+		Paths:    []string{s.dir},
+		Debounce: 500 * time.Millisecond,
+	})
+	writeFile(c, s.path("b"), "hello")
+	writeFile(c, s.path("a"), "hello")
+	select {
+	case events := <-w.Changes():
+		c.Assert(events, gc.HasLen, 2)
+		c.Assert(events[0].Path, gc.Equals, s.path("a"))
+		c.Assert(events[0].Op&fswatch.Create, gc.Equals, fswatch.Create)
+		c.Assert(events[1].Path, gc.Equals, s.path("b"))
+		c.Assert(events[1].Op&fswatch.Create, gc.Equals, fswatch.Create)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for changes")
+	}
+}
+
+func (s *watcherSuite) TestPoll(c *gc.C) {
+	clock := testclock.NewClock(time.Now())
+	w := s.watch(c, fswatch.Config{
+		Paths:        []string{s.dir},
+		Poll:         true,
+		PollInterval: time.Second,
+		Clock:        clock,
+	})
+	path := s.path("a")
+
+	writeFile(c, path, "hello")
+	err := clock.WaitAdvance(time.Second, testing.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Create})
+
+	err = os.Chmod(path, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	err = clock.WaitAdvance(time.Second, testing.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Chmod})
+
+	writeFile(c, path, "hello again")
+	err = clock.WaitAdvance(time.Second, testing.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Write})
+
+	err = os.Remove(path)
+	c.Assert(err, jc.ErrorIsNil)
+	err = clock.WaitAdvance(time.Second, testing.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	assertChanges(c, w, map[string]fswatch.Op{path: fswatch.Remove})
+
+	err = clock.WaitAdvance(time.Second, testing.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	assertNoChanges(c, w)
+}
+
+func (s *watcherSuite) TestStop(c *gc.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := fswatch.Watch(ctx, fswatch.Config{Paths: []string{s.dir}})
+	c.Assert(err, jc.ErrorIsNil)
+	cancel()
+	c.Assert(w.Wait(), jc.ErrorIsNil)
+	_, ok := <-w.Changes()
+	c.Assert(ok, jc.IsFalse)
+	c.Assert(w.Stop(), jc.ErrorIsNil)
+}
+
+func (s *watcherSuite) TestMissingDirectory(c *gc.C) {
+	_, err := fswatch.Watch(context.Background(), fswatch.Config{
+		Paths: []string{s.path("missing", "file")},
+	})
+	c.Assert(err, gc.ErrorMatches, `cannot watch ".*file": .*`)
+}
+
+func (s *watcherSuite) TestValidate(c *gc.C) {
+	for i, test := range []struct {
+		config fswatch.Config
+		err    string
+	}{{
+		config: fswatch.Config{},
+		err:    `empty Paths not valid`,
+	}, {
+		config: fswatch.Config{Paths: []string{s.dir}, Debounce: -1},
+		err:    `negative Debounce not valid`,
+	}, {
+		config: fswatch.Config{Paths: []string{s.dir}, PollInterval: -1},
+		err:    `negative PollInterval not valid`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := fswatch.Watch(context.Background(), test.config)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (*watcherSuite) TestOpString(c *gc.C) {
+	c.Assert(fswatch.Create.String(), gc.Equals, "CREATE")
+	c.Assert((fswatch.Write | fswatch.Chmod).String(), gc.Equals, "WRITE|CHMOD")
+	c.Assert(fswatch.Op(0).String(), gc.Equals, "NONE")
+}
@@ -4,13 +4,27 @@
 package utils
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
 )
 
 var gomaxprocs = runtime.GOMAXPROCS
 var numCPU = runtime.NumCPU
 
+// cgroupRoot and procSelfCgroup hold where cgroups are mounted and
+// where the process's cgroup membership is found. They are variables
+// so that tests can use fake files.
+var (
+	cgroupRoot     = "/sys/fs/cgroup"
+	procSelfCgroup = "/proc/self/cgroup"
+)
+
 // UseMultipleCPUs sets GOMAXPROCS to the number of CPU cores unless it has
 // already been overridden by the GOMAXPROCS environment variable.
 func UseMultipleCPUs() {
@@ -24,3 +38,142 @@ func UseMultipleCPUs() {
 	logger.Debugf("setting GOMAXPROCS to %d", n)
 	gomaxprocs(n)
 }
+
+// UseCPUQuota sets GOMAXPROCS to the number of CPUs allowed by the
+// process's cgroup CPU quota, rounded up, unless it has been overridden
+// by the GOMAXPROCS environment variable. Without this, a process in a
+// container limited to, say, two CPUs on a large machine runs many more
+// threads than it can use and spends much of its time throttled.
+// Both cgroup v1 and v2 are supported; if there is no quota, as on
+// systems without cgroups, GOMAXPROCS is left alone.
+//
+// The returned function restores GOMAXPROCS to its previous value.
+func UseCPUQuota() (undo func(), err error) {
+	undo = func() {}
+	if envGOMAXPROCS := os.Getenv("GOMAXPROCS"); envGOMAXPROCS != "" {
+		logger.Debugf("GOMAXPROCS already set in environment to %q", envGOMAXPROCS)
+		return undo, nil
+	}
+	quota, ok, err := cpuQuota()
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read CPU quota")
+	}
+	if !ok {
+		logger.Debugf("no CPU quota found")
+		return undo, nil
+	}
+	n := int(quota)
+	if float64(n) < quota {
+		n++
+	}
+	if n < 1 {
+		n = 1
+	}
+	if cpus := numCPU(); n > cpus {
+		n = cpus
+	}
+	logger.Debugf("setting GOMAXPROCS to %d for CPU quota %g", n, quota)
+	prev := gomaxprocs(n)
+	return func() { gomaxprocs(prev) }, nil
+}
+
+// cpuQuota returns the number of CPUs the process may use according to
+// its cgroup, and whether there is a limit at all.
+func cpuQuota() (float64, bool, error) {
+	data, err := ioutil.ReadFile(procSelfCgroup)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Trace(err)
+	}
+	// Each line is of the form hierarchy-ID:controllers:path, where
+	// the cgroup v2 hierarchy has ID 0 and no controllers.
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			return cgroupV2Quota(fields[2])
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "cpu" {
+				return cgroupV1Quota(fields[2])
+			}
+		}
+	}
+	return 0, false, nil
+}
+
+// cgroupDirs returns the directories in which to look for the control
+// files of the cgroup with the given path, under the given mount point.
+// Inside a container the path is usually relative to the host's root
+// cgroup, while the container sees its own cgroup at the mount point.
+func cgroupDirs(mount, path string) []string {
+	return []string{filepath.Join(mount, path), mount}
+}
+
+func cgroupV2Quota(path string) (float64, bool, error) {
+	for _, dir := range cgroupDirs(cgroupRoot, path) {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "cpu.max"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, false, errors.Trace(err)
+		}
+		// The file holds the quota, or "max" for no limit, and the
+		// period, both in microseconds.
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 {
+			return 0, false, errors.Errorf("unexpected cpu.max contents %q", data)
+		}
+		if fields[0] == "max" {
+			return 0, false, nil
+		}
+		return quotaRatio(fields[0], fields[1])
+	}
+	return 0, false, nil
+}
+
+func cgroupV1Quota(path string) (float64, bool, error) {
+	for _, mount := range []string{"cpu", "cpu,cpuacct"} {
+		for _, dir := range cgroupDirs(filepath.Join(cgroupRoot, mount), path) {
+			quota, err := ioutil.ReadFile(filepath.Join(dir, "cpu.cfs_quota_us"))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return 0, false, errors.Trace(err)
+			}
+			period, err := ioutil.ReadFile(filepath.Join(dir, "cpu.cfs_period_us"))
+			if err != nil {
+				return 0, false, errors.Trace(err)
+			}
+			// A quota of -1 means no limit.
+			if strings.TrimSpace(string(quota)) == "-1" {
+				return 0, false, nil
+			}
+			return quotaRatio(string(quota), string(period))
+		}
+	}
+	return 0, false, nil
+}
+
+// quotaRatio returns the number of CPUs allowed by the given quota and
+// period.
+func quotaRatio(quota, period string) (float64, bool, error) {
+	q, err := strconv.ParseInt(strings.TrimSpace(quota), 10, 64)
+	if err != nil {
+		return 0, false, errors.Errorf("invalid CPU quota %q", quota)
+	}
+	p, err := strconv.ParseInt(strings.TrimSpace(period), 10, 64)
+	if err != nil || p <= 0 {
+		return 0, false, errors.Errorf("invalid CPU period %q", period)
+	}
+	if q <= 0 {
+		return 0, false, nil
+	}
+	return float64(q) / float64(p), true, nil
+}
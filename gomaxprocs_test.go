@@ -4,9 +4,12 @@
 package utils_test
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/utils"
@@ -49,3 +52,114 @@ func (s *gomaxprocsSuite) TestUseMultipleCPUsWhenEnabled(c *gc.C) {
 	utils.UseMultipleCPUs()
 	c.Check(s.setMaxProcs, gc.Equals, 4)
 }
+
+// fakeCgroups makes UseCPUQuota see the given /proc/self/cgroup
+// contents and cgroup files, keyed by path relative to the cgroup root.
+func (s *gomaxprocsSuite) fakeCgroups(c *gc.C, procSelfCgroup string, files map[string]string) {
+	dir := c.MkDir()
+	s.PatchValue(utils.CgroupRoot, dir)
+	s.PatchValue(utils.ProcSelfCgroup, filepath.Join(dir, "self-cgroup"))
+	err := ioutil.WriteFile(filepath.Join(dir, "self-cgroup"), []byte(procSelfCgroup), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		c.Assert(err, jc.ErrorIsNil)
+		err = ioutil.WriteFile(path, []byte(contents), 0644)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
+
+var cpuQuotaTests = []struct {
+	about          string
+	procSelfCgroup string
+	files          map[string]string
+	numCPU         int
+	expect         int
+}{{
+	about:          "cgroup v2 quota",
+	procSelfCgroup: "0::/user.slice\n",
+	files:          map[string]string{"user.slice/cpu.max": "150000 100000\n"},
+	numCPU:         8,
+	expect:         2,
+}, {
+	about:          "cgroup v2 quota at the mount point",
+	procSelfCgroup: "0::/docker/abc\n",
+	files:          map[string]string{"cpu.max": "300000 100000\n"},
+	numCPU:         8,
+	expect:         3,
+}, {
+	about:          "cgroup v2 without limit",
+	procSelfCgroup: "0::/\n",
+	files:          map[string]string{"cpu.max": "max 100000\n"},
+	numCPU:         8,
+	expect:         -1,
+}, {
+	about:          "cgroup v1 quota",
+	procSelfCgroup: "12:pids:/lxc\n4:cpu,cpuacct:/lxc\n",
+	files: map[string]string{
+		"cpu,cpuacct/lxc/cpu.cfs_quota_us":  "50000\n",
+		"cpu,cpuacct/lxc/cpu.cfs_period_us": "100000\n",
+	},
+	numCPU: 8,
+	expect: 1,
+}, {
+	about:          "cgroup v1 without limit",
+	procSelfCgroup: "4:cpu,cpuacct:/\n",
+	files: map[string]string{
+		"cpu/cpu.cfs_quota_us":  "-1\n",
+		"cpu/cpu.cfs_period_us": "100000\n",
+	},
+	numCPU: 8,
+	expect: -1,
+}, {
+	about:          "quota larger than the number of CPUs",
+	procSelfCgroup: "0::/\n",
+	files:          map[string]string{"cpu.max": "1600000 100000\n"},
+	numCPU:         4,
+	expect:         4,
+}, {
+	about:          "no cgroup files",
+	procSelfCgroup: "0::/\n",
+	numCPU:         4,
+	expect:         -1,
+}}
+
+func (s *gomaxprocsSuite) TestUseCPUQuota(c *gc.C) {
+	for i, test := range cpuQuotaTests {
+		c.Logf("test %d: %s", i, test.about)
+		s.fakeCgroups(c, test.procSelfCgroup, test.files)
+		s.numCPUResponse = test.numCPU
+		s.setMaxProcs = -1
+		undo, err := utils.UseCPUQuota()
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(s.setMaxProcs, gc.Equals, test.expect)
+		undo()
+		if test.expect != -1 {
+			// The fake GOMAXPROCS always returns 1.
+			c.Check(s.setMaxProcs, gc.Equals, 1)
+		}
+	}
+}
+
+func (s *gomaxprocsSuite) TestUseCPUQuotaNoCgroups(c *gc.C) {
+	s.PatchValue(utils.ProcSelfCgroup, filepath.Join(c.MkDir(), "missing"))
+	_, err := utils.UseCPUQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.setMaxProcs, gc.Equals, -1)
+}
+
+func (s *gomaxprocsSuite) TestUseCPUQuotaDoesNothingWhenGOMAXPROCSSet(c *gc.C) {
+	s.fakeCgroups(c, "0::/\n", map[string]string{"cpu.max": "100000 100000\n"})
+	os.Setenv("GOMAXPROCS", "3")
+	_, err := utils.UseCPUQuota()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.setMaxProcs, gc.Equals, -1)
+}
+
+func (s *gomaxprocsSuite) TestUseCPUQuotaInvalid(c *gc.C) {
+	s.fakeCgroups(c, "0::/\n", map[string]string{"cpu.max": "lots 100000\n"})
+	_, err := utils.UseCPUQuota()
+	c.Assert(err, gc.ErrorMatches, `cannot read CPU quota: invalid CPU quota "lots"`)
+	c.Assert(s.setMaxProcs, gc.Equals, -1)
+}
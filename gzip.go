@@ -0,0 +1,109 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/juju/errors"
+)
+
+// NewGzipWriter returns a writer that compresses the data written to
+// it into w, at the given compression level: one of the levels defined
+// in compress/gzip, such as gzip.BestSpeed or gzip.DefaultCompression.
+// The writer must be closed to flush the compressed data; closing it
+// does not close w.
+func NewGzipWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	gzw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, errors.NotValidf("compression level %d", level)
+	}
+	return gzw, nil
+}
+
+// NewGzipReader returns a reader that decompresses the data read from
+// r. If maxSize is positive, reading fails with an error once more than
+// maxSize bytes have been decompressed, which guards against
+// decompression bombs. Closing the reader does not close r.
+func NewGzipReader(r io.Reader, maxSize int64) (io.ReadCloser, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read gzip header")
+	}
+	if maxSize <= 0 {
+		return gzr, nil
+	}
+	return &limitedGzipReader{
+		gzr:       gzr,
+		maxSize:   maxSize,
+		remaining: maxSize,
+	}, nil
+}
+
+// limitedGzipReader is the reader returned by NewGzipReader when the
+// decompressed size is limited.
+type limitedGzipReader struct {
+	gzr       *gzip.Reader
+	maxSize   int64
+	remaining int64
+}
+
+// Read implements io.Reader.
+func (r *limitedGzipReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, errors.Errorf("decompressed data exceeds %d bytes", r.maxSize)
+	}
+	// Allow reading one byte more than the limit, so that we can
+	// tell data of exactly the maximum size from data that's larger.
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.gzr.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n + int(r.remaining), errors.Errorf("decompressed data exceeds %d bytes", r.maxSize)
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (r *limitedGzipReader) Close() error {
+	return r.gzr.Close()
+}
+
+// CompressBytes compresses data with gzip at the given compression
+// level, as accepted by NewGzipWriter.
+func CompressBytes(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := NewGzipWriter(&buf, level)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressBytes decompresses gzip compressed data. If maxSize is
+// positive, it returns an error if the decompressed data is larger than
+// maxSize bytes.
+func DecompressBytes(data []byte, maxSize int64) ([]byte, error) {
+	r, err := NewGzipReader(bytes.NewReader(data), maxSize)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer r.Close()
+	result, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type gzipSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&gzipSuite{})
+
+func (*gzipSuite) TestCompressDecompressBytes(c *gc.C) {
+	data := bytes.Repeat([]byte("hello world "), 1000)
+	for _, level := range []int{gzip.NoCompression, gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression} {
+		c.Logf("level %d", level)
+		compressed, err := utils.CompressBytes(data, level)
+		c.Assert(err, jc.ErrorIsNil)
+		result, err := utils.DecompressBytes(compressed, 0)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(result, jc.DeepEquals, data)
+
+		// The existing Gunzip understands the result too.
+		result, err = utils.Gunzip(compressed)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(result, jc.DeepEquals, data)
+	}
+}
+
+func (*gzipSuite) TestCompressBytesInvalidLevel(c *gc.C) {
+	_, err := utils.CompressBytes([]byte("data"), 42)
+	c.Assert(err, gc.ErrorMatches, "compression level 42 not valid")
+}
+
+func (*gzipSuite) TestDecompressBytesMaxSize(c *gc.C) {
+	data := make([]byte, 1<<20)
+	compressed, err := utils.CompressBytes(data, gzip.BestCompression)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(len(compressed) < 1<<12, jc.IsTrue)
+
+	result, err := utils.DecompressBytes(compressed, 1<<20)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.HasLen, 1<<20)
+
+	_, err = utils.DecompressBytes(compressed, 1<<20-1)
+	c.Assert(err, gc.ErrorMatches, "decompressed data exceeds 1048575 bytes")
+}
+
+func (*gzipSuite) TestDecompressBytesInvalid(c *gc.C) {
+	_, err := utils.DecompressBytes([]byte("not gzip"), 0)
+	c.Assert(err, gc.ErrorMatches, "cannot read gzip header: .*")
+}
+
+func (*gzipSuite) TestGzipReaderWriter(c *gc.C) {
+	var buf bytes.Buffer
+	w, err := utils.NewGzipWriter(&buf, gzip.BestSpeed)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = w.Write([]byte("hello "))
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = w.Write([]byte("world"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(w.Close(), jc.ErrorIsNil)
+
+	r, err := utils.NewGzipReader(&buf, 11)
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "hello world")
+	c.Assert(r.Close(), jc.ErrorIsNil)
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package hash
+
+import (
+	"hash"
+	"io"
+	"os"
+
+	"github.com/juju/errors"
+)
+
+// HashReaderWithSize reads all the data from reader, computing a
+// fingerprint with each of the given hash funcs in a single pass. It
+// returns the fingerprints, in the same order as the hash funcs, and
+// the number of bytes read.
+func HashReaderWithSize(reader io.Reader, newHashes ...func() hash.Hash) ([]Fingerprint, int64, error) {
+	if reader == nil {
+		return nil, 0, errors.New("missing reader")
+	}
+	if len(newHashes) == 0 {
+		return nil, 0, errors.New("missing new hash func")
+	}
+	hashes := make([]hash.Hash, len(newHashes))
+	writers := make([]io.Writer, len(newHashes))
+	for i, newHash := range newHashes {
+		if newHash == nil {
+			return nil, 0, errors.New("missing new hash func")
+		}
+		hashes[i] = newHash()
+		writers[i] = hashes[i]
+	}
+	size, err := io.Copy(io.MultiWriter(writers...), reader)
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	fps := make([]Fingerprint, len(hashes))
+	for i, h := range hashes {
+		fps[i] = NewValidFingerprint(h)
+	}
+	return fps, size, nil
+}
+
+// HashFile returns the fingerprint of the contents of the file at the
+// given path, computed with the given hash func, and the file's size.
+func HashFile(path string, newHash func() hash.Hash) (Fingerprint, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Fingerprint{}, 0, errors.Trace(err)
+	}
+	defer f.Close()
+	fps, size, err := HashReaderWithSize(f, newHash)
+	if err != nil {
+		return Fingerprint{}, 0, errors.Annotatef(err, "cannot hash %q", path)
+	}
+	return fps[0], size, nil
+}
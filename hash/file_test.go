@@ -0,0 +1,65 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package hash_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/testing/filetesting"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/hash"
+)
+
+var _ = gc.Suite(&FileSuite{})
+
+type FileSuite struct {
+	testing.IsolationSuite
+}
+
+func (s *FileSuite) TestHashReaderWithSize(c *gc.C) {
+	data := "some data"
+	fps, size, err := hash.HashReaderWithSize(strings.NewReader(data), sha256.New, md5.New)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(size, gc.Equals, int64(len(data)))
+	c.Assert(fps, gc.HasLen, 2)
+	c.Check(fps[0].Hex(), gc.Equals, "1307990e6ba5ca145eb35e99182a9bec46531bc54ddf656a602c780fa0240dee")
+	c.Check(fps[1].Hex(), gc.Equals, "1e50210a0202497fb79bc38b6ade6c34")
+}
+
+func (s *FileSuite) TestHashReaderWithSizeErrors(c *gc.C) {
+	_, _, err := hash.HashReaderWithSize(nil, sha256.New)
+	c.Check(err, gc.ErrorMatches, "missing reader")
+	_, _, err = hash.HashReaderWithSize(strings.NewReader(""))
+	c.Check(err, gc.ErrorMatches, "missing new hash func")
+	_, _, err = hash.HashReaderWithSize(strings.NewReader(""), nil)
+	c.Check(err, gc.ErrorMatches, "missing new hash func")
+
+	stub := &testing.Stub{}
+	stub.SetErrors(errors.New("boom"))
+	reader := filetesting.NewStubReader(stub, "")
+	_, _, err = hash.HashReaderWithSize(reader, sha256.New)
+	c.Check(err, gc.ErrorMatches, "boom")
+}
+
+func (s *FileSuite) TestHashFile(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "file")
+	err := ioutil.WriteFile(path, []byte("some data"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	fp, size, err := hash.HashFile(path, sha256.New)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(size, gc.Equals, int64(9))
+	c.Check(fp.Hex(), gc.Equals, "1307990e6ba5ca145eb35e99182a9bec46531bc54ddf656a602c780fa0240dee")
+
+	_, _, err = hash.HashFile(filepath.Join(c.MkDir(), "missing"), sha256.New)
+	c.Check(err, gc.ErrorMatches, "open .*missing: no such file or directory")
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package hash
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// ManifestEntry describes a file, directory or symbolic link in a
+// Manifest.
+type ManifestEntry struct {
+	// SHA256 holds the hex-encoded SHA256 sum of the contents of a
+	// regular file. It is empty for other kinds of entry.
+	SHA256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+
+	// Size holds the size of a regular file.
+	Size int64 `json:"size" yaml:"size"`
+
+	// Mode holds the type and permission bits of the entry.
+	Mode os.FileMode `json:"mode" yaml:"mode"`
+
+	// Target holds the target of a symbolic link.
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+}
+
+// Manifest describes the contents of a directory tree. It maps the
+// slash-separated path of each entry, relative to the root of the
+// tree, to its description. It can be stored as JSON or YAML.
+type Manifest map[string]ManifestEntry
+
+// GenerateManifest returns the manifest of the directory tree at root.
+// Symbolic links are recorded, not followed.
+func GenerateManifest(root string) (Manifest, error) {
+	m := make(Manifest)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		entry, err := manifestEntry(path, info)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		m[filepath.ToSlash(rel)] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot generate manifest of %q", root)
+	}
+	return m, nil
+}
+
+func manifestEntry(path string, info os.FileInfo) (ManifestEntry, error) {
+	entry := ManifestEntry{
+		Mode: info.Mode() & (os.ModeType | os.ModePerm),
+	}
+	switch {
+	case info.Mode().IsRegular():
+		fp, size, err := HashFile(path, sha256.New)
+		if err != nil {
+			return ManifestEntry{}, errors.Trace(err)
+		}
+		entry.SHA256 = fp.Hex()
+		entry.Size = size
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return ManifestEntry{}, errors.Trace(err)
+		}
+		entry.Target = filepath.ToSlash(target)
+	}
+	return entry, nil
+}
+
+// ManifestDiff describes the differences between two manifests. Each
+// field holds slash-separated paths in lexical order.
+type ManifestDiff struct {
+	// Added holds the entries that are not in the expected manifest.
+	Added []string
+
+	// Removed holds the entries that are only in the expected
+	// manifest.
+	Removed []string
+
+	// Modified holds the entries whose contents, size, mode or
+	// target differ.
+	Modified []string
+}
+
+// Empty reports whether the manifests were the same.
+func (d ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Err returns nil if the manifests were the same, and otherwise an
+// error describing the differences.
+func (d ManifestDiff) Err() error {
+	if d.Empty() {
+		return nil
+	}
+	var parts []string
+	for _, group := range []struct {
+		name  string
+		paths []string
+	}{
+		{"added", d.Added},
+		{"removed", d.Removed},
+		{"modified", d.Modified},
+	} {
+		if len(group.paths) > 0 {
+			parts = append(parts, group.name+" "+strings.Join(group.paths, ", "))
+		}
+	}
+	return errors.Errorf("tree does not match manifest: %s", strings.Join(parts, "; "))
+}
+
+// CompareManifests returns the differences between the expected and
+// actual manifests.
+func CompareManifests(expected, actual Manifest) ManifestDiff {
+	var d ManifestDiff
+	for path, entry := range actual {
+		want, ok := expected[path]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, path)
+		case want != entry:
+			d.Modified = append(d.Modified, path)
+		}
+	}
+	for path := range expected {
+		if _, ok := actual[path]; !ok {
+			d.Removed = append(d.Removed, path)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Modified)
+	return d
+}
+
+// VerifyManifest returns the differences between the directory tree at
+// root and the expected manifest. The error is only non-nil if the
+// tree can't be read; use ManifestDiff.Err to treat differences as an
+// error.
+func VerifyManifest(root string, expected Manifest) (ManifestDiff, error) {
+	actual, err := GenerateManifest(root)
+	if err != nil {
+		return ManifestDiff{}, errors.Trace(err)
+	}
+	return CompareManifests(expected, actual), nil
+}
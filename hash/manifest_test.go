@@ -0,0 +1,125 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package hash_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/hash"
+)
+
+var _ = gc.Suite(&ManifestSuite{})
+
+type ManifestSuite struct {
+	testing.IsolationSuite
+	root string
+}
+
+func (s *ManifestSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.root = c.MkDir()
+	err := os.Mkdir(filepath.Join(s.root, "bin"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	s.writeFile(c, "bin/tool", "#!/bin/sh\n", 0755)
+	s.writeFile(c, "README", "some data", 0644)
+}
+
+func (s *ManifestSuite) writeFile(c *gc.C, name, data string, perm os.FileMode) {
+	path := filepath.Join(s.root, filepath.FromSlash(name))
+	err := ioutil.WriteFile(path, []byte(data), perm)
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Chmod(path, perm)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *ManifestSuite) TestGenerateManifest(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("permissions and symbolic links differ on Windows")
+	}
+	err := os.Symlink("bin/tool", filepath.Join(s.root, "link"))
+	c.Assert(err, jc.ErrorIsNil)
+	m, err := hash.GenerateManifest(s.root)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m, jc.DeepEquals, hash.Manifest{
+		"README": {
+			SHA256: "1307990e6ba5ca145eb35e99182a9bec46531bc54ddf656a602c780fa0240dee",
+			Size:   9,
+			Mode:   0644,
+		},
+		"bin": {
+			Mode: os.ModeDir | 0755,
+		},
+		"bin/tool": {
+			SHA256: "a8076d3d28d21e02012b20eaf7dbf75409a6277134439025f282e368e3305abf",
+			Size:   10,
+			Mode:   0755,
+		},
+		"link": {
+			Mode:   os.ModeSymlink | 0777,
+			Target: "bin/tool",
+		},
+	})
+}
+
+func (s *ManifestSuite) TestVerifyManifest(c *gc.C) {
+	m, err := hash.GenerateManifest(s.root)
+	c.Assert(err, jc.ErrorIsNil)
+
+	diff, err := hash.VerifyManifest(s.root, m)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(diff.Empty(), jc.IsTrue)
+	c.Assert(diff.Err(), jc.ErrorIsNil)
+
+	s.writeFile(c, "README", "other data", 0644)
+	s.writeFile(c, "bin/extra", "", 0644)
+	err = os.Remove(filepath.Join(s.root, "bin", "tool"))
+	c.Assert(err, jc.ErrorIsNil)
+
+	diff, err = hash.VerifyManifest(s.root, m)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(diff, jc.DeepEquals, hash.ManifestDiff{
+		Added:    []string{"bin/extra"},
+		Removed:  []string{"bin/tool"},
+		Modified: []string{"README"},
+	})
+	c.Assert(diff.Empty(), jc.IsFalse)
+	c.Assert(diff.Err(), gc.ErrorMatches, `tree does not match manifest: added bin/extra; removed bin/tool; modified README`)
+}
+
+func (s *ManifestSuite) TestVerifyManifestMode(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("permissions differ on Windows")
+	}
+	m, err := hash.GenerateManifest(s.root)
+	c.Assert(err, jc.ErrorIsNil)
+	err = os.Chmod(filepath.Join(s.root, "bin", "tool"), 0700)
+	c.Assert(err, jc.ErrorIsNil)
+	diff, err := hash.VerifyManifest(s.root, m)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(diff.Modified, jc.DeepEquals, []string{"bin/tool"})
+}
+
+func (s *ManifestSuite) TestManifestRoundTrip(c *gc.C) {
+	m, err := hash.GenerateManifest(s.root)
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := json.Marshal(m)
+	c.Assert(err, jc.ErrorIsNil)
+	var m2 hash.Manifest
+	err = json.Unmarshal(data, &m2)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hash.CompareManifests(m, m2).Empty(), jc.IsTrue)
+}
+
+func (s *ManifestSuite) TestGenerateManifestMissingRoot(c *gc.C) {
+	_, err := hash.GenerateManifest(filepath.Join(s.root, "missing"))
+	c.Assert(err, gc.ErrorMatches, `cannot generate manifest of ".*missing": .*`)
+}
@@ -0,0 +1,32 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// HMACSHA256 returns the HMAC-SHA256 of message using key.
+func HMACSHA256(key, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// VerifyHMACSHA256 reports whether mac is the HMAC-SHA256 of message
+// using key. The comparison takes constant time, so it does not reveal
+// how much of mac is correct.
+func VerifyHMACSHA256(key, message, mac []byte) bool {
+	return hmac.Equal(HMACSHA256(key, message), mac)
+}
+
+// ConstantTimeEquals reports whether a and b are equal, taking time
+// that depends only on their lengths. It should be used in place of ==
+// or bytes.Equal when comparing secrets such as MACs and tokens, where
+// the time taken by an early return could be used to guess the secret.
+func ConstantTimeEquals(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
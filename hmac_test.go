@@ -0,0 +1,43 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"encoding/hex"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type hmacSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&hmacSuite{})
+
+func (*hmacSuite) TestHMACSHA256(c *gc.C) {
+	// Test case 2 from RFC 4231.
+	mac := utils.HMACSHA256([]byte("Jefe"), []byte("what do ya want for nothing?"))
+	c.Assert(hex.EncodeToString(mac), gc.Equals, "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843")
+}
+
+func (*hmacSuite) TestVerifyHMACSHA256(c *gc.C) {
+	key := []byte("key")
+	message := []byte("message")
+	mac := utils.HMACSHA256(key, message)
+	c.Assert(utils.VerifyHMACSHA256(key, message, mac), jc.IsTrue)
+	c.Assert(utils.VerifyHMACSHA256([]byte("other"), message, mac), jc.IsFalse)
+	c.Assert(utils.VerifyHMACSHA256(key, []byte("other"), mac), jc.IsFalse)
+	c.Assert(utils.VerifyHMACSHA256(key, message, mac[:len(mac)-1]), jc.IsFalse)
+}
+
+func (*hmacSuite) TestConstantTimeEquals(c *gc.C) {
+	c.Assert(utils.ConstantTimeEquals([]byte("abc"), []byte("abc")), jc.IsTrue)
+	c.Assert(utils.ConstantTimeEquals([]byte("abc"), []byte("abd")), jc.IsFalse)
+	c.Assert(utils.ConstantTimeEquals([]byte("abc"), []byte("ab")), jc.IsFalse)
+	c.Assert(utils.ConstantTimeEquals(nil, []byte{}), jc.IsTrue)
+}
@@ -6,11 +6,20 @@ package utils
 
 import (
 	"os"
+	"os/user"
 )
 
 // Home returns the os-specific home path as specified in the environment.
+// If $HOME is not set, the home directory of the current user is
+// returned.
 func Home() string {
-	return os.Getenv("HOME")
+	if home := os.Getenv("HOME"); home != "" {
+		return home
+	}
+	if u, err := user.Current(); err == nil {
+		return u.HomeDir
+	}
+	return ""
 }
 
 // SetHome sets the os-specific home path in the environment.
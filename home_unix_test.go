@@ -5,7 +5,10 @@
 package utils_test
 
 import (
+	"os/user"
+
 	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/utils"
@@ -22,3 +25,10 @@ func (s *homeSuite) TestHomeLinux(c *gc.C) {
 	s.PatchEnvironment("HOME", h)
 	c.Check(utils.Home(), gc.Equals, h)
 }
+
+func (s *homeSuite) TestHomeUnset(c *gc.C) {
+	s.PatchEnvironment("HOME", "")
+	u, err := user.Current()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(utils.Home(), gc.Equals, u.HomeDir)
+}
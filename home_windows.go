@@ -9,7 +9,11 @@ import (
 )
 
 // Home returns the os-specific home path as specified in the environment.
+// If %HOMEPATH% is not set, %USERPROFILE% is used instead.
 func Home() string {
+	if os.Getenv("HOMEPATH") == "" {
+		return os.Getenv("USERPROFILE")
+	}
 	return filepath.Join(os.Getenv("HOMEDRIVE"), os.Getenv("HOMEPATH"))
 }
 
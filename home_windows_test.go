@@ -40,3 +40,10 @@ func (s *homeSuite) TestHome(c *gc.C) {
 	c.Check(os.Getenv("HOMEDRIVE"), gc.Equals, drive)
 	c.Check(utils.Home(), gc.Equals, drive+path2)
 }
+
+func (s *homeSuite) TestHomeUserProfile(c *gc.C) {
+	s.PatchEnvironment("HOMEPATH", "")
+	s.PatchEnvironment("HOMEDRIVE", "")
+	s.PatchEnvironment("USERPROFILE", `C:\Users\foo`)
+	c.Check(utils.Home(), gc.Equals, `C:\Users\foo`)
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+const (
+	// maxHostnameLength holds the maximum length of a host name,
+	// without any trailing dot.
+	maxHostnameLength = 253
+
+	// maxLabelLength holds the maximum length of each dot-separated
+	// label in a host name.
+	maxLabelLength = 63
+)
+
+// IsValidHostname reports whether s is a valid host name as described
+// by RFC 1123: one or more dot-separated labels, each of 1 to 63
+// letters, digits and hyphens, not starting or ending with a hyphen,
+// and no more than 253 characters in all. A single trailing dot, as in
+// "example.com.", is allowed.
+func IsValidHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > maxHostnameLength {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if !isValidLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidFQDN reports whether s is a valid fully qualified domain
+// name: a valid host name with at least two labels, whose top-level
+// domain is not entirely numeric. The last condition means that IPv4
+// addresses are not valid FQDNs.
+func IsValidFQDN(s string) bool {
+	if !IsValidHostname(s) {
+		return false
+	}
+	labels := strings.Split(strings.TrimSuffix(s, "."), ".")
+	if len(labels) < 2 {
+		return false
+	}
+	return strings.TrimLeft(labels[len(labels)-1], "0123456789") != ""
+}
+
+// NormaliseHostname returns s lower cased and without any trailing
+// dot, so that names for the same host compare equal. It returns an
+// error satisfying errors.IsNotValid if s is not a valid host name.
+func NormaliseHostname(s string) (string, error) {
+	if !IsValidHostname(s) {
+		return "", errors.NotValidf("host name %q", s)
+	}
+	return strings.ToLower(strings.TrimSuffix(s, ".")), nil
+}
+
+// isValidLabel reports whether label is a valid label of a host name.
+func isValidLabel(label string) bool {
+	if label == "" || len(label) > maxLabelLength {
+		return false
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(label); i++ {
+		switch c := label[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type hostnameSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&hostnameSuite{})
+
+var longLabel = strings.Repeat("a", 63)
+
+var hostnameTests = []struct {
+	name     string
+	hostname bool
+	fqdn     bool
+}{
+	{"localhost", true, false},
+	{"localhost.", true, false},
+	{"example.com", true, true},
+	{"example.com.", true, true},
+	{"Example.COM", true, true},
+	{"www.example.com", true, true},
+	{"a-b.c-d.example", true, true},
+	{"3com.com", true, true},
+	{"123", true, false},
+	{"10.0.0.1", true, false},
+	{"host.123abc", true, true},
+	{longLabel + ".com", true, true},
+	{strings.Repeat(longLabel+".", 3) + strings.Repeat("a", 61), true, true},
+	{strings.Repeat(longLabel+".", 3) + strings.Repeat("a", 61) + ".", true, true},
+
+	{"", false, false},
+	{".", false, false},
+	{"example..com", false, false},
+	{".example.com", false, false},
+	{"example.com..", false, false},
+	{"-example.com", false, false},
+	{"example-.com", false, false},
+	{"exa_mple.com", false, false},
+	{"exa mple.com", false, false},
+	{"example.com:80", false, false},
+	{"exämple.com", false, false},
+	{"::1", false, false},
+	{longLabel + "a.com", false, false},
+	{strings.Repeat(longLabel+".", 3) + strings.Repeat("a", 62), false, false},
+}
+
+func (*hostnameSuite) TestIsValidHostname(c *gc.C) {
+	for i, test := range hostnameTests {
+		c.Logf("test %d: %q", i, test.name)
+		c.Check(utils.IsValidHostname(test.name), gc.Equals, test.hostname)
+	}
+}
+
+func (*hostnameSuite) TestIsValidFQDN(c *gc.C) {
+	for i, test := range hostnameTests {
+		c.Logf("test %d: %q", i, test.name)
+		c.Check(utils.IsValidFQDN(test.name), gc.Equals, test.fqdn)
+	}
+}
+
+func (*hostnameSuite) TestNormaliseHostname(c *gc.C) {
+	for i, test := range []struct {
+		name     string
+		expected string
+	}{
+		{"example.com", "example.com"},
+		{"Example.COM.", "example.com"},
+		{"LOCALHOST", "localhost"},
+	} {
+		c.Logf("test %d: %q", i, test.name)
+		name, err := utils.NormaliseHostname(test.name)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(name, gc.Equals, test.expected)
+	}
+
+	_, err := utils.NormaliseHostname("exa_mple.com")
+	c.Assert(err, gc.ErrorMatches, `host name "exa_mple.com" not valid`)
+	c.Assert(errors.IsNotValid(err), jc.IsTrue)
+}
@@ -15,10 +15,17 @@ import (
 // that it fails when an attempt is made to dial a non-local
 // host.
 func installHTTPDialShim(t *http.Transport) {
+	installHTTPDialer(t, &net.Dialer{})
+}
+
+// installHTTPDialer makes t dial connections with dialer, failing
+// when an attempt is made to dial a non-local host if outgoing
+// access is not allowed.
+func installHTTPDialer(t *http.Transport, dialer Dialer) {
 	t.Dial = func(network, addr string) (net.Conn, error) {
 		if !OutgoingAccessAllowed && !isLocalAddr(addr) {
 			return nil, fmt.Errorf("access to address %q not allowed", addr)
 		}
-		return net.Dial(network, addr)
+		return dialer.Dial(network, addr)
 	}
 }
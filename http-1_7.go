@@ -18,6 +18,11 @@ var ctxtDialer = &net.Dialer{
 	KeepAlive: 30 * time.Second,
 }
 
+// contextDialer is implemented by Dialers that can be cancelled.
+type contextDialer interface {
+	DialContext(ctxt context.Context, network, addr string) (net.Conn, error)
+}
+
 // installHTTPDialShim patches the default HTTP transport so
 // that it fails when an attempt is made to dial a non-local
 // host.
@@ -26,10 +31,32 @@ var ctxtDialer = &net.Dialer{
 // the DialContext field was introduced (and set in http.DefaultTransport)
 // which overrides the Dial field.
 func installHTTPDialShim(t *http.Transport) {
+	installHTTPDialer(t, ctxtDialer)
+}
+
+// installHTTPDialer makes t dial connections with dialer, failing
+// when an attempt is made to dial a non-local host if outgoing
+// access is not allowed.
+func installHTTPDialer(t *http.Transport, dialer Dialer) {
+	dial, ok := dialer.(contextDialer)
+	if !ok {
+		dial = plainDialer{dialer}
+	}
+	t.Dial = nil
 	t.DialContext = func(ctxt context.Context, network, addr string) (net.Conn, error) {
 		if !OutgoingAccessAllowed && !isLocalAddr(addr) {
 			return nil, fmt.Errorf("access to address %q not allowed", addr)
 		}
-		return ctxtDialer.DialContext(ctxt, network, addr)
+		return dial.DialContext(ctxt, network, addr)
 	}
 }
+
+// plainDialer adapts a Dialer that can't be cancelled to
+// contextDialer.
+type plainDialer struct {
+	Dialer
+}
+
+func (d plainDialer) DialContext(ctxt context.Context, network, addr string) (net.Conn, error) {
+	return d.Dial(network, addr)
+}
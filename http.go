@@ -96,8 +96,14 @@ func GetNonValidatingHTTPClient() *http.Client {
 // separated by a single colon (":") character, within a base64 encoded string
 // in the credentials."
 func BasicAuthHeader(username, password string) http.Header {
-	auth := username + ":" + password
-	encoded := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+	// Build the credentials as bytes, so that they can be zeroed
+	// after use.
+	auth := make([]byte, 0, len(username)+1+len(password))
+	auth = append(auth, username...)
+	auth = append(auth, ':')
+	auth = append(auth, password...)
+	defer ZeroBytes(auth)
+	encoded := "Basic " + base64.StdEncoding.EncodeToString(auth)
 	return http.Header{
 		"Authorization": {encoded},
 	}
@@ -119,6 +125,7 @@ func ParseBasicAuthHeader(h http.Header) (userid, password string, err error) {
 	if err != nil {
 		return "", "", fmt.Errorf("invalid HTTP auth encoding")
 	}
+	defer ZeroBytes(challenge)
 	tokens := strings.SplitN(string(challenge), ":", 2)
 	if len(tokens) != 2 {
 		return "", "", fmt.Errorf("invalid HTTP auth contents")
@@ -126,6 +133,26 @@ func ParseBasicAuthHeader(h http.Header) (userid, password string, err error) {
 	return tokens[0], tokens[1], nil
 }
 
+// Dialer is implemented by values that can make network connections,
+// such as *net.Dialer. When built with Go 1.7 or later, a Dialer that
+// also has a method
+//
+//	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+//
+// is dialled using that method instead, so that requests can be
+// cancelled while connecting.
+type Dialer interface {
+	Dial(network, addr string) (net.Conn, error)
+}
+
+// InstallHTTPDialer makes the given transport use dialer to make its
+// connections, for example to reach hosts through an SSH tunnel.
+// Connections to non-local hosts are still refused when
+// OutgoingAccessAllowed is false.
+func InstallHTTPDialer(t *http.Transport, dialer Dialer) {
+	installHTTPDialer(t, dialer)
+}
+
 // OutgoingAccessAllowed determines whether connections other than
 // localhost can be dialled.
 var OutgoingAccessAllowed = true
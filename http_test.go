@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/pem"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -212,6 +214,46 @@ func (s *httpDialSuite) TestSecureClientAllowAccess(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `Get http://0.1.2.3:1234: dial tcp 0.1.2.3:1234: connect: .*`)
 }
 
+// recordingDialer connects all its connections to a single address,
+// recording the addresses it was asked to dial.
+type recordingDialer struct {
+	addr  string
+	dials []string
+}
+
+func (d *recordingDialer) Dial(network, addr string) (net.Conn, error) {
+	d.dials = append(d.dials, addr)
+	return net.Dial(network, d.addr)
+}
+
+func (s *httpDialSuite) TestInstallHTTPDialer(c *gc.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("hello from " + req.Host))
+	}))
+	defer server.Close()
+
+	dialer := &recordingDialer{addr: server.Listener.Addr().String()}
+	transport := utils.NewHttpTLSTransport(utils.SecureTLSConfig())
+	utils.InstallHTTPDialer(transport, dialer)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get("http://somewhere.invalid:1234/")
+	c.Assert(err, gc.IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(body), gc.Equals, "hello from somewhere.invalid:1234")
+	c.Assert(dialer.dials, gc.DeepEquals, []string{"somewhere.invalid:1234"})
+
+	// Make sure that the next request has to dial rather than
+	// reusing the connection from the first.
+	transport.CloseIdleConnections()
+	s.PatchValue(&utils.OutgoingAccessAllowed, false)
+	_, err = client.Get("http://somewhere.invalid:1234/")
+	c.Assert(err, gc.ErrorMatches, `.*access to address "somewhere.invalid:1234" not allowed`)
+	c.Assert(dialer.dials, gc.HasLen, 1)
+}
+
 var isLocalAddrTests = []struct {
 	addr    string
 	isLocal bool
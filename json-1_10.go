@@ -0,0 +1,13 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build go1.10
+
+package utils
+
+import "encoding/json"
+
+func disallowUnknownFields(dec *json.Decoder) error {
+	dec.DisallowUnknownFields()
+	return nil
+}
@@ -0,0 +1,16 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build !go1.10
+
+package utils
+
+import (
+	"encoding/json"
+
+	"github.com/juju/errors"
+)
+
+func disallowUnknownFields(dec *json.Decoder) error {
+	return errors.NotSupportedf("strict JSON decoding before Go 1.10")
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/juju/errors"
+)
+
+// WriteJSONFile marshals obj as indented JSON and atomically writes it
+// to path with the given permissions, replacing any existing file.
+func WriteJSONFile(path string, obj interface{}, perms os.FileMode) error {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data = append(data, '\n')
+	return errors.Trace(AtomicWriteFile(path, data, perms))
+}
+
+// ReadJSONFile unmarshals the JSON contained in the file at path into
+// obj. The file must hold a single JSON value. If path is not found,
+// the error returned will be compatible with os.IsNotExist.
+func ReadJSONFile(path string, obj interface{}) error {
+	return readJSONFile(path, obj, false)
+}
+
+// ReadJSONFileStrict is like ReadJSONFile, but returns an error if the
+// JSON contains object keys that don't match any field of the struct
+// they are decoded into, which usually means a misspelt setting. It
+// requires Go 1.10 or later.
+func ReadJSONFileStrict(path string, obj interface{}) error {
+	return readJSONFile(path, obj, true)
+}
+
+func readJSONFile(path string, obj interface{}, strict bool) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err // cannot wrap here because callers check for NotFound.
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		if err := disallowUnknownFields(dec); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := dec.Decode(obj); err != nil {
+		return errors.Annotatef(err, "cannot parse %q", path)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.Errorf("cannot parse %q: unexpected data after JSON value", path)
+	}
+	return nil
+}
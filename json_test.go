@@ -0,0 +1,93 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type jsonSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&jsonSuite{})
+
+type jsonConfig struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (*jsonSuite) TestRoundTrip(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "config.json")
+	err := utils.WriteJSONFile(path, jsonConfig{Name: "foo", Count: 3}, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "{\n  \"name\": \"foo\",\n  \"count\": 3\n}\n")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0600))
+	}
+
+	var cfg jsonConfig
+	err = utils.ReadJSONFile(path, &cfg)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg, gc.Equals, jsonConfig{Name: "foo", Count: 3})
+}
+
+func (*jsonSuite) TestReadJSONFileNotFound(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "missing.json")
+	err := utils.ReadJSONFile(path, &jsonConfig{})
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+	err = utils.ReadJSONFileStrict(path, &jsonConfig{})
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+}
+
+func (*jsonSuite) TestReadJSONFileInvalid(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "config.json")
+	for i, test := range []struct {
+		data string
+		err  string
+	}{{
+		data: `{"name": `,
+		err:  `cannot parse ".*": unexpected EOF`,
+	}, {
+		data: `{"count": "three"}`,
+		err:  `cannot parse ".*": json: cannot unmarshal string .*`,
+	}, {
+		data: `{"name": "foo"} {"name": "bar"}`,
+		err:  `cannot parse ".*": unexpected data after JSON value`,
+	}} {
+		c.Logf("test %d: %s", i, test.data)
+		err := ioutil.WriteFile(path, []byte(test.data), 0644)
+		c.Assert(err, jc.ErrorIsNil)
+		err = utils.ReadJSONFile(path, &jsonConfig{})
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (*jsonSuite) TestReadJSONFileStrict(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "config.json")
+	err := ioutil.WriteFile(path, []byte(`{"name": "foo", "cuont": 3}`), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	var cfg jsonConfig
+	err = utils.ReadJSONFile(path, &cfg)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cfg, gc.Equals, jsonConfig{Name: "foo"})
+
+	err = utils.ReadJSONFileStrict(path, &cfg)
+	c.Assert(err, gc.ErrorMatches, `cannot parse ".*": json: unknown field "cuont"`)
+}
@@ -6,17 +6,26 @@ package utils
 import (
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/clock"
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
 )
 
+// ErrLimiterQueueFull is returned by ContextLimiter.AcquireContext when
+// the maximum number of callers are already waiting.
+var ErrLimiterQueueFull = errors.New("limiter queue full")
+
 type empty struct{}
 type limiter struct {
-	wait     chan empty
-	minPause time.Duration
-	maxPause time.Duration
-	clock    clock.Clock
+	wait      chan empty
+	minPause  time.Duration
+	maxPause  time.Duration
+	clock     clock.Clock
+	maxQueued int64
+	queued    *int64
 }
 
 // Limiter represents a limited resource (eg a semaphore).
@@ -33,6 +42,28 @@ type Limiter interface {
 	Release() error
 }
 
+// ContextLimiter is a Limiter that can also wait for a resource until a
+// context is done. All the limiters created by this package implement
+// it.
+type ContextLimiter interface {
+	Limiter
+
+	// AcquireContext requests a unit of resource, blocking until one
+	// is available or the context is done, in which case it returns
+	// the context's error. It returns ErrLimiterQueueFull without
+	// waiting if the limiter's queue of waiting callers is full.
+	AcquireContext(ctx context.Context) error
+}
+
+// NewContextLimiter creates a limiter that allows at most maxQueued
+// callers to wait in AcquireContext at once. If maxQueued is 0, the
+// number of waiting callers is not limited.
+func NewContextLimiter(maxAllowed, maxQueued int) ContextLimiter {
+	l := NewLimiter(maxAllowed).(limiter)
+	l.maxQueued = int64(maxQueued)
+	return l
+}
+
 // NewLimiter creates a limiter.
 func NewLimiter(maxAllowed int) Limiter {
 	return NewLimiterWithPause(maxAllowed, 0, 0, nil)
@@ -50,6 +81,7 @@ func NewLimiterWithPause(maxAllowed int, minPause, maxPause time.Duration, clk c
 		minPause: minPause,
 		maxPause: maxPause,
 		clock:    clk,
+		queued:   new(int64),
 	}
 }
 
@@ -78,6 +110,29 @@ func (l limiter) AcquireWait() {
 	l.wait <- e
 }
 
+// AcquireContext implements ContextLimiter.
+func (l limiter) AcquireContext(ctx context.Context) error {
+	e := empty{}
+	select {
+	case l.wait <- e:
+		return nil
+	default:
+	}
+	if l.maxQueued > 0 {
+		if atomic.AddInt64(l.queued, 1) > l.maxQueued {
+			atomic.AddInt64(l.queued, -1)
+			return ErrLimiterQueueFull
+		}
+		defer atomic.AddInt64(l.queued, -1)
+	}
+	select {
+	case l.wait <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Release returns the resource to the available pool.
 func (l limiter) Release() error {
 	select {
@@ -10,6 +10,7 @@ import (
 	"github.com/juju/clock/testclock"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/utils"
@@ -108,3 +109,64 @@ func (*limiterSuite) TestAcquirePauses(c *gc.C) {
 		c.Fatal("acquire failed")
 	}
 }
+
+func (*limiterSuite) TestAcquireContext(c *gc.C) {
+	l := utils.NewContextLimiter(1, 0)
+	c.Assert(l.AcquireContext(context.Background()), jc.ErrorIsNil)
+	c.Check(l.Acquire(), jc.IsFalse)
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- l.AcquireContext(context.Background())
+	}()
+	select {
+	case err := <-acquired:
+		c.Fatalf("acquired while full: %v", err)
+	case <-time.After(testing.ShortWait):
+	}
+	c.Assert(l.Release(), jc.ErrorIsNil)
+	select {
+	case err := <-acquired:
+		c.Assert(err, jc.ErrorIsNil)
+	case <-time.After(longWait):
+		c.Fatalf("timed out waiting for AcquireContext")
+	}
+}
+
+func (*limiterSuite) TestAcquireContextDone(c *gc.C) {
+	l := utils.NewContextLimiter(1, 0)
+	c.Assert(l.Acquire(), jc.IsTrue)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(l.AcquireContext(ctx), gc.Equals, context.Canceled)
+	// The cancelled caller didn't take a unit.
+	c.Assert(l.Release(), jc.ErrorIsNil)
+	c.Assert(l.Release(), gc.ErrorMatches, "Release without an associated Acquire")
+}
+
+func (*limiterSuite) TestAcquireContextQueueFull(c *gc.C) {
+	l := utils.NewContextLimiter(1, 1)
+	c.Assert(l.Acquire(), jc.IsTrue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := make(chan error)
+	go func() {
+		waiting <- l.AcquireContext(ctx)
+	}()
+	// Wait for the first caller to be queued.
+	deadline := time.Now().Add(longWait)
+	for utils.LimiterQueued(l) == 0 {
+		if time.Now().After(deadline) {
+			c.Fatalf("caller never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.Assert(l.AcquireContext(context.Background()), gc.Equals, utils.ErrLimiterQueueFull)
+	cancel()
+	c.Assert(<-waiting, gc.Equals, context.Canceled)
+}
+
+func (*limiterSuite) TestLimitersImplementContextLimiter(c *gc.C) {
+	_, ok := utils.NewLimiter(1).(utils.ContextLimiter)
+	c.Assert(ok, jc.IsTrue)
+}
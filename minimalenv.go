@@ -0,0 +1,102 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// MinimalEnvOptions holds options for MinimalEnv.
+type MinimalEnvOptions struct {
+	// Keep holds the names of variables to copy from the source
+	// environment, in addition to those in MinimalEnvKeep. A name
+	// ending in "*" matches all variables with that prefix.
+	Keep []string
+
+	// Set holds entries in "key=value" form to add to the
+	// environment, replacing any with the same name.
+	Set []string
+
+	// Path, if not empty, is used as the value of PATH instead of
+	// MinimalEnvPath.
+	Path string
+
+	// Locale, if not empty, is used instead of "C.UTF-8" as the
+	// value of LANG and LC_ALL. It is ignored on Windows.
+	Locale string
+}
+
+// MinimalEnv returns a minimal environment for running child
+// processes, so that they behave the same whatever the environment of
+// the current process. Only the variables named in MinimalEnvKeep and
+// opts.Keep are copied from environ, which is usually the result of
+// os.Environ. PATH is always set, as is the locale on systems other
+// than Windows.
+//
+// The entries are returned sorted by name. As on the rest of the
+// system, names are case-insensitive on Windows.
+func MinimalEnv(environ []string, opts MinimalEnvOptions) []string {
+	keep := append(append([]string(nil), MinimalEnvKeep...), opts.Keep...)
+	entries := make(map[string]string)
+	for _, entry := range environ {
+		name, ok := envEntryName(entry)
+		if !ok || !envNameMatches(name, keep) {
+			continue
+		}
+		entries[envKey(name)] = entry
+	}
+	path := opts.Path
+	if path == "" {
+		path = MinimalEnvPath
+	}
+	entries[envKey("PATH")] = "PATH=" + path
+	for _, entry := range localeEnv(opts.Locale) {
+		name, _ := envEntryName(entry)
+		entries[envKey(name)] = entry
+	}
+	for _, entry := range opts.Set {
+		if name, ok := envEntryName(entry); ok {
+			entries[envKey(name)] = entry
+		}
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]string, len(keys))
+	for i, key := range keys {
+		result[i] = entries[key]
+	}
+	return result
+}
+
+// envEntryName returns the name of a "key=value" environment entry.
+// It returns false if the entry is malformed, or is one of the
+// Windows per-drive entries like "=C:=C:\foo", which have no name.
+func envEntryName(entry string) (string, bool) {
+	i := strings.Index(entry, "=")
+	if i <= 0 {
+		return "", false
+	}
+	return entry[:i], true
+}
+
+// envNameMatches reports whether name matches any of the given names
+// or prefix patterns.
+func envNameMatches(name string, patterns []string) bool {
+	key := envKey(name)
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(key, envKey(strings.TrimSuffix(p, "*"))) {
+				return true
+			}
+		} else if key == envKey(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"runtime"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type minimalEnvSuite struct{}
+
+var _ = gc.Suite(&minimalEnvSuite{})
+
+func (*minimalEnvSuite) SetUpSuite(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("tests assume a unix environment")
+	}
+}
+
+func (*minimalEnvSuite) TestMinimalEnv(c *gc.C) {
+	env := utils.MinimalEnv([]string{
+		"USER=fred",
+		"HOME=/home/fred",
+		"PATH=/home/fred/bin:/usr/bin",
+		"LC_TIME=en_GB.UTF-8",
+		"SECRET_TOKEN=xyz",
+		"malformed",
+	}, utils.MinimalEnvOptions{})
+	c.Assert(env, jc.DeepEquals, []string{
+		"HOME=/home/fred",
+		"LANG=C.UTF-8",
+		"LC_ALL=C.UTF-8",
+		"PATH=" + utils.MinimalEnvPath,
+		"USER=fred",
+	})
+}
+
+func (*minimalEnvSuite) TestMinimalEnvOptions(c *gc.C) {
+	env := utils.MinimalEnv([]string{
+		"HOME=/home/fred",
+		"JUJU_MODEL=foo",
+		"JUJU_DEV_FEATURE_FLAGS=bar",
+		"HTTP_PROXY=http://proxy",
+		"SECRET_TOKEN=xyz",
+	}, utils.MinimalEnvOptions{
+		Keep:   []string{"JUJU_*", "HTTP_PROXY", "NOT_SET"},
+		Set:    []string{"HOME=/tmp", "EXTRA=1", "bad"},
+		Path:   "/bin",
+		Locale: "en_US.UTF-8",
+	})
+	c.Assert(env, jc.DeepEquals, []string{
+		"EXTRA=1",
+		"HOME=/tmp",
+		"HTTP_PROXY=http://proxy",
+		"JUJU_DEV_FEATURE_FLAGS=bar",
+		"JUJU_MODEL=foo",
+		"LANG=en_US.UTF-8",
+		"LC_ALL=en_US.UTF-8",
+		"PATH=/bin",
+	})
+}
+
+func (*minimalEnvSuite) TestMinimalEnvEmpty(c *gc.C) {
+	env := utils.MinimalEnv(nil, utils.MinimalEnvOptions{})
+	c.Assert(env, jc.DeepEquals, []string{
+		"LANG=C.UTF-8",
+		"LC_ALL=C.UTF-8",
+		"PATH=" + utils.MinimalEnvPath,
+	})
+}
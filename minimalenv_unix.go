@@ -0,0 +1,41 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package utils
+
+// MinimalEnvKeep holds the names of the variables that MinimalEnv
+// copies from the source environment.
+var MinimalEnvKeep = []string{
+	"HOME",
+	"LOGNAME",
+	"TMPDIR",
+	"USER",
+}
+
+// MinimalEnvPath holds the default value of PATH set by MinimalEnv.
+const MinimalEnvPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// defaultLocale holds the locale set by MinimalEnv by default, so that
+// UTF-8 output isn't mangled. It is provided by musl, by Debian and
+// Ubuntu, and by glibc from 2.35, but not by older glibc based
+// distributions or by macOS, where programs fall back to the C locale.
+// Set MinimalEnvOptions.Locale where that matters.
+const defaultLocale = "C.UTF-8"
+
+// envKey returns the key used to compare environment variable names.
+func envKey(name string) string {
+	return name
+}
+
+// localeEnv returns the environment entries that set the locale.
+func localeEnv(locale string) []string {
+	if locale == "" {
+		locale = defaultLocale
+	}
+	return []string{
+		"LANG=" + locale,
+		"LC_ALL=" + locale,
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"strings"
+)
+
+// MinimalEnvKeep holds the names of the variables that MinimalEnv
+// copies from the source environment. Many programs, including those
+// built with Go, fail in odd ways without these.
+var MinimalEnvKeep = []string{
+	"COMSPEC",
+	"PATHEXT",
+	"SYSTEMDRIVE",
+	"SYSTEMROOT",
+	"TEMP",
+	"TMP",
+	"USERPROFILE",
+	"WINDIR",
+}
+
+// MinimalEnvPath holds the default value of PATH set by MinimalEnv.
+const MinimalEnvPath = `C:\Windows\system32;C:\Windows;C:\Windows\System32\WindowsPowerShell\v1.0`
+
+// envKey returns the key used to compare environment variable names.
+func envKey(name string) string {
+	return strings.ToUpper(name)
+}
+
+// localeEnv returns the environment entries that set the locale. The
+// locale isn't set by the environment on Windows.
+func localeEnv(locale string) []string {
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+)
+
+// MkdirAllForUser is like os.MkdirAll, but makes sure that access to
+// the directories it creates, and to path itself if it already exists,
+// is limited as perm describes and that they belong to owner, so that
+// they can be trusted with secrets. Existing parent directories are
+// left alone.
+//
+// On Unix, the permissions are set regardless of the umask, and owner
+// is the name of the user who should own the directories. If owner is
+// empty, the ownership is left alone.
+//
+// On Windows, where the permission bits of os.MkdirAll are ignored,
+// each directory is given a protected access control list, inherited
+// by its contents, that grants owner the access perm gives the user,
+// and grants everyone the access perm gives others; the group bits are
+// ignored. LocalSystem always has full access. The owner may be a user
+// name or a SID such as "S-1-5-18"; if it is empty, the user running
+// the current process is used.
+func MkdirAllForUser(path string, perm os.FileMode, owner string) error {
+	path = filepath.Clean(path)
+	missing, err := missingDirs(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(path, perm); err != nil {
+		return errors.Trace(err)
+	}
+	dirs := missing
+	if len(dirs) == 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !info.IsDir() {
+			return errors.Errorf("%q is not a directory", path)
+		}
+		dirs = []string{path}
+	}
+	if err := setDirAccess(dirs, perm.Perm(), owner); err != nil {
+		return errors.Annotatef(err, "cannot set access to %q", path)
+	}
+	return nil
+}
+
+// missingDirs returns path and those of its parents that don't exist,
+// outermost first.
+func missingDirs(path string) ([]string, error) {
+	var missing []string
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		missing = append([]string{path}, missing...)
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+		path = parent
+	}
+	return missing, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package utils
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/juju/errors"
+)
+
+// setDirAccess sets the permissions of dirs to perm and, if owner isn't
+// empty, their owner and group to those of the named user.
+func setDirAccess(dirs []string, perm os.FileMode, owner string) error {
+	uid, gid := -1, -1
+	if owner != "" {
+		u, err := user.Lookup(owner)
+		if err != nil {
+			return errors.Annotatef(err, "cannot lookup %q user id", owner)
+		}
+		if uid, err = strconv.Atoi(u.Uid); err != nil {
+			return errors.Errorf("invalid user id %q", u.Uid)
+		}
+		if gid, err = strconv.Atoi(u.Gid); err != nil {
+			return errors.Errorf("invalid group id %q", u.Gid)
+		}
+	}
+	for _, dir := range dirs {
+		if uid != -1 {
+			if err := os.Chown(dir, uid, gid); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		// The directory was created subject to the umask.
+		if err := os.Chmod(dir, perm); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type mkdirSuite struct {
+	testing.IsolationSuite
+	dir string
+}
+
+var _ = gc.Suite(&mkdirSuite{})
+
+func (s *mkdirSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.dir = c.MkDir()
+	oldUmask := syscall.Umask(022)
+	s.AddCleanup(func(*gc.C) { syscall.Umask(oldUmask) })
+}
+
+func assertPerm(c *gc.C, path string, perm os.FileMode) {
+	info, err := os.Stat(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.IsDir(), jc.IsTrue)
+	c.Assert(info.Mode().Perm(), gc.Equals, perm, gc.Commentf("%s", path))
+}
+
+func (s *mkdirSuite) TestCreates(c *gc.C) {
+	path := filepath.Join(s.dir, "a", "b", "secrets")
+	err := utils.MkdirAllForUser(path, 0770, "")
+	c.Assert(err, jc.ErrorIsNil)
+	// The umask would otherwise have removed the group write bit.
+	assertPerm(c, filepath.Join(s.dir, "a"), 0770)
+	assertPerm(c, filepath.Join(s.dir, "a", "b"), 0770)
+	assertPerm(c, path, 0770)
+}
+
+func (s *mkdirSuite) TestExisting(c *gc.C) {
+	err := os.Chmod(s.dir, 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	path := filepath.Join(s.dir, "secrets")
+	err = os.Mkdir(path, 0755)
+	c.Assert(err, jc.ErrorIsNil)
+
+	err = utils.MkdirAllForUser(path, 0700, "")
+	c.Assert(err, jc.ErrorIsNil)
+	assertPerm(c, path, 0700)
+	assertPerm(c, s.dir, 0755)
+}
+
+func (s *mkdirSuite) TestOwner(c *gc.C) {
+	username, err := utils.LocalUsername()
+	c.Assert(err, jc.ErrorIsNil)
+	path := filepath.Join(s.dir, "a", "secrets")
+	err = utils.MkdirAllForUser(path, 0700, username)
+	c.Assert(err, jc.ErrorIsNil)
+	for _, dir := range []string{filepath.Dir(path), path} {
+		ok, err := utils.IsFileOwner(dir, username)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(ok, jc.IsTrue)
+	}
+}
+
+func (s *mkdirSuite) TestUnknownOwner(c *gc.C) {
+	path := filepath.Join(s.dir, "secrets")
+	err := utils.MkdirAllForUser(path, 0700, "no-such-user-i-hope")
+	c.Assert(err, gc.ErrorMatches, `cannot set access to ".*secrets": cannot lookup "no-such-user-i-hope" user id: .*`)
+}
+
+func (s *mkdirSuite) TestNotDirectory(c *gc.C) {
+	path := filepath.Join(s.dir, "file")
+	err := ioutil.WriteFile(path, nil, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	err = utils.MkdirAllForUser(path, 0700, "")
+	c.Assert(err, gc.ErrorMatches, `.*not a directory`)
+}
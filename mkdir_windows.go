@@ -0,0 +1,125 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build windows
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/juju/errors"
+)
+
+const (
+	sddl_revision_1                     = 1
+	dacl_security_information           = 0x4
+	protected_dacl_security_information = 0x80000000
+)
+
+// The SDDL abbreviations for the LocalSystem and Everyone SIDs.
+const (
+	systemSID   = "SY"
+	everyoneSID = "WD"
+)
+
+//sys convertStringSecurityDescriptorToSecurityDescriptor(sddl *uint16, revision uint32, sd **byte, size *uint32) (err error) = advapi32.ConvertStringSecurityDescriptorToSecurityDescriptorW
+//sys setFileSecurity(fileName *uint16, securityInformation uint32, sd *byte) (err error) = advapi32.SetFileSecurityW
+//sys localFree(mem uintptr) (handle uintptr, err error) [failretval!=0] = kernel32.LocalFree
+
+// setDirAccess gives dirs a protected access control list that grants
+// owner and everyone the access that perm gives the user and others.
+func setDirAccess(dirs []string, perm os.FileMode, owner string) error {
+	sid, err := ownerSID(owner)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sddl, err := syscall.UTF16PtrFromString(dirSDDL(sid, perm))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var sd *byte
+	if err := convertStringSecurityDescriptorToSecurityDescriptor(sddl, sddl_revision_1, &sd, nil); err != nil {
+		return os.NewSyscallError("ConvertStringSecurityDescriptorToSecurityDescriptor", err)
+	}
+	defer localFree(uintptr(unsafe.Pointer(sd)))
+	for _, dir := range dirs {
+		name, err := syscall.UTF16PtrFromString(dir)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := setFileSecurity(name, dacl_security_information|protected_dacl_security_information, sd); err != nil {
+			return &os.PathError{Op: "SetFileSecurity", Path: dir, Err: err}
+		}
+	}
+	return nil
+}
+
+// dirSDDL returns the security descriptor, in the Security Descriptor
+// Definition Language, for a directory with the given permissions.
+func dirSDDL(sid string, perm os.FileMode) string {
+	aces := []string{ace(systemSID, 07)}
+	if rights := perm >> 6 & 07; rights != 0 {
+		aces = append(aces, ace(sid, rights))
+	}
+	if rights := perm & 07; rights != 0 {
+		aces = append(aces, ace(everyoneSID, rights))
+	}
+	return "D:P" + strings.Join(aces, "")
+}
+
+// ace returns an access control entry allowing sid the access given
+// by the Unix permission bits in rights, inherited by the contents of
+// the directory.
+func ace(sid string, rights os.FileMode) string {
+	var access string
+	if rights == 07 {
+		access = "FA"
+	} else {
+		if rights&04 != 0 {
+			access += "FR"
+		}
+		if rights&02 != 0 {
+			access += "FW"
+		}
+		if rights&01 != 0 {
+			access += "FX"
+		}
+	}
+	// OICI makes files and directories created inside inherit the
+	// entry.
+	return fmt.Sprintf("(A;OICI;%s;;;%s)", access, sid)
+}
+
+// ownerSID returns the SID for owner, which may be a user name or a SID.
+// If owner is empty, the SID of the user running the current process is
+// returned.
+func ownerSID(owner string) (string, error) {
+	if owner == "" {
+		token, err := syscall.OpenCurrentProcessToken()
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		defer token.Close()
+		user, err := token.GetTokenUser()
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		return user.User.Sid.String()
+	}
+	if strings.HasPrefix(owner, "S-") {
+		if _, err := syscall.StringToSid(owner); err != nil {
+			return "", errors.NotValidf("SID %q", owner)
+		}
+		return owner, nil
+	}
+	sid, err := getUserSID(owner)
+	if err != nil {
+		return "", errors.NewUserNotFound(err, fmt.Sprintf("cannot lookup %q user", owner))
+	}
+	return sid, nil
+}
@@ -170,6 +170,8 @@ func (r *readSeeker) Seek(off int64, whence int) (int64, error) {
 		off += r.off
 	case 2:
 		off = r.r.Size() + off
+	default:
+		return 0, errors.Errorf("invalid whence %d", whence)
 	}
 	if off < 0 {
 		return 0, errors.New("negative position")
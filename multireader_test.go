@@ -6,8 +6,11 @@ package utils_test
 import (
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing/iotest"
+	"time"
 
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils"
@@ -179,6 +182,24 @@ func (*multiReaderSeekerSuite) TestSeekPastEnd(c *gc.C) {
 	c.Assert(string(data), gc.Equals, "netwo")
 }
 
+func (*multiReaderSeekerSuite) TestSeekInvalidWhence(c *gc.C) {
+	r := newMultiStringReader([]string{"one", "two"})
+	_, err := r.Seek(0, 3)
+	c.Assert(err, gc.ErrorMatches, "invalid whence 3")
+}
+
+func (*multiReaderSeekerSuite) TestServeContentRange(c *gc.C) {
+	r := newMultiStringReader([]string{"one", "two", "three"})
+	req, err := http.NewRequest("GET", "/artifact", nil)
+	c.Assert(err, jc.ErrorIsNil)
+	req.Header.Set("Range", "bytes=2-7")
+	rec := httptest.NewRecorder()
+	http.ServeContent(rec, req, "artifact", time.Time{}, r)
+	c.Assert(rec.Code, gc.Equals, http.StatusPartialContent)
+	c.Assert(rec.Header().Get("Content-Range"), gc.Equals, "bytes 2-7/11")
+	c.Assert(rec.Body.String(), gc.Equals, "etwoth")
+}
+
 type multiReaderAtSuite struct{}
 
 var _ = gc.Suite(&multiReaderAtSuite{})
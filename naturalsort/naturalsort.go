@@ -0,0 +1,114 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// Package naturalsort orders strings with embedded numbers the way
+// people expect, so that "node2" sorts before "node10", and "1/lxd/2"
+// before "1/lxd/10".
+package naturalsort
+
+import (
+	"sort"
+	"strings"
+)
+
+// Sort sorts the strings in s in natural order, in place.
+func Sort(s []string) {
+	sort.Sort(naturally(s))
+}
+
+type naturally []string
+
+func (n naturally) Len() int {
+	return len(n)
+}
+
+func (n naturally) Swap(a, b int) {
+	n[a], n[b] = n[b], n[a]
+}
+
+func (n naturally) Less(a, b int) bool {
+	return Less(n[a], n[b])
+}
+
+// Less reports whether a sorts before b in natural order. The strings
+// are compared as alternating runs of non-digits, which are compared
+// bytewise, and runs of the digits 0-9, which are compared by their
+// numeric value however long they are. Strings that only differ in the
+// zero padding of their numbers are ordered bytewise.
+func Less(a, b string) bool {
+	aVal, bVal := a, b
+	for {
+		// If bVal is empty, then aVal can't be less than it.
+		if bVal == "" {
+			break
+		}
+		// If aVal is empty here, then is must be less than bVal.
+		if aVal == "" {
+			return true
+		}
+
+		aPrefix, aNumber, aRemainder := splitAtNumber(aVal)
+		bPrefix, bNumber, bRemainder := splitAtNumber(bVal)
+		if aPrefix != bPrefix {
+			return aPrefix < bPrefix
+		}
+		if c := compareNumbers(aNumber, bNumber); c != 0 {
+			return c < 0
+		}
+
+		// Everything is the same so far, try again with the remainder.
+		aVal = aRemainder
+		bVal = bRemainder
+	}
+	if aVal == "" {
+		// The strings are equal apart from zero padding.
+		return a < b
+	}
+	return false
+}
+
+// splitAtNumber splits the given string at the first digit, returning
+// the prefix before the number, the first series of digits, and the
+// remainder of the string after them. If no digits are present, the
+// number and remainder are empty.
+func splitAtNumber(str string) (prefix, number, remainder string) {
+	i := strings.IndexFunc(str, isDigit)
+	if i == -1 {
+		return str, "", ""
+	}
+	j := strings.IndexFunc(str[i:], isNotDigit)
+	if j == -1 {
+		return str[:i], str[i:], ""
+	}
+	return str[:i], str[i : i+j], str[i+j:]
+}
+
+// compareNumbers compares two series of digits by numeric value,
+// returning -1, 0 or 1. An empty series sorts before any number.
+func compareNumbers(a, b string) int {
+	if a == "" || b == "" {
+		return len(a) - len(b)
+	}
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	switch {
+	case len(a) != len(b):
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+func isNotDigit(r rune) bool {
+	return !isDigit(r)
+}
@@ -0,0 +1,50 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package naturalsort_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/naturalsort"
+)
+
+type naturalSortSuite struct{}
+
+var _ = gc.Suite(&naturalSortSuite{})
+
+var lessTests = []struct {
+	a, b string
+	less bool
+}{
+	{"node2", "node10", true},
+	{"node10", "node2", false},
+	{"node2", "node2", false},
+	{"", "a", true},
+	{"a", "", false},
+	{"", "", false},
+	{"a", "a1", true},
+	{"1", "a", true},
+	{"a01", "a1", true},
+	{"a1", "a01", false},
+	{"a01b", "a1c", true},
+	{"v99999999999999999999", "v100000000000000000000", true},
+	{"v100000000000000000000", "v99999999999999999999", false},
+	{"/dev/sda2", "/dev/sda10", true},
+	{"/dev/sda10", "/dev/sdb1", true},
+	{"x٣", "x٢", false},
+}
+
+func (*naturalSortSuite) TestLess(c *gc.C) {
+	for i, test := range lessTests {
+		c.Logf("test %d: %q < %q", i, test.a, test.b)
+		c.Check(naturalsort.Less(test.a, test.b), gc.Equals, test.less)
+	}
+}
+
+func (*naturalSortSuite) TestSort(c *gc.C) {
+	s := []string{"node10", "node1", "node01", "xenial", "node2", "bionic", "node"}
+	naturalsort.Sort(s)
+	c.Assert(s, jc.DeepEquals, []string{"bionic", "node", "node01", "node1", "node2", "node10", "xenial"})
+}
@@ -46,34 +46,75 @@ func GetIPv6Address(addresses []net.Addr) (string, error) {
 	return "", fmt.Errorf("no addresses match")
 }
 
-// GetAddressForInterface looks for the network interface
-// and returns the IPv4 address from the possible addresses.
-func GetAddressForInterface(interfaceName string) (string, error) {
-	iface, err := net.InterfaceByName(interfaceName)
+// Network interfaces and their addresses are found using these,
+// which are replaced in tests.
+var (
+	netInterfaces   = net.Interfaces
+	interfaceByName = net.InterfaceByName
+	interfaceAddrs  = (*net.Interface).Addrs
+)
+
+// upInterfaceAddrs returns the addresses of the named network
+// interface, which must be up.
+func upInterfaceAddrs(interfaceName string) ([]net.Addr, error) {
+	iface, err := interfaceByName(interfaceName)
 	if err != nil {
 		logger.Errorf("cannot find network interface %q: %v", interfaceName, err)
-		return "", err
+		return nil, err
 	}
-	addrs, err := iface.Addrs()
+	if iface.Flags&net.FlagUp == 0 {
+		return nil, fmt.Errorf("network interface %q is down", interfaceName)
+	}
+	addrs, err := interfaceAddrs(iface)
 	if err != nil {
 		logger.Errorf("cannot get addresses for network interface %q: %v", interfaceName, err)
+		return nil, err
+	}
+	return addrs, nil
+}
+
+// GetAddressForInterface looks for the network interface
+// and returns the IPv4 address from the possible addresses.
+// It returns an error if the interface is down.
+func GetAddressForInterface(interfaceName string) (string, error) {
+	addrs, err := upInterfaceAddrs(interfaceName)
+	if err != nil {
 		return "", err
 	}
 	return GetIPv4Address(addrs)
 }
 
+// GetV4AddressesForInterface looks for the network interface and
+// returns all of its IPv4 addresses. It returns an error if the
+// interface is down or has no IPv4 addresses.
+func GetV4AddressesForInterface(interfaceName string) ([]string, error) {
+	addrs, err := upInterfaceAddrs(interfaceName)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, addr := range addrs {
+		ip, _, err := net.ParseCIDR(addr.String())
+		if err != nil {
+			return nil, err
+		}
+		if ipv4 := ip.To4(); ipv4 != nil {
+			result = append(result, ipv4.String())
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("network interface %q has no IPv4 addresses", interfaceName)
+	}
+	return result, nil
+}
+
 // GetV4OrV6AddressForInterface looks for the network interface
 // and returns preferably the IPv4 address, and if it doesn't
-// exists then IPv6 address.
+// exists then IPv6 address. It returns an error if the interface
+// is down.
 func GetV4OrV6AddressForInterface(interfaceName string) (string, error) {
-	iface, err := net.InterfaceByName(interfaceName)
-	if err != nil {
-		logger.Errorf("cannot find network interface %q: %v", interfaceName, err)
-		return "", err
-	}
-	addrs, err := iface.Addrs()
+	addrs, err := upInterfaceAddrs(interfaceName)
 	if err != nil {
-		logger.Errorf("cannot get addresses for network interface %q: %v", interfaceName, err)
 		return "", err
 	}
 	if ip, err := GetIPv4Address(addrs); err == nil {
@@ -81,3 +122,44 @@ func GetV4OrV6AddressForInterface(interfaceName string) (string, error) {
 	}
 	return GetIPv6Address(addrs)
 }
+
+// GetPrimaryAddress returns the address that the machine is most
+// likely to be reached at: the first IPv4 address of the network
+// interfaces that are up and are not loopback interfaces, or failing
+// that the first such IPv6 address. Loopback and link-local addresses
+// are never returned. Interfaces are considered in the order the
+// system reports them.
+func GetPrimaryAddress() (string, error) {
+	ifaces, err := netInterfaces()
+	if err != nil {
+		return "", fmt.Errorf("cannot get network interfaces: %v", err)
+	}
+	var ipv6 string
+	for i := range ifaces {
+		iface := &ifaces[i]
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := interfaceAddrs(iface)
+		if err != nil {
+			logger.Warningf("cannot get addresses for network interface %q: %v", iface.Name, err)
+			continue
+		}
+		for _, addr := range addrs {
+			ip, _, err := net.ParseCIDR(addr.String())
+			if err != nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			if ipv4 := ip.To4(); ipv4 != nil {
+				return ipv4.String(), nil
+			}
+			if ipv6 == "" {
+				ipv6 = ip.String()
+			}
+		}
+	}
+	if ipv6 != "" {
+		return ipv6, nil
+	}
+	return "", fmt.Errorf("no network interfaces that are up have usable addresses")
+}
@@ -4,6 +4,7 @@
 package utils_test
 
 import (
+	"errors"
 	"net"
 
 	"github.com/juju/testing"
@@ -125,3 +126,157 @@ func (*networkSuite) TestGetIPv6Address(c *gc.C) {
 		}
 	}
 }
+
+// patchInterfaces makes the network interface functions report the
+// given interfaces, with the given addresses for each name.
+func (s *networkSuite) patchInterfaces(ifaces []net.Interface, addrs map[string][]net.Addr) {
+	s.PatchValue(utils.NetInterfaces, func() ([]net.Interface, error) {
+		return ifaces, nil
+	})
+	s.PatchValue(utils.InterfaceByName, func(name string) (*net.Interface, error) {
+		for i := range ifaces {
+			if ifaces[i].Name == name {
+				return &ifaces[i], nil
+			}
+		}
+		return nil, errors.New("no such network interface")
+	})
+	s.PatchValue(utils.InterfaceAddrs, func(iface *net.Interface) ([]net.Addr, error) {
+		if addrs, ok := addrs[iface.Name]; ok {
+			return addrs, nil
+		}
+		return nil, errors.New("cannot get addresses")
+	})
+}
+
+func (s *networkSuite) TestGetAddressForInterface(c *gc.C) {
+	s.patchInterfaces([]net.Interface{
+		{Name: "eth0", Flags: net.FlagUp},
+		{Name: "eth1"},
+		{Name: "eth2", Flags: net.FlagUp},
+	}, map[string][]net.Addr{
+		"eth0": makeAddresses("2001:db8::1/64", "10.0.3.1/24", "10.0.4.1/24"),
+		"eth1": makeAddresses("10.0.5.1/24"),
+	})
+
+	ip, err := utils.GetAddressForInterface("eth0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ip, gc.Equals, "10.0.3.1")
+
+	_, err = utils.GetAddressForInterface("eth1")
+	c.Assert(err, gc.ErrorMatches, `network interface "eth1" is down`)
+
+	_, err = utils.GetAddressForInterface("eth2")
+	c.Assert(err, gc.ErrorMatches, "cannot get addresses")
+
+	_, err = utils.GetAddressForInterface("eth3")
+	c.Assert(err, gc.ErrorMatches, "no such network interface")
+}
+
+func (s *networkSuite) TestGetV4AddressesForInterface(c *gc.C) {
+	s.patchInterfaces([]net.Interface{
+		{Name: "eth0", Flags: net.FlagUp},
+		{Name: "eth1", Flags: net.FlagUp},
+		{Name: "eth2"},
+	}, map[string][]net.Addr{
+		"eth0": makeAddresses("2001:db8::1/64", "10.0.3.1/24", "10.0.4.1/24"),
+		"eth1": makeAddresses("2001:db8::2/64"),
+		"eth2": makeAddresses("10.0.5.1/24"),
+	})
+
+	ips, err := utils.GetV4AddressesForInterface("eth0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ips, gc.DeepEquals, []string{"10.0.3.1", "10.0.4.1"})
+
+	_, err = utils.GetV4AddressesForInterface("eth1")
+	c.Assert(err, gc.ErrorMatches, `network interface "eth1" has no IPv4 addresses`)
+
+	_, err = utils.GetV4AddressesForInterface("eth2")
+	c.Assert(err, gc.ErrorMatches, `network interface "eth2" is down`)
+}
+
+func (s *networkSuite) TestGetV4OrV6AddressForInterfaceDown(c *gc.C) {
+	s.patchInterfaces([]net.Interface{{Name: "eth0"}}, map[string][]net.Addr{
+		"eth0": makeAddresses("10.0.3.1/24"),
+	})
+	_, err := utils.GetV4OrV6AddressForInterface("eth0")
+	c.Assert(err, gc.ErrorMatches, `network interface "eth0" is down`)
+}
+
+func (s *networkSuite) TestGetPrimaryAddress(c *gc.C) {
+	for i, test := range []struct {
+		about       string
+		ifaces      []net.Interface
+		addrs       map[string][]net.Addr
+		expected    string
+		errorString string
+	}{{
+		about: "first IPv4 address of an up, non-loopback interface",
+		ifaces: []net.Interface{
+			{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+			{Name: "eth0"},
+			{Name: "eth1", Flags: net.FlagUp},
+			{Name: "eth2", Flags: net.FlagUp},
+		},
+		addrs: map[string][]net.Addr{
+			"lo":   makeAddresses("127.0.0.1/8"),
+			"eth0": makeAddresses("10.0.3.1/24"),
+			"eth1": makeAddresses("fe80::1/64", "169.254.1.1/16", "2001:db8::1/64"),
+			"eth2": makeAddresses("10.0.4.1/24", "10.0.5.1/24"),
+		},
+		expected: "10.0.4.1",
+	}, {
+		about: "IPv6 address if there is no IPv4 address",
+		ifaces: []net.Interface{
+			{Name: "eth0", Flags: net.FlagUp},
+			{Name: "eth1", Flags: net.FlagUp},
+		},
+		addrs: map[string][]net.Addr{
+			"eth0": makeAddresses("fe80::1/64", "2001:db8::1/64"),
+			"eth1": makeAddresses("2001:db8::2/64"),
+		},
+		expected: "2001:db8::1",
+	}, {
+		about: "interfaces with unreadable addresses are skipped",
+		ifaces: []net.Interface{
+			{Name: "eth0", Flags: net.FlagUp},
+			{Name: "eth1", Flags: net.FlagUp},
+		},
+		addrs: map[string][]net.Addr{
+			"eth1": makeAddresses("10.0.3.1/24"),
+		},
+		expected: "10.0.3.1",
+	}, {
+		about: "no usable addresses",
+		ifaces: []net.Interface{
+			{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+			{Name: "eth0"},
+			{Name: "eth1", Flags: net.FlagUp},
+		},
+		addrs: map[string][]net.Addr{
+			"lo":   makeAddresses("127.0.0.1/8"),
+			"eth0": makeAddresses("10.0.3.1/24"),
+			"eth1": makeAddresses("fe80::1/64"),
+		},
+		errorString: "no network interfaces that are up have usable addresses",
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		s.patchInterfaces(test.ifaces, test.addrs)
+		ip, err := utils.GetPrimaryAddress()
+		if test.errorString == "" {
+			c.Check(err, gc.IsNil)
+			c.Check(ip, gc.Equals, test.expected)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.errorString)
+			c.Check(ip, gc.Equals, "")
+		}
+	}
+}
+
+func (s *networkSuite) TestGetPrimaryAddressError(c *gc.C) {
+	s.PatchValue(utils.NetInterfaces, func() ([]net.Interface, error) {
+		return nil, errors.New("boom")
+	})
+	_, err := utils.GetPrimaryAddress()
+	c.Assert(err, gc.ErrorMatches, "cannot get network interfaces: boom")
+}
@@ -4,6 +4,13 @@
 // Package os provides access to operating system related configuration.
 package os
 
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
 var HostOS = hostOS // for monkey patching
 
 type OSType int
@@ -16,26 +23,124 @@ const (
 	CentOS
 	GenericLinux
 	OpenSUSE
+	FreeBSD
+	OpenBSD
+	NetBSD
+	SUSE
+	AmazonLinux
 )
 
+// osTypeNames maps each OSType to the name returned by String.
+var osTypeNames = map[OSType]string{
+	Ubuntu:       "Ubuntu",
+	Windows:      "Windows",
+	OSX:          "OSX",
+	CentOS:       "CentOS",
+	GenericLinux: "GenericLinux",
+	OpenSUSE:     "OpenSUSE",
+	FreeBSD:      "FreeBSD",
+	OpenBSD:      "OpenBSD",
+	NetBSD:       "NetBSD",
+	SUSE:         "SUSE",
+	AmazonLinux:  "AmazonLinux",
+}
+
 func (t OSType) String() string {
-	switch t {
-	case Ubuntu:
-		return "Ubuntu"
-	case Windows:
-		return "Windows"
-	case OSX:
-		return "OSX"
-	case CentOS:
-		return "CentOS"
-	case GenericLinux:
-		return "GenericLinux"
-	case OpenSUSE:
-		return "OpenSUSE"
+	if name, ok := osTypeNames[t]; ok {
+		return name
 	}
 	return "Unknown"
 }
 
+// ParseOSType returns the OSType with the given name, as returned by
+// String. The comparison is case insensitive.
+func ParseOSType(s string) (OSType, error) {
+	if strings.EqualFold(s, Unknown.String()) {
+		return Unknown, nil
+	}
+	for t, name := range osTypeNames {
+		if strings.EqualFold(s, name) {
+			return t, nil
+		}
+	}
+	return Unknown, errors.NotValidf("OS type %q", s)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t OSType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It also accepts the
+// number that OSType was encoded as before it marshaled as a name.
+func (t *OSType) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		return t.setNumber(n)
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Trace(err)
+	}
+	return t.set(s)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (t OSType) MarshalYAML() (interface{}, error) {
+	return t.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. It also accepts the
+// number that OSType was encoded as before it marshaled as a name.
+func (t *OSType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var n int
+	if err := unmarshal(&n); err == nil {
+		return t.setNumber(n)
+	}
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return errors.Trace(err)
+	}
+	return t.set(s)
+}
+
+func (t *OSType) set(s string) error {
+	parsed, err := ParseOSType(s)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*t = parsed
+	return nil
+}
+
+func (t *OSType) setNumber(n int) error {
+	if _, ok := osTypeNames[OSType(n)]; !ok && OSType(n) != Unknown {
+		return errors.NotValidf("OS type %d", n)
+	}
+	*t = OSType(n)
+	return nil
+}
+
+// IsUbuntu returns true if the host OS is Ubuntu.
+func IsUbuntu() bool {
+	return HostOS() == Ubuntu
+}
+
+// IsCentOS returns true if the host OS is CentOS.
+func IsCentOS() bool {
+	return HostOS() == CentOS
+}
+
+// IsWindows returns true if the host OS is Windows.
+func IsWindows() bool {
+	return HostOS() == Windows
+}
+
+// IsMacOS returns true if the host OS is macOS.
+func IsMacOS() bool {
+	return HostOS() == OSX
+}
+
 // EquivalentTo returns true if the OS type is equivalent to another
 // OS type.
 func (t OSType) EquivalentTo(t2 OSType) bool {
@@ -48,7 +153,16 @@ func (t OSType) EquivalentTo(t2 OSType) bool {
 // IsLinux returns true if the OS type is a Linux variant.
 func (t OSType) IsLinux() bool {
 	switch t {
-	case Ubuntu, CentOS, GenericLinux, OpenSUSE:
+	case Ubuntu, CentOS, GenericLinux, OpenSUSE, SUSE, AmazonLinux:
+		return true
+	}
+	return false
+}
+
+// IsBSD returns true if the OS type is a BSD variant.
+func (t OSType) IsBSD() bool {
+	switch t {
+	case FreeBSD, OpenBSD, NetBSD:
 		return true
 	}
 	return false
@@ -4,6 +4,7 @@
 package os
 
 import (
+	"encoding/csv"
 	"errors"
 	"io/ioutil"
 	"strings"
@@ -14,8 +15,13 @@ var (
 	// osReleaseFile is the name of the file that is read in order to determine
 	// the linux type release version.
 	osReleaseFile = "/etc/os-release"
-	osOnce        sync.Once
-	os            OSType // filled in by the first call to hostOS
+
+	// ubuntuDistroInfo lists the known Ubuntu releases. It is provided
+	// by the distro-info-data package.
+	ubuntuDistroInfo = "/usr/share/distro-info/ubuntu.csv"
+
+	osOnce sync.Once
+	os     OSType // filled in by the first call to hostOS
 )
 
 func hostOS() OSType {
@@ -35,15 +41,65 @@ func updateOS(f string) (OSType, error) {
 		return Unknown, err
 	}
 	switch values["ID"] {
-	case strings.ToLower(Ubuntu.String()):
+	case strings.ToLower(Ubuntu.String()), "ubuntu-core":
 		return Ubuntu, nil
 	case strings.ToLower(CentOS.String()):
 		return CentOS, nil
-	case strings.ToLower(OpenSUSE.String()):
+	case strings.ToLower(OpenSUSE.String()), "opensuse-leap", "opensuse-tumbleweed":
 		return OpenSUSE, nil
-	default:
-		return GenericLinux, nil
+	case "sles", "sled":
+		return SUSE, nil
+	case "amzn":
+		return AmazonLinux, nil
+	}
+	// Derivatives such as Linux Mint list the distributions they are
+	// based on in ID_LIKE. They are only treated as Ubuntu if they are
+	// based on an Ubuntu release we know about, so that the OS agrees
+	// with the series reported for the host.
+	for _, like := range strings.Fields(values["ID_LIKE"]) {
+		if like == strings.ToLower(Ubuntu.String()) && isUbuntuRelease(values) {
+			return Ubuntu, nil
+		}
+	}
+	return GenericLinux, nil
+}
+
+// isUbuntuRelease reports whether the UBUNTU_CODENAME or VERSION_ID in
+// the given os-release values names a release listed in
+// ubuntuDistroInfo. If ubuntuDistroInfo can't be read, as on hosts
+// without the distro-info-data package, it falls back to trusting
+// os-release, and reports whether UBUNTU_CODENAME is set.
+func isUbuntuRelease(values map[string]string) bool {
+	contents, err := ioutil.ReadFile(ubuntuDistroInfo)
+	if err != nil {
+		return values["UBUNTU_CODENAME"] != ""
+	}
+	r := csv.NewReader(strings.NewReader(string(contents)))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil || len(records) == 0 {
+		return false
+	}
+	versionField, seriesField := -1, -1
+	for i, name := range records[0] {
+		switch name {
+		case "version":
+			versionField = i
+		case "series":
+			seriesField = i
+		}
+	}
+	codename, version := values["UBUNTU_CODENAME"], values["VERSION_ID"]
+	for _, fields := range records[1:] {
+		if seriesField >= 0 && seriesField < len(fields) && codename != "" && fields[seriesField] == codename {
+			return true
+		}
+		if versionField >= 0 && versionField < len(fields) && version != "" &&
+			strings.TrimSuffix(fields[versionField], " LTS") == version {
+			return true
+		}
 	}
+	return false
 }
 
 // ReadOSRelease parses the information in the os-release file.
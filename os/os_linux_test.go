@@ -0,0 +1,75 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package os
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+)
+
+type linuxSuite struct{}
+
+var _ = gc.Suite(&linuxSuite{})
+
+func (s *linuxSuite) TestUpdateOS(c *gc.C) {
+	distroInfo := filepath.Join(c.MkDir(), "ubuntu.csv")
+	err := ioutil.WriteFile(distroInfo, []byte(`version,codename,series,created,release,eol
+16.04 LTS,Xenial Xerus,xenial,2015-10-22,2016-04-21,2021-04-21
+18.04 LTS,Bionic Beaver,bionic,2017-10-19,2018-04-26,2023-04-26
+`), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	defer func(orig string) { ubuntuDistroInfo = orig }(ubuntuDistroInfo)
+	ubuntuDistroInfo = distroInfo
+
+	for i, test := range []struct {
+		contents string
+		expected OSType
+	}{
+		{"ID=ubuntu\n", Ubuntu},
+		{"ID=ubuntu-core\n", Ubuntu},
+		{"ID=\"centos\"\n", CentOS},
+		{"ID=opensuse-leap\n", OpenSUSE},
+		{"ID=\"sles\"\n", SUSE},
+		{"ID=\"amzn\"\n", AmazonLinux},
+		{"ID=linuxmint\nID_LIKE=ubuntu\nVERSION_ID=\"19\"\nUBUNTU_CODENAME=bionic\n", Ubuntu},
+		{"ID=pop\nID_LIKE=\"ubuntu debian\"\nVERSION_ID=\"16.04\"\n", Ubuntu},
+		{"ID=linuxmint\nID_LIKE=ubuntu\n", GenericLinux},
+		{"ID=linuxmint\nID_LIKE=ubuntu\nVERSION_ID=\"21\"\nUBUNTU_CODENAME=jammy\n", GenericLinux},
+		{"ID=arch\n", GenericLinux},
+	} {
+		c.Logf("test %d", i)
+		f := filepath.Join(c.MkDir(), "os-release")
+		err := ioutil.WriteFile(f, []byte(test.contents), 0644)
+		c.Assert(err, jc.ErrorIsNil)
+		os, err := updateOS(f)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(os, gc.Equals, test.expected)
+	}
+}
+
+func (s *linuxSuite) TestUpdateOSWithoutDistroInfo(c *gc.C) {
+	defer func(orig string) { ubuntuDistroInfo = orig }(ubuntuDistroInfo)
+	ubuntuDistroInfo = filepath.Join(c.MkDir(), "missing.csv")
+
+	for i, test := range []struct {
+		contents string
+		expected OSType
+	}{
+		{"ID=ubuntu\n", Ubuntu},
+		{"ID=linuxmint\nID_LIKE=ubuntu\nVERSION_ID=\"21\"\nUBUNTU_CODENAME=jammy\n", Ubuntu},
+		{"ID=pop\nID_LIKE=\"ubuntu debian\"\nVERSION_ID=\"16.04\"\n", GenericLinux},
+		{"ID=elementary\nID_LIKE=debian\nUBUNTU_CODENAME=focal\n", GenericLinux},
+	} {
+		c.Logf("test %d", i)
+		f := filepath.Join(c.MkDir(), "os-release")
+		err := ioutil.WriteFile(f, []byte(test.contents), 0644)
+		c.Assert(err, jc.ErrorIsNil)
+		os, err := updateOS(f)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(os, gc.Equals, test.expected)
+	}
+}
@@ -4,7 +4,11 @@
 package os
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"runtime"
+	"strconv"
 
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
@@ -26,7 +30,7 @@ func (s *osSuite) TestHostOS(c *gc.C) {
 		// TODO(mjs) - this should really do more by patching out
 		// osReleaseFile and testing the corner cases.
 		switch os {
-		case Ubuntu, CentOS, GenericLinux:
+		case Ubuntu, CentOS, GenericLinux, SUSE, AmazonLinux:
 		case OpenSUSE:
 			c.Assert(os, gc.Equals, OpenSUSE)
 		default:
@@ -61,3 +65,124 @@ func (s *osSuite) TestIsLinux(c *gc.C) {
 	c.Check(Windows.IsLinux(), jc.IsFalse)
 	c.Check(Unknown.IsLinux(), jc.IsFalse)
 }
+
+func (s *osSuite) TestIsBSD(c *gc.C) {
+	c.Check(FreeBSD.IsBSD(), jc.IsTrue)
+	c.Check(OpenBSD.IsBSD(), jc.IsTrue)
+	c.Check(NetBSD.IsBSD(), jc.IsTrue)
+
+	c.Check(FreeBSD.IsLinux(), jc.IsFalse)
+	c.Check(Ubuntu.IsBSD(), jc.IsFalse)
+	c.Check(OSX.IsBSD(), jc.IsFalse)
+}
+
+var allOSTypes = []OSType{
+	Unknown, Ubuntu, Windows, OSX, CentOS, GenericLinux, OpenSUSE,
+	FreeBSD, OpenBSD, NetBSD, SUSE, AmazonLinux,
+}
+
+func (s *osSuite) TestParseOSTypeRoundTrip(c *gc.C) {
+	for _, t := range allOSTypes {
+		parsed, err := ParseOSType(t.String())
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(parsed, gc.Equals, t)
+	}
+}
+
+func (s *osSuite) TestParseOSTypeCaseInsensitive(c *gc.C) {
+	parsed, err := ParseOSType("amazonlinux")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(parsed, gc.Equals, AmazonLinux)
+}
+
+func (s *osSuite) TestParseOSTypeInvalid(c *gc.C) {
+	_, err := ParseOSType("plan9")
+	c.Assert(err, gc.ErrorMatches, `OS type "plan9" not valid`)
+}
+
+func (s *osSuite) TestJSONRoundTrip(c *gc.C) {
+	for _, t := range allOSTypes {
+		data, err := json.Marshal(t)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(string(data), gc.Equals, `"`+t.String()+`"`)
+		var result OSType
+		err = json.Unmarshal(data, &result)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(result, gc.Equals, t)
+	}
+}
+
+func (s *osSuite) TestUnmarshalJSONInvalid(c *gc.C) {
+	var result OSType
+	err := json.Unmarshal([]byte(`"plan9"`), &result)
+	c.Assert(err, gc.ErrorMatches, `OS type "plan9" not valid`)
+}
+
+func (s *osSuite) TestUnmarshalJSONNumber(c *gc.C) {
+	for _, t := range allOSTypes {
+		var result OSType
+		err := json.Unmarshal([]byte(strconv.Itoa(int(t))), &result)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(result, gc.Equals, t)
+	}
+	var result OSType
+	err := json.Unmarshal([]byte(`99`), &result)
+	c.Assert(err, gc.ErrorMatches, `OS type 99 not valid`)
+	err = json.Unmarshal([]byte(`-1`), &result)
+	c.Assert(err, gc.ErrorMatches, `OS type -1 not valid`)
+}
+
+// yamlUnmarshaler returns an unmarshal function, as passed to
+// UnmarshalYAML, that decodes value, failing like yaml.v2 does if it
+// can't be stored in the target.
+func yamlUnmarshaler(value interface{}) func(interface{}) error {
+	return func(v interface{}) error {
+		switch v := v.(type) {
+		case *int:
+			n, ok := value.(int)
+			if !ok {
+				return errors.New("cannot unmarshal !!str into int")
+			}
+			*v = n
+		case *string:
+			*v = fmt.Sprint(value)
+		}
+		return nil
+	}
+}
+
+func (s *osSuite) TestUnmarshalYAMLNumber(c *gc.C) {
+	for _, t := range allOSTypes {
+		var result OSType
+		err := result.UnmarshalYAML(yamlUnmarshaler(int(t)))
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(result, gc.Equals, t)
+	}
+	var result OSType
+	err := result.UnmarshalYAML(yamlUnmarshaler(99))
+	c.Assert(err, gc.ErrorMatches, `OS type 99 not valid`)
+}
+
+func (s *osSuite) TestYAMLRoundTrip(c *gc.C) {
+	for _, t := range allOSTypes {
+		value, err := t.MarshalYAML()
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(value, gc.Equals, t.String())
+		var result OSType
+		err = result.UnmarshalYAML(yamlUnmarshaler(value))
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(result, gc.Equals, t)
+	}
+}
+
+func (s *osSuite) TestHostPredicates(c *gc.C) {
+	defer func(orig func() OSType) { HostOS = orig }(HostOS)
+	for _, t := range allOSTypes {
+		hostType := t
+		HostOS = func() OSType { return hostType }
+		c.Check(IsUbuntu(), gc.Equals, t == Ubuntu)
+		c.Check(IsCentOS(), gc.Equals, t == CentOS)
+		c.Check(IsWindows(), gc.Equals, t == Windows)
+		c.Check(IsMacOS(), gc.Equals, t == OSX)
+	}
+}
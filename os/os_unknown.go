@@ -5,6 +5,16 @@
 
 package os
 
+import "runtime"
+
 func hostOS() OSType {
+	switch runtime.GOOS {
+	case "freebsd":
+		return FreeBSD
+	case "openbsd":
+		return OpenBSD
+	case "netbsd":
+		return NetBSD
+	}
 	return Unknown
 }
@@ -0,0 +1,128 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package packaging
+
+import (
+	"strings"
+
+	"github.com/juju/utils/exec"
+	jujuos "github.com/juju/utils/os"
+)
+
+// commands describes how to drive a package manager.
+type commands struct {
+	name string
+
+	// update, install, remove and query hold the commands for each
+	// operation. Package names are appended to all but update.
+	update  []string
+	install []string
+	remove  []string
+	query   []string
+
+	// env holds extra environment variables for the commands.
+	env []string
+
+	// isInstalled reports whether the output of the query command
+	// shows the package is installed.
+	isInstalled func(resp *exec.ExecResponse) bool
+
+	// isLocked reports whether a failed command failed because
+	// another process holds the package database lock.
+	isLocked func(resp *exec.ExecResponse) bool
+}
+
+// managers holds the package managers known to NewManager.
+var managers = map[string]commands{
+	"apt": {
+		name:    "apt",
+		update:  []string{"apt-get", "--quiet", "--assume-yes", "update"},
+		install: []string{"apt-get", "--quiet", "--assume-yes", "--option=Dpkg::Options::=--force-confold", "install"},
+		remove:  []string{"apt-get", "--quiet", "--assume-yes", "remove"},
+		query:   []string{"dpkg-query", "--show", "--showformat=${Status}"},
+		env:     []string{"DEBIAN_FRONTEND=noninteractive", "LC_ALL=C"},
+		// Removed packages whose configuration files remain are
+		// still known to dpkg, so check the status.
+		isInstalled: func(resp *exec.ExecResponse) bool {
+			return resp.Code == 0 && strings.HasSuffix(strings.TrimSpace(string(resp.Stdout)), " installed")
+		},
+		isLocked: outputContains("Could not get lock", "Unable to lock", "Unable to acquire the dpkg frontend lock"),
+	},
+	"yum": {
+		name:        "yum",
+		update:      []string{"yum", "--assumeyes", "makecache"},
+		install:     []string{"yum", "--assumeyes", "install"},
+		remove:      []string{"yum", "--assumeyes", "remove"},
+		query:       []string{"rpm", "--query"},
+		env:         []string{"LC_ALL=C"},
+		isInstalled: exitedZero,
+		isLocked:    outputContains("Existing lock", "yum lock"),
+	},
+	"dnf": {
+		name:        "dnf",
+		update:      []string{"dnf", "--assumeyes", "makecache"},
+		install:     []string{"dnf", "--assumeyes", "install"},
+		remove:      []string{"dnf", "--assumeyes", "remove"},
+		query:       []string{"rpm", "--query"},
+		env:         []string{"LC_ALL=C"},
+		isInstalled: exitedZero,
+		isLocked:    outputContains("Failed to obtain the transaction lock"),
+	},
+	"zypper": {
+		name:        "zypper",
+		update:      []string{"zypper", "--non-interactive", "refresh"},
+		install:     []string{"zypper", "--non-interactive", "install"},
+		remove:      []string{"zypper", "--non-interactive", "remove"},
+		query:       []string{"rpm", "--query"},
+		env:         []string{"LC_ALL=C"},
+		isInstalled: exitedZero,
+		// zypper exits with ZYPPER_EXIT_ZYPP_LOCKED when another
+		// process holds its lock.
+		isLocked: func(resp *exec.ExecResponse) bool {
+			return resp.Code == 7
+		},
+	},
+}
+
+// osManagers holds the package manager for each operating system that
+// always uses the same one.
+var osManagers = map[jujuos.OSType]string{
+	jujuos.Ubuntu:      "apt",
+	jujuos.CentOS:      "yum",
+	jujuos.AmazonLinux: "yum",
+	jujuos.OpenSUSE:    "zypper",
+	jujuos.SUSE:        "zypper",
+}
+
+// distroManagers holds the package manager for each distribution ID
+// from os-release, for distributions without their own series.
+var distroManagers = map[string]string{
+	"ubuntu":   "apt",
+	"debian":   "apt",
+	"raspbian": "apt",
+	"centos":   "yum",
+	"rhel":     "yum",
+	"ol":       "yum",
+	"amzn":     "yum",
+	"fedora":   "dnf",
+	"sles":     "zypper",
+}
+
+func exitedZero(resp *exec.ExecResponse) bool {
+	return resp.Code == 0
+}
+
+// outputContains returns a function that reports whether a command's
+// output contains any of the given strings.
+func outputContains(patterns ...string) func(resp *exec.ExecResponse) bool {
+	return func(resp *exec.ExecResponse) bool {
+		output := string(resp.Stdout) + string(resp.Stderr)
+		for _, pattern := range patterns {
+			if strings.Contains(output, pattern) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// Package packaging provides a common interface to the system package
+// managers of the Linux distributions Juju supports, so that
+// provisioning code doesn't need to know how to drive each of them.
+package packaging
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"golang.org/x/net/context"
+
+	"github.com/juju/utils"
+	"github.com/juju/utils/exec"
+	"github.com/juju/utils/proxy"
+	"github.com/juju/utils/series"
+)
+
+var logger = loggo.GetLogger("juju.utils.packaging")
+
+// runCommand is used to run package manager commands. It is a variable
+// so that tests can avoid running real commands.
+var runCommand = exec.RunCommand
+
+// defaultLockRetries holds the default value of Config.LockRetries.
+const defaultLockRetries = 30
+
+// lockBackoff holds the waits between attempts when the package
+// database is locked.
+var lockBackoff = utils.BackoffConfig{
+	Initial: time.Second,
+	Max:     30 * time.Second,
+	Factor:  2,
+	Jitter:  0.1,
+}
+
+// Manager is implemented by package managers.
+type Manager interface {
+	// Name returns the name of the package manager, such as "apt".
+	Name() string
+
+	// Update refreshes the list of available packages.
+	Update(ctx context.Context) error
+
+	// Install installs the given packages.
+	Install(ctx context.Context, packages ...string) error
+
+	// Remove removes the given packages.
+	Remove(ctx context.Context, packages ...string) error
+
+	// IsInstalled reports whether the given package is installed.
+	IsInstalled(ctx context.Context, pkg string) (bool, error)
+}
+
+// Config holds the configuration for a Manager.
+type Config struct {
+	// Proxy holds the proxy settings passed to the package manager
+	// in its environment.
+	Proxy proxy.Settings
+
+	// LockRetries holds the number of times a command is retried
+	// when it fails because another process holds the package
+	// database lock, as often happens while unattended upgrades run
+	// on a new machine. If it is zero, 30 is used; if it is
+	// negative, commands are not retried.
+	LockRetries int
+
+	// Clock is used to wait between retries. If it is nil,
+	// clock.WallClock is used.
+	Clock clock.Clock
+}
+
+// NewManager returns the package manager with the given name: one of
+// "apt", "yum", "dnf" or "zypper".
+func NewManager(name string, config Config) (Manager, error) {
+	cmds, ok := managers[name]
+	if !ok {
+		return nil, errors.NotSupportedf("package manager %q", name)
+	}
+	if config.LockRetries == 0 {
+		config.LockRetries = defaultLockRetries
+	}
+	if config.Clock == nil {
+		config.Clock = clock.WallClock
+	}
+	return &manager{
+		commands: cmds,
+		config:   config,
+	}, nil
+}
+
+// HostManager returns the package manager of the machine the current
+// process is running on.
+func HostManager(config Config) (Manager, error) {
+	info, err := series.HostOSInfo()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	name, err := ManagerNameForOS(info)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return NewManager(name, config)
+}
+
+// ManagerNameForSeries returns the name of the package manager used by
+// the given series, as accepted by NewManager.
+func ManagerNameForSeries(s string) (string, error) {
+	osType, err := series.GetOSFromSeries(s)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if name, ok := osManagers[osType]; ok {
+		return name, nil
+	}
+	return "", errors.NotSupportedf("package management on %s", osType)
+}
+
+// ManagerNameForOS is like ManagerNameForSeries, but falls back to the
+// distribution ID when the series doesn't determine the package
+// manager, as for "genericlinux".
+func ManagerNameForOS(info series.OSInfo) (string, error) {
+	if name, err := ManagerNameForSeries(info.Series); err == nil {
+		return name, nil
+	}
+	if name, ok := distroManagers[info.ID]; ok {
+		return name, nil
+	}
+	if strings.HasPrefix(info.ID, "opensuse") {
+		return "zypper", nil
+	}
+	return "", errors.NotSupportedf("package management on %q", info.ID)
+}
+
+// manager implements Manager by running the commands of a package
+// manager.
+type manager struct {
+	commands commands
+	config   Config
+}
+
+// Name implements Manager.
+func (m *manager) Name() string {
+	return m.commands.name
+}
+
+// Update implements Manager.
+func (m *manager) Update(ctx context.Context) error {
+	_, err := m.run(ctx, m.commands.update, true)
+	return errors.Annotatef(err, "cannot update package lists")
+}
+
+// Install implements Manager.
+func (m *manager) Install(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+	_, err := m.run(ctx, append(m.commands.install, packages...), true)
+	return errors.Annotatef(err, "cannot install %s", strings.Join(packages, ", "))
+}
+
+// Remove implements Manager.
+func (m *manager) Remove(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+	_, err := m.run(ctx, append(m.commands.remove, packages...), true)
+	return errors.Annotatef(err, "cannot remove %s", strings.Join(packages, ", "))
+}
+
+// IsInstalled implements Manager.
+func (m *manager) IsInstalled(ctx context.Context, pkg string) (bool, error) {
+	resp, err := m.run(ctx, append(m.commands.query, pkg), false)
+	if err != nil {
+		return false, errors.Annotatef(err, "cannot query %s", pkg)
+	}
+	return m.commands.isInstalled(resp), nil
+}
+
+// run runs the given command, retrying while the package database is
+// locked. If check is true, a non-zero exit code is an error.
+func (m *manager) run(ctx context.Context, args []string, check bool) (*exec.ExecResponse, error) {
+	args = append([]string(nil), args...)
+	spec := exec.CommandSpec{
+		Path: args[0],
+		Args: args[1:],
+		Env:  m.environ(),
+	}
+	backoff, err := utils.NewBackoff(utils.BackoffConfig{
+		Initial: lockBackoff.Initial,
+		Max:     lockBackoff.Max,
+		Factor:  lockBackoff.Factor,
+		Jitter:  lockBackoff.Jitter,
+		Clock:   m.config.Clock,
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for attempt := 0; ; attempt++ {
+		logger.Debugf("running %s", strings.Join(args, " "))
+		resp, err := runCommand(ctx, spec)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if resp.Code == 0 || !check {
+			return resp, nil
+		}
+		if !m.commands.isLocked(resp) || attempt >= m.config.LockRetries {
+			return nil, commandError(args[0], resp)
+		}
+		logger.Infof("package database locked, retrying %s", args[0])
+		if err := backoff.Wait(ctx); err != nil {
+			return nil, errors.Annotate(err, "waiting for package database lock")
+		}
+	}
+}
+
+// environ returns the environment for package manager commands.
+func (m *manager) environ() []string {
+	env := os.Environ()
+	env = append(env, m.config.Proxy.AsEnvironmentValues()...)
+	return append(env, m.commands.env...)
+}
+
+// commandError returns an error describing a failed command.
+func commandError(name string, resp *exec.ExecResponse) error {
+	output := strings.TrimSpace(string(resp.Stderr))
+	if output == "" {
+		output = strings.TrimSpace(string(resp.Stdout))
+	}
+	return errors.Errorf("%s exited with code %d: %s", name, resp.Code, output)
+}
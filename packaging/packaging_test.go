@@ -0,0 +1,276 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package packaging_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/exec"
+	"github.com/juju/utils/packaging"
+	"github.com/juju/utils/proxy"
+	"github.com/juju/utils/series"
+)
+
+type packagingSuite struct {
+	testing.IsolationSuite
+	clock *testclock.Clock
+
+	// commands records the commands run.
+	commands []string
+	// envs records the environment of each command.
+	envs [][]string
+	// responses holds the responses to return, in order.
+	responses []*exec.ExecResponse
+}
+
+var _ = gc.Suite(&packagingSuite{})
+
+func (s *packagingSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.clock = testclock.NewClock(time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC))
+	s.commands = nil
+	s.envs = nil
+	s.responses = nil
+	s.PatchValue(packaging.RunCommand, func(ctx context.Context, spec exec.CommandSpec) (*exec.ExecResponse, error) {
+		s.commands = append(s.commands, strings.Join(append([]string{spec.Path}, spec.Args...), " "))
+		s.envs = append(s.envs, spec.Env)
+		if len(s.responses) == 0 {
+			return &exec.ExecResponse{}, nil
+		}
+		resp := s.responses[0]
+		s.responses = s.responses[1:]
+		return resp, nil
+	})
+}
+
+func (s *packagingSuite) newManager(c *gc.C, name string) packaging.Manager {
+	m, err := packaging.NewManager(name, packaging.Config{
+		Proxy: proxy.Settings{Http: "http://proxy.example.com:3128"},
+		Clock: s.clock,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.Name(), gc.Equals, name)
+	return m
+}
+
+func (s *packagingSuite) TestCommands(c *gc.C) {
+	for i, test := range []struct {
+		name     string
+		expected []string
+	}{{
+		name: "apt",
+		expected: []string{
+			"apt-get --quiet --assume-yes update",
+			"apt-get --quiet --assume-yes --option=Dpkg::Options::=--force-confold install git curl",
+			"apt-get --quiet --assume-yes remove git",
+		},
+	}, {
+		name: "yum",
+		expected: []string{
+			"yum --assumeyes makecache",
+			"yum --assumeyes install git curl",
+			"yum --assumeyes remove git",
+		},
+	}, {
+		name: "dnf",
+		expected: []string{
+			"dnf --assumeyes makecache",
+			"dnf --assumeyes install git curl",
+			"dnf --assumeyes remove git",
+		},
+	}, {
+		name: "zypper",
+		expected: []string{
+			"zypper --non-interactive refresh",
+			"zypper --non-interactive install git curl",
+			"zypper --non-interactive remove git",
+		},
+	}} {
+		c.Logf("test %d: %s", i, test.name)
+		s.commands = nil
+		m := s.newManager(c, test.name)
+		ctx := context.Background()
+		c.Assert(m.Update(ctx), jc.ErrorIsNil)
+		c.Assert(m.Install(ctx, "git", "curl"), jc.ErrorIsNil)
+		c.Assert(m.Remove(ctx, "git"), jc.ErrorIsNil)
+		c.Check(s.commands, jc.DeepEquals, test.expected)
+	}
+}
+
+func (s *packagingSuite) TestProxyEnvironment(c *gc.C) {
+	m := s.newManager(c, "apt")
+	err := m.Install(context.Background(), "git")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.envs, gc.HasLen, 1)
+	c.Assert(s.envs[0], jc.Contains, "http_proxy=http://proxy.example.com:3128")
+	c.Assert(s.envs[0], jc.Contains, "HTTP_PROXY=http://proxy.example.com:3128")
+	c.Assert(s.envs[0], jc.Contains, "DEBIAN_FRONTEND=noninteractive")
+}
+
+func (s *packagingSuite) TestInstallNothing(c *gc.C) {
+	m := s.newManager(c, "apt")
+	c.Assert(m.Install(context.Background()), jc.ErrorIsNil)
+	c.Assert(m.Remove(context.Background()), jc.ErrorIsNil)
+	c.Assert(s.commands, gc.HasLen, 0)
+}
+
+func (s *packagingSuite) TestIsInstalled(c *gc.C) {
+	for i, test := range []struct {
+		name      string
+		resp      *exec.ExecResponse
+		installed bool
+	}{{
+		name:      "apt",
+		resp:      &exec.ExecResponse{Stdout: []byte("install ok installed")},
+		installed: true,
+	}, {
+		name: "apt",
+		resp: &exec.ExecResponse{Stdout: []byte("deinstall ok config-files")},
+	}, {
+		name: "apt",
+		resp: &exec.ExecResponse{Code: 1, Stderr: []byte("dpkg-query: no packages found matching git")},
+	}, {
+		name:      "yum",
+		resp:      &exec.ExecResponse{Stdout: []byte("git-1.8.3.1-14.el7_5.x86_64")},
+		installed: true,
+	}, {
+		name: "zypper",
+		resp: &exec.ExecResponse{Code: 1, Stdout: []byte("package git is not installed")},
+	}} {
+		c.Logf("test %d: %s %s", i, test.name, test.resp.Stdout)
+		s.commands = nil
+		s.responses = []*exec.ExecResponse{test.resp}
+		installed, err := s.newManager(c, test.name).IsInstalled(context.Background(), "git")
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(installed, gc.Equals, test.installed)
+		c.Check(s.commands, gc.HasLen, 1)
+	}
+}
+
+func (s *packagingSuite) TestFailure(c *gc.C) {
+	s.responses = []*exec.ExecResponse{{
+		Code:   100,
+		Stderr: []byte("E: Unable to locate package nonesuch\n"),
+	}}
+	err := s.newManager(c, "apt").Install(context.Background(), "nonesuch")
+	c.Assert(err, gc.ErrorMatches, `cannot install nonesuch: apt-get exited with code 100: E: Unable to locate package nonesuch`)
+	c.Assert(s.commands, gc.HasLen, 1)
+}
+
+func (s *packagingSuite) TestRetryWhileLocked(c *gc.C) {
+	locked := &exec.ExecResponse{
+		Code:   100,
+		Stderr: []byte("E: Could not get lock /var/lib/dpkg/lock - open (11: Resource temporarily unavailable)\n"),
+	}
+	s.responses = []*exec.ExecResponse{locked, locked, {}}
+	m := s.newManager(c, "apt")
+	done := make(chan error)
+	go func() {
+		done <- m.Install(context.Background(), "git")
+	}()
+	for i := 0; i < 2; i++ {
+		err := s.clock.WaitAdvance(time.Minute, testing.LongWait, 1)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	select {
+	case err := <-done:
+		c.Assert(err, jc.ErrorIsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for install")
+	}
+	c.Assert(s.commands, gc.HasLen, 3)
+}
+
+func (s *packagingSuite) TestRetryGivesUp(c *gc.C) {
+	locked := &exec.ExecResponse{Code: 7, Stderr: []byte("System management is locked")}
+	s.responses = []*exec.ExecResponse{locked, locked}
+	m, err := packaging.NewManager("zypper", packaging.Config{
+		LockRetries: 1,
+		Clock:       s.clock,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	done := make(chan error)
+	go func() {
+		done <- m.Update(context.Background())
+	}()
+	err = s.clock.WaitAdvance(time.Minute, testing.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	select {
+	case err := <-done:
+		c.Assert(err, gc.ErrorMatches, `cannot update package lists: zypper exited with code 7: System management is locked`)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for update")
+	}
+	c.Assert(s.commands, gc.HasLen, 2)
+}
+
+func (s *packagingSuite) TestRetryCancelled(c *gc.C) {
+	locked := &exec.ExecResponse{Code: 7}
+	s.responses = []*exec.ExecResponse{locked}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := s.newManager(c, "zypper").Update(ctx)
+	c.Assert(err, gc.ErrorMatches, `cannot update package lists: waiting for package database lock: .*`)
+	c.Assert(errors.Cause(err), gc.Equals, context.Canceled)
+}
+
+func (*packagingSuite) TestNewManagerUnknown(c *gc.C) {
+	_, err := packaging.NewManager("pacman", packaging.Config{})
+	c.Assert(err, gc.ErrorMatches, `package manager "pacman" not supported`)
+	c.Assert(errors.IsNotSupported(err), jc.IsTrue)
+}
+
+func (*packagingSuite) TestManagerNameForSeries(c *gc.C) {
+	for _, test := range []struct {
+		series string
+		name   string
+		err    string
+	}{
+		{series: "bionic", name: "apt"},
+		{series: "xenial", name: "apt"},
+		{series: "centos7", name: "yum"},
+		{series: "opensuseleap", name: "zypper"},
+		{series: "win2012r2", err: `package management on Windows not supported`},
+	} {
+		c.Logf("series %s", test.series)
+		name, err := packaging.ManagerNameForSeries(test.series)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(name, gc.Equals, test.name)
+	}
+}
+
+func (*packagingSuite) TestManagerNameForOS(c *gc.C) {
+	for _, test := range []struct {
+		info series.OSInfo
+		name string
+		err  string
+	}{
+		{info: series.OSInfo{Series: "bionic", ID: "ubuntu"}, name: "apt"},
+		{info: series.OSInfo{Series: "genericlinux", ID: "debian"}, name: "apt"},
+		{info: series.OSInfo{Series: "genericlinux", ID: "fedora"}, name: "dnf"},
+		{info: series.OSInfo{Series: "genericlinux", ID: "opensuse-tumbleweed"}, name: "zypper"},
+		{info: series.OSInfo{Series: "genericlinux", ID: "arch"}, err: `package management on "arch" not supported`},
+	} {
+		c.Logf("OS %+v", test.info)
+		name, err := packaging.ManagerNameForOS(test.info)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(name, gc.Equals, test.name)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package parallel
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// ErrPoolClosed is returned by Pool.Go when the pool is no longer
+// accepting tasks.
+var ErrPoolClosed = errors.New("pool was closed")
+
+// PanicError is the error recorded for a task that panicked.
+type PanicError struct {
+	// Value holds the value passed to panic.
+	Value interface{}
+
+	// Stack holds the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// Pool runs tasks on a bounded number of goroutines. Unlike Run, each
+// task is given a context that is cancelled when the pool is killed,
+// and panics in tasks are recovered and reported as errors rather than
+// crashing the process.
+type Pool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	slots  chan struct{}
+	wg     sync.WaitGroup
+
+	// mu guards the fields below it.
+	mu     sync.Mutex
+	closed bool
+	next   int
+	errs   []taskError
+}
+
+// taskError records the error from a task along with the order in
+// which the task was submitted.
+type taskError struct {
+	index int
+	err   error
+}
+
+// NewPool returns a new pool that runs at most size tasks at once.
+// The contexts passed to tasks are derived from ctx, so cancelling ctx
+// asks all the tasks to stop.
+func NewPool(ctx context.Context, size int) *Pool {
+	if size < 1 {
+		panic("parameter size must be >= 1")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pool{
+		ctx:    ctx,
+		cancel: cancel,
+		slots:  make(chan struct{}, size),
+	}
+}
+
+// Go runs task in the pool, waiting for one of the running tasks to
+// finish if there are already the maximum number running. The context
+// passed to the task is cancelled when the task returns, or earlier if
+// the pool is killed or its parent context is done.
+//
+// Go returns ErrPoolClosed if Wait or Kill has been called, and the
+// parent context's error if it is done.
+func (p *Pool) Go(task func(ctx context.Context) error) error {
+	if p.ctx.Err() != nil {
+		return p.doneError()
+	}
+	select {
+	case p.slots <- struct{}{}:
+	case <-p.ctx.Done():
+		return p.doneError()
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return ErrPoolClosed
+	}
+	index := p.next
+	p.next++
+	p.wg.Add(1)
+	p.mu.Unlock()
+
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.slots }()
+		if err := p.run(task); err != nil {
+			p.mu.Lock()
+			p.errs = append(p.errs, taskError{index, err})
+			p.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// run calls the task with its own context, recovering any panic.
+func (p *Pool) run(task func(ctx context.Context) error) (err error) {
+	ctx, cancel := context.WithCancel(p.ctx)
+	defer cancel()
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{
+				Value: v,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+	return task(ctx)
+}
+
+// Wait stops the pool accepting new tasks and waits for all the
+// running tasks to finish. If any tasks failed, it returns an Errors
+// value holding their errors in the order the tasks were submitted.
+func (p *Pool) Wait() error {
+	p.close()
+	p.wg.Wait()
+	p.cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.errs) == 0 {
+		return nil
+	}
+	sort.Sort(byIndex(p.errs))
+	errs := make(Errors, len(p.errs))
+	for i, e := range p.errs {
+		errs[i] = e.err
+	}
+	return errs
+}
+
+// Kill stops the pool accepting new tasks and cancels the contexts of
+// all the running tasks. It does not wait for them to finish; call
+// Wait for that.
+func (p *Pool) Kill() {
+	p.close()
+	p.cancel()
+}
+
+func (p *Pool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+}
+
+// doneError returns the error from Go when the pool's context is done.
+func (p *Pool) doneError() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	return p.ctx.Err()
+}
+
+type byIndex []taskError
+
+func (b byIndex) Len() int           { return len(b) }
+func (b byIndex) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byIndex) Less(i, j int) bool { return b[i].index < b[j].index }
@@ -0,0 +1,160 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package parallel_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/parallel"
+)
+
+type poolSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&poolSuite{})
+
+func (*poolSuite) TestMaxConcurrency(c *gc.C) {
+	const (
+		total = 20
+		size  = 3
+	)
+	var mu sync.Mutex
+	running, maxRunning, runs := 0, 0, 0
+	pool := parallel.NewPool(context.Background(), size)
+	for i := 0; i < total; i++ {
+		err := pool.Go(func(context.Context) error {
+			mu.Lock()
+			runs++
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	c.Assert(pool.Wait(), jc.ErrorIsNil)
+	c.Assert(runs, gc.Equals, total)
+	c.Assert(running, gc.Equals, 0)
+	c.Assert(maxRunning, gc.Equals, size)
+}
+
+func (*poolSuite) TestErrorsInSubmissionOrder(c *gc.C) {
+	pool := parallel.NewPool(context.Background(), 10)
+	for i := 0; i < 10; i++ {
+		i := i
+		pool.Go(func(context.Context) error {
+			// Make later tasks fail first.
+			time.Sleep(time.Duration(10-i) * time.Millisecond)
+			if i%3 == 0 {
+				return fmt.Errorf("error %d", i)
+			}
+			return nil
+		})
+	}
+	err := pool.Wait()
+	c.Assert(err, gc.ErrorMatches, `error 0 \(and 3 more\)`)
+	errs, ok := err.(parallel.Errors)
+	c.Assert(ok, jc.IsTrue)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	c.Assert(msgs, jc.DeepEquals, []string{"error 0", "error 3", "error 6", "error 9"})
+}
+
+func (*poolSuite) TestPanicCaptured(c *gc.C) {
+	pool := parallel.NewPool(context.Background(), 2)
+	pool.Go(func(context.Context) error {
+		panic("oops")
+	})
+	pool.Go(func(context.Context) error {
+		return nil
+	})
+	err := pool.Wait()
+	c.Assert(err, gc.ErrorMatches, "task panicked: oops")
+	panicErr, ok := err.(parallel.Errors)[0].(*parallel.PanicError)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(panicErr.Value, gc.Equals, "oops")
+	c.Assert(string(panicErr.Stack), gc.Matches, "(?s).*pool_test.go.*")
+}
+
+func (*poolSuite) TestTaskContextCancelledOnReturn(c *gc.C) {
+	pool := parallel.NewPool(context.Background(), 1)
+	ctxs := make(chan context.Context, 1)
+	pool.Go(func(ctx context.Context) error {
+		ctxs <- ctx
+		return nil
+	})
+	c.Assert(pool.Wait(), jc.ErrorIsNil)
+	ctx := <-ctxs
+	c.Assert(ctx.Err(), gc.Equals, context.Canceled)
+}
+
+func (*poolSuite) TestKill(c *gc.C) {
+	pool := parallel.NewPool(context.Background(), 2)
+	started := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		err := pool.Go(func(ctx context.Context) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	<-started
+	<-started
+
+	// A task waiting for a free slot is rejected when the pool is
+	// killed.
+	blocked := make(chan error)
+	go func() {
+		blocked <- pool.Go(func(context.Context) error {
+			return errors.New("should not run")
+		})
+	}()
+	pool.Kill()
+	select {
+	case err := <-blocked:
+		c.Assert(err, gc.Equals, parallel.ErrPoolClosed)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for Go to return")
+	}
+	c.Assert(pool.Wait(), gc.ErrorMatches, `context canceled \(and 1 more\)`)
+	c.Assert(pool.Go(func(context.Context) error { return nil }), gc.Equals, parallel.ErrPoolClosed)
+}
+
+func (*poolSuite) TestGoAfterWait(c *gc.C) {
+	pool := parallel.NewPool(context.Background(), 1)
+	c.Assert(pool.Wait(), jc.ErrorIsNil)
+	err := pool.Go(func(context.Context) error { return nil })
+	c.Assert(err, gc.Equals, parallel.ErrPoolClosed)
+}
+
+func (*poolSuite) TestParentContextDone(c *gc.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := parallel.NewPool(ctx, 1)
+	cancel()
+	err := pool.Go(func(context.Context) error { return nil })
+	c.Assert(err, gc.Equals, context.Canceled)
+	c.Assert(pool.Wait(), jc.ErrorIsNil)
+}
+
+func (*poolSuite) TestZeroSizePanics(c *gc.C) {
+	c.Assert(func() { parallel.NewPool(context.Background(), 0) }, gc.PanicMatches, "parameter size must be >= 1")
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package parallel
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/juju/clock"
+	"golang.org/x/net/context"
+)
+
+// TryFirst runs the given attempts concurrently and returns the result
+// of the first one to succeed, cancelling the context passed to the
+// others. It is a simpler alternative to Try for the common case of
+// trying several equivalent endpoints.
+//
+// If stagger is positive, the attempts are started in order, each one
+// stagger after the previous one, or as soon as all the running
+// attempts have failed, so that a fast first choice avoids loading the
+// others. Otherwise all the attempts are started at once.
+//
+// If all the attempts fail, TryFirst returns an Errors value holding
+// their errors in the order of the attempts. If ctx is done first, it
+// returns ctx's error. Successful results that are not returned are
+// closed if they implement io.Closer.
+func TryFirst(
+	ctx context.Context,
+	clk clock.Clock,
+	stagger time.Duration,
+	attempts ...func(ctx context.Context) (interface{}, error),
+) (interface{}, error) {
+	if len(attempts) == 0 {
+		return nil, errors.New("no attempts")
+	}
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attemptResult, len(attempts))
+	started, finished := 0, 0
+	startNext := func() <-chan time.Time {
+		i := started
+		started++
+		go func() {
+			val, err := attempts[i](attemptCtx)
+			results <- attemptResult{i, val, err}
+		}()
+		if started == len(attempts) {
+			return nil
+		}
+		if stagger <= 0 {
+			return closedTimeChan
+		}
+		return clk.After(stagger)
+	}
+	// discard closes any successful results of the attempts still
+	// running once TryFirst has returned.
+	discard := func() {
+		go func() {
+			for i := finished; i < started; i++ {
+				r := <-results
+				if closer, ok := r.val.(io.Closer); ok && r.err == nil {
+					closer.Close()
+				}
+			}
+		}()
+	}
+
+	errs := make(Errors, len(attempts))
+	next := startNext()
+	for {
+		select {
+		case r := <-results:
+			finished++
+			if r.err == nil {
+				discard()
+				return r.val, nil
+			}
+			errs[r.index] = r.err
+			if finished == len(attempts) {
+				return nil, errs
+			}
+			if finished == started {
+				// Everything running has failed, so don't wait
+				// to start the next attempt.
+				next = startNext()
+			}
+		case <-next:
+			next = startNext()
+		case <-ctx.Done():
+			discard()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// closedTimeChan is used by TryFirst to start attempts without delay.
+var closedTimeChan = func() <-chan time.Time {
+	c := make(chan time.Time)
+	close(c)
+	return c
+}()
+
+// attemptResult holds the result of one of the attempts run by
+// TryFirst.
+type attemptResult struct {
+	index int
+	val   interface{}
+	err   error
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package parallel_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/parallel"
+)
+
+type tryFirstSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&tryFirstSuite{})
+
+type attempt func(ctx context.Context) (interface{}, error)
+
+func succeed(val interface{}) attempt {
+	return func(context.Context) (interface{}, error) {
+		return val, nil
+	}
+}
+
+func fail(msg string) attempt {
+	return func(context.Context) (interface{}, error) {
+		return nil, errors.New(msg)
+	}
+}
+
+// block returns an attempt that waits for its context to be done,
+// sending the context's error on the given channel.
+func block(done chan<- error) attempt {
+	return func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		done <- ctx.Err()
+		return nil, ctx.Err()
+	}
+}
+
+func (*tryFirstSuite) TestFirstSuccess(c *gc.C) {
+	val, err := parallel.TryFirst(context.Background(), clock.WallClock, 0,
+		fail("first"), succeed("second"), fail("third"),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(val, gc.Equals, "second")
+}
+
+func (*tryFirstSuite) TestAllFail(c *gc.C) {
+	_, err := parallel.TryFirst(context.Background(), clock.WallClock, 0,
+		fail("first"), fail("second"), fail("third"),
+	)
+	c.Assert(err, gc.ErrorMatches, `first \(and 2 more\)`)
+	errs := err.(parallel.Errors)
+	c.Assert(errs, gc.HasLen, 3)
+	c.Assert(errs[2], gc.ErrorMatches, "third")
+}
+
+func (*tryFirstSuite) TestNoAttempts(c *gc.C) {
+	_, err := parallel.TryFirst(context.Background(), clock.WallClock, 0)
+	c.Assert(err, gc.ErrorMatches, "no attempts")
+}
+
+func (*tryFirstSuite) TestStaggered(c *gc.C) {
+	clk := testclock.NewClock(time.Time{})
+	firstDone := make(chan error, 1)
+	type result struct {
+		val interface{}
+		err error
+	}
+	resultc := make(chan result)
+	go func() {
+		val, err := parallel.TryFirst(context.Background(), clk, time.Second,
+			block(firstDone), succeed("second"),
+		)
+		resultc <- result{val, err}
+	}()
+
+	// The second attempt only starts after the stagger.
+	c.Assert(clk.WaitAdvance(time.Second, testing.LongWait, 1), jc.ErrorIsNil)
+	select {
+	case r := <-resultc:
+		c.Assert(r.err, jc.ErrorIsNil)
+		c.Assert(r.val, gc.Equals, "second")
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for result")
+	}
+	// The first attempt was cancelled.
+	select {
+	case err := <-firstDone:
+		c.Assert(err, gc.Equals, context.Canceled)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("first attempt not cancelled")
+	}
+}
+
+func (*tryFirstSuite) TestStaggeredFailureStartsNextAttempt(c *gc.C) {
+	// No time passes, so the second attempt must be started by the
+	// first one failing.
+	clk := testclock.NewClock(time.Time{})
+	val, err := parallel.TryFirst(context.Background(), clk, time.Hour,
+		fail("first"), succeed("second"),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(val, gc.Equals, "second")
+}
+
+func (*tryFirstSuite) TestContextDone(c *gc.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		time.Sleep(shortWait)
+		cancel()
+	}()
+	_, err := parallel.TryFirst(ctx, clock.WallClock, 0, block(done))
+	c.Assert(err, gc.Equals, context.Canceled)
+}
+
+func (*tryFirstSuite) TestExtraResultsClosed(c *gc.C) {
+	closed := make(chan struct{})
+	release := make(chan struct{})
+	slow := func(context.Context) (interface{}, error) {
+		<-release
+		return &closeResult{closed: closed}, nil
+	}
+	val, err := parallel.TryFirst(context.Background(), clock.WallClock, 0,
+		slow, succeed("fast"),
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(val, gc.Equals, "fast")
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("extra result not closed")
+	}
+}
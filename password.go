@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"crypto/sha512"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 
@@ -34,6 +35,27 @@ func RandomBytes(n int) ([]byte, error) {
 	return buf, nil
 }
 
+// RandomHex returns a hex-encoded token made from n random bytes, for
+// use as an API key or other secret. The token is 2*n characters long.
+func RandomHex(n int) (string, error) {
+	b, err := RandomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RandomBase64 returns a token made from n random bytes, encoded with
+// unpadded URL-safe base64 so that it can be used in URLs and headers
+// without escaping.
+func RandomBase64(n int) (string, error) {
+	b, err := RandomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 // RandomPassword generates a random base64-encoded password.
 func RandomPassword() (string, error) {
 	b, err := RandomBytes(randomPasswordBytes)
@@ -43,6 +65,15 @@ func RandomPassword() (string, error) {
 	return base64.StdEncoding.EncodeToString(b), nil
 }
 
+// RandomReadablePassword generates a random password of length n for a
+// person to read or type. It only contains characters from
+// ReadableRunes, so it needs to be longer than a password from
+// RandomPassword to be as strong: each character carries just under 6
+// bits of entropy.
+func RandomReadablePassword(n int) (string, error) {
+	return SecureRandomString(n, ReadableRunes)
+}
+
 // RandomSalt generates a random base64 data suitable for using as a password
 // salt The pbkdf2 guideline is to use 8 bytes of salt, so we do 12 raw bytes
 // into 16 base64 bytes. (The alternative is 6 raw into 8 base64).
@@ -74,7 +105,9 @@ func UserPasswordHash(password string, salt string) string {
 	// uses the MD5 sum of the password anyway, so there's
 	// no point in using more bytes. (18 so we don't get base 64
 	// padding characters).
-	h := pbkdf2.Key([]byte(password), []byte(salt), iter, 18, sha512.New)
+	pw := []byte(password)
+	defer ZeroBytes(pw)
+	h := pbkdf2.Key(pw, []byte(salt), iter, 18, sha512.New)
 	return base64.StdEncoding.EncodeToString(h)
 }
 
@@ -85,8 +118,10 @@ func UserPasswordHash(password string, salt string) string {
 // search. And using a faster hash allows us to restart the state machines and
 // have 1000s of agents log in in a reasonable amount of time.
 func AgentPasswordHash(password string) string {
+	pw := []byte(password)
+	defer ZeroBytes(pw)
 	sum := sha512.New()
-	sum.Write([]byte(password))
+	sum.Write(pw)
 	h := sum.Sum(nil)
 	return base64.StdEncoding.EncodeToString(h[:18])
 }
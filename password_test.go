@@ -4,6 +4,9 @@
 package utils_test
 
 import (
+	"crypto/rand"
+	"errors"
+
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
@@ -34,6 +37,39 @@ func (*passwordSuite) TestRandomBytes(c *gc.C) {
 	c.Errorf("all same bytes in result of RandomBytes")
 }
 
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func (s *passwordSuite) TestRandomBytesError(c *gc.C) {
+	s.PatchValue(&rand.Reader, errorReader{errors.New("no entropy")})
+	_, err := utils.RandomBytes(16)
+	c.Assert(err, gc.ErrorMatches, "cannot read random bytes: no entropy")
+	_, err = utils.RandomHex(16)
+	c.Assert(err, gc.ErrorMatches, "cannot read random bytes: no entropy")
+	_, err = utils.RandomBase64(16)
+	c.Assert(err, gc.ErrorMatches, "cannot read random bytes: no entropy")
+}
+
+func (*passwordSuite) TestRandomHex(c *gc.C) {
+	token, err := utils.RandomHex(16)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(token, gc.Matches, "^[0-9a-f]{32}$")
+	other, err := utils.RandomHex(16)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(token, gc.Not(gc.Equals), other)
+}
+
+func (*passwordSuite) TestRandomBase64(c *gc.C) {
+	token, err := utils.RandomBase64(32)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(token, gc.Matches, "^[A-Za-z0-9_-]{43}$")
+}
+
 func (*passwordSuite) TestRandomPassword(c *gc.C) {
 	p, err := utils.RandomPassword()
 	c.Assert(err, gc.IsNil)
@@ -53,6 +89,14 @@ func (*passwordSuite) TestRandomSalt(c *gc.C) {
 	c.Assert(salt, gc.Matches, base64Chars)
 }
 
+func (*passwordSuite) TestRandomReadablePassword(c *gc.C) {
+	p, err := utils.RandomReadablePassword(20)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(p, gc.HasLen, 20)
+	c.Assert(p, gc.Matches, "^[a-zA-Z2-9]+$")
+	c.Assert(p, gc.Not(gc.Matches), ".*[01OIl].*")
+}
+
 var testPasswords = []string{"", "a", "a longer password than i would usually bother with"}
 
 var testSalts = []string{"abcd", "abcdefgh", "abcdefghijklmnop", utils.CompatSalt}
@@ -0,0 +1,18 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build go1.7
+
+package utils
+
+import (
+	"net"
+
+	"golang.org/x/net/context"
+)
+
+// dialContext dials addr, giving up when ctx is done.
+func dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, addr)
+}
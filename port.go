@@ -0,0 +1,68 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
+)
+
+// waitForPortBackoff holds the configuration of the waits between
+// attempts to connect in WaitForPort.
+var waitForPortBackoff = BackoffConfig{
+	Initial: 50 * time.Millisecond,
+	Max:     2 * time.Second,
+	Factor:  2,
+	Jitter:  0.1,
+}
+
+// IsPortFree reports whether a TCP listener can be opened on the given
+// host and port.
+func IsPortFree(host string, port int) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// PickFreePort returns a TCP port, chosen by the system, that is free
+// on the loopback interface. Note that another process may take the
+// port before the caller uses it, so it's better to listen on port 0
+// directly when possible.
+func PickFreePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, errors.Annotate(err, "cannot pick free port")
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// WaitForPort waits until a TCP connection can be made to the given
+// address, trying repeatedly with increasing waits between attempts.
+// It returns an error satisfying errors.Cause(err) == ctx.Err() if ctx
+// is done first.
+func WaitForPort(ctx context.Context, addr string) error {
+	backoff, err := NewBackoff(waitForPortBackoff)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for {
+		conn, dialErr := dialContext(ctx, "tcp", addr)
+		if dialErr == nil {
+			conn.Close()
+			return nil
+		}
+		logger.Debugf("waiting for %s: %v", addr, dialErr)
+		if err := backoff.Wait(ctx); err != nil {
+			return errors.Annotatef(err, "waiting for %s (last error: %v)", addr, dialErr)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type portSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&portSuite{})
+
+func (*portSuite) TestIsPortFree(c *gc.C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, jc.ErrorIsNil)
+	port := listener.Addr().(*net.TCPAddr).Port
+	c.Assert(utils.IsPortFree("127.0.0.1", port), jc.IsFalse)
+	listener.Close()
+	c.Assert(utils.IsPortFree("127.0.0.1", port), jc.IsTrue)
+}
+
+func (*portSuite) TestPickFreePort(c *gc.C) {
+	port, err := utils.PickFreePort()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(port, jc.GreaterThan, 0)
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	c.Assert(err, jc.ErrorIsNil)
+	listener.Close()
+}
+
+func (*portSuite) TestWaitForPort(c *gc.C) {
+	port, err := utils.PickFreePort()
+	c.Assert(err, jc.ErrorIsNil)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	listening := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		listener, err := net.Listen("tcp", addr)
+		c.Check(err, jc.ErrorIsNil)
+		listening <- listener
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), testing.LongWait)
+	defer cancel()
+	err = utils.WaitForPort(ctx, addr)
+	c.Assert(err, jc.ErrorIsNil)
+	listener := <-listening
+	if listener != nil {
+		listener.Close()
+	}
+}
+
+func (*portSuite) TestWaitForPortContextDone(c *gc.C) {
+	port, err := utils.PickFreePort()
+	c.Assert(err, jc.ErrorIsNil)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err = utils.WaitForPort(ctx, addr)
+	c.Assert(err, gc.ErrorMatches, `waiting for `+addr+` \(last error: .*\): context deadline exceeded`)
+	c.Assert(errors.Cause(err), gc.Equals, context.DeadlineExceeded)
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package proxy
+
+import (
+	"net"
+	"strings"
+)
+
+// NoProxyMatches reports whether addr, in "host" or "host:port" form,
+// is matched by the given no_proxy value, and so should not be
+// proxied. The value is a comma-separated list of entries, each of
+// which may be:
+//
+//   - "*", which matches every address;
+//   - a domain, such as "example.com", which matches that host and any
+//     of its subdomains;
+//   - a domain with a leading "." or "*.", such as ".example.com",
+//     which matches only subdomains;
+//   - an IP address, such as "10.0.3.1" or "::1";
+//   - a CIDR, such as "10.0.0.0/8", which matches any IP address in
+//     the range.
+//
+// Domain and IP address entries may be followed by a port, as in
+// "example.com:8080" or "[::1]:8080", to match only addresses with
+// that port. Host names are compared without regard to case, and are
+// never resolved.
+func NoProxyMatches(addr, noProxy string) bool {
+	host, port := splitAddr(addr)
+	if host == "" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && ipNet.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		entryHost, entryPort := splitAddr(entry)
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && ip.Equal(entryIP) {
+				return true
+			}
+			continue
+		}
+		if matchesDomain(host, entryHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitAddr splits addr into a lower case host name, without any
+// trailing dot or IPv6 brackets, and a port, which is empty if there
+// is none.
+func splitAddr(addr string) (host, port string) {
+	host = addr
+	if h, p, err := net.SplitHostPort(addr); err == nil {
+		host, port = h, p
+	} else if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		host = addr[1 : len(addr)-1]
+	}
+	return strings.TrimSuffix(strings.ToLower(host), "."), port
+}
+
+// matchesDomain reports whether host is matched by the no_proxy domain
+// entry.
+func matchesDomain(host, domain string) bool {
+	if strings.HasPrefix(domain, "*.") {
+		domain = domain[1:]
+	}
+	if strings.HasPrefix(domain, ".") {
+		return strings.HasSuffix(host, domain)
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package proxy_test
+
+import (
+	"github.com/juju/testing"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/proxy"
+)
+
+type noProxySuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&noProxySuite{})
+
+var noProxyMatchesTests = []struct {
+	addr    string
+	noProxy string
+	matches bool
+}{
+	// Empty values never match.
+	{"example.com", "", false},
+	{"", "*", false},
+	{"example.com", " , ", false},
+
+	// Wildcard.
+	{"example.com", "*", true},
+	{"10.0.0.1:80", "foo.com, *", true},
+
+	// Exact hosts and their subdomains.
+	{"example.com", "example.com", true},
+	{"example.com:8080", "example.com", true},
+	{"www.example.com", "example.com", true},
+	{"a.b.example.com", "example.com", true},
+	{"EXAMPLE.com.", "Example.COM", true},
+	{"badexample.com", "example.com", false},
+	{"example.com.evil.org", "example.com", false},
+	{"example.org", "foo.com,example.com", false},
+
+	// Leading dot or wildcard only matches subdomains.
+	{"www.example.com", ".example.com", true},
+	{"www.example.com", "*.example.com", true},
+	{"example.com", ".example.com", false},
+	{"example.com", "*.example.com", false},
+	{"badexample.com", ".example.com", false},
+
+	// IP addresses.
+	{"10.0.3.1", "10.0.3.1", true},
+	{"10.0.3.1:17070", "10.0.3.1", true},
+	{"10.0.3.10", "10.0.3.1", false},
+	{"110.0.3.1", "10.0.3.1", false},
+	{"[::1]:80", "::1", true},
+	{"[2001:db8::1]:443", "2001:db8:0::1", true},
+	{"[2001:db8::1]", "2001:db8::1", true},
+	{"::ffff:10.0.3.1", "10.0.3.1", true},
+	{"10.0.3.1", "10.0.3.1.example.com", false},
+
+	// CIDRs.
+	{"10.1.2.3", "10.0.0.0/8", true},
+	{"10.1.2.3:80", "192.168.0.0/16,10.0.0.0/8", true},
+	{"11.1.2.3", "10.0.0.0/8", false},
+	{"[2001:db8::5]:80", "2001:db8::/32", true},
+	{"2001:db9::5", "2001:db8::/32", false},
+	{"10.example.com", "10.0.0.0/8", false},
+
+	// Ports.
+	{"example.com:8080", "example.com:8080", true},
+	{"www.example.com:8080", "example.com:8080", true},
+	{"example.com:8081", "example.com:8080", false},
+	{"example.com", "example.com:8080", false},
+	{"10.0.3.1:22", "10.0.3.1:22", true},
+	{"10.0.3.1:80", "10.0.3.1:22", false},
+	{"[::1]:8080", "[::1]:8080", true},
+	{"[::1]:80", "[::1]:8080", false},
+}
+
+func (s *noProxySuite) TestNoProxyMatches(c *gc.C) {
+	for i, test := range noProxyMatchesTests {
+		c.Logf("test %d: %q in %q", i, test.addr, test.noProxy)
+		c.Check(proxy.NoProxyMatches(test.addr, test.noProxy), gc.Equals, test.matches)
+	}
+}
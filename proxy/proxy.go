@@ -5,6 +5,9 @@ package proxy
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
 
@@ -133,3 +136,75 @@ func (s *Settings) FullNoProxy() string {
 	noProxySet := set.NewStrings(allNoProxy...)
 	return strings.Join(noProxySet.SortedValues(), ",")
 }
+
+// ProxyFunc returns a function that chooses the proxy for an HTTP
+// request according to the settings, for use as the Proxy field of an
+// http.Transport. Requests for https URLs use the Https proxy and all
+// others use the Http proxy. Requests to localhost and to the hosts in
+// FullNoProxy, as matched by NoProxyMatches, are not proxied.
+//
+// The settings are copied, so later changes to s have no effect on
+// the returned function.
+func (s *Settings) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	settings := *s
+	noProxy := settings.FullNoProxy()
+	return func(req *http.Request) (*url.URL, error) {
+		value := settings.Http
+		if req.URL.Scheme == "https" {
+			value = settings.Https
+		}
+		if value == "" || !useProxy(canonicalAddr(req.URL), noProxy) {
+			return nil, nil
+		}
+		return parseProxyURL(value)
+	}
+}
+
+// InstallInTransport makes the given transport use the proxies in the
+// settings. Unlike http.ProxyFromEnvironment, which reads the process
+// environment only once, this takes effect whenever it is called.
+func (s *Settings) InstallInTransport(t *http.Transport) {
+	t.Proxy = s.ProxyFunc()
+}
+
+// useProxy reports whether requests to the given address, in "host" or
+// "host:port" form, should be proxied.
+func useProxy(addr, noProxy string) bool {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return false
+	}
+	return !NoProxyMatches(addr, noProxy)
+}
+
+// canonicalAddr returns the "host:port" address for u, adding the
+// default port for its scheme if necessary.
+func canonicalAddr(u *url.URL) string {
+	if _, _, err := net.SplitHostPort(u.Host); err == nil {
+		return u.Host
+	}
+	port := "80"
+	if u.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(strings.Trim(u.Host, "[]"), port)
+}
+
+// parseProxyURL parses a proxy setting, which may omit the "http://"
+// prefix.
+func parseProxyURL(value string) (*url.URL, error) {
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy address %q: %v", value, err)
+	}
+	return proxyURL, nil
+}
@@ -4,6 +4,9 @@
 package proxy_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 
 	"github.com/juju/testing"
@@ -238,3 +241,112 @@ func (s *proxySuite) TestAutoNoProxy(c *gc.C) {
 	proxies.AutoNoProxy = "10.0.3.1,10.0.3.2"
 	c.Assert(proxies.AsEnvironmentValues(), gc.DeepEquals, expectedSecond)
 }
+
+func (s *proxySuite) TestProxyFunc(c *gc.C) {
+	proxies := proxy.Settings{
+		Http:        "http://user@10.0.0.1:3128",
+		Https:       "proxy.example.com:3129",
+		NoProxy:     "10.0.3.1,internal.example.com",
+		AutoNoProxy: "10.0.3.2",
+	}
+	proxyFunc := proxies.ProxyFunc()
+	// Later changes should have no effect.
+	proxies.Http = "changed"
+
+	for i, test := range []struct {
+		url      string
+		expected string
+	}{
+		{"http://example.com/", "http://user@10.0.0.1:3128"},
+		{"http://example.com:8080/", "http://user@10.0.0.1:3128"},
+		{"ftp://example.com/", "http://user@10.0.0.1:3128"},
+		{"https://example.com/", "http://proxy.example.com:3129"},
+		{"http://10.0.3.1/", ""},
+		{"https://10.0.3.2:17070/", ""},
+		{"http://internal.example.com:8080/", ""},
+		{"http://localhost:8080/", ""},
+		{"http://127.0.0.1/", ""},
+		{"http://[::1]:80/", ""},
+	} {
+		c.Logf("test %d: %s", i, test.url)
+		req, err := http.NewRequest("GET", test.url, nil)
+		c.Assert(err, gc.IsNil)
+		proxyURL, err := proxyFunc(req)
+		c.Assert(err, gc.IsNil)
+		if test.expected == "" {
+			c.Check(proxyURL, gc.IsNil)
+		} else {
+			c.Assert(proxyURL, gc.NotNil)
+			c.Check(proxyURL.String(), gc.Equals, test.expected)
+		}
+	}
+}
+
+func (s *proxySuite) TestProxyFuncNoProxies(c *gc.C) {
+	proxies := proxy.Settings{NoProxy: "*"}
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	c.Assert(err, gc.IsNil)
+	proxyURL, err := proxies.ProxyFunc()(req)
+	c.Assert(err, gc.IsNil)
+	c.Assert(proxyURL, gc.IsNil)
+
+	proxies = proxy.Settings{Http: "http://10.0.0.1", NoProxy: "*"}
+	proxyURL, err = proxies.ProxyFunc()(req)
+	c.Assert(err, gc.IsNil)
+	c.Assert(proxyURL, gc.IsNil)
+}
+
+func (s *proxySuite) TestProxyFuncInvalid(c *gc.C) {
+	proxies := proxy.Settings{Http: "http://10.0.0.1:port"}
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	c.Assert(err, gc.IsNil)
+	_, err = proxies.ProxyFunc()(req)
+	c.Assert(err, gc.ErrorMatches, `invalid proxy address "http://10.0.0.1:port": .*`)
+}
+
+func (s *proxySuite) TestInstallInTransport(c *gc.C) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requested = append(requested, req.URL.String())
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	c.Assert(err, gc.IsNil)
+
+	proxies := proxy.Settings{Http: serverURL.Host}
+	transport := &http.Transport{}
+	proxies.InstallInTransport(transport)
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get("http://example.com/path")
+	c.Assert(err, gc.IsNil)
+	resp.Body.Close()
+	c.Assert(requested, gc.DeepEquals, []string{"http://example.com/path"})
+}
+
+func (s *proxySuite) TestProxyFuncNoProxyPorts(c *gc.C) {
+	proxies := proxy.Settings{
+		Http:    "http://10.0.0.1:3128",
+		Https:   "http://10.0.0.1:3128",
+		NoProxy: "example.com:80,.internal,10.0.0.0/8",
+	}
+	proxyFunc := proxies.ProxyFunc()
+	for i, test := range []struct {
+		url     string
+		proxied bool
+	}{
+		{"http://example.com/", false},
+		{"http://www.example.com:80/", false},
+		{"https://example.com/", true},
+		{"http://example.com:8080/", true},
+		{"http://api.internal/", false},
+		{"http://10.20.30.40/", false},
+		{"http://[2001:db8::1]/", true},
+	} {
+		c.Logf("test %d: %s", i, test.url)
+		req, err := http.NewRequest("GET", test.url, nil)
+		c.Assert(err, gc.IsNil)
+		proxyURL, err := proxyFunc(req)
+		c.Assert(err, gc.IsNil)
+		c.Check(proxyURL != nil, gc.Equals, test.proxied)
+	}
+}
@@ -4,6 +4,9 @@
 package utils
 
 import (
+	cryptorand "crypto/rand"
+	"fmt"
+	"math/big"
 	"math/rand"
 	"sync"
 	"time"
@@ -14,6 +17,11 @@ var (
 	LowerAlpha = []rune("abcdefghijklmnopqrstuvwxyz")
 	UpperAlpha = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
 	Digits     = []rune("0123456789")
+
+	// ReadableRunes holds letters and digits that are unlikely to be
+	// confused with one another when read or typed by a person. It
+	// leaves out 0, O, 1, I and l.
+	ReadableRunes = []rune("abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789")
 )
 
 var (
@@ -28,7 +36,8 @@ func init() {
 }
 
 // RandomString will return a string of length n that will only
-// contain runes inside validRunes
+// contain runes inside validRunes. It is not suitable for secrets; use
+// SecureRandomString for those.
 func RandomString(n int, validRunes []rune) string {
 	randomStringMu.Lock()
 	defer randomStringMu.Unlock()
@@ -40,3 +49,28 @@ func RandomString(n int, validRunes []rune) string {
 
 	return string(runes)
 }
+
+// SecureRandomString returns a string of length n that only contains
+// runes inside validRunes, chosen using crypto/rand. Each rune is
+// equally likely to be chosen, so the string is suitable for use as a
+// password or other secret. An error is returned if n is negative.
+func SecureRandomString(n int, validRunes []rune) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("negative length %d", n)
+	}
+	if len(validRunes) == 0 {
+		return "", fmt.Errorf("no valid runes")
+	}
+	max := big.NewInt(int64(len(validRunes)))
+	runes := make([]rune, n)
+	for i := range runes {
+		// rand.Int samples uniformly, without the bias that taking a
+		// random byte modulo len(validRunes) would introduce.
+		index, err := cryptorand.Int(cryptorand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("cannot read random bytes: %v", err)
+		}
+		runes[i] = validRunes[index.Int64()]
+	}
+	return string(runes), nil
+}
@@ -33,3 +33,32 @@ func (randomStringSuite) TestContentInValidRunes(c *gc.C) {
 		c.Assert(string(validChars), jc.Contains, string(char))
 	}
 }
+
+func (randomStringSuite) TestSecureRandomString(c *gc.C) {
+	s, err := utils.SecureRandomString(length, validChars)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert([]rune(s), gc.HasLen, length)
+	for _, char := range s {
+		c.Assert(string(validChars), jc.Contains, string(char))
+	}
+}
+
+func (randomStringSuite) TestSecureRandomStringUsesAllRunes(c *gc.C) {
+	seen := make(map[rune]bool)
+	s, err := utils.SecureRandomString(1000, utils.Digits)
+	c.Assert(err, jc.ErrorIsNil)
+	for _, char := range s {
+		seen[char] = true
+	}
+	c.Assert(seen, gc.HasLen, len(utils.Digits))
+}
+
+func (randomStringSuite) TestSecureRandomStringNoRunes(c *gc.C) {
+	_, err := utils.SecureRandomString(length, nil)
+	c.Assert(err, gc.ErrorMatches, "no valid runes")
+}
+
+func (randomStringSuite) TestSecureRandomStringNegativeLength(c *gc.C) {
+	_, err := utils.SecureRandomString(-1, validChars)
+	c.Assert(err, gc.ErrorMatches, "negative length -1")
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// The ratelimit package provides a token-bucket rate limiter for pacing
+// requests or other operations.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
+)
+
+// Bucket is a token bucket. It starts full, holding capacity tokens,
+// and is refilled with one token every fill interval until it is full
+// again. Taking a token from the bucket allows one operation, so the
+// bucket allows bursts of up to capacity operations and an average rate
+// of one operation per fill interval. It is safe for concurrent use.
+type Bucket struct {
+	clock        clock.Clock
+	start        time.Time
+	fillInterval time.Duration
+	capacity     int64
+
+	// mu guards the fields below it.
+	mu sync.Mutex
+
+	// avail holds the number of tokens available as of latestTick.
+	// It is negative when tokens have been reserved by waiters.
+	avail int64
+
+	// latestTick holds the number of fill intervals between start
+	// and the last time avail was updated.
+	latestTick int64
+}
+
+// NewBucket returns a new bucket that holds at most capacity tokens
+// and gains a token every fillInterval. If clk is nil, clock.WallClock
+// is used.
+func NewBucket(clk clock.Clock, fillInterval time.Duration, capacity int64) *Bucket {
+	if fillInterval <= 0 {
+		panic("non-positive fill interval")
+	}
+	if capacity <= 0 {
+		panic("non-positive capacity")
+	}
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	return &Bucket{
+		clock:        clk,
+		start:        clk.Now(),
+		fillInterval: fillInterval,
+		capacity:     capacity,
+		avail:        capacity,
+	}
+}
+
+// NewBucketWithRate returns a new bucket that holds at most capacity
+// tokens and gains rate tokens per second.
+func NewBucketWithRate(clk clock.Clock, rate float64, capacity int64) *Bucket {
+	if rate <= 0 {
+		panic("non-positive rate")
+	}
+	return NewBucket(clk, time.Duration(float64(time.Second)/rate), capacity)
+}
+
+// Allow takes a token from the bucket if one is available, and reports
+// whether it did.
+func (b *Bucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN takes n tokens from the bucket if they are all available, and
+// reports whether it did.
+func (b *Bucket) AllowN(n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.adjust(b.clock.Now())
+	if b.avail < n {
+		return false
+	}
+	b.avail -= n
+	return true
+}
+
+// Available returns the number of tokens currently available. It is
+// negative when callers are waiting for tokens.
+func (b *Bucket) Available() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.adjust(b.clock.Now())
+	return b.avail
+}
+
+// Wait takes a token from the bucket, waiting until one is available
+// or the context is done.
+func (b *Bucket) Wait(ctx context.Context) error {
+	return b.WaitN(ctx, 1)
+}
+
+// WaitN takes n tokens from the bucket, waiting until they are
+// available or the context is done, in which case it returns the
+// context's error and no tokens are taken. Waiters are served in the
+// order they call WaitN. If the context's deadline is too soon for the
+// tokens to become available, WaitN returns an error immediately.
+func (b *Bucket) WaitN(ctx context.Context, n int64) error {
+	if n > b.capacity {
+		return errors.Errorf("cannot wait for %d tokens from bucket with capacity %d", n, b.capacity)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	now := b.clock.Now()
+	wait := b.take(now, n)
+	if wait == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(wait)) {
+		b.avail += n
+		b.mu.Unlock()
+		return errors.Errorf("rate limit wait of %v would exceed context deadline", wait)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.clock.After(wait):
+		return nil
+	case <-ctx.Done():
+		// Return the reserved tokens so that they're available
+		// to other callers.
+		b.mu.Lock()
+		b.adjust(b.clock.Now())
+		b.avail += n
+		if b.avail > b.capacity {
+			b.avail = b.capacity
+		}
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// take reserves n tokens and returns how long the caller must wait
+// before using them. Called with b.mu held.
+func (b *Bucket) take(now time.Time, n int64) time.Duration {
+	tick := b.adjust(now)
+	b.avail -= n
+	if b.avail >= 0 {
+		return 0
+	}
+	endTick := tick - b.avail
+	return b.start.Add(time.Duration(endTick) * b.fillInterval).Sub(now)
+}
+
+// adjust adds the tokens gained since the last adjustment and returns
+// the current tick. Called with b.mu held.
+func (b *Bucket) adjust(now time.Time) int64 {
+	tick := int64(now.Sub(b.start) / b.fillInterval)
+	if tick <= b.latestTick {
+		return b.latestTick
+	}
+	b.avail += tick - b.latestTick
+	if b.avail > b.capacity {
+		b.avail = b.capacity
+	}
+	b.latestTick = tick
+	return tick
+}
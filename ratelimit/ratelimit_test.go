@@ -0,0 +1,132 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package ratelimit_test
+
+import (
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/ratelimit"
+)
+
+type bucketSuite struct {
+	testing.IsolationSuite
+	clock *testclock.Clock
+}
+
+var _ = gc.Suite(&bucketSuite{})
+
+func (s *bucketSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.clock = testclock.NewClock(time.Now())
+}
+
+func (s *bucketSuite) TestAllowBurst(c *gc.C) {
+	b := ratelimit.NewBucket(s.clock, time.Second, 3)
+	for i := 0; i < 3; i++ {
+		c.Assert(b.Allow(), jc.IsTrue)
+	}
+	c.Assert(b.Allow(), jc.IsFalse)
+	c.Assert(b.Available(), gc.Equals, int64(0))
+}
+
+func (s *bucketSuite) TestAllowRefill(c *gc.C) {
+	b := ratelimit.NewBucket(s.clock, time.Second, 3)
+	c.Assert(b.AllowN(3), jc.IsTrue)
+	s.clock.Advance(1500 * time.Millisecond)
+	c.Assert(b.Available(), gc.Equals, int64(1))
+	c.Assert(b.AllowN(2), jc.IsFalse)
+	c.Assert(b.Allow(), jc.IsTrue)
+	c.Assert(b.Allow(), jc.IsFalse)
+
+	// The bucket never holds more than its capacity.
+	s.clock.Advance(time.Hour)
+	c.Assert(b.Available(), gc.Equals, int64(3))
+}
+
+func (s *bucketSuite) TestNewBucketWithRate(c *gc.C) {
+	b := ratelimit.NewBucketWithRate(s.clock, 4, 1)
+	c.Assert(b.Allow(), jc.IsTrue)
+	s.clock.Advance(200 * time.Millisecond)
+	c.Assert(b.Allow(), jc.IsFalse)
+	s.clock.Advance(50 * time.Millisecond)
+	c.Assert(b.Allow(), jc.IsTrue)
+}
+
+func (s *bucketSuite) TestWaitAvailable(c *gc.C) {
+	b := ratelimit.NewBucket(s.clock, time.Second, 1)
+	c.Assert(b.Wait(context.Background()), jc.ErrorIsNil)
+	c.Assert(b.Available(), gc.Equals, int64(0))
+}
+
+func (s *bucketSuite) TestWaitBlocks(c *gc.C) {
+	b := ratelimit.NewBucket(s.clock, time.Second, 1)
+	c.Assert(b.Allow(), jc.IsTrue)
+
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(context.Background())
+	}()
+	err := s.clock.WaitAdvance(999*time.Millisecond, testing.LongWait, 1)
+	c.Assert(err, jc.ErrorIsNil)
+	select {
+	case err := <-done:
+		c.Fatalf("wait finished early: %v", err)
+	case <-time.After(testing.ShortWait):
+	}
+	s.clock.Advance(time.Millisecond)
+	select {
+	case err := <-done:
+		c.Assert(err, jc.ErrorIsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for Wait")
+	}
+}
+
+func (s *bucketSuite) TestWaitCancelled(c *gc.C) {
+	b := ratelimit.NewBucket(s.clock, time.Second, 1)
+	c.Assert(b.Allow(), jc.IsTrue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(ctx)
+	}()
+	select {
+	case <-s.clock.Alarms():
+	case <-time.After(testing.LongWait):
+		c.Fatalf("Wait never started waiting")
+	}
+	c.Assert(b.Available(), gc.Equals, int64(-1))
+	cancel()
+	select {
+	case err := <-done:
+		c.Assert(err, gc.Equals, context.Canceled)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for Wait")
+	}
+	// The reserved token has been given back.
+	c.Assert(b.Available(), gc.Equals, int64(0))
+}
+
+func (s *bucketSuite) TestWaitDeadlineTooSoon(c *gc.C) {
+	b := ratelimit.NewBucket(s.clock, time.Minute, 1)
+	c.Assert(b.Allow(), jc.IsTrue)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := b.Wait(ctx)
+	c.Assert(err, gc.ErrorMatches, "rate limit wait of 1m0s would exceed context deadline")
+	c.Assert(b.Available(), gc.Equals, int64(0))
+}
+
+func (s *bucketSuite) TestWaitNTooMany(c *gc.C) {
+	b := ratelimit.NewBucket(s.clock, time.Second, 2)
+	err := b.WaitN(context.Background(), 3)
+	c.Assert(err, gc.ErrorMatches, "cannot wait for 3 tokens from bucket with capacity 2")
+}
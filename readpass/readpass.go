@@ -6,6 +6,7 @@ package readpass
 import (
 	"os"
 
+	"github.com/juju/utils"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -14,3 +15,15 @@ func ReadPassword() (string, error) {
 	pass, err := terminal.ReadPassword(int(fd))
 	return string(pass), err
 }
+
+// ReadSecret reads a password from the terminal without echoing it,
+// like ReadPassword, but returns it as a SecretBytes so that the
+// caller can zero it once it has been used.
+func ReadSecret() (*utils.SecretBytes, error) {
+	fd := os.Stdin.Fd()
+	pass, err := terminal.ReadPassword(int(fd))
+	if err != nil {
+		return nil, err
+	}
+	return utils.NewSecretBytes(pass), nil
+}
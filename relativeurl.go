@@ -14,8 +14,8 @@ import (
 // On success, the returned path will always be non-empty and relative
 // to basePath, even if basePath and targPath share no elements.
 //
-// It is assumed that both basePath and targPath are normalized
-// (have no . or .. elements).
+// The paths may contain . and .. elements, which are resolved as
+// ResolveReference does.
 //
 // An error is returned if basePath or targPath are not absolute paths.
 func RelativeURLPath(basePath, targPath string) (string, error) {
@@ -25,8 +25,11 @@ func RelativeURLPath(basePath, targPath string) (string, error) {
 	if !strings.HasPrefix(targPath, "/") {
 		return "", errors.New("non-absolute target URL")
 	}
-	baseParts := strings.Split(basePath, "/")
-	targParts := strings.Split(targPath, "/")
+	// Only the directory of the base path is used when resolving
+	// references against it.
+	baseDir := basePath[:strings.LastIndex(basePath, "/")+1]
+	baseParts := strings.Split(removeDotSegments(baseDir), "/")
+	targParts := strings.Split(removeDotSegments(targPath), "/")
 
 	// For the purposes of dotdot, the last element of
 	// the paths are irrelevant. We save the last part
@@ -57,6 +60,35 @@ func RelativeURLPath(basePath, targPath string) (string, error) {
 		// and there were no previous elements, so "."
 		// is appropriate.
 		final = "."
+	} else if result[0] == "" || strings.Contains(result[0], ":") {
+		// A path starting with a slash would be taken as absolute,
+		// and a first element like "a:b" as a URL scheme, so make
+		// them unambiguously relative.
+		final = "./" + final
 	}
 	return final, nil
 }
+
+// removeDotSegments resolves the . and .. elements in the absolute
+// path p, as described in RFC 3986 section 5.2.4. A final . or ..
+// element leaves a trailing slash, and empty elements are kept.
+func removeDotSegments(p string) string {
+	parts := strings.Split(p, "/")[1:]
+	result := make([]string, 0, len(parts))
+	for i, part := range parts {
+		switch part {
+		case ".":
+		case "..":
+			if len(result) > 0 {
+				result = result[:len(result)-1]
+			}
+		default:
+			result = append(result, part)
+			continue
+		}
+		if i == len(parts)-1 {
+			result = append(result, "")
+		}
+	}
+	return "/" + strings.Join(result, "/")
+}
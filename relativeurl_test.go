@@ -23,6 +23,10 @@ var relativeURLTests = []struct {
 	target      string
 	expect      string
 	expectError string
+
+	// resolved holds the path that expect resolves to, if it's
+	// not the same as target because target isn't clean.
+	resolved string
 }{{
 	expectError: "non-absolute base URL",
 }, {
@@ -127,6 +131,53 @@ var relativeURLTests = []struct {
 	base:   "/foo/bar/",
 	target: "/",
 	expect: "../../",
+}, {
+	base:     "/foo/./bar/../baz",
+	target:   "/foo/other",
+	expect:   "other",
+	resolved: "/foo/other",
+}, {
+	base:   "/foo/bar/..",
+	target: "/foo/other",
+	expect: "../other",
+}, {
+	base:   "/foo/bar/.",
+	target: "/foo/other",
+	expect: "../other",
+}, {
+	base:   "/foo/bar/",
+	target: "/foo/bar//baz",
+	expect: ".//baz",
+}, {
+	base:   "/foo//bar/",
+	target: "/foo/baz",
+	expect: "../../baz",
+}, {
+	base:   "/foo",
+	target: "//x/y",
+	expect: ".//x/y",
+}, {
+	base:     "/foo/bar",
+	target:   "/foo/baz/../qux/.",
+	expect:   "qux/",
+	resolved: "/foo/qux/",
+}, {
+	base:     "/foo/bar",
+	target:   "/../../foo/x",
+	expect:   "x",
+	resolved: "/foo/x",
+}, {
+	base:   "/foo/bar",
+	target: "/foo/a:b",
+	expect: "./a:b",
+}, {
+	base:   "/foo/bar/",
+	target: "/foo/a:b/c",
+	expect: "../a:b/c",
+}, {
+	base:   "/foo/",
+	target: "/foo/a:b/c",
+	expect: "./a:b/c",
 }}
 
 func (*relativeURLSuite) TestRelativeURL(c *gc.C) {
@@ -135,9 +186,14 @@ func (*relativeURLSuite) TestRelativeURL(c *gc.C) {
 		// Sanity check the test itself.
 		if test.expectError == "" {
 			baseURL := &url.URL{Path: test.base}
-			expectURL := &url.URL{Path: test.expect}
+			expectURL, err := url.Parse(test.expect)
+			c.Assert(err, gc.IsNil)
 			targetURL := baseURL.ResolveReference(expectURL)
-			c.Check(targetURL.Path, gc.Equals, test.target, gc.Commentf("resolve reference failure (%q + %q != %q)", test.base, test.expect, test.target))
+			resolved := test.target
+			if test.resolved != "" {
+				resolved = test.resolved
+			}
+			c.Check(targetURL.Path, gc.Equals, resolved, gc.Commentf("resolve reference failure (%q + %q != %q)", test.base, test.expect, resolved))
 		}
 
 		result, err := utils.RelativeURLPath(test.base, test.target)
@@ -0,0 +1,188 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// RingBuffer is an io.Writer that retains only the last bytes written
+// to it, for capturing the tail of command output or logs to include in
+// error reports. It is safe for concurrent use.
+type RingBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	start     int
+	full      bool
+	truncated bool
+}
+
+// NewRingBuffer returns a RingBuffer that retains the last size bytes
+// written to it.
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		panic("non-positive ring buffer size")
+	}
+	return &RingBuffer{buf: make([]byte, 0, size)}
+}
+
+// Write implements io.Writer. It never returns an error.
+func (r *RingBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(p)
+	size := cap(r.buf)
+	if len(p) > size {
+		r.truncated = true
+		p = p[len(p)-size:]
+	}
+	if !r.full {
+		room := size - len(r.buf)
+		if len(p) <= room {
+			r.buf = append(r.buf, p...)
+			return n, nil
+		}
+		r.buf = append(r.buf, p[:room]...)
+		p = p[room:]
+		r.full = true
+	}
+	r.truncated = true
+	for len(p) > 0 {
+		copied := copy(r.buf[r.start:], p)
+		p = p[copied:]
+		r.start = (r.start + copied) % size
+	}
+	return n, nil
+}
+
+// Bytes returns a copy of the retained bytes.
+func (r *RingBuffer) Bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]byte, 0, len(r.buf))
+	result = append(result, r.buf[r.start:]...)
+	return append(result, r.buf[:r.start]...)
+}
+
+// String returns the retained bytes as a string.
+func (r *RingBuffer) String() string {
+	return string(r.Bytes())
+}
+
+// Len returns the number of bytes retained.
+func (r *RingBuffer) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.buf)
+}
+
+// Truncated reports whether any bytes written have been discarded.
+func (r *RingBuffer) Truncated() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.truncated
+}
+
+// Reset discards all the bytes written.
+func (r *RingBuffer) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf = r.buf[:0]
+	r.start = 0
+	r.full = false
+	r.truncated = false
+}
+
+// maxRingLineLen holds the maximum length of a line retained by a
+// LineRingBuffer. Longer lines are cut short, so that output without
+// newlines can't use unbounded memory.
+const maxRingLineLen = 64 * 1024
+
+// LineRingBuffer is an io.Writer that retains only the last lines
+// written to it. It is safe for concurrent use.
+type LineRingBuffer struct {
+	mu        sync.Mutex
+	lines     []string
+	start     int
+	partial   bytes.Buffer
+	truncated bool
+}
+
+// NewLineRingBuffer returns a LineRingBuffer that retains the last n
+// lines written to it. Lines longer than 64KiB are cut short.
+func NewLineRingBuffer(n int) *LineRingBuffer {
+	if n <= 0 {
+		panic("non-positive line ring buffer size")
+	}
+	return &LineRingBuffer{lines: make([]string, 0, n)}
+}
+
+// Write implements io.Writer. It never returns an error.
+func (r *LineRingBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			r.appendPartial(p)
+			break
+		}
+		r.appendPartial(p[:i])
+		r.addLine(r.partial.String())
+		r.partial.Reset()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+func (r *LineRingBuffer) appendPartial(p []byte) {
+	if room := maxRingLineLen - r.partial.Len(); len(p) > room {
+		p = p[:room]
+	}
+	r.partial.Write(p)
+}
+
+func (r *LineRingBuffer) addLine(line string) {
+	if len(r.lines) < cap(r.lines) {
+		r.lines = append(r.lines, line)
+		return
+	}
+	r.truncated = true
+	r.lines[r.start] = line
+	r.start = (r.start + 1) % len(r.lines)
+}
+
+// Lines returns the retained lines, without their newlines, oldest
+// first. A final line that has not yet been terminated by a newline is
+// included, and counts towards the number of lines retained.
+func (r *LineRingBuffer) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]string, 0, len(r.lines)+1)
+	result = append(result, r.lines[r.start:]...)
+	result = append(result, r.lines[:r.start]...)
+	if r.partial.Len() > 0 {
+		result = append(result, r.partial.String())
+		if len(result) > cap(r.lines) {
+			result = result[1:]
+		}
+	}
+	return result
+}
+
+// String returns the retained lines joined by newlines.
+func (r *LineRingBuffer) String() string {
+	return strings.Join(r.Lines(), "\n")
+}
+
+// Truncated reports whether any lines written have been discarded.
+// Lines that were cut short don't count.
+func (r *LineRingBuffer) Truncated() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.truncated || len(r.lines) == cap(r.lines) && r.partial.Len() > 0
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type ringBufferSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&ringBufferSuite{})
+
+func (*ringBufferSuite) TestRingBuffer(c *gc.C) {
+	for i, test := range []struct {
+		writes    []string
+		expect    string
+		truncated bool
+	}{{
+		expect: "",
+	}, {
+		writes: []string{"abc", "de"},
+		expect: "abcde",
+	}, {
+		writes:    []string{"abcde", "f"},
+		expect:    "bcdef",
+		truncated: true,
+	}, {
+		writes:    []string{"abc", "defg"},
+		expect:    "cdefg",
+		truncated: true,
+	}, {
+		writes:    []string{"abcdefghij"},
+		expect:    "fghij",
+		truncated: true,
+	}, {
+		writes:    []string{"ab", "cd", "ef", "gh", "ij", "k"},
+		expect:    "ghijk",
+		truncated: true,
+	}} {
+		c.Logf("test %d: %q", i, test.writes)
+		r := utils.NewRingBuffer(5)
+		for _, w := range test.writes {
+			n, err := io.WriteString(r, w)
+			c.Assert(err, jc.ErrorIsNil)
+			c.Assert(n, gc.Equals, len(w))
+		}
+		c.Check(r.String(), gc.Equals, test.expect)
+		c.Check(r.Len(), gc.Equals, len(test.expect))
+		c.Check(r.Truncated(), gc.Equals, test.truncated)
+	}
+}
+
+func (*ringBufferSuite) TestRingBufferReset(c *gc.C) {
+	r := utils.NewRingBuffer(3)
+	io.WriteString(r, "abcdef")
+	r.Reset()
+	c.Assert(r.Len(), gc.Equals, 0)
+	c.Assert(r.Truncated(), jc.IsFalse)
+	io.WriteString(r, "xy")
+	c.Assert(r.String(), gc.Equals, "xy")
+}
+
+func (*ringBufferSuite) TestLineRingBuffer(c *gc.C) {
+	for i, test := range []struct {
+		writes    []string
+		expect    []string
+		truncated bool
+	}{{
+		expect: []string{},
+	}, {
+		writes: []string{"one\ntwo\n"},
+		expect: []string{"one", "two"},
+	}, {
+		writes: []string{"on", "e\nt", "wo"},
+		expect: []string{"one", "two"},
+	}, {
+		writes: []string{"\n\n"},
+		expect: []string{"", ""},
+	}, {
+		writes:    []string{"one\ntwo\nthree\nfour\n"},
+		expect:    []string{"two", "three", "four"},
+		truncated: true,
+	}, {
+		writes:    []string{"one\ntwo\nthree\nfo", "ur"},
+		expect:    []string{"two", "three", "four"},
+		truncated: true,
+	}} {
+		c.Logf("test %d: %q", i, test.writes)
+		r := utils.NewLineRingBuffer(3)
+		for _, w := range test.writes {
+			n, err := io.WriteString(r, w)
+			c.Assert(err, jc.ErrorIsNil)
+			c.Assert(n, gc.Equals, len(w))
+		}
+		c.Check(r.Lines(), jc.DeepEquals, test.expect)
+		c.Check(r.String(), gc.Equals, strings.Join(test.expect, "\n"))
+		c.Check(r.Truncated(), gc.Equals, test.truncated)
+	}
+}
+
+func (*ringBufferSuite) TestLineRingBufferManyLines(c *gc.C) {
+	r := utils.NewLineRingBuffer(2)
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(r, "line %d\n", i)
+	}
+	c.Assert(r.Lines(), jc.DeepEquals, []string{"line 998", "line 999"})
+}
+
+func (*ringBufferSuite) TestLineRingBufferLongLine(c *gc.C) {
+	r := utils.NewLineRingBuffer(2)
+	long := strings.Repeat("x", 100*1024)
+	io.WriteString(r, long)
+	io.WriteString(r, long+"\nshort\n")
+	lines := r.Lines()
+	c.Assert(lines, gc.HasLen, 2)
+	c.Assert(lines[0], gc.HasLen, 64*1024)
+	c.Assert(lines[1], gc.Equals, "short")
+}
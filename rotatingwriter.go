@@ -0,0 +1,225 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// RotatingWriterParams holds the parameters for NewRotatingWriter.
+type RotatingWriterParams struct {
+	// Path holds the path of the file to write to.
+	Path string
+
+	// MaxSize holds the size in bytes after which the file is
+	// rotated.
+	MaxSize int64
+
+	// MaxBackups holds the number of rotated files to keep. They are
+	// named Path.1, Path.2 and so on, with Path.1 the most recent.
+	// If it is zero, the file is simply removed when it is rotated.
+	MaxBackups int
+
+	// Compress specifies that rotated files are compressed with
+	// gzip, in which case they are named Path.1.gz and so on.
+	Compress bool
+
+	// Perm holds the permissions used when creating the file. If it
+	// is zero, 0644 is used.
+	Perm os.FileMode
+}
+
+// RotatingWriter is an io.WriteCloser that writes to a file, rotating
+// it when it grows past a maximum size, so it can be used as the
+// destination for a logger without the log growing without bound. It
+// is safe for concurrent use.
+type RotatingWriter struct {
+	params RotatingWriterParams
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+}
+
+// NewRotatingWriter returns a RotatingWriter that appends to the file
+// described by params, creating it if necessary.
+func NewRotatingWriter(params RotatingWriterParams) (*RotatingWriter, error) {
+	if params.Path == "" {
+		return nil, errors.NotValidf("empty Path")
+	}
+	if params.MaxSize <= 0 {
+		return nil, errors.NotValidf("non-positive MaxSize")
+	}
+	if params.MaxBackups < 0 {
+		return nil, errors.NotValidf("negative MaxBackups")
+	}
+	if params.Perm == 0 {
+		params.Perm = 0644
+	}
+	w := &RotatingWriter{params: params}
+	if err := w.open(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return w, nil
+}
+
+// Write implements io.Writer. The file is rotated before the write if
+// the data would take it past the maximum size; data is never split
+// between files, so a single write larger than the maximum size is
+// written whole to a new file.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, errors.New("write to closed RotatingWriter")
+	}
+	if w.file == nil {
+		// An earlier rotation failed to reopen the file.
+		if err := w.open(); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.params.MaxSize {
+		if err := w.rotate(); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately, regardless of its size.
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New("rotate of closed RotatingWriter")
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(w.rotate())
+}
+
+// Close implements io.Closer.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open opens the file for appending, recording its current size.
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.params.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, w.params.Perm)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Trace(err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate closes the file, moves it to the first backup, shifting the
+// existing backups along and removing the oldest, and opens a new
+// file. The file is closed before it is moved because open files
+// cannot be renamed on Windows. If the backups cannot be shifted, the
+// file is reopened for appending so that only the current write
+// fails.
+func (w *RotatingWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		err = errors.Annotate(err, "cannot close file for rotation")
+	} else {
+		err = errors.Trace(w.shiftBackups())
+	}
+	if openErr := w.open(); err == nil {
+		err = errors.Trace(openErr)
+	}
+	return err
+}
+
+func (w *RotatingWriter) shiftBackups() error {
+	path := w.params.Path
+	if w.params.MaxBackups == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+		return nil
+	}
+	oldest := w.backupName(w.params.MaxBackups)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+	for i := w.params.MaxBackups - 1; i > 0; i-- {
+		err := ReplaceFile(w.backupName(i), w.backupName(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Annotate(err, "cannot rename backup")
+		}
+	}
+	if !w.params.Compress {
+		return errors.Annotate(ReplaceFile(path, w.backupName(1)), "cannot rename file")
+	}
+	if err := compressFile(path, w.backupName(1), w.params.Perm); err != nil {
+		return errors.Annotate(err, "cannot compress file")
+	}
+	return errors.Trace(os.Remove(path))
+}
+
+// backupName returns the name of the ith most recent backup.
+func (w *RotatingWriter) backupName(i int) string {
+	name := fmt.Sprintf("%s.%d", w.params.Path, i)
+	if w.params.Compress {
+		name += ".gz"
+	}
+	return name
+}
+
+// compressFile writes the contents of the file at source, compressed
+// with gzip, to a new file at dest.
+func compressFile(source, dest string, perm os.FileMode) (err error) {
+	in, err := os.Open(source)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = errors.Trace(closeErr)
+		}
+		if err != nil {
+			os.Remove(dest)
+		}
+	}()
+	gzw := gzip.NewWriter(out)
+	if _, err := io.Copy(gzw, in); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(gzw.Close())
+}
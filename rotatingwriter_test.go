@@ -0,0 +1,169 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type rotatingWriterSuite struct {
+	testing.IsolationSuite
+	path string
+}
+
+var _ = gc.Suite(&rotatingWriterSuite{})
+
+func (s *rotatingWriterSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.path = filepath.Join(c.MkDir(), "log")
+}
+
+func (s *rotatingWriterSuite) newWriter(c *gc.C, maxBackups int, compress bool) *utils.RotatingWriter {
+	w, err := utils.NewRotatingWriter(utils.RotatingWriterParams{
+		Path:       s.path,
+		MaxSize:    10,
+		MaxBackups: maxBackups,
+		Compress:   compress,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(*gc.C) { w.Close() })
+	return w
+}
+
+func writeChunks(c *gc.C, w *utils.RotatingWriter, data ...string) {
+	for _, d := range data {
+		n, err := w.Write([]byte(d))
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(n, gc.Equals, len(d))
+	}
+}
+
+func assertFileContents(c *gc.C, path, expected string) {
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, expected)
+}
+
+func (s *rotatingWriterSuite) TestRotation(c *gc.C) {
+	w := s.newWriter(c, 2, false)
+	writeChunks(c, w, "aaaaa", "bbbbb", "ccccc", "ddddd", "eeeee", "fffff", "gg")
+	assertFileContents(c, s.path, "gg")
+	assertFileContents(c, s.path+".1", "eeeeefffff")
+	assertFileContents(c, s.path+".2", "cccccddddd")
+	_, err := os.Stat(s.path + ".3")
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *rotatingWriterSuite) TestLargeWrite(c *gc.C) {
+	w := s.newWriter(c, 1, false)
+	writeChunks(c, w, "abc", "a write larger than the limit", "def")
+	assertFileContents(c, s.path, "def")
+	assertFileContents(c, s.path+".1", "a write larger than the limit")
+}
+
+func (s *rotatingWriterSuite) TestNoBackups(c *gc.C) {
+	w := s.newWriter(c, 0, false)
+	writeChunks(c, w, "aaaaaaaa", "bbbbbbbb")
+	assertFileContents(c, s.path, "bbbbbbbb")
+	matches, err := filepath.Glob(s.path + ".*")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(matches, gc.HasLen, 0)
+}
+
+func (s *rotatingWriterSuite) TestAppendsToExistingFile(c *gc.C) {
+	err := ioutil.WriteFile(s.path, []byte("12345678"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	w := s.newWriter(c, 1, false)
+	writeChunks(c, w, "ab", "cd")
+	assertFileContents(c, s.path, "cd")
+	assertFileContents(c, s.path+".1", "12345678ab")
+}
+
+func (s *rotatingWriterSuite) TestCompress(c *gc.C) {
+	w := s.newWriter(c, 2, true)
+	writeChunks(c, w, "aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "d")
+	assertFileContents(c, s.path, "d")
+	for i, expected := range []string{"cccccccccc", "bbbbbbbbbb"} {
+		data, err := ioutil.ReadFile(fmt.Sprintf("%s.%d.gz", s.path, i+1))
+		c.Assert(err, jc.ErrorIsNil)
+		data, err = utils.DecompressBytes(data, 0)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(string(data), gc.Equals, expected)
+	}
+	_, err := os.Stat(s.path + ".1")
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *rotatingWriterSuite) TestRotate(c *gc.C) {
+	w := s.newWriter(c, 1, false)
+	writeChunks(c, w, "abc")
+	err := w.Rotate()
+	c.Assert(err, jc.ErrorIsNil)
+	writeChunks(c, w, "def")
+	assertFileContents(c, s.path, "def")
+	assertFileContents(c, s.path+".1", "abc")
+}
+
+func (s *rotatingWriterSuite) TestRotationFailure(c *gc.C) {
+	w := s.newWriter(c, 1, false)
+	// A non-empty directory in place of the oldest backup cannot be
+	// removed, so the rotation fails.
+	err := os.Mkdir(s.path+".1", 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ioutil.WriteFile(filepath.Join(s.path+".1", "x"), nil, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	writeChunks(c, w, "aaaaaaaa")
+	_, err = w.Write([]byte("bbbbbbbb"))
+	c.Assert(err, gc.NotNil)
+
+	// Later writes carry on appending to the file.
+	writeChunks(c, w, "cc")
+	assertFileContents(c, s.path, "aaaaaaaacc")
+
+	err = os.RemoveAll(s.path + ".1")
+	c.Assert(err, jc.ErrorIsNil)
+	writeChunks(c, w, "dd")
+	assertFileContents(c, s.path, "dd")
+	assertFileContents(c, s.path+".1", "aaaaaaaacc")
+}
+
+func (s *rotatingWriterSuite) TestWriteAfterClose(c *gc.C) {
+	w := s.newWriter(c, 1, false)
+	c.Assert(w.Close(), jc.ErrorIsNil)
+	_, err := w.Write([]byte("x"))
+	c.Assert(err, gc.ErrorMatches, "write to closed RotatingWriter")
+	c.Assert(w.Close(), jc.ErrorIsNil)
+}
+
+func (s *rotatingWriterSuite) TestInvalidParams(c *gc.C) {
+	for i, test := range []struct {
+		params utils.RotatingWriterParams
+		err    string
+	}{{
+		params: utils.RotatingWriterParams{MaxSize: 1},
+		err:    "empty Path not valid",
+	}, {
+		params: utils.RotatingWriterParams{Path: s.path},
+		err:    "non-positive MaxSize not valid",
+	}, {
+		params: utils.RotatingWriterParams{Path: s.path, MaxSize: 1, MaxBackups: -1},
+		err:    "negative MaxBackups not valid",
+	}} {
+		c.Logf("test %d", i)
+		_, err := utils.NewRotatingWriter(test.params)
+		c.Check(err, gc.ErrorMatches, test.err)
+		c.Check(errors.IsNotValid(err), jc.IsTrue)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// redacted is printed in place of the contents of a SecretBytes.
+const redacted = "[REDACTED]"
+
+// ZeroBytes overwrites b with zeros, so that a secret such as a
+// password or key doesn't linger in memory after it has been used.
+// Note that this can't help with strings, which are immutable, or with
+// any copies of b.
+func ZeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// SecretBytes holds a secret, such as a password or private key, that
+// is zeroed when it is closed. Its contents are never printed by the
+// fmt package, whatever the verb, so it can't be leaked into logs by
+// accident, even when it is held by value in a struct that is printed.
+// Copies of a SecretBytes share the same secret. It is safe for
+// concurrent use. The zero value holds no secret.
+type SecretBytes struct {
+	// state is kept behind a pointer so that fmt, which prints the
+	// unexported fields of structs, never sees the secret.
+	state *secretState
+}
+
+type secretState struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+// NewSecretBytes returns a SecretBytes holding data. The SecretBytes
+// takes ownership of data, which will be zeroed when it is closed.
+func NewSecretBytes(data []byte) *SecretBytes {
+	return &SecretBytes{state: &secretState{data: data}}
+}
+
+// Bytes returns the secret, or nil if the SecretBytes has been closed.
+// The returned slice shares memory with the SecretBytes, so it is
+// zeroed when the SecretBytes is closed.
+func (s SecretBytes) Bytes() []byte {
+	if s.state == nil {
+		return nil
+	}
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	return s.state.data
+}
+
+// Len returns the length of the secret.
+func (s SecretBytes) Len() int {
+	return len(s.Bytes())
+}
+
+// Close implements io.Closer by zeroing the secret. It always returns
+// nil.
+func (s SecretBytes) Close() error {
+	if s.state == nil {
+		return nil
+	}
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	ZeroBytes(s.state.data)
+	s.state.data = nil
+	return nil
+}
+
+// String implements fmt.Stringer without revealing the secret.
+func (s SecretBytes) String() string {
+	return redacted
+}
+
+// GoString implements fmt.GoStringer without revealing the secret.
+func (s SecretBytes) GoString() string {
+	return redacted
+}
+
+// Format implements fmt.Formatter so that the secret isn't revealed by
+// verbs, such as %x and %q, that would otherwise bypass String.
+func (s SecretBytes) Format(f fmt.State, verb rune) {
+	io.WriteString(f, redacted)
+}
+
+// MarshalText implements encoding.TextMarshaler so that the secret
+// isn't revealed when encoded as JSON or YAML.
+func (s SecretBytes) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type secretSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&secretSuite{})
+
+func (*secretSuite) TestZeroBytes(c *gc.C) {
+	b := []byte("hunter2")
+	utils.ZeroBytes(b)
+	c.Assert(b, jc.DeepEquals, make([]byte, 7))
+	utils.ZeroBytes(nil)
+}
+
+func (*secretSuite) TestSecretBytesClose(c *gc.C) {
+	data := []byte("hunter2")
+	s := utils.NewSecretBytes(data)
+	c.Assert(string(s.Bytes()), gc.Equals, "hunter2")
+	c.Assert(s.Len(), gc.Equals, 7)
+
+	c.Assert(s.Close(), jc.ErrorIsNil)
+	c.Assert(data, jc.DeepEquals, make([]byte, 7))
+	c.Assert(s.Bytes(), gc.IsNil)
+	c.Assert(s.Len(), gc.Equals, 0)
+	c.Assert(s.Close(), jc.ErrorIsNil)
+}
+
+func (*secretSuite) TestSecretBytesNotPrinted(c *gc.C) {
+	s := utils.NewSecretBytes([]byte("hunter2"))
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%d"} {
+		c.Check(fmt.Sprintf(format, s), gc.Equals, "[REDACTED]", gc.Commentf("format %s", format))
+	}
+	c.Check(fmt.Sprint(s), gc.Equals, "[REDACTED]")
+	c.Check(fmt.Sprintf("%v", struct{ S *utils.SecretBytes }{s}), gc.Equals, "{[REDACTED]}")
+
+	data, err := json.Marshal(struct{ S *utils.SecretBytes }{s})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(string(data), gc.Equals, `{"S":"[REDACTED]"}`)
+}
+
+func (*secretSuite) TestSecretBytesValueNotPrinted(c *gc.C) {
+	type config struct {
+		Name   string
+		Secret utils.SecretBytes
+	}
+	conf := config{Name: "db", Secret: *utils.NewSecretBytes([]byte("hunter2"))}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%x"} {
+		out := fmt.Sprintf(format, conf)
+		c.Check(out, gc.Not(jc.Contains), "hunter2", gc.Commentf("format %s", format))
+		c.Check(out, gc.Not(jc.Contains), fmt.Sprintf("%x", "hunter2"), gc.Commentf("format %s", format))
+		c.Check(out, jc.Contains, "[REDACTED]", gc.Commentf("format %s", format))
+	}
+	c.Check(string(conf.Secret.Bytes()), gc.Equals, "hunter2")
+
+	data, err := json.Marshal(conf)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(string(data), gc.Equals, `{"Name":"db","Secret":"[REDACTED]"}`)
+
+	var zero utils.SecretBytes
+	c.Check(zero.Bytes(), gc.IsNil)
+	c.Check(zero.Close(), jc.ErrorIsNil)
+}
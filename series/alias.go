@@ -0,0 +1,70 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// aliasSeries maps alternative names seen in the wild to the series
+// they refer to. It is extended by RegisterSeriesAlias.
+var aliasSeries = map[string]string{
+	"opensuse-leap": "opensuseleap",
+	"win2k8r2":      "win2008r2",
+	"win2k12":       "win2012",
+	"win2k12r2":     "win2012r2",
+	"win2k16":       "win2016",
+}
+
+// centosPointRelease matches CentOS point releases such as "centos7.9",
+// capturing the major version.
+var centosPointRelease = regexp.MustCompile(`^centos(\d+)\.\d+(\.\d+)?$`)
+
+// RegisterSeriesAlias records alias as another name for series, so that
+// lookups such as GetOSFromSeries accept it.
+func RegisterSeriesAlias(alias, series string) error {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	if alias == "" {
+		return errors.NotValidf("empty series alias")
+	}
+	if series == "" {
+		return errors.NotValidf("empty series for alias %q", alias)
+	}
+	seriesVersionsMutex.Lock()
+	defer seriesVersionsMutex.Unlock()
+	aliasSeries[alias] = series
+	return nil
+}
+
+// ResolveSeriesAlias returns the series that name refers to. As well as
+// registered aliases, it understands version numbers (such as "18.04")
+// and CentOS point releases (such as "centos7.9"). If name is not
+// recognised as an alias it is returned unchanged.
+func ResolveSeriesAlias(name string) string {
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
+	return resolveSeriesAlias(name)
+}
+
+// resolveSeriesAlias implements ResolveSeriesAlias. It must be called
+// with seriesVersionsMutex held.
+func resolveSeriesAlias(name string) string {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if series, ok := aliasSeries[lower]; ok {
+		return series
+	}
+	if series, ok := versionSeries[strings.TrimSuffix(lower, " lts")]; ok {
+		return series
+	}
+	if m := centosPointRelease.FindStringSubmatch(lower); m != nil {
+		return "centos" + m[1]
+	}
+	if lower != name {
+		return lower
+	}
+	return name
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/os"
+	"github.com/juju/utils/series"
+)
+
+type aliasSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&aliasSuite{})
+
+func (s *aliasSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	cleanup := series.SetSeriesVersions(map[string]string{
+		"xenial":    "16.04",
+		"bionic":    "18.04",
+		"centos7":   "centos7",
+		"win2012r2": "win2012r2",
+	})
+	s.AddCleanup(func(*gc.C) { cleanup() })
+	aliases := make(map[string]string)
+	for k, v := range *series.AliasSeries {
+		aliases[k] = v
+	}
+	s.PatchValue(series.AliasSeries, aliases)
+}
+
+func (s *aliasSuite) TestResolveSeriesAlias(c *gc.C) {
+	for i, test := range []struct {
+		name     string
+		expected string
+	}{
+		{"bionic", "bionic"},
+		{"Bionic", "bionic"},
+		{"18.04", "bionic"},
+		{"16.04 LTS", "xenial"},
+		{"centos7.9", "centos7"},
+		{"centos7.5.1804", "centos7"},
+		{"win2k12r2", "win2012r2"},
+		{"plan9", "plan9"},
+	} {
+		c.Logf("test %d: %q", i, test.name)
+		c.Check(series.ResolveSeriesAlias(test.name), gc.Equals, test.expected)
+	}
+}
+
+func (s *aliasSuite) TestGetOSFromSeriesAlias(c *gc.C) {
+	for name, expected := range map[string]os.OSType{
+		"centos7.9": os.CentOS,
+		"18.04":     os.Ubuntu,
+		"XENIAL":    os.Ubuntu,
+		"win2k12r2": os.Windows,
+	} {
+		osType, err := series.GetOSFromSeries(name)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(osType, gc.Equals, expected)
+	}
+}
+
+func (s *aliasSuite) TestRegisterSeriesAlias(c *gc.C) {
+	_, err := series.GetOSFromSeries("beaver")
+	c.Assert(series.IsUnknownOSForSeriesError(err), jc.IsTrue)
+
+	err = series.RegisterSeriesAlias("Beaver", "bionic")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(series.ResolveSeriesAlias("beaver"), gc.Equals, "bionic")
+	osType, err := series.GetOSFromSeries("beaver")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(osType, gc.Equals, os.Ubuntu)
+
+	info, err := series.GetSeriesInfo("bionic")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.Aliases, jc.DeepEquals, []string{"beaver"})
+}
+
+func (s *aliasSuite) TestRegisterSeriesAliasInvalid(c *gc.C) {
+	err := series.RegisterSeriesAlias("", "bionic")
+	c.Assert(err, gc.ErrorMatches, "empty series alias not valid")
+	err = series.RegisterSeriesAlias("beaver", "")
+	c.Assert(err, gc.ErrorMatches, `empty series for alias "beaver" not valid`)
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// Base identifies an operating system and version in the "os@version"
+// form (for example "ubuntu@18.04") that is gradually replacing series
+// names.
+type Base struct {
+	// OS is the lower case name of the operating system, as returned
+	// by strings.ToLower(os.OSType.String()).
+	OS string
+
+	// Version is the version of the operating system.
+	Version string
+}
+
+// String returns the base in "os@version" form.
+func (b Base) String() string {
+	return b.OS + "@" + b.Version
+}
+
+// ParseBase parses a base in "os@version" form.
+func ParseBase(s string) (Base, error) {
+	parts := strings.Split(s, "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Base{}, errors.NotValidf("base %q", s)
+	}
+	return Base{
+		OS:      strings.ToLower(parts[0]),
+		Version: parts[1],
+	}, nil
+}
+
+// MarshalJSON implements json.Marshaler. A base is encoded as a string
+// in "os@version" form.
+func (b Base) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Base) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Trace(err)
+	}
+	return b.set(s)
+}
+
+// MarshalYAML implements yaml.Marshaler. A base is encoded as a string
+// in "os@version" form.
+func (b Base) MarshalYAML() (interface{}, error) {
+	return b.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (b *Base) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return errors.Trace(err)
+	}
+	return b.set(s)
+}
+
+func (b *Base) set(s string) error {
+	parsed, err := ParseBase(s)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*b = parsed
+	return nil
+}
+
+// BaseFromSeries returns the base corresponding to the given series.
+func BaseFromSeries(series string) (Base, error) {
+	osType, err := GetOSFromSeries(series)
+	if err != nil {
+		return Base{}, errors.Trace(err)
+	}
+	version, err := SeriesVersion(series)
+	if err != nil {
+		return Base{}, errors.Trace(err)
+	}
+	osName := strings.ToLower(osType.String())
+	// Versions of non-Ubuntu series are prefixed by the OS name
+	// (for example "centos7"), which is redundant in a base.
+	version = strings.TrimPrefix(version, osName)
+	if version == "" {
+		return Base{}, errors.NotSupportedf("base for series %q", series)
+	}
+	return Base{OS: osName, Version: version}, nil
+}
+
+// SeriesFromBase returns the series corresponding to the given base.
+func SeriesFromBase(b Base) (string, error) {
+	for _, version := range []string{b.Version, b.OS + b.Version} {
+		series, err := VersionSeries(version)
+		if err != nil {
+			continue
+		}
+		osType, err := GetOSFromSeries(series)
+		if err == nil && strings.ToLower(osType.String()) == b.OS {
+			return series, nil
+		}
+	}
+	return "", errors.NotFoundf("series for base %q", b)
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"encoding/json"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/series"
+)
+
+type baseSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&baseSuite{})
+
+func (s *baseSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	cleanup := series.SetSeriesVersions(map[string]string{
+		"xenial":       "16.04",
+		"bionic":       "18.04",
+		"centos7":      "centos7",
+		"opensuseleap": "opensuse42",
+		"win2012r2":    "win2012r2",
+		"genericlinux": "genericlinux",
+	})
+	s.AddCleanup(func(*gc.C) { cleanup() })
+}
+
+var baseSeriesTests = []struct {
+	series string
+	base   series.Base
+}{
+	{"bionic", series.Base{OS: "ubuntu", Version: "18.04"}},
+	{"centos7", series.Base{OS: "centos", Version: "7"}},
+	{"opensuseleap", series.Base{OS: "opensuse", Version: "42"}},
+	{"win2012r2", series.Base{OS: "windows", Version: "win2012r2"}},
+}
+
+func (s *baseSuite) TestBaseFromSeries(c *gc.C) {
+	for i, test := range baseSeriesTests {
+		c.Logf("test %d: %s", i, test.series)
+		base, err := series.BaseFromSeries(test.series)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(base, gc.Equals, test.base)
+	}
+}
+
+func (s *baseSuite) TestBaseFromSeriesNotSupported(c *gc.C) {
+	_, err := series.BaseFromSeries("genericlinux")
+	c.Assert(err, gc.ErrorMatches, `base for series "genericlinux" not supported`)
+	_, err = series.BaseFromSeries("plan9")
+	c.Assert(series.IsUnknownOSForSeriesError(err), jc.IsTrue)
+}
+
+func (s *baseSuite) TestSeriesFromBase(c *gc.C) {
+	for i, test := range baseSeriesTests {
+		c.Logf("test %d: %s", i, test.base)
+		result, err := series.SeriesFromBase(test.base)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(result, gc.Equals, test.series)
+	}
+}
+
+func (s *baseSuite) TestSeriesFromBaseNotFound(c *gc.C) {
+	_, err := series.SeriesFromBase(series.Base{OS: "centos", Version: "18.04"})
+	c.Assert(err, gc.ErrorMatches, `series for base "centos@18.04" not found`)
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+}
+
+func (s *baseSuite) TestParseBase(c *gc.C) {
+	base, err := series.ParseBase("Ubuntu@22.04")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(base, gc.Equals, series.Base{OS: "ubuntu", Version: "22.04"})
+	c.Assert(base.String(), gc.Equals, "ubuntu@22.04")
+
+	for _, bad := range []string{"", "ubuntu", "ubuntu@", "@22.04", "ubuntu@22.04@x"} {
+		_, err := series.ParseBase(bad)
+		c.Check(err, gc.ErrorMatches, `base ".*" not valid`)
+		c.Check(errors.IsNotValid(err), jc.IsTrue)
+	}
+}
+
+func (s *baseSuite) TestJSONRoundTrip(c *gc.C) {
+	type config struct {
+		Base series.Base `json:"base"`
+	}
+	data, err := json.Marshal(config{series.Base{OS: "ubuntu", Version: "18.04"}})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{"base":"ubuntu@18.04"}`)
+	var result config
+	err = json.Unmarshal(data, &result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result.Base, gc.Equals, series.Base{OS: "ubuntu", Version: "18.04"})
+}
+
+func (s *baseSuite) TestUnmarshalJSONInvalid(c *gc.C) {
+	var result series.Base
+	err := json.Unmarshal([]byte(`"ubuntu"`), &result)
+	c.Assert(err, gc.ErrorMatches, `base "ubuntu" not valid`)
+	err = json.Unmarshal([]byte(`18.04`), &result)
+	c.Assert(err, gc.NotNil)
+}
+
+func (s *baseSuite) TestYAMLRoundTrip(c *gc.C) {
+	base := series.Base{OS: "centos", Version: "7"}
+	value, err := base.MarshalYAML()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(value, gc.Equals, "centos@7")
+	var result series.Base
+	err = result.UnmarshalYAML(func(v interface{}) error {
+		*(v.(*string)) = value.(string)
+		return nil
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.Equals, base)
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var (
+	// These are variables so they can be overridden for testing.
+	rootDir     = "/"
+	initRootDir = "/proc/1/root"
+	mountsFile  = "/proc/self/mounts"
+)
+
+// RunningInChroot returns true if the root directory of the current
+// process is not that of init, which indicates that it is running in a
+// chroot. The series detected there describes the chroot rather than
+// the real host. If the root of init can't be examined, which usually
+// requires privileges, false is returned.
+func RunningInChroot() bool {
+	root, err := os.Stat(rootDir)
+	if err != nil {
+		return false
+	}
+	initRoot, err := os.Stat(initRootDir)
+	if err != nil {
+		return false
+	}
+	return !os.SameFile(root, initRoot)
+}
+
+// RootIsOverlay returns true if the root filesystem of the current
+// process is an overlayfs mount, as is typical of image build
+// environments. The series detected there describes the image being
+// built rather than the real host.
+func RootIsOverlay() bool {
+	data, err := ioutil.ReadFile(mountsFile)
+	if err != nil {
+		return false
+	}
+	return rootFilesystemType(string(data)) == "overlay"
+}
+
+// rootFilesystemType returns the type of the filesystem mounted at / in
+// the given contents of a mounts file. If / was mounted more than once
+// the last mount is the one that is visible.
+func rootFilesystemType(contents string) string {
+	fsType := ""
+	for _, line := range strings.Split(contents, "\n") {
+		// Each line is of the form device mount-point type options dump pass.
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "/" {
+			continue
+		}
+		fsType = fields[2]
+	}
+	return fsType
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/series"
+)
+
+type chrootSuite struct {
+	testing.CleanupSuite
+	dir string
+}
+
+var _ = gc.Suite(&chrootSuite{})
+
+func (s *chrootSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.dir = c.MkDir()
+	s.PatchValue(series.MountsFile, filepath.Join(s.dir, "mounts"))
+}
+
+func (s *chrootSuite) TestRunningInChroot(c *gc.C) {
+	root := filepath.Join(s.dir, "root")
+	other := filepath.Join(s.dir, "other")
+	c.Assert(os.Mkdir(root, 0755), jc.ErrorIsNil)
+	c.Assert(os.Mkdir(other, 0755), jc.ErrorIsNil)
+	s.PatchValue(series.RootDir, root)
+
+	s.PatchValue(series.InitRootDir, root)
+	c.Check(series.RunningInChroot(), jc.IsFalse)
+
+	s.PatchValue(series.InitRootDir, other)
+	c.Check(series.RunningInChroot(), jc.IsTrue)
+}
+
+func (s *chrootSuite) TestRunningInChrootCannotStat(c *gc.C) {
+	s.PatchValue(series.InitRootDir, filepath.Join(s.dir, "missing"))
+	c.Assert(series.RunningInChroot(), jc.IsFalse)
+}
+
+func (s *chrootSuite) TestRootIsOverlay(c *gc.C) {
+	for i, test := range []struct {
+		contents string
+		expected bool
+	}{{
+		"overlay / overlay rw,relatime,lowerdir=/l,upperdir=/u,workdir=/w 0 0\nproc /proc proc rw 0 0\n",
+		true,
+	}, {
+		"/dev/sda1 / ext4 rw,relatime 0 0\nproc /proc proc rw 0 0\n",
+		false,
+	}, {
+		"rootfs / rootfs rw 0 0\noverlay / overlay rw 0 0\n",
+		true,
+	}, {
+		"overlay /var/lib/docker/overlay2/x/merged overlay rw 0 0\n",
+		false,
+	}} {
+		c.Logf("test %d", i)
+		err := ioutil.WriteFile(filepath.Join(s.dir, "mounts"), []byte(test.contents), 0644)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(series.RootIsOverlay(), gc.Equals, test.expected)
+	}
+}
+
+func (s *chrootSuite) TestRootIsOverlayNoMountsFile(c *gc.C) {
+	c.Assert(series.RootIsOverlay(), jc.IsFalse)
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ContainerType identifies the kind of container the current process
+// is running in.
+type ContainerType string
+
+const (
+	// NoContainer is returned when the process is not running in a
+	// container that we can detect.
+	NoContainer ContainerType = ""
+
+	// LXC is a plain LXC container, not managed by LXD.
+	LXC ContainerType = "lxc"
+
+	// LXD is an LXC container managed by LXD.
+	LXD ContainerType = "lxd"
+
+	// Docker is a Docker container.
+	Docker ContainerType = "docker"
+
+	// Containerd is a container run directly by containerd, such as
+	// a Kubernetes pod.
+	Containerd ContainerType = "containerd"
+
+	// SystemdNspawn is a container started by systemd-nspawn.
+	SystemdNspawn ContainerType = "systemd-nspawn"
+)
+
+var (
+	// These are variables so they can be overridden for testing.
+	containerEnv         = func() string { return os.Getenv("container") }
+	systemdContainerFile = "/run/systemd/container"
+	dockerEnvFile        = "/.dockerenv"
+	lxdSocketFile        = "/dev/lxd/sock"
+	initCgroupFile       = "/proc/1/cgroup"
+)
+
+// RunningInContainer returns true if the current process appears to be
+// running inside a container.
+func RunningInContainer() bool {
+	return HostContainer() != NoContainer
+}
+
+// HostContainer returns the type of container the current process is
+// running in, or NoContainer if it isn't in one. A container type
+// advertised by the container manager that we don't recognise is
+// returned verbatim.
+func HostContainer() ContainerType {
+	if value := containerEnv(); value != "" {
+		return containerFromName(value)
+	}
+	if data, err := ioutil.ReadFile(systemdContainerFile); err == nil {
+		if value := strings.TrimSpace(string(data)); value != "" {
+			return containerFromName(value)
+		}
+	}
+	if fileExists(dockerEnvFile) {
+		return Docker
+	}
+	if fileExists(lxdSocketFile) {
+		return LXD
+	}
+	data, err := ioutil.ReadFile(initCgroupFile)
+	if err != nil {
+		return NoContainer
+	}
+	return containerFromCgroup(string(data))
+}
+
+// containerFromName maps the value of the container environment
+// variable (or /run/systemd/container) to a ContainerType.
+func containerFromName(name string) ContainerType {
+	switch name {
+	case "lxc", "lxc-libvirt":
+		if fileExists(lxdSocketFile) {
+			return LXD
+		}
+		return LXC
+	case "docker":
+		return Docker
+	case "containerd":
+		return Containerd
+	case "systemd-nspawn":
+		return SystemdNspawn
+	}
+	return ContainerType(name)
+}
+
+// containerFromCgroup inspects the contents of a /proc/<pid>/cgroup
+// file for the tell-tale paths used by the various container managers.
+func containerFromCgroup(contents string) ContainerType {
+	for _, line := range strings.Split(contents, "\n") {
+		// Each line is of the form hierarchy-ID:controller-list:cgroup-path.
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		path := parts[2]
+		switch {
+		case strings.Contains(path, "/docker/"), strings.Contains(path, "/docker-"):
+			return Docker
+		case strings.Contains(path, "/lxc/"), strings.Contains(path, "/lxc.payload"):
+			return LXC
+		case strings.Contains(path, "/kubepods"), strings.Contains(path, "containerd"):
+			return Containerd
+		case strings.Contains(path, "/machine.slice/machine-"):
+			return SystemdNspawn
+		}
+	}
+	return NoContainer
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/series"
+)
+
+type containerSuite struct {
+	testing.CleanupSuite
+	dir string
+}
+
+var _ = gc.Suite(&containerSuite{})
+
+func (s *containerSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.dir = c.MkDir()
+	s.PatchValue(series.ContainerEnv, func() string { return "" })
+	s.PatchValue(series.SystemdContainerFile, filepath.Join(s.dir, "container"))
+	s.PatchValue(series.DockerEnvFile, filepath.Join(s.dir, "dockerenv"))
+	s.PatchValue(series.LXDSocketFile, filepath.Join(s.dir, "lxd-sock"))
+	s.PatchValue(series.InitCgroupFile, filepath.Join(s.dir, "cgroup"))
+}
+
+func (s *containerSuite) writeFile(c *gc.C, name, contents string) {
+	err := ioutil.WriteFile(filepath.Join(s.dir, name), []byte(contents), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *containerSuite) TestNoContainer(c *gc.C) {
+	s.writeFile(c, "cgroup", "12:pids:/init.scope\n1:name=systemd:/init.scope\n")
+	c.Assert(series.HostContainer(), gc.Equals, series.NoContainer)
+	c.Assert(series.RunningInContainer(), jc.IsFalse)
+}
+
+func (s *containerSuite) TestNoCgroupFile(c *gc.C) {
+	c.Assert(series.HostContainer(), gc.Equals, series.NoContainer)
+}
+
+func (s *containerSuite) TestContainerEnv(c *gc.C) {
+	for i, test := range []struct {
+		value    string
+		expected series.ContainerType
+	}{
+		{"lxc", series.LXC},
+		{"docker", series.Docker},
+		{"containerd", series.Containerd},
+		{"systemd-nspawn", series.SystemdNspawn},
+		{"podman", series.ContainerType("podman")},
+	} {
+		c.Logf("test %d: %s", i, test.value)
+		value := test.value
+		s.PatchValue(series.ContainerEnv, func() string { return value })
+		c.Check(series.HostContainer(), gc.Equals, test.expected)
+		c.Check(series.RunningInContainer(), jc.IsTrue)
+	}
+}
+
+func (s *containerSuite) TestContainerEnvLXD(c *gc.C) {
+	s.PatchValue(series.ContainerEnv, func() string { return "lxc" })
+	s.writeFile(c, "lxd-sock", "")
+	c.Assert(series.HostContainer(), gc.Equals, series.LXD)
+}
+
+func (s *containerSuite) TestSystemdContainerFile(c *gc.C) {
+	s.writeFile(c, "container", "systemd-nspawn\n")
+	c.Assert(series.HostContainer(), gc.Equals, series.SystemdNspawn)
+}
+
+func (s *containerSuite) TestDockerEnvFile(c *gc.C) {
+	s.writeFile(c, "dockerenv", "")
+	c.Assert(series.HostContainer(), gc.Equals, series.Docker)
+}
+
+func (s *containerSuite) TestCgroup(c *gc.C) {
+	for i, test := range []struct {
+		contents string
+		expected series.ContainerType
+	}{{
+		"12:pids:/docker/0123456789abcdef\n1:name=systemd:/docker/0123456789abcdef\n",
+		series.Docker,
+	}, {
+		"0::/system.slice/docker-0123456789abcdef.scope\n",
+		series.Docker,
+	}, {
+		"12:pids:/lxc/juju-machine-0\n",
+		series.LXC,
+	}, {
+		"11:memory:/kubepods/besteffort/pod1234/abcdef\n",
+		series.Containerd,
+	}, {
+		"1:name=systemd:/machine.slice/machine-test.scope\n",
+		series.SystemdNspawn,
+	}, {
+		"some junk\n",
+		series.NoContainer,
+	}} {
+		c.Logf("test %d", i)
+		s.writeFile(c, "cgroup", test.contents)
+		c.Check(series.HostContainer(), gc.Equals, test.expected)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
+)
+
+// CachePolicy determines how long a Detector holds on to the series it
+// has detected before detecting it again. Whatever the policy, a failed
+// detection is never cached, so that transient failures are retried by
+// the next call.
+type CachePolicy int
+
+const (
+	// CacheForever keeps the first successful result for the lifetime
+	// of the Detector. This is the default.
+	CacheForever CachePolicy = iota
+
+	// CacheTTL keeps a result until it is older than the Detector's
+	// TTL, after which the series is detected again.
+	CacheTTL
+
+	// NoCache detects the series again on every call. Concurrent calls
+	// still share a detection that is in progress.
+	NoCache
+)
+
+// ReleasePaths holds the locations of the files a Detector reads to
+// determine the host series. An empty path means the standard location
+// is used.
+type ReleasePaths struct {
+	// OSRelease is the os-release file, read on Linux.
+	OSRelease string
+
+	// DebianVersion is the debian_version file, read on Linux when
+	// there is no os-release file or lsb_release command.
+	DebianVersion string
+
+	// SystemVersion is the SystemVersion.plist file, read on macOS.
+	SystemVersion string
+}
+
+// pathOrDefault returns path, or def if path is empty.
+func pathOrDefault(path, def string) string {
+	if path == "" {
+		return def
+	}
+	return path
+}
+
+var (
+	// defaultDetectorMu guards defaultDetector.
+	defaultDetectorMu sync.Mutex
+
+	// defaultDetector is used by HostSeries, HostSeriesContext,
+	// ForceHostSeries and OSReleaseFields.
+	defaultDetector = NewDetector()
+)
+
+// SetDefaultDetector sets the Detector used by HostSeries,
+// HostSeriesContext, ForceHostSeries and OSReleaseFields, returning the
+// one it replaces. It can be used to set a Detector with a different
+// CachePolicy for environments where the host OS can change underneath
+// a running process.
+func SetDefaultDetector(d *Detector) *Detector {
+	defaultDetectorMu.Lock()
+	defer defaultDetectorMu.Unlock()
+	old := defaultDetector
+	defaultDetector = d
+	return old
+}
+
+// getDefaultDetector returns the Detector set by SetDefaultDetector.
+func getDefaultDetector() *Detector {
+	defaultDetectorMu.Lock()
+	defer defaultDetectorMu.Unlock()
+	return defaultDetector
+}
+
+// Detector determines the series of the host, caching the result
+// according to its CachePolicy. Nothing is read from the host until
+// the series is first requested.
+type Detector struct {
+	policy CachePolicy
+	ttl    time.Duration
+	clock  clock.Clock
+	paths  ReleasePaths
+
+	// readRelease is called to determine the series and os-release
+	// fields. If it is nil the platform specific detection is used.
+	readRelease func(ReleasePaths) hostRelease
+
+	// mu guards current.
+	mu sync.Mutex
+
+	// current holds the most recent detection, which may still be in
+	// progress.
+	current *detection
+}
+
+// hostRelease holds the results of reading the host's release
+// information.
+type hostRelease struct {
+	series    string
+	seriesErr error
+
+	// fields holds the raw os-release fields, which are only
+	// available on Linux.
+	fields    map[string]string
+	fieldsErr error
+}
+
+// detection holds the result of a single attempt to determine the
+// series. The other fields must not be read until done is closed.
+type detection struct {
+	done    chan struct{}
+	release hostRelease
+	when    time.Time
+}
+
+// NewDetector returns a new Detector that caches the detected series
+// for its lifetime.
+func NewDetector() *Detector {
+	return NewDetectorWithPolicy(CacheForever, 0, clock.WallClock)
+}
+
+// NewDetectorWithPolicy returns a new Detector that caches the detected
+// series according to policy. The ttl is only used with the CacheTTL
+// policy, and is measured using clk.
+func NewDetectorWithPolicy(policy CachePolicy, ttl time.Duration, clk clock.Clock) *Detector {
+	return NewDetectorWithPaths(policy, ttl, clk, ReleasePaths{})
+}
+
+// NewDetectorWithPaths returns a new Detector like NewDetectorWithPolicy
+// that reads the host's release information from the given paths, for
+// example to determine the series of a mounted image.
+func NewDetectorWithPaths(policy CachePolicy, ttl time.Duration, clk clock.Clock, paths ReleasePaths) *Detector {
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	return &Detector{
+		policy:      policy,
+		ttl:         ttl,
+		clock:       clk,
+		paths:       paths,
+		readRelease: readHostRelease,
+	}
+}
+
+// HostSeries returns the series of the machine the current process is
+// running on, detecting it if there is no usable cached result. If ctx
+// is done before the detection completes, the context's error is
+// returned and the detection carries on in the background for the
+// benefit of later calls.
+func (d *Detector) HostSeries(ctx context.Context) (string, error) {
+	return hostSeriesResult(d.wait(ctx))
+}
+
+// hostSeriesResult returns the series from the result of waiting for a
+// detection.
+func hostSeriesResult(release hostRelease, err error) (string, error) {
+	if err != nil {
+		return "unknown", errors.Annotate(err, "cannot determine host series")
+	}
+	if release.seriesErr != nil {
+		return release.series, errors.Annotate(release.seriesErr, "cannot determine host series")
+	}
+	return release.series, nil
+}
+
+// OSReleaseFields returns the fields of the host's os-release file (or
+// its lsb_release and debian_version fallbacks), which are read and
+// cached along with the series. The returned map is a copy, and may be
+// modified by the caller. A NotSupported error is returned on operating
+// systems other than Linux.
+func (d *Detector) OSReleaseFields(ctx context.Context) (map[string]string, error) {
+	release, err := d.wait(ctx)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read os-release fields")
+	}
+	if release.fieldsErr != nil {
+		return nil, errors.Annotate(release.fieldsErr, "cannot read os-release fields")
+	}
+	fields := make(map[string]string, len(release.fields))
+	for k, v := range release.fields {
+		fields[k] = v
+	}
+	return fields, nil
+}
+
+// ForceHostSeries is like HostSeries, but ignores any cached result and
+// detects the series again. The new result is cached for later calls.
+func (d *Detector) ForceHostSeries(ctx context.Context) (string, error) {
+	d.mu.Lock()
+	det := d.start()
+	d.current = det
+	d.mu.Unlock()
+	return hostSeriesResult(det.wait(ctx))
+}
+
+// wait returns the result of the current detection, starting a new one
+// if there is no usable cached result.
+func (d *Detector) wait(ctx context.Context) (hostRelease, error) {
+	d.mu.Lock()
+	det := d.current
+	if det == nil || d.expired(det) {
+		det = d.start()
+		d.current = det
+	}
+	d.mu.Unlock()
+	return det.wait(ctx)
+}
+
+// wait returns the result of the detection once it completes.
+func (det *detection) wait(ctx context.Context) (hostRelease, error) {
+	select {
+	case <-det.done:
+		return det.release, nil
+	case <-ctx.Done():
+		return hostRelease{}, ctx.Err()
+	}
+}
+
+// expired reports whether det should be replaced by a new detection.
+// A detection that is still in progress never expires, and a failed
+// one always does.
+func (d *Detector) expired(det *detection) bool {
+	select {
+	case <-det.done:
+	default:
+		return false
+	}
+	if det.release.seriesErr != nil {
+		return true
+	}
+	switch d.policy {
+	case NoCache:
+		return true
+	case CacheTTL:
+		return d.clock.Now().Sub(det.when) >= d.ttl
+	}
+	return false
+}
+
+// start begins a new detection in the background.
+func (d *Detector) start() *detection {
+	read := d.readRelease
+	if read == nil {
+		read = readHostRelease
+	}
+	clk := d.clock
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	paths := d.paths
+	det := &detection{done: make(chan struct{})}
+	go func() {
+		defer close(det.done)
+		det.release = read(paths)
+		det.when = clk.Now()
+	}()
+	return det
+}
@@ -0,0 +1,206 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/series"
+)
+
+type detectorSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&detectorSuite{})
+
+func (*detectorSuite) TestHostSeriesCached(c *gc.C) {
+	calls := 0
+	d := series.NewTestDetector(series.CacheForever, 0, nil, func() (string, error) {
+		calls++
+		return "bionic", nil
+	})
+	for i := 0; i < 2; i++ {
+		s, err := d.HostSeries(context.Background())
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(s, gc.Equals, "bionic")
+	}
+	c.Assert(calls, gc.Equals, 1)
+}
+
+func (*detectorSuite) TestHostSeriesError(c *gc.C) {
+	d := series.NewTestDetector(series.CacheForever, 0, nil, func() (string, error) {
+		return "unknown", errors.New("boom")
+	})
+	s, err := d.HostSeries(context.Background())
+	c.Assert(err, gc.ErrorMatches, "cannot determine host series: boom")
+	c.Assert(s, gc.Equals, "unknown")
+}
+
+func (*detectorSuite) TestHostSeriesContextDone(c *gc.C) {
+	unblock := make(chan struct{})
+	d := series.NewTestDetector(series.CacheForever, 0, nil, func() (string, error) {
+		<-unblock
+		return "xenial", nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s, err := d.HostSeries(ctx)
+	c.Assert(err, gc.ErrorMatches, "cannot determine host series: context canceled")
+	c.Assert(s, gc.Equals, "unknown")
+
+	// Detection carries on in the background.
+	close(unblock)
+	s, err = d.HostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s, gc.Equals, "xenial")
+}
+
+func (*detectorSuite) TestHostSeriesCacheTTL(c *gc.C) {
+	clk := testclock.NewClock(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+	results := []string{"xenial", "bionic"}
+	d := series.NewTestDetector(series.CacheTTL, time.Hour, clk, func() (string, error) {
+		result := results[0]
+		results = results[1:]
+		return result, nil
+	})
+	s, err := d.HostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s, gc.Equals, "xenial")
+
+	clk.Advance(time.Hour - time.Second)
+	s, err = d.HostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s, gc.Equals, "xenial")
+
+	clk.Advance(time.Second)
+	s, err = d.HostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s, gc.Equals, "bionic")
+}
+
+func (*detectorSuite) TestHostSeriesNoCache(c *gc.C) {
+	calls := 0
+	d := series.NewTestDetector(series.NoCache, 0, nil, func() (string, error) {
+		calls++
+		return "bionic", nil
+	})
+	for i := 0; i < 3; i++ {
+		s, err := d.HostSeries(context.Background())
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(s, gc.Equals, "bionic")
+	}
+	c.Assert(calls, gc.Equals, 3)
+}
+
+// patchDefaultDetector sets the default Detector to d until the current
+// test finishes.
+func patchDefaultDetector(s *testing.CleanupSuite, d *series.Detector) {
+	orig := series.SetDefaultDetector(d)
+	s.AddCleanup(func(*gc.C) { series.SetDefaultDetector(orig) })
+}
+
+func (s *detectorSuite) TestHostSeriesOrDefault(c *gc.C) {
+	patchDefaultDetector(&s.CleanupSuite, series.NewTestDetector(series.CacheForever, 0, nil, func() (string, error) {
+		return "bionic", nil
+	}))
+	c.Assert(series.HostSeriesOrDefault("xenial"), gc.Equals, "bionic")
+
+	patchDefaultDetector(&s.CleanupSuite, series.NewTestDetector(series.CacheForever, 0, nil, func() (string, error) {
+		return "unknown", errors.New("boom")
+	}))
+	c.Assert(series.HostSeriesOrDefault("xenial"), gc.Equals, "xenial")
+}
+
+func (*detectorSuite) TestOSReleaseFieldsCachedWithSeries(c *gc.C) {
+	calls := 0
+	d := series.NewTestDetectorWithFields(series.CacheForever, 0, nil, func() (map[string]string, error) {
+		calls++
+		return map[string]string{"ID": "ubuntu", "VERSION_CODENAME": "bionic"}, nil
+	})
+	s, err := d.HostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s, gc.Equals, "bionic")
+
+	fields, err := d.OSReleaseFields(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fields, jc.DeepEquals, map[string]string{"ID": "ubuntu", "VERSION_CODENAME": "bionic"})
+	c.Assert(calls, gc.Equals, 1)
+
+	// The caller may modify the fields without affecting the cache.
+	fields["ID"] = "debian"
+	fields, err = d.OSReleaseFields(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fields["ID"], gc.Equals, "ubuntu")
+}
+
+func (*detectorSuite) TestOSReleaseFieldsError(c *gc.C) {
+	d := series.NewTestDetectorWithFields(series.CacheForever, 0, nil, func() (map[string]string, error) {
+		return nil, errors.New("boom")
+	})
+	_, err := d.OSReleaseFields(context.Background())
+	c.Assert(err, gc.ErrorMatches, "cannot read os-release fields: boom")
+}
+
+func (*detectorSuite) TestHostSeriesErrorRetried(c *gc.C) {
+	calls := 0
+	d := series.NewTestDetector(series.CacheForever, 0, nil, func() (string, error) {
+		calls++
+		if calls == 1 {
+			return "unknown", errors.New("boom")
+		}
+		return "bionic", nil
+	})
+	_, err := d.HostSeries(context.Background())
+	c.Assert(err, gc.ErrorMatches, "cannot determine host series: boom")
+
+	for i := 0; i < 2; i++ {
+		s, err := d.HostSeries(context.Background())
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(s, gc.Equals, "bionic")
+	}
+	c.Assert(calls, gc.Equals, 2)
+}
+
+func (*detectorSuite) TestForceHostSeries(c *gc.C) {
+	results := []string{"xenial", "bionic"}
+	d := series.NewTestDetector(series.CacheForever, 0, nil, func() (string, error) {
+		result := results[0]
+		results = results[1:]
+		return result, nil
+	})
+	s, err := d.HostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s, gc.Equals, "xenial")
+
+	s, err = d.ForceHostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s, gc.Equals, "bionic")
+
+	// The forced result is cached.
+	s, err = d.HostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s, gc.Equals, "bionic")
+}
+
+func (s *detectorSuite) TestForceHostSeriesDefaultDetector(c *gc.C) {
+	calls := 0
+	patchDefaultDetector(&s.CleanupSuite, series.NewTestDetector(series.CacheForever, 0, nil, func() (string, error) {
+		calls++
+		return "bionic", nil
+	}))
+	_, err := series.HostSeries()
+	c.Assert(err, jc.ErrorIsNil)
+	result, err := series.ForceHostSeries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.Equals, "bionic")
+	c.Assert(calls, gc.Equals, 2)
+}
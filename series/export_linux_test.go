@@ -6,7 +6,14 @@ package series
 var (
 	DistroInfo    = &distroInfo
 	ReadSeries    = readSeries
+	ReadOSInfo    = readOSInfo
+	PrettyName    = prettyName
 	OSReleaseFile = &osReleaseFile
+
+	DebianVersionFile = &debianVersionFile
+	LSBRelease        = &lsbRelease
+
+	KernelReleaseFile = &kernelReleaseFile
 )
 
 func SetUbuntuSeries(value map[string]string) func() {
@@ -3,10 +3,34 @@
 
 package series
 
+import (
+	"time"
+
+	"github.com/juju/clock"
+	"github.com/juju/errors"
+)
+
 var (
-	KernelToMajor                 = kernelToMajor
-	MacOSXSeriesFromKernelVersion = macOSXSeriesFromKernelVersion
-	MacOSXSeriesFromMajorVersion  = macOSXSeriesFromMajorVersion
+	KernelToMajor                     = kernelToMajor
+	KernelToMajorMinor                = kernelToMajorMinor
+	MacOSXSeriesFromKernelVersion     = macOSXSeriesFromKernelVersion
+	MacOSXSeriesFromMajorVersion      = macOSXSeriesFromMajorVersion
+	MacOSXPrettyNameFromKernelVersion = macOSXPrettyNameFromKernelVersion
+	MacOSXSeriesFromSystemVersion     = macOSXSeriesFromSystemVersion
+
+	AliasSeries = &aliasSeries
+
+	ContainerEnv         = &containerEnv
+	SystemdContainerFile = &systemdContainerFile
+	DockerEnvFile        = &dockerEnvFile
+	LXDSocketFile        = &lxdSocketFile
+	InitCgroupFile       = &initCgroupFile
+
+	RootDir     = &rootDir
+	InitRootDir = &initRootDir
+	MountsFile  = &mountsFile
+
+	MaxMetadataSize = &maxMetadataSize
 )
 
 func SetSeriesVersions(value map[string]string) func() {
@@ -21,3 +45,68 @@ func SetSeriesVersions(value map[string]string) func() {
 		updatedseriesVersions = origUpdated
 	}
 }
+
+func NewTestDetector(policy CachePolicy, ttl time.Duration, clk clock.Clock, readSeries func() (string, error)) *Detector {
+	d := NewDetectorWithPolicy(policy, ttl, clk)
+	d.readRelease = func(ReleasePaths) hostRelease {
+		series, err := readSeries()
+		return hostRelease{
+			series:    series,
+			seriesErr: err,
+			fieldsErr: errors.NotFoundf("os-release fields"),
+		}
+	}
+	return d
+}
+
+func NewTestDetectorWithFields(policy CachePolicy, ttl time.Duration, clk clock.Clock, readFields func() (map[string]string, error)) *Detector {
+	d := NewDetectorWithPolicy(policy, ttl, clk)
+	d.readRelease = func(ReleasePaths) hostRelease {
+		fields, err := readFields()
+		return hostRelease{
+			series:    fields["VERSION_CODENAME"],
+			fields:    fields,
+			fieldsErr: err,
+		}
+	}
+	return d
+}
+
+// BackupSeriesTables saves the series lookup tables and returns a
+// function that restores them.
+func BackupSeriesTables() func() {
+	copyMap := func(m map[string]string) map[string]string {
+		result := make(map[string]string)
+		for k, v := range m {
+			result[k] = v
+		}
+		return result
+	}
+	origVersions := copyMap(seriesVersions)
+	origUbuntu := copyMap(ubuntuSeries)
+	origCentOS := copyMap(centosSeries)
+	origOpenSUSE := copyMap(opensuseSeries)
+	origLTS := make(map[string]bool)
+	for k, v := range ubuntuLTS {
+		origLTS[k] = v
+	}
+	origDates := make(map[string]seriesDates)
+	for k, v := range ubuntuDates {
+		origDates[k] = v
+	}
+	origLatestLts := latestLtsSeries
+	origWindows := make([]WindowsVersion, len(windowsVersionTable))
+	copy(origWindows, windowsVersionTable)
+	return func() {
+		seriesVersions = origVersions
+		ubuntuSeries = origUbuntu
+		centosSeries = origCentOS
+		opensuseSeries = origOpenSUSE
+		ubuntuLTS = origLTS
+		ubuntuDates = origDates
+		latestLtsSeries = origLatestLts
+		windowsVersionTable = origWindows
+		updateVersionSeries()
+		windowsVersionMatchOrder, windowsVersions, windowsNanoVersions = windowsVersionMaps(windowsVersionTable)
+	}
+}
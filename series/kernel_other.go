@@ -0,0 +1,16 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !linux,!darwin
+
+package series
+
+import (
+	"runtime"
+
+	"github.com/juju/errors"
+)
+
+func kernelVersion() (string, error) {
+	return "", errors.NotSupportedf("kernel version on %s", runtime.GOOS)
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// ParseLSBRelease parses the output of "lsb_release -a" and returns the
+// values keyed as they would be in an os-release file (ID, VERSION_ID,
+// VERSION_CODENAME and PRETTY_NAME), so that it can be used in place of
+// os-release on systems that lack it.
+func ParseLSBRelease(output string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		switch strings.TrimSpace(parts[0]) {
+		case "Distributor ID":
+			values["ID"] = strings.ToLower(value)
+		case "Release":
+			values["VERSION_ID"] = value
+		case "Codename":
+			values["VERSION_CODENAME"] = value
+		case "Description":
+			values["PRETTY_NAME"] = value
+		}
+	}
+	if values["ID"] == "" {
+		return nil, errors.New("lsb_release output is missing Distributor ID")
+	}
+	return values, nil
+}
+
+// ParseDebianVersion parses the contents of /etc/debian_version and
+// returns the values keyed as they would be in an os-release file. A
+// numeric release such as "9.5" is reported as VERSION_ID, whereas a
+// testing or unstable release such as "buster/sid" is reported as
+// VERSION_CODENAME.
+func ParseDebianVersion(contents string) (map[string]string, error) {
+	version := strings.TrimSpace(contents)
+	if version == "" {
+		return nil, errors.New("debian version file is empty")
+	}
+	values := map[string]string{"ID": "debian"}
+	if version[0] >= '0' && version[0] <= '9' {
+		values["VERSION_ID"] = version
+	} else {
+		values["VERSION_CODENAME"] = strings.SplitN(version, "/", 2)[0]
+	}
+	return values, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/series"
+)
+
+type lsbReleaseSuite struct{}
+
+var _ = gc.Suite(&lsbReleaseSuite{})
+
+func (*lsbReleaseSuite) TestParseLSBRelease(c *gc.C) {
+	values, err := series.ParseLSBRelease(`No LSB modules are available.
+Distributor ID:	Ubuntu
+Description:	Ubuntu 18.04.1 LTS
+Release:	18.04
+Codename:	bionic
+`)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(values, jc.DeepEquals, map[string]string{
+		"ID":               "ubuntu",
+		"VERSION_ID":       "18.04",
+		"VERSION_CODENAME": "bionic",
+		"PRETTY_NAME":      "Ubuntu 18.04.1 LTS",
+	})
+}
+
+func (*lsbReleaseSuite) TestParseLSBReleaseMissingID(c *gc.C) {
+	_, err := series.ParseLSBRelease("Release:\t18.04\n")
+	c.Assert(err, gc.ErrorMatches, "lsb_release output is missing Distributor ID")
+}
+
+func (*lsbReleaseSuite) TestParseDebianVersion(c *gc.C) {
+	for i, test := range []struct {
+		contents string
+		expected map[string]string
+	}{{
+		"9.5\n",
+		map[string]string{"ID": "debian", "VERSION_ID": "9.5"},
+	}, {
+		"buster/sid\n",
+		map[string]string{"ID": "debian", "VERSION_CODENAME": "buster"},
+	}} {
+		c.Logf("test %d", i)
+		values, err := series.ParseDebianVersion(test.contents)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(values, jc.DeepEquals, test.expected)
+	}
+}
+
+func (*lsbReleaseSuite) TestParseDebianVersionEmpty(c *gc.C) {
+	_, err := series.ParseDebianVersion("\n")
+	c.Assert(err, gc.ErrorMatches, "debian version file is empty")
+}
@@ -0,0 +1,29 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build !go1.7
+
+package series
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// doRequest sends req with client, giving up when ctx is done.
+//
+// Request.WithContext was introduced in Go 1.7, so before that the
+// request is cancelled through its Cancel channel.
+func doRequest(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	req.Cancel = ctx.Done()
+	resp, err := client.Do(req)
+	if err != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+	return resp, err
+}
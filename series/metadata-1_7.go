@@ -0,0 +1,17 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build go1.7
+
+package series
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// doRequest sends req with client, giving up when ctx is done.
+func doRequest(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	return client.Do(req.WithContext(ctx))
+}
@@ -0,0 +1,201 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/juju/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/net/context"
+
+	"github.com/juju/utils/os"
+)
+
+// SeriesMetadata is the document fetched by a MetadataUpdater. It
+// describes series that may have been released since this package was
+// built.
+type SeriesMetadata struct {
+	Series []SeriesMetadataEntry `json:"series"`
+}
+
+// SeriesMetadataEntry describes a single series.
+type SeriesMetadataEntry struct {
+	// Series is the name of the series, for example "cosmic".
+	Series string `json:"series"`
+
+	// OS is the operating system of the series. Only Ubuntu, CentOS
+	// and OpenSUSE series are supported.
+	OS os.OSType `json:"os"`
+
+	// Version is the version of the series, for example "18.10" or
+	// "centos7".
+	Version string `json:"version"`
+
+	// LTS records whether an Ubuntu series is a long term support
+	// release.
+	LTS bool `json:"lts,omitempty"`
+
+	// Release and EOL are the release and end of life dates of the
+	// series in YYYY-MM-DD form.
+	Release string `json:"release,omitempty"`
+	EOL     string `json:"eol,omitempty"`
+}
+
+// Verifier checks the signature on a metadata document.
+type Verifier interface {
+	// Verify checks the signature on the signed document and returns
+	// the signed content.
+	Verify(signed []byte) ([]byte, error)
+}
+
+// pgpVerifier is a Verifier for PGP clearsigned documents, as used by
+// simplestreams.
+type pgpVerifier struct {
+	keyring openpgp.EntityList
+}
+
+// NewPGPVerifier returns a Verifier that accepts documents clearsigned
+// by one of the keys in the given armored public keyring.
+func NewPGPVerifier(armoredKeyring io.Reader) (Verifier, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(armoredKeyring)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read public keyring")
+	}
+	return &pgpVerifier{keyring: keyring}, nil
+}
+
+// Verify implements Verifier.
+func (v *pgpVerifier) Verify(signed []byte) ([]byte, error) {
+	block, _ := clearsign.Decode(signed)
+	if block == nil {
+		return nil, errors.New("no PGP signature embedded in plain text data")
+	}
+	_, err := openpgp.CheckDetachedSignature(v.keyring, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot verify signature")
+	}
+	return block.Plaintext, nil
+}
+
+// maxMetadataSize holds the largest metadata document that a
+// MetadataUpdater will read.
+var maxMetadataSize int64 = 1 << 20
+
+// MetadataUpdater fetches a signed series metadata document and merges
+// it into the series known by this package, so that new releases can
+// be recognised without a new binary.
+type MetadataUpdater struct {
+	// URL is the location of the signed metadata document.
+	URL string
+
+	// Verifier checks the signature on the document.
+	Verifier Verifier
+
+	// Client is used to fetch the document. If it is nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Update fetches, verifies and merges the metadata document.
+func (u *MetadataUpdater) Update(ctx context.Context) error {
+	if u.Verifier == nil {
+		return errors.NotValidf("metadata updater without verifier")
+	}
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequest("GET", u.URL, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	resp, err := doRequest(ctx, client, req)
+	if err != nil {
+		return errors.Annotatef(err, "cannot fetch series metadata from %q", u.URL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("cannot fetch series metadata from %q: %s", u.URL, resp.Status)
+	}
+	signed, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxMetadataSize+1))
+	if err != nil {
+		return errors.Annotatef(err, "cannot read series metadata from %q", u.URL)
+	}
+	if int64(len(signed)) > maxMetadataSize {
+		return errors.Errorf("series metadata from %q exceeds %d bytes", u.URL, maxMetadataSize)
+	}
+	data, err := u.Verifier.Verify(signed)
+	if err != nil {
+		return errors.Annotatef(err, "series metadata from %q", u.URL)
+	}
+	return errors.Trace(MergeSeriesMetadata(data))
+}
+
+// MergeSeriesMetadata parses a JSON encoded SeriesMetadata document,
+// which must already have been verified, and merges it into the series
+// known by this package. Existing series are updated.
+func MergeSeriesMetadata(data []byte) error {
+	var metadata SeriesMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return errors.Annotate(err, "cannot parse series metadata")
+	}
+	// Check everything before changing anything, so that a bad
+	// document doesn't leave us with partial updates.
+	dates := make([]seriesDates, len(metadata.Series))
+	for i, entry := range metadata.Series {
+		if entry.Series == "" || entry.Version == "" {
+			return errors.NotValidf("series metadata entry %d without series or version", i)
+		}
+		switch entry.OS {
+		case os.Ubuntu, os.CentOS, os.OpenSUSE:
+		default:
+			return errors.NotSupportedf("series %q for OS %q", entry.Series, entry.OS)
+		}
+		var err error
+		if dates[i].release, err = parseMetadataDate(entry.Release); err != nil {
+			return errors.Annotatef(err, "series %q release date", entry.Series)
+		}
+		if dates[i].eol, err = parseMetadataDate(entry.EOL); err != nil {
+			return errors.Annotatef(err, "series %q end of life date", entry.Series)
+		}
+	}
+
+	seriesVersionsMutex.Lock()
+	defer seriesVersionsMutex.Unlock()
+	for i, entry := range metadata.Series {
+		seriesVersions[entry.Series] = entry.Version
+		switch entry.OS {
+		case os.Ubuntu:
+			ubuntuSeries[entry.Series] = entry.Version
+			ubuntuDates[entry.Series] = dates[i]
+			if entry.LTS {
+				ubuntuLTS[entry.Series] = true
+			} else {
+				delete(ubuntuLTS, entry.Series)
+			}
+		case os.CentOS:
+			centosSeries[entry.Series] = entry.Version
+		case os.OpenSUSE:
+			opensuseSeries[entry.Series] = entry.Version
+		}
+	}
+	updateVersionSeries()
+	latestLtsSeries = ""
+	return nil
+}
+
+func parseMetadataDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, errors.Trace(err)
+}
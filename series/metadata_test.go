@@ -0,0 +1,195 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/os"
+	"github.com/juju/utils/series"
+)
+
+type metadataSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&metadataSuite{})
+
+func (s *metadataSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	restore := series.BackupSeriesTables()
+	s.AddCleanup(func(*gc.C) { restore() })
+	cleanup := series.SetSeriesVersions(map[string]string{
+		"bionic":  "18.04",
+		"centos7": "centos7",
+	})
+	s.AddCleanup(func(*gc.C) { cleanup() })
+}
+
+const seriesMetadata = `{
+	"series": [
+		{"series": "cosmic", "os": "Ubuntu", "version": "18.10", "release": "2018-10-18", "eol": "2019-07-18"},
+		{"series": "focal", "os": "Ubuntu", "version": "20.04", "lts": true},
+		{"series": "centos8", "os": "CentOS", "version": "centos8"}
+	]
+}`
+
+// prefixVerifier is a Verifier that accepts documents starting with
+// "signed:".
+type prefixVerifier struct{}
+
+func (prefixVerifier) Verify(signed []byte) ([]byte, error) {
+	if !bytes.HasPrefix(signed, []byte("signed:")) {
+		return nil, errors.New("bad signature")
+	}
+	return signed[len("signed:"):], nil
+}
+
+func (s *metadataSuite) checkMerged(c *gc.C) {
+	version, err := series.SeriesVersion("cosmic")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(version, gc.Equals, "18.10")
+	name, err := series.VersionSeries("20.04")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(name, gc.Equals, "focal")
+	c.Assert(series.LatestLts(), gc.Equals, "focal")
+
+	info, err := series.GetSeriesInfo("cosmic")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info, jc.DeepEquals, series.SeriesInfo{
+		Series:   "cosmic",
+		OS:       os.Ubuntu,
+		Version:  "18.10",
+		Released: time.Date(2018, 10, 18, 0, 0, 0, 0, time.UTC),
+		EOL:      time.Date(2019, 7, 18, 0, 0, 0, 0, time.UTC),
+	})
+	osType, err := series.GetOSFromSeries("centos8")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(osType, gc.Equals, os.CentOS)
+}
+
+func (s *metadataSuite) TestMergeSeriesMetadata(c *gc.C) {
+	err := series.MergeSeriesMetadata([]byte(seriesMetadata))
+	c.Assert(err, jc.ErrorIsNil)
+	s.checkMerged(c)
+}
+
+func (s *metadataSuite) TestMergeSeriesMetadataConcurrentLookups(c *gc.C) {
+	// Run with -race to check that lookups don't race with merges.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			series.MergeSeriesMetadata([]byte(seriesMetadata))
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		series.GetOSFromSeries("centos7")
+		series.CentOSVersionSeries("centos7")
+		series.LatestLts()
+		series.ResolveSeriesAlias("18.04")
+	}
+	<-done
+	s.checkMerged(c)
+}
+
+func (s *metadataSuite) TestMergeSeriesMetadataInvalid(c *gc.C) {
+	for i, test := range []struct {
+		data string
+		err  string
+	}{{
+		`{"series": [{"series": "cosmic", "os": "Ubuntu"}]}`,
+		`series metadata entry 0 without series or version not valid`,
+	}, {
+		`{"series": [{"series": "win2019", "os": "Windows", "version": "win2019"}]}`,
+		`series "win2019" for OS "Windows" not supported`,
+	}, {
+		`{"series": [{"series": "cosmic", "os": "Ubuntu", "version": "18.10", "eol": "soon"}]}`,
+		`series "cosmic" end of life date: .*`,
+	}, {
+		`{"series": [{"series": "cosmic", "os": "Plan9", "version": "18.10"}]}`,
+		`cannot parse series metadata: .*`,
+	}} {
+		c.Logf("test %d", i)
+		err := series.MergeSeriesMetadata([]byte(test.data))
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+	_, err := series.SeriesVersion("cosmic")
+	c.Assert(err, jc.Satisfies, series.IsUnknownSeriesVersionError)
+}
+
+func (s *metadataSuite) TestUpdate(c *gc.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("signed:" + seriesMetadata))
+	}))
+	defer server.Close()
+
+	updater := &series.MetadataUpdater{
+		URL:      server.URL,
+		Verifier: prefixVerifier{},
+	}
+	err := updater.Update(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	s.checkMerged(c)
+}
+
+func (s *metadataSuite) TestUpdateBadSignature(c *gc.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(seriesMetadata))
+	}))
+	defer server.Close()
+
+	updater := &series.MetadataUpdater{
+		URL:      server.URL,
+		Verifier: prefixVerifier{},
+	}
+	err := updater.Update(context.Background())
+	c.Assert(err, gc.ErrorMatches, `series metadata from ".*": bad signature`)
+	_, err = series.SeriesVersion("cosmic")
+	c.Assert(err, jc.Satisfies, series.IsUnknownSeriesVersionError)
+}
+
+func (s *metadataSuite) TestUpdateTooLarge(c *gc.C) {
+	s.PatchValue(series.MaxMetadataSize, int64(len("signed:"+seriesMetadata)-1))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("signed:" + seriesMetadata))
+	}))
+	defer server.Close()
+
+	updater := &series.MetadataUpdater{
+		URL:      server.URL,
+		Verifier: prefixVerifier{},
+	}
+	err := updater.Update(context.Background())
+	c.Assert(err, gc.ErrorMatches, `series metadata from ".*" exceeds [0-9]+ bytes`)
+	_, err = series.SeriesVersion("cosmic")
+	c.Assert(err, jc.Satisfies, series.IsUnknownSeriesVersionError)
+}
+
+func (s *metadataSuite) TestUpdateHTTPError(c *gc.C) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	updater := &series.MetadataUpdater{
+		URL:      server.URL,
+		Verifier: prefixVerifier{},
+	}
+	err := updater.Update(context.Background())
+	c.Assert(err, gc.ErrorMatches, `cannot fetch series metadata from ".*": 404 Not Found`)
+}
+
+func (s *metadataSuite) TestUpdateNoVerifier(c *gc.C) {
+	updater := &series.MetadataUpdater{URL: "http://0.1.2.3/"}
+	err := updater.Update(context.Background())
+	c.Assert(err, gc.ErrorMatches, `metadata updater without verifier not valid`)
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import "github.com/juju/errors"
+
+// OSInfo describes the operating system of the host.
+type OSInfo struct {
+	// Series is the series of the host, as returned by HostSeries.
+	// For Linux distributions we don't know about this will be
+	// "genericlinux".
+	Series string
+
+	// ID is the distribution ID from os-release (for example "ubuntu"
+	// or "arch"). It is only set on Linux.
+	ID string
+
+	// VersionID is the distribution version from os-release (for
+	// example "18.04"). It is empty on Linux distributions without a
+	// version, such as Arch, and on other operating systems.
+	VersionID string
+
+	// PrettyName is the human readable name of the distribution from
+	// os-release, if available.
+	PrettyName string
+}
+
+// HostOSInfo returns information about the operating system of the
+// machine the current process is running on. Unlike HostSeries, it still
+// identifies the distribution when the series is "genericlinux".
+func HostOSInfo() (OSInfo, error) {
+	series, err := HostSeries()
+	if err != nil {
+		return OSInfo{}, err
+	}
+	info := readOSInfo()
+	info.Series = series
+	return info, nil
+}
+
+// HostOSPrettyName returns the human readable name of the operating
+// system of the machine the current process is running on, suitable for
+// logs and status output. This is the PRETTY_NAME from os-release on
+// Linux, the ProductName on Windows and the marketing name on macOS.
+func HostOSPrettyName() (string, error) {
+	name, err := prettyName()
+	if err != nil {
+		return "", errors.Annotate(err, "cannot determine host OS name")
+	}
+	return name, nil
+}
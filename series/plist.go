@@ -0,0 +1,124 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// systemVersionFile holds the version of macOS.
+var systemVersionFile = "/System/Library/CoreServices/SystemVersion.plist"
+
+// macOSXVersionSeries maps from the major and minor portion of the
+// macOS ProductVersion to the Mac OSX series.
+var macOSXVersionSeries = map[string]string{
+	"10.14": "mojave",
+	"10.13": "highsierra",
+	"10.12": "sierra",
+	"10.11": "elcapitan",
+	"10.10": "yosemite",
+	"10.9":  "mavericks",
+	"10.8":  "mountainlion",
+	"10.7":  "lion",
+	"10.6":  "snowleopard",
+	"10.5":  "leopard",
+	"10.4":  "tiger",
+	"10.3":  "panther",
+	"10.2":  "jaguar",
+	"10.1":  "puma",
+}
+
+// ParseSystemVersionPlist parses the contents of a macOS
+// SystemVersion.plist file and returns the string values in its
+// top-level dictionary, such as ProductVersion and ProductBuildVersion.
+// Only the subset of the plist format used by that file is supported.
+func ParseSystemVersionPlist(data []byte) (map[string]string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	values := make(map[string]string)
+	var (
+		depth  int
+		inDict bool
+		key    string
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Annotate(err, "cannot parse plist")
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case t.Name.Local == "dict" && depth == 2:
+				inDict = true
+			case inDict && depth == 3 && t.Name.Local == "key":
+				var k string
+				if err := decoder.DecodeElement(&k, &t); err != nil {
+					return nil, errors.Annotate(err, "cannot parse plist key")
+				}
+				depth--
+				key = k
+			case inDict && depth == 3 && t.Name.Local == "string" && key != "":
+				var v string
+				if err := decoder.DecodeElement(&v, &t); err != nil {
+					return nil, errors.Annotatef(err, "cannot parse plist value for %q", key)
+				}
+				depth--
+				values[key] = v
+				key = ""
+			default:
+				// Values of other types are ignored.
+				key = ""
+			}
+		case xml.EndElement:
+			if t.Name.Local == "dict" && depth == 2 {
+				inDict = false
+			}
+			depth--
+		}
+	}
+	if len(values) == 0 {
+		return nil, errors.New("plist contains no string values")
+	}
+	return values, nil
+}
+
+// macOSXSeriesFromProductVersion returns the series for a macOS
+// ProductVersion such as "10.14.1".
+func macOSXSeriesFromProductVersion(version string) (string, error) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) >= 2 {
+		if series, ok := macOSXVersionSeries[parts[0]+"."+parts[1]]; ok {
+			return series, nil
+		}
+	}
+	return "unknown", errors.Trace(UnknownVersionSeriesError(version))
+}
+
+// macOSXSeriesFromSystemVersion returns the series recorded in the
+// given SystemVersion.plist file.
+func macOSXSeriesFromSystemVersion(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "unknown", errors.Trace(err)
+	}
+	values, err := ParseSystemVersionPlist(data)
+	if err != nil {
+		return "unknown", errors.Annotatef(err, "cannot parse %s", path)
+	}
+	version := values["ProductVersion"]
+	if version == "" {
+		return "unknown", errors.Errorf("%s is missing ProductVersion", path)
+	}
+	return macOSXSeriesFromProductVersion(version)
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/series"
+)
+
+type plistSuite struct{}
+
+var _ = gc.Suite(&plistSuite{})
+
+const systemVersionPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>ProductBuildVersion</key>
+	<string>18B75</string>
+	<key>ProductCopyright</key>
+	<string>1983-2018 Apple Inc.</string>
+	<key>ProductName</key>
+	<string>Mac OS X</string>
+	<key>ProductUserVisibleVersion</key>
+	<string>10.14.1</string>
+	<key>ProductVersion</key>
+	<string>10.14.1</string>
+	<key>iOSSupportVersion</key>
+	<array>
+		<string>12.0</string>
+	</array>
+	<key>Ignored</key>
+	<true/>
+</dict>
+</plist>
+`
+
+func (*plistSuite) TestParseSystemVersionPlist(c *gc.C) {
+	values, err := series.ParseSystemVersionPlist([]byte(systemVersionPlist))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(values, jc.DeepEquals, map[string]string{
+		"ProductBuildVersion":       "18B75",
+		"ProductCopyright":          "1983-2018 Apple Inc.",
+		"ProductName":               "Mac OS X",
+		"ProductUserVisibleVersion": "10.14.1",
+		"ProductVersion":            "10.14.1",
+	})
+}
+
+func (*plistSuite) TestParseSystemVersionPlistInvalid(c *gc.C) {
+	_, err := series.ParseSystemVersionPlist([]byte("<plist><dict><key>"))
+	c.Assert(err, gc.ErrorMatches, "cannot parse plist.*")
+
+	_, err = series.ParseSystemVersionPlist([]byte("<plist><dict></dict></plist>"))
+	c.Assert(err, gc.ErrorMatches, "plist contains no string values")
+}
+
+func (*plistSuite) TestMacOSXSeriesFromSystemVersion(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "SystemVersion.plist")
+	err := ioutil.WriteFile(path, []byte(systemVersionPlist), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	result, err := series.MacOSXSeriesFromSystemVersion(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.Equals, "mojave")
+}
+
+func (*plistSuite) TestMacOSXSeriesFromSystemVersionUnknown(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "SystemVersion.plist")
+	contents := `<plist><dict><key>ProductVersion</key><string>11.0</string></dict></plist>`
+	err := ioutil.WriteFile(path, []byte(contents), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	result, err := series.MacOSXSeriesFromSystemVersion(path)
+	c.Assert(err, jc.Satisfies, series.IsUnknownVersionSeriesError)
+	c.Assert(result, gc.Equals, "unknown")
+}
+
+func (*plistSuite) TestMacOSXSeriesFromSystemVersionMissing(c *gc.C) {
+	_, err := series.MacOSXSeriesFromSystemVersion(filepath.Join(c.MkDir(), "missing"))
+	c.Assert(err, gc.NotNil)
+}
@@ -0,0 +1,12 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// +build !linux,!darwin,!windows
+
+package series
+
+import "runtime"
+
+func prettyName() (string, error) {
+	return runtime.GOOS, nil
+}
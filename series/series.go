@@ -8,9 +8,10 @@ package series
 import (
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/juju/errors"
+	"golang.org/x/net/context"
+
 	"github.com/juju/utils/os"
 )
 
@@ -23,24 +24,36 @@ var (
 	// TODO(katco): Remove globals (lp:1633571)
 	// Override for testing.
 	MustHostSeries = mustHostSeries
-
-	seriesOnce sync.Once
-	// These are filled in by the first call to hostSeries
-	series    string
-	seriesErr error
 )
 
 // HostSeries returns the series of the machine the current process is
 // running on.
 func HostSeries() (string, error) {
-	var err error
-	seriesOnce.Do(func() {
-		series, err = readSeries()
-		if err != nil {
-			seriesErr = errors.Annotate(err, "cannot determine host series")
-		}
-	})
-	return series, seriesErr
+	return HostSeriesContext(context.Background())
+}
+
+// HostSeriesContext returns the series of the machine the current
+// process is running on. If ctx is done before the series has been
+// determined, the context's error is returned; detection continues in
+// the background so that a later call may still succeed.
+func HostSeriesContext(ctx context.Context) (string, error) {
+	return getDefaultDetector().HostSeries(ctx)
+}
+
+// ForceHostSeries returns the series of the machine the current process
+// is running on, ignoring any cached result. Later calls to HostSeries
+// use the new result.
+func ForceHostSeries() (string, error) {
+	return getDefaultDetector().ForceHostSeries(context.Background())
+}
+
+// OSReleaseFields returns the raw fields of the host's os-release file,
+// such as VARIANT, BUILD_ID or VERSION_CODENAME. They are read once
+// along with the host series and cached in the same way. An error
+// satisfying errors.IsNotSupported is returned on operating systems
+// other than Linux.
+func OSReleaseFields() (map[string]string, error) {
+	return getDefaultDetector().OSReleaseFields(context.Background())
 }
 
 // mustHostSeries calls HostSeries and panics if there is an error.
@@ -52,6 +65,27 @@ func mustHostSeries() string {
 	return series
 }
 
+// HostSeriesOrDefault returns the series of the machine the current
+// process is running on, or def if it cannot be determined.
+func HostSeriesOrDefault(def string) string {
+	series, err := HostSeries()
+	if err != nil {
+		logger.Debugf("using default series %q: %v", def, err)
+		return def
+	}
+	return series
+}
+
+// OSFromSeriesOrDefault returns the operating system of the given
+// series, or def if the series is not recognised.
+func OSFromSeriesOrDefault(series string, def os.OSType) os.OSType {
+	operatingSystem, err := GetOSFromSeries(series)
+	if err != nil {
+		return def
+	}
+	return operatingSystem
+}
+
 // MustOSFromSeries will panic if the series represents an "unknown"
 // operating system
 func MustOSFromSeries(series string) os.OSType {
@@ -62,6 +96,45 @@ func MustOSFromSeries(series string) os.OSType {
 	return operatingSystem
 }
 
+// KernelVersion returns the release version of the running kernel,
+// for example "4.15.0-36-generic" on Linux or "17.7.0" on Darwin.
+func KernelVersion() (string, error) {
+	return kernelVersion()
+}
+
+// KernelMajorMinor returns the major and minor portions of the running
+// kernel's version, so that callers can gate features on it.
+func KernelMajorMinor() (major, minor int, err error) {
+	return kernelToMajorMinor(kernelVersion)
+}
+
+// kernelToMajorMinor takes a dotted version and returns the Major and
+// Minor portions. Any non-numeric suffix on the minor portion (such as
+// "-generic") is ignored, and a missing minor portion is reported as 0.
+func kernelToMajorMinor(getKernelVersion func() (string, error)) (int, int, error) {
+	fullVersion, err := getKernelVersion()
+	if err != nil {
+		return 0, 0, err
+	}
+	parts := strings.SplitN(strings.TrimSpace(fullVersion), ".", 3)
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errors.Annotatef(err, "invalid kernel version %q", fullVersion)
+	}
+	if len(parts) == 1 {
+		return major, 0, nil
+	}
+	digits := parts[1]
+	if i := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		digits = digits[:i]
+	}
+	minor, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, 0, errors.Annotatef(err, "invalid kernel version %q", fullVersion)
+	}
+	return major, minor, nil
+}
+
 // kernelToMajor takes a dotted version and returns just the Major portion
 func kernelToMajor(getKernelVersion func() (string, error)) (int, error) {
 	fullVersion, err := getKernelVersion()
@@ -109,10 +182,36 @@ var macOSXSeries = map[int]string{
 	5:  "puma",
 }
 
+// macOSXPrettyNames maps from the Mac OSX series to its marketing name.
+var macOSXPrettyNames = map[string]string{
+	"mojave":       "macOS Mojave",
+	"highsierra":   "macOS High Sierra",
+	"sierra":       "macOS Sierra",
+	"elcapitan":    "OS X El Capitan",
+	"yosemite":     "OS X Yosemite",
+	"mavericks":    "OS X Mavericks",
+	"mountainlion": "OS X Mountain Lion",
+	"lion":         "Mac OS X Lion",
+	"snowleopard":  "Mac OS X Snow Leopard",
+	"leopard":      "Mac OS X Leopard",
+	"tiger":        "Mac OS X Tiger",
+	"panther":      "Mac OS X Panther",
+	"jaguar":       "Mac OS X Jaguar",
+	"puma":         "Mac OS X Puma",
+}
+
+func macOSXPrettyNameFromKernelVersion(getKernelVersion func() (string, error)) (string, error) {
+	series, err := macOSXSeriesFromKernelVersion(getKernelVersion)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return macOSXPrettyNames[series], nil
+}
+
 func macOSXSeriesFromMajorVersion(majorVersion int) (string, error) {
 	series, ok := macOSXSeries[majorVersion]
 	if !ok {
-		return "unknown", errors.Errorf("unknown series %q", series)
+		return "unknown", errors.Trace(UnknownVersionSeriesError(strconv.Itoa(majorVersion)))
 	}
 	return series, nil
 }
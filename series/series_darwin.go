@@ -5,13 +5,39 @@ package series
 
 import (
 	"syscall"
+
+	"github.com/juju/errors"
 )
 
+var kernelVersion = sysctlVersion
+
 func sysctlVersion() (string, error) {
 	return syscall.Sysctl("kern.osrelease")
 }
 
 // readSeries returns the best approximation to what version this machine is.
 func readSeries() (string, error) {
-	return macOSXSeriesFromKernelVersion(sysctlVersion)
+	return readSeriesFrom(ReleasePaths{})
+}
+
+// readSeriesFrom returns the series using the version in the given
+// SystemVersion.plist if possible, falling back to the kernel version.
+func readSeriesFrom(paths ReleasePaths) (string, error) {
+	path := pathOrDefault(paths.SystemVersion, systemVersionFile)
+	series, err := macOSXSeriesFromSystemVersion(path)
+	if err == nil {
+		return series, nil
+	}
+	logger.Debugf("cannot determine series from %s: %v", path, err)
+	plistErr := err
+	series, err = macOSXSeriesFromKernelVersion(kernelVersion)
+	if err != nil {
+		return series, errors.Annotatef(err, "cannot determine series from %s (%v) or from kern.osrelease sysctl", path, plistErr)
+	}
+	return series, nil
+}
+
+// prettyName returns the marketing name of this version of macOS.
+func prettyName() (string, error) {
+	return macOSXPrettyNameFromKernelVersion(sysctlVersion)
 }
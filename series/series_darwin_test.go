@@ -4,6 +4,9 @@
 package series
 
 import (
+	"path/filepath"
+
+	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils/set"
 	gc "gopkg.in/check.v1"
@@ -29,3 +32,13 @@ func (s *macOSXSeriesSuite) TestOSVersion(c *gc.C) {
 	c.Assert(err, jc.ErrorIsNil)
 	c.Check(version, jc.Satisfies, knownSeries.Contains)
 }
+
+func (s *macOSXSeriesSuite) TestReadSeriesFromReportsBothFailures(c *gc.C) {
+	defer func(orig func() (string, error)) { kernelVersion = orig }(kernelVersion)
+	kernelVersion = func() (string, error) {
+		return "", errors.New("sysctl failed")
+	}
+	path := filepath.Join(c.MkDir(), "SystemVersion.plist")
+	_, err := readSeriesFrom(ReleasePaths{SystemVersion: path})
+	c.Assert(err, gc.ErrorMatches, `cannot determine series from .*SystemVersion.plist \(.*no such file or directory\) or from kern.osrelease sysctl: sysctl failed`)
+}
@@ -6,7 +6,9 @@ package series
 import (
 	"encoding/csv"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"strings"
 	"time"
 
@@ -18,23 +20,129 @@ var (
 	// osReleaseFile is the name of the file that is read in order to determine
 	// the linux type release version.
 	osReleaseFile = "/etc/os-release"
+
+	// debianVersionFile is read to determine the release of Debian
+	// based systems that have neither os-release nor lsb_release.
+	debianVersionFile = "/etc/debian_version"
+
+	// kernelReleaseFile holds the release version of the running kernel.
+	kernelReleaseFile = "/proc/sys/kernel/osrelease"
 )
 
 func readSeries() (string, error) {
-	values, err := jujuos.ReadOSRelease(osReleaseFile)
+	return seriesFromOSReleaseResult(readOSRelease())
+}
+
+// readHostRelease reads the os-release fields once, and determines the
+// series from them.
+func readHostRelease(paths ReleasePaths) hostRelease {
+	values, err := readOSReleaseFrom(
+		pathOrDefault(paths.OSRelease, osReleaseFile),
+		pathOrDefault(paths.DebianVersion, debianVersionFile),
+	)
+	series, seriesErr := seriesFromOSReleaseResult(values, err)
+	return hostRelease{
+		series:    series,
+		seriesErr: seriesErr,
+		fields:    values,
+		fieldsErr: err,
+	}
+}
+
+// seriesFromOSReleaseResult returns the series for the result of
+// readOSRelease.
+func seriesFromOSReleaseResult(values map[string]string, err error) (string, error) {
+	if errors.IsNotFound(err) {
+		logger.Debugf("%v, assuming %s", err, genericLinuxSeries)
+		return genericLinuxSeries, nil
+	}
 	if err != nil {
 		return "unknown", err
 	}
+	seriesVersionsMutex.Lock()
 	updateSeriesVersionsOnce()
+	seriesVersionsMutex.Unlock()
 	return seriesFromOSRelease(values)
 }
 
+// lsbRelease returns the output of "lsb_release -a". It is a variable
+// so it can be overridden for testing.
+var lsbRelease = func() (string, error) {
+	out, err := exec.Command("lsb_release", "-a").Output()
+	return string(out), err
+}
+
+// readOSRelease returns the os-release values for the host.
+func readOSRelease() (map[string]string, error) {
+	return readOSReleaseFrom(osReleaseFile, debianVersionFile)
+}
+
+// readOSReleaseFrom returns the values in the given os-release file. If
+// the file does not exist, the output of lsb_release and then the
+// contents of the given debian_version file are tried in its place. A
+// NotFound error describing why each source could not be used is
+// returned if none of them are available.
+func readOSReleaseFrom(osReleaseFile, debianVersionFile string) (map[string]string, error) {
+	values, err := jujuos.ReadOSRelease(osReleaseFile)
+	if err == nil {
+		return values, nil
+	}
+	if _, ok := err.(*os.PathError); !ok {
+		return nil, errors.Annotatef(err, "cannot parse %s", osReleaseFile)
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Annotatef(err, "cannot read %s", osReleaseFile)
+	}
+	failures := []string{osReleaseFile + ": " + describeFileError(err)}
+
+	out, err := lsbRelease()
+	if err == nil {
+		values, err := ParseLSBRelease(out)
+		if err == nil {
+			return values, nil
+		}
+		failures = append(failures, "lsb_release: cannot parse output: "+err.Error())
+	} else {
+		failures = append(failures, "lsb_release: "+err.Error())
+	}
+
+	data, err := ioutil.ReadFile(debianVersionFile)
+	if err == nil {
+		values, err := ParseDebianVersion(string(data))
+		if err == nil {
+			return values, nil
+		}
+		failures = append(failures, debianVersionFile+": cannot parse: "+err.Error())
+	} else {
+		failures = append(failures, debianVersionFile+": "+describeFileError(err))
+	}
+	return nil, errors.NewNotFound(nil, "no OS release information found ("+strings.Join(failures, "; ")+")")
+}
+
+// describeFileError returns a short description of why a file could not
+// be read.
+func describeFileError(err error) string {
+	switch {
+	case os.IsNotExist(err):
+		return "missing"
+	case os.IsPermission(err):
+		return "permission denied"
+	}
+	return err.Error()
+}
+
 func seriesFromOSRelease(values map[string]string) (string, error) {
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
 	switch values["ID"] {
 	case strings.ToLower(jujuos.Ubuntu.String()):
 		return getValue(ubuntuSeries, values["VERSION_ID"])
+	case "ubuntu-core":
+		return getValue(ubuntuCoreSeries, values["VERSION_ID"])
 	case strings.ToLower(jujuos.CentOS.String()):
-		codename := fmt.Sprintf("%s%s", values["ID"], values["VERSION_ID"])
+		// Point releases, such as "7.9", are aliases of the
+		// major release.
+		codename := resolveSeriesAlias(fmt.Sprintf("%s%s", values["ID"], values["VERSION_ID"]))
 		return getValue(centosSeries, codename)
 	case strings.ToLower(jujuos.OpenSUSE.String()):
 		codename := fmt.Sprintf("%s%s",
@@ -42,30 +150,101 @@ func seriesFromOSRelease(values map[string]string) (string, error) {
 			strings.Split(values["VERSION_ID"], ".")[0])
 		return getValue(opensuseSeries, codename)
 	default:
+		if series, ok := ubuntuDerivativeSeries(values); ok {
+			logger.Debugf("treating %q as Ubuntu derivative of series %q", values["ID"], series)
+			return series, nil
+		}
+		logger.Debugf("unrecognised distribution %q version %q, using %s",
+			values["ID"], values["VERSION_ID"], genericLinuxSeries)
 		return genericLinuxSeries, nil
 	}
 }
 
+// ubuntuDerivativeSeries returns the Ubuntu series that a derivative
+// distribution, such as Linux Mint, Pop!_OS or elementary OS, is based
+// on. It returns false if the distribution is not an Ubuntu derivative
+// or the underlying series cannot be determined.
+func ubuntuDerivativeSeries(values map[string]string) (string, bool) {
+	var isDerivative bool
+	for _, like := range strings.Fields(values["ID_LIKE"]) {
+		if like == strings.ToLower(jujuos.Ubuntu.String()) {
+			isDerivative = true
+			break
+		}
+	}
+	if !isDerivative {
+		return "", false
+	}
+	if codename := values["UBUNTU_CODENAME"]; codename != "" {
+		if _, ok := ubuntuSeries[codename]; ok {
+			return codename, true
+		}
+	}
+	// Some derivatives use the Ubuntu version as their own.
+	series, err := getValue(ubuntuSeries, values["VERSION_ID"])
+	return series, err == nil
+}
+
+// prettyName returns the human readable name of the distribution.
+func prettyName() (string, error) {
+	values, err := readOSRelease()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if name := values["PRETTY_NAME"]; name != "" {
+		return name, nil
+	}
+	if name := values["NAME"]; name != "" {
+		return strings.TrimSpace(name + " " + values["VERSION"]), nil
+	}
+	return "Linux", nil
+}
+
+// readOSInfo returns the distribution details from os-release (or its
+// fallbacks), leaving them empty if they can't be read.
+func readOSInfo() OSInfo {
+	values, err := readOSRelease()
+	if err != nil {
+		logger.Debugf("cannot read OS release information: %v", err)
+		return OSInfo{}
+	}
+	return OSInfo{
+		ID:         values["ID"],
+		VersionID:  values["VERSION_ID"],
+		PrettyName: values["PRETTY_NAME"],
+	}
+}
+
 func getValue(from map[string]string, val string) (string, error) {
 	for serie, ver := range from {
 		if ver == val {
 			return serie, nil
 		}
 	}
-	return "unknown", errors.New("could not determine series")
+	return "unknown", errors.Trace(UnknownVersionSeriesError(val))
 }
 
 // ReleaseVersion looks for the value of VERSION_ID in the content of
-// the os-release.  If the value is not found, the file is not found, or
-// an error occurs reading the file, an empty string is returned.
+// the os-release, falling back to lsb_release and debian_version if the
+// file does not exist.  If the value is not found, none of those sources
+// are available, or an error occurs reading them, an empty string is
+// returned.
 func ReleaseVersion() string {
-	release, err := jujuos.ReadOSRelease(osReleaseFile)
+	release, err := readOSRelease()
 	if err != nil {
 		return ""
 	}
 	return release["VERSION_ID"]
 }
 
+func kernelVersion() (string, error) {
+	data, err := ioutil.ReadFile(kernelReleaseFile)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
 func updateLocalSeriesVersions() error {
 	return updateDistroInfo()
 }
@@ -101,7 +280,7 @@ func updateDistroInfo() error {
 	var foundPrecise bool
 	for _, fields := range records {
 		var version, series string
-		var release string
+		var release, eol string
 		for i, field := range fields {
 			if i >= len(fieldNames) {
 				break
@@ -113,6 +292,8 @@ func updateDistroInfo() error {
 				series = field
 			case "release":
 				release = field
+			case "eol":
+				eol = field
 			}
 		}
 		if version == "" || series == "" || release == "" {
@@ -132,6 +313,12 @@ func updateDistroInfo() error {
 			continue
 		}
 
+		dates := seriesDates{release: releaseDate}
+		if eolDate, err := time.Parse("2006-01-02", eol); err == nil {
+			dates.eol = eolDate
+		}
+		ubuntuDates[series] = dates
+
 		// The numeric version may contain a LTS moniker so strip that out.
 		trimmedVersion := strings.TrimSuffix(version, " LTS")
 		seriesVersions[series] = trimmedVersion
@@ -5,10 +5,13 @@ package series_test
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 
+	"github.com/juju/errors"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/utils/series"
@@ -38,6 +41,10 @@ func (s *linuxVersionSuite) SetUpTest(c *gc.C) {
 
 	cleanup := series.SetSeriesVersions(make(map[string]string))
 	s.AddCleanup(func(*gc.C) { cleanup() })
+	s.PatchValue(series.LSBRelease, func() (string, error) {
+		return "", errors.New("lsb_release not found")
+	})
+	s.PatchValue(series.DebianVersionFile, filepath.Join(c.MkDir(), "debian_version"))
 }
 
 func (s *linuxVersionSuite) TestOSVersion(c *gc.C) {
@@ -164,6 +171,13 @@ VERSION_ID='12.04'
 	`NAME="CentOS Linux"
 ID="centos"
 VERSION_ID="7"
+`,
+	"centos7",
+	"",
+}, {
+	`NAME="CentOS Linux"
+ID="centos"
+VERSION_ID="7.9.2009"
 `,
 	"centos7",
 	"",
@@ -221,19 +235,71 @@ VERSION_ID="12"
 
 	"",
 	"unknown",
-	"OS release file is missing ID",
+	"cannot parse .*: OS release file is missing ID",
 }, {
 	`NAME="CentOS Linux"
 ID="centos"
 `,
 	"unknown",
-	"could not determine series",
+	`unknown series for version: "centos"`,
 }, {
 	`NAME=openSUSE
 ID=opensuse
 VERSION_ID="42.3"`,
 	"opensuseleap",
 	"",
+}, {
+	`NAME="Ubuntu Core"
+VERSION="18"
+ID=ubuntu-core
+PRETTY_NAME="Ubuntu Core 18"
+VERSION_ID="18"
+`,
+	"core18",
+	"",
+}, {
+	`NAME="Ubuntu Core"
+ID=ubuntu-core
+VERSION_ID="99"
+`,
+	"unknown",
+	`unknown series for version: "99"`,
+}, {
+	`NAME="Linux Mint"
+VERSION="19 (Tara)"
+ID=linuxmint
+ID_LIKE=ubuntu
+VERSION_ID="19"
+UBUNTU_CODENAME=bionic
+`,
+	"bionic",
+	"",
+}, {
+	`NAME="Pop!_OS"
+ID=pop
+ID_LIKE="ubuntu debian"
+VERSION_ID="18.04"
+`,
+	"bionic",
+	"",
+}, {
+	`NAME="elementary OS"
+VERSION="5.0 Juno"
+ID=elementary
+ID_LIKE=ubuntu
+VERSION_ID="5.0"
+UBUNTU_CODENAME=notaseries
+`,
+	"genericlinux",
+	"",
+}, {
+	`NAME="Debian GNU/Linux"
+ID=debian
+VERSION_ID="9"
+UBUNTU_CODENAME=bionic
+`,
+	"genericlinux",
+	"",
 },
 }
 
@@ -255,3 +321,241 @@ func (s *readSeriesSuite) TestReadSeries(c *gc.C) {
 		c.Assert(series, gc.Equals, t.series)
 	}
 }
+
+var lsbReleaseOutput = `Distributor ID:	Ubuntu
+Description:	Ubuntu 16.04.5 LTS
+Release:	16.04
+Codename:	xenial
+`
+
+func (s *readSeriesSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	d := c.MkDir()
+	s.PatchValue(series.OSReleaseFile, filepath.Join(d, "os-release"))
+	s.PatchValue(series.DebianVersionFile, filepath.Join(d, "debian_version"))
+	s.PatchValue(series.LSBRelease, func() (string, error) {
+		return "", errors.New("lsb_release not found")
+	})
+}
+
+func (s *readSeriesSuite) TestReadSeriesFallbackLSBRelease(c *gc.C) {
+	s.PatchValue(series.LSBRelease, func() (string, error) {
+		return lsbReleaseOutput, nil
+	})
+	series, err := series.ReadSeries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(series, gc.Equals, "xenial")
+}
+
+func (s *readSeriesSuite) TestReadSeriesFallbackDebianVersion(c *gc.C) {
+	err := ioutil.WriteFile(*series.DebianVersionFile, []byte("9.5\n"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	series, err := series.ReadSeries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(series, gc.Equals, "genericlinux")
+}
+
+func (s *readSeriesSuite) TestReadSeriesNoReleaseInfo(c *gc.C) {
+	series, err := series.ReadSeries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(series, gc.Equals, "genericlinux")
+}
+
+func (s *readSeriesSuite) TestReleaseVersionFallbackLSBRelease(c *gc.C) {
+	s.PatchValue(series.LSBRelease, func() (string, error) {
+		return lsbReleaseOutput, nil
+	})
+	c.Assert(series.ReleaseVersion(), gc.Equals, "16.04")
+}
+
+type kernelReleaseSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&kernelReleaseSuite{})
+
+func (s *kernelReleaseSuite) TestKernelVersion(c *gc.C) {
+	filename := filepath.Join(c.MkDir(), "osrelease")
+	s.PatchValue(series.KernelReleaseFile, filename)
+	err := ioutil.WriteFile(filename, []byte("4.15.0-36-generic\n"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	version, err := series.KernelVersion()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(version, gc.Equals, "4.15.0-36-generic")
+
+	major, minor, err := series.KernelMajorMinor()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(major, gc.Equals, 4)
+	c.Check(minor, gc.Equals, 15)
+}
+
+func (s *kernelReleaseSuite) TestKernelVersionMissingFile(c *gc.C) {
+	s.PatchValue(series.KernelReleaseFile, filepath.Join(c.MkDir(), "missing"))
+	_, err := series.KernelVersion()
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *readSeriesSuite) TestReadOSInfoGenericLinux(c *gc.C) {
+	err := ioutil.WriteFile(*series.OSReleaseFile, []byte(`NAME="Arch Linux"
+ID=arch
+PRETTY_NAME="Arch Linux"
+`), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	version, err := series.ReadSeries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(version, gc.Equals, "genericlinux")
+	c.Assert(series.ReadOSInfo(), jc.DeepEquals, series.OSInfo{
+		ID:         "arch",
+		PrettyName: "Arch Linux",
+	})
+}
+
+func (s *readSeriesSuite) TestReadOSInfoFedora(c *gc.C) {
+	err := ioutil.WriteFile(*series.OSReleaseFile, []byte(`NAME=Fedora
+ID=fedora
+VERSION_ID=24
+PRETTY_NAME="Fedora 24 (Twenty Four)"
+`), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(series.ReadOSInfo(), jc.DeepEquals, series.OSInfo{
+		ID:         "fedora",
+		VersionID:  "24",
+		PrettyName: "Fedora 24 (Twenty Four)",
+	})
+}
+
+func (s *readSeriesSuite) TestReadOSInfoMissing(c *gc.C) {
+	c.Assert(series.ReadOSInfo(), jc.DeepEquals, series.OSInfo{})
+}
+
+func (s *readSeriesSuite) TestPrettyName(c *gc.C) {
+	for i, test := range []struct {
+		contents string
+		expected string
+	}{
+		{"ID=ubuntu\nPRETTY_NAME=\"Ubuntu 18.04.1 LTS\"\n", "Ubuntu 18.04.1 LTS"},
+		{"ID=centos\nNAME=\"CentOS Linux\"\nVERSION=\"7 (Core)\"\n", "CentOS Linux 7 (Core)"},
+		{"ID=arch\n", "Linux"},
+	} {
+		c.Logf("test %d", i)
+		err := ioutil.WriteFile(*series.OSReleaseFile, []byte(test.contents), 0644)
+		c.Assert(err, jc.ErrorIsNil)
+		name, err := series.PrettyName()
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(name, gc.Equals, test.expected)
+	}
+}
+
+func (s *readSeriesSuite) TestPrettyNameMissing(c *gc.C) {
+	_, err := series.PrettyName()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *readSeriesSuite) TestReadOSReleaseFailures(c *gc.C) {
+	s.PatchValue(series.LSBRelease, func() (string, error) {
+		return "junk", nil
+	})
+	err := os.Mkdir(*series.DebianVersionFile, 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = series.PrettyName()
+	c.Assert(err, gc.ErrorMatches, `no OS release information found \(`+
+		`.*/os-release: missing; `+
+		`lsb_release: cannot parse output: lsb_release output is missing Distributor ID; `+
+		`.*/debian_version: read .*: is a directory\)`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *readSeriesSuite) TestReadOSReleaseUnreadable(c *gc.C) {
+	err := os.Mkdir(*series.OSReleaseFile, 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = series.ReadSeries()
+	c.Assert(err, gc.ErrorMatches, `cannot read .*/os-release: read .*: is a directory`)
+}
+
+func (s *readSeriesSuite) TestOSReleaseFields(c *gc.C) {
+	contents := `NAME="Fedora"
+VERSION_ID=28
+VARIANT="Workstation Edition"
+ID=fedora
+`
+	err := ioutil.WriteFile(*series.OSReleaseFile, []byte(contents), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	patchDefaultDetector(&s.CleanupSuite, series.NewDetector())
+
+	fields, err := series.OSReleaseFields()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fields["VARIANT"], gc.Equals, "Workstation Edition")
+	c.Assert(fields["ID"], gc.Equals, "fedora")
+	hostSeries, err := series.HostSeries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hostSeries, gc.Equals, "genericlinux")
+}
+
+func (s *readSeriesSuite) TestOSReleaseFieldsMissing(c *gc.C) {
+	patchDefaultDetector(&s.CleanupSuite, series.NewDetector())
+	_, err := series.OSReleaseFields()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	hostSeries, err := series.HostSeries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hostSeries, gc.Equals, "genericlinux")
+}
+
+func (s *readSeriesSuite) TestDetectorWithPaths(c *gc.C) {
+	dir := c.MkDir()
+	osRelease := filepath.Join(dir, "os-release")
+	d := series.NewDetectorWithPaths(series.CacheForever, 0, nil, series.ReleasePaths{
+		OSRelease: osRelease,
+	})
+
+	// Nothing is read until the series is requested.
+	contents := "ID=ubuntu\nVERSION_ID=\"16.04\"\n"
+	err := ioutil.WriteFile(osRelease, []byte(contents), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	hostSeries, err := d.HostSeries(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(hostSeries, gc.Equals, "xenial")
+	fields, err := d.OSReleaseFields(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fields["VERSION_ID"], gc.Equals, "16.04")
+}
+
+func (s *readSeriesSuite) TestConcurrentDetectors(c *gc.C) {
+	// Run with -race to check that detectors updating the known
+	// series don't race with each other or with lookups.
+	cleanup := series.SetSeriesVersions(make(map[string]string))
+	s.AddCleanup(func(*gc.C) { cleanup() })
+	osRelease := filepath.Join(c.MkDir(), "os-release")
+	err := ioutil.WriteFile(osRelease, []byte("ID=ubuntu\nVERSION_ID=\"16.04\"\n"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+
+	results := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		d := series.NewDetectorWithPaths(series.NoCache, 0, nil, series.ReleasePaths{
+			OSRelease: osRelease,
+		})
+		go func() {
+			hostSeries, err := d.ForceHostSeries(context.Background())
+			c.Check(err, jc.ErrorIsNil)
+			results <- hostSeries
+		}()
+	}
+	series.SupportedSeries()
+	c.Assert(<-results, gc.Equals, "xenial")
+	c.Assert(<-results, gc.Equals, "xenial")
+}
+
+func (s *readSeriesSuite) TestDetectorWithPathsDebianVersion(c *gc.C) {
+	dir := c.MkDir()
+	debianVersion := filepath.Join(dir, "debian_version")
+	err := ioutil.WriteFile(debianVersion, []byte("buster/sid\n"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	d := series.NewDetectorWithPaths(series.CacheForever, 0, nil, series.ReleasePaths{
+		OSRelease:     filepath.Join(dir, "os-release"),
+		DebianVersion: debianVersion,
+	})
+	fields, err := d.OSReleaseFields(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fields, jc.DeepEquals, map[string]string{"ID": "debian", "VERSION_CODENAME": "buster"})
+}
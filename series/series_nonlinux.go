@@ -5,6 +5,12 @@
 
 package series
 
+import (
+	"runtime"
+
+	"github.com/juju/errors"
+)
+
 // TODO(ericsnow) Refactor dependents so we can remove this for non-linux.
 
 // ReleaseVersion is a function that has no meaning except on linux.
@@ -12,6 +18,21 @@ func ReleaseVersion() string {
 	return ""
 }
 
+// readHostRelease determines the series. There are no os-release
+// fields other than on Linux.
+func readHostRelease(paths ReleasePaths) hostRelease {
+	series, err := readSeriesFrom(paths)
+	return hostRelease{
+		series:    series,
+		seriesErr: err,
+		fieldsErr: errors.NotSupportedf("os-release on %s", runtime.GOOS),
+	}
+}
+
+func readOSInfo() OSInfo {
+	return OSInfo{}
+}
+
 func updateLocalSeriesVersions() error {
 	return nil
 }
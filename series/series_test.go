@@ -64,6 +64,42 @@ func (*kernelVersionSuite) TestKernelToMajorVersionEmpty(c *gc.C) {
 	c.Check(majorVersion, gc.Equals, 0)
 }
 
+func (*kernelVersionSuite) TestKernelToMajorMinor(c *gc.C) {
+	for i, test := range []struct {
+		version string
+		major   int
+		minor   int
+		err     string
+	}{
+		{version: "13.1.0", major: 13, minor: 1},
+		{version: "4.15.0-36-generic", major: 4, minor: 15},
+		{version: "5.4-rc1", major: 5, minor: 4},
+		{version: "1234", major: 1234},
+		{version: "a.b.c", err: `invalid kernel version "a.b.c": .*`},
+		{version: "4.x", err: `invalid kernel version "4.x": .*`},
+	} {
+		c.Logf("test %d: %q", i, test.version)
+		version := test.version
+		major, minor, err := series.KernelToMajorMinor(func() (string, error) {
+			return version, nil
+		})
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(major, gc.Equals, test.major)
+		c.Check(minor, gc.Equals, test.minor)
+	}
+}
+
+func (*kernelVersionSuite) TestKernelToMajorMinorError(c *gc.C) {
+	major, minor, err := series.KernelToMajorMinor(sysctlError)
+	c.Assert(err, gc.ErrorMatches, "no such syscall")
+	c.Check(major, gc.Equals, 0)
+	c.Check(minor, gc.Equals, 0)
+}
+
 func (*kernelVersionSuite) TestMacOSXSeriesFromKernelVersion(c *gc.C) {
 	series, err := series.MacOSXSeriesFromKernelVersion(sysctlMacOS10dot9dot2)
 	c.Assert(err, jc.ErrorIsNil)
@@ -91,16 +127,26 @@ func (*kernelVersionSuite) TestMacOSXSeries(c *gc.C) {
 		{version: 15, series: "elcapitan"},
 		{version: 16, series: "sierra"},
 		{version: 18, series: "mojave"},
-		{version: 4, series: "unknown", err: `unknown series ""`},
-		{version: 0, series: "unknown", err: `unknown series ""`},
+		{version: 4, series: "unknown", err: `unknown series for version: "4"`},
+		{version: 0, series: "unknown", err: `unknown series for version: "0"`},
 	}
 	for _, test := range tests {
-		series, err := series.MacOSXSeriesFromMajorVersion(test.version)
+		result, err := series.MacOSXSeriesFromMajorVersion(test.version)
 		if test.err != "" {
 			c.Assert(err, gc.ErrorMatches, test.err)
+			c.Assert(series.IsUnknownVersionSeriesError(err), jc.IsTrue)
 		} else {
 			c.Assert(err, jc.ErrorIsNil)
 		}
-		c.Check(series, gc.Equals, test.series)
+		c.Check(result, gc.Equals, test.series)
 	}
 }
+
+func (*kernelVersionSuite) TestMacOSXPrettyNameFromKernelVersion(c *gc.C) {
+	name, err := series.MacOSXPrettyNameFromKernelVersion(sysctlMacOS10dot9dot2)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(name, gc.Equals, "OS X Mavericks")
+
+	_, err = series.MacOSXPrettyNameFromKernelVersion(sysctlError)
+	c.Assert(err, gc.ErrorMatches, "no such syscall")
+}
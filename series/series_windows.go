@@ -25,29 +25,42 @@ var (
 func getVersionFromRegistry() (string, error) {
 	k, err := registry.OpenKey(registry.LOCAL_MACHINE, currentVersionKey, registry.QUERY_VALUE)
 	if err != nil {
-		return "", errors.Trace(err)
+		return "", errors.Annotatef(err, "cannot open registry key %q", currentVersionKey)
 	}
 	defer k.Close()
 	s, _, err := k.GetStringValue("ProductName")
 	if err != nil {
-		return "", errors.Trace(err)
+		return "", errors.Annotatef(err, "cannot read ProductName from registry key %q", currentVersionKey)
 	}
 
 	return s, nil
 }
 
+// prettyName returns the Windows product name.
+func prettyName() (string, error) {
+	return getVersionFromRegistry()
+}
+
+// readSeriesFrom returns the series from the registry. There are no
+// release files to read on Windows, so paths is ignored.
+func readSeriesFrom(paths ReleasePaths) (string, error) {
+	return readSeries()
+}
+
 func readSeries() (string, error) {
 	ver, err := getVersionFromRegistry()
 	if err != nil {
 		return "unknown", errors.Trace(err)
 	}
 
-	var lookAt = windowsVersions
-
 	isNano, err := isWindowsNano()
-	if err != nil && os.IsNotExist(err) {
+	if err != nil && os.IsNotExist(errors.Cause(err)) {
 		return "unknown", errors.Trace(err)
 	}
+
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
+	var lookAt = windowsVersions
 	if isNano {
 		lookAt = windowsNanoVersions
 	}
@@ -59,19 +72,19 @@ func readSeries() (string, error) {
 			}
 		}
 	}
-	return "unknown", errors.Errorf("unknown series %q", ver)
+	return "unknown", errors.Trace(UnknownVersionSeriesError(ver))
 }
 
 func isWindowsNano() (bool, error) {
 	k, err := registry.OpenKey(registry.LOCAL_MACHINE, isNanoKey, registry.QUERY_VALUE)
 	if err != nil {
-		return false, errors.Trace(err)
+		return false, errors.Annotatef(err, "cannot open registry key %q", isNanoKey)
 	}
 	defer k.Close()
 
 	s, _, err := k.GetIntegerValue("NanoServer")
 	if err != nil {
-		return false, errors.Trace(err)
+		return false, errors.Annotatef(err, "cannot read NanoServer from registry key %q", isNanoKey)
 	}
 	return s == 1, nil
 }
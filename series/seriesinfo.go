@@ -0,0 +1,85 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils/os"
+)
+
+// SeriesInfo holds everything we know about a series.
+type SeriesInfo struct {
+	// Series is the name of the series, for example "bionic".
+	Series string
+
+	// OS is the operating system of the series.
+	OS os.OSType
+
+	// Version is the version of the series, for example "18.04". It
+	// is empty if the version is not known.
+	Version string
+
+	// LTS is true if the series is an Ubuntu long term support release.
+	LTS bool
+
+	// Released and EOL are the release and end of life dates of the
+	// series. They are zero if not known.
+	Released time.Time
+	EOL      time.Time
+
+	// Aliases holds the other names by which the series is known,
+	// such as the Windows product names and registered aliases that
+	// map to it.
+	Aliases []string
+}
+
+// GetSeriesInfo returns the information we have about the given series.
+func GetSeriesInfo(series string) (SeriesInfo, error) {
+	osType, err := GetOSFromSeries(series)
+	if err != nil {
+		return SeriesInfo{}, errors.Trace(err)
+	}
+	version, err := SeriesVersion(series)
+	if err != nil && !IsUnknownSeriesVersionError(err) {
+		return SeriesInfo{}, errors.Trace(err)
+	}
+
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
+	info := SeriesInfo{
+		Series:   series,
+		OS:       osType,
+		Version:  version,
+		LTS:      ubuntuLTS[series],
+		Released: ubuntuDates[series].release,
+		EOL:      ubuntuDates[series].eol,
+		Aliases:  seriesAliases(series),
+	}
+	return info, nil
+}
+
+// seriesAliases returns the other names that map to series, sorted.
+func seriesAliases(series string) []string {
+	var aliases []string
+	for name, s := range windowsVersions {
+		if s == series {
+			aliases = append(aliases, name)
+		}
+	}
+	for name, s := range windowsNanoVersions {
+		if s == series {
+			aliases = append(aliases, name)
+		}
+	}
+	for name, s := range aliasSeries {
+		if s == series {
+			aliases = append(aliases, name)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"time"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/os"
+	"github.com/juju/utils/series"
+)
+
+type seriesInfoSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&seriesInfoSuite{})
+
+func (s *seriesInfoSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	cleanup := series.SetSeriesVersions(map[string]string{
+		"xenial":    "16.04",
+		"bionic":    "18.04",
+		"win2012r2": "win2012r2",
+	})
+	s.AddCleanup(func(*gc.C) { cleanup() })
+}
+
+func (s *seriesInfoSuite) TestGetSeriesInfoUbuntu(c *gc.C) {
+	info, err := series.GetSeriesInfo("xenial")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info, jc.DeepEquals, series.SeriesInfo{
+		Series:   "xenial",
+		OS:       os.Ubuntu,
+		Version:  "16.04",
+		LTS:      true,
+		Released: time.Date(2016, 4, 21, 0, 0, 0, 0, time.UTC),
+		EOL:      time.Date(2021, 4, 30, 0, 0, 0, 0, time.UTC),
+	})
+}
+
+func (s *seriesInfoSuite) TestGetSeriesInfoWindows(c *gc.C) {
+	info, err := series.GetSeriesInfo("win2012r2")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info, jc.DeepEquals, series.SeriesInfo{
+		Series:  "win2012r2",
+		OS:      os.Windows,
+		Version: "win2012r2",
+		Aliases: []string{"Windows Server 2012 R2", "Windows Storage Server 2012 R2", "win2k12r2"},
+	})
+}
+
+func (s *seriesInfoSuite) TestGetSeriesInfoUnknownVersion(c *gc.C) {
+	info, err := series.GetSeriesInfo("mojave")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info, jc.DeepEquals, series.SeriesInfo{
+		Series: "mojave",
+		OS:     os.OSX,
+	})
+}
+
+func (s *seriesInfoSuite) TestGetSeriesInfoUnknownSeries(c *gc.C) {
+	_, err := series.GetSeriesInfo("plan9")
+	c.Assert(err, gc.ErrorMatches, `unknown OS for series: "plan9"`)
+	c.Assert(series.IsUnknownOSForSeriesError(err), jc.IsTrue)
+}
@@ -5,8 +5,10 @@ package series
 
 import (
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
@@ -18,39 +20,49 @@ var (
 	logger = loggo.GetLogger("juju.juju.series")
 )
 
-type unknownOSForSeriesError string
+// UnknownOSForSeriesError is returned when the operating system for a
+// series cannot be determined. Its value is the series.
+type UnknownOSForSeriesError string
 
-func (e unknownOSForSeriesError) Error() string {
+func (e UnknownOSForSeriesError) Error() string {
 	return `unknown OS for series: "` + string(e) + `"`
 }
 
-// IsUnknownOSForSeriesError returns true if err is of type unknownOSForSeriesError.
+// IsUnknownOSForSeriesError returns true if err is of type
+// UnknownOSForSeriesError.
 func IsUnknownOSForSeriesError(err error) bool {
-	_, ok := errors.Cause(err).(unknownOSForSeriesError)
+	_, ok := errors.Cause(err).(UnknownOSForSeriesError)
 	return ok
 }
 
-type unknownSeriesVersionError string
+// UnknownSeriesVersionError is returned when the version for a series
+// cannot be determined. Its value is the series.
+type UnknownSeriesVersionError string
 
-func (e unknownSeriesVersionError) Error() string {
+func (e UnknownSeriesVersionError) Error() string {
 	return `unknown version for series: "` + string(e) + `"`
 }
 
-// IsUnknownSeriesVersionError returns true if err is of type unknownSeriesVersionError.
+// IsUnknownSeriesVersionError returns true if err is of type
+// UnknownSeriesVersionError.
 func IsUnknownSeriesVersionError(err error) bool {
-	_, ok := errors.Cause(err).(unknownSeriesVersionError)
+	_, ok := errors.Cause(err).(UnknownSeriesVersionError)
 	return ok
 }
 
-type unknownVersionSeriesError string
+// UnknownVersionSeriesError is returned when the series for a version
+// cannot be determined. Its value is the version, which may be an OS
+// release version, a Darwin kernel version or a Windows product name.
+type UnknownVersionSeriesError string
 
-func (e unknownVersionSeriesError) Error() string {
+func (e UnknownVersionSeriesError) Error() string {
 	return `unknown series for version: "` + string(e) + `"`
 }
 
-// IsUnknownVersionSeriesError returns true if err is of type unknownVersionSeriesError.
+// IsUnknownVersionSeriesError returns true if err is of type
+// UnknownVersionSeriesError.
 func IsUnknownVersionSeriesError(err error) bool {
-	_, ok := errors.Cause(err).(unknownVersionSeriesError)
+	_, ok := errors.Cause(err).(UnknownVersionSeriesError)
 	return ok
 }
 
@@ -117,6 +129,17 @@ var ubuntuSeries = map[string]string{
 	"bionic":  "18.04",
 }
 
+// ubuntuCoreSeries maps the series of Ubuntu Core (snap based) systems
+// to the VERSION_ID in their os-release. Ubuntu Core is reported as the
+// Ubuntu OS, but its series are kept distinct from classic Ubuntu as
+// package management and filesystem layout differ.
+var ubuntuCoreSeries = map[string]string{
+	"core16": "16",
+	"core18": "18",
+	"core20": "20",
+	"core22": "22",
+}
+
 // ubuntuLTS provides a lookup for current LTS series.  Like seriesVersions,
 // the values here are current at the time of writing. On Ubuntu systems this
 // map is updated by updateDistroInfo, using data from
@@ -129,59 +152,39 @@ var ubuntuLTS = map[string]bool{
 	"bionic":  true,
 }
 
-// Windows versions come in various flavors:
-// Standard, Datacenter, etc. We use string prefix match them to one
-// of the following. Specify the longest name in a particular series first
-// For example, if we have "Win 2012" and "Win 2012 R2", we specify "Win 2012 R2" first.
-// We need to make sure we manually update this list with each new windows release.
-var windowsVersionMatchOrder = []string{
-	"Hyper-V Server 2012 R2",
-	"Hyper-V Server 2012",
-	"Windows Server 2008 R2",
-	"Windows Server 2012 R2",
-	"Windows Server 2012",
-	"Hyper-V Server 2016",
-	"Windows Server 2016",
-	"Windows Storage Server 2012 R2",
-	"Windows Storage Server 2012",
-	"Windows Storage Server 2016",
-	"Windows 7",
-	"Windows 8.1",
-	"Windows 8",
-	"Windows 10",
-}
-
-// windowsVersions is a mapping consisting of the output from
-// the following WMI query: (gwmi Win32_OperatingSystem).Name
-var windowsVersions = map[string]string{
-	"Hyper-V Server 2012 R2":         "win2012hvr2",
-	"Hyper-V Server 2012":            "win2012hv",
-	"Windows Server 2008 R2":         "win2008r2",
-	"Windows Server 2012 R2":         "win2012r2",
-	"Windows Server 2012":            "win2012",
-	"Hyper-V Server 2016":            "win2016hv",
-	"Windows Server 2016":            "win2016",
-	"Windows Storage Server 2012 R2": "win2012r2",
-	"Windows Storage Server 2012":    "win2012",
-	"Windows Storage Server 2016":    "win2016",
-	"Windows 7":                      "win7",
-	"Windows 8.1":                    "win81",
-	"Windows 8":                      "win8",
-	"Windows 10":                     "win10",
-}
-
-// windowsNanoVersions is a mapping from the product name
-// stored in registry to a juju defined nano-series
-// On the nano version so far the product name actually
-// is identical to the correspondent main windows version
-// and the information about it being nano is stored in
-// a different place.
-var windowsNanoVersions = map[string]string{
-	"Windows Server 2016": "win2016nano",
+// ubuntuDates provides the release and end of life dates of Ubuntu
+// series. Like ubuntuLTS, the values here are current at the time of
+// writing and are updated from distro-info on Ubuntu systems.
+var ubuntuDates = map[string]seriesDates{
+	"precise": {date(2012, 4, 26), date(2017, 4, 28)},
+	"quantal": {date(2012, 10, 18), date(2014, 5, 16)},
+	"raring":  {date(2013, 4, 25), date(2014, 1, 27)},
+	"saucy":   {date(2013, 10, 17), date(2014, 7, 17)},
+	"trusty":  {date(2014, 4, 17), date(2019, 4, 25)},
+	"utopic":  {date(2014, 10, 23), date(2015, 7, 23)},
+	"vivid":   {date(2015, 4, 23), date(2016, 2, 4)},
+	"wily":    {date(2015, 10, 22), date(2016, 7, 28)},
+	"xenial":  {date(2016, 4, 21), date(2021, 4, 30)},
+	"yakkety": {date(2016, 10, 13), date(2017, 7, 20)},
+	"zesty":   {date(2017, 4, 13), date(2018, 1, 13)},
+	"artful":  {date(2017, 10, 19), date(2018, 7, 19)},
+	"bionic":  {date(2018, 4, 26), date(2023, 4, 26)},
+}
+
+// seriesDates holds the release and end of life dates of a series.
+type seriesDates struct {
+	release time.Time
+	eol     time.Time
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
 
 // WindowsVersions returns all windows versions as a map
 func WindowsVersions() map[string]string {
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
 	save := make(map[string]string)
 	for i, val := range windowsVersions {
 		save[i] = val
@@ -201,6 +204,8 @@ func WindowsVersions() map[string]string {
 // because we might want to take decisions dependant on
 // whether we have a nano series or not in more general code.
 func IsWindowsNano(series string) bool {
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
 	for _, val := range windowsNanoVersions {
 		if val == series {
 			return true
@@ -209,24 +214,85 @@ func IsWindowsNano(series string) bool {
 	return false
 }
 
+// IsUbuntuCore returns true if the series is one of the Ubuntu Core
+// series, such as "core18".
+func IsUbuntuCore(series string) bool {
+	_, ok := ubuntuCoreSeries[series]
+	return ok
+}
+
 // GetOSFromSeries will return the operating system based
 // on the series that is passed to it
 func GetOSFromSeries(series string) (os.OSType, error) {
 	if series == "" {
 		return os.Unknown, errors.NotValidf("series %q", series)
 	}
+	seriesVersionsMutex.RLock()
 	osType, err := getOSFromSeries(series)
+	seriesVersionsMutex.RUnlock()
 	if err == nil {
 		return osType, nil
 	}
+	seriesVersionsMutex.Lock()
+	defer seriesVersionsMutex.Unlock()
 	updateSeriesVersionsOnce()
-	return getOSFromSeries(series)
+	if osType, err := getOSFromSeries(series); err == nil {
+		return osType, nil
+	}
+	if alias := resolveSeriesAlias(series); alias != series {
+		if osType, err := getOSFromSeries(alias); err == nil {
+			return osType, nil
+		}
+	}
+	return os.Unknown, errors.Trace(UnknownOSForSeriesError(series))
 }
 
+// GetOSVersionFromSeries returns both the operating system and the
+// version of the given series, saving callers a separate call to
+// SeriesVersion. Series aliases are resolved as with GetOSFromSeries.
+// The versions of macOS series are product versions such as "10.14",
+// and the versions of Windows series are the series themselves.
+func GetOSVersionFromSeries(series string) (os.OSType, string, error) {
+	osType, err := GetOSFromSeries(series)
+	if err != nil {
+		return os.Unknown, "", errors.Trace(err)
+	}
+	seriesVersionsMutex.RLock()
+	if _, err := getOSFromSeries(series); err != nil {
+		series = resolveSeriesAlias(series)
+	}
+	seriesVersionsMutex.RUnlock()
+	switch osType {
+	case os.OSX:
+		for version, s := range macOSXVersionSeries {
+			if s == series {
+				return osType, version, nil
+			}
+		}
+	case os.Windows:
+		return osType, series, nil
+	case os.Ubuntu:
+		if version, ok := ubuntuCoreSeries[series]; ok {
+			return osType, version, nil
+		}
+	}
+	version, err := SeriesVersion(series)
+	if err != nil {
+		return os.Unknown, "", errors.Trace(err)
+	}
+	return osType, version, nil
+}
+
+// getOSFromSeries implements GetOSFromSeries without resolving aliases
+// or updating the known series. It must be called with
+// seriesVersionsMutex held.
 func getOSFromSeries(series string) (os.OSType, error) {
 	if _, ok := ubuntuSeries[series]; ok {
 		return os.Ubuntu, nil
 	}
+	if _, ok := ubuntuCoreSeries[series]; ok {
+		return os.Ubuntu, nil
+	}
 	if _, ok := centosSeries[series]; ok {
 		return os.CentOS, nil
 	}
@@ -252,72 +318,97 @@ func getOSFromSeries(series string) (os.OSType, error) {
 		}
 	}
 
-	return os.Unknown, errors.Trace(unknownOSForSeriesError(series))
+	return os.Unknown, errors.Trace(UnknownOSForSeriesError(series))
 }
 
 var (
-	seriesVersionsMutex sync.Mutex
+	// seriesVersionsMutex guards the maps of known series, which may be
+	// updated at runtime from distro-info or series metadata. Readers
+	// must hold at least the read lock.
+	seriesVersionsMutex sync.RWMutex
 )
 
 // SeriesVersion returns the version for the specified series.
 func SeriesVersion(series string) (string, error) {
 	if series == "" {
-		return "", errors.Trace(unknownSeriesVersionError(""))
+		return "", errors.Trace(UnknownSeriesVersionError(""))
 	}
-	seriesVersionsMutex.Lock()
-	defer seriesVersionsMutex.Unlock()
-	if vers, ok := seriesVersions[series]; ok {
+	seriesVersionsMutex.RLock()
+	vers, ok := seriesVersions[series]
+	seriesVersionsMutex.RUnlock()
+	if ok {
 		return vers, nil
 	}
+	seriesVersionsMutex.Lock()
+	defer seriesVersionsMutex.Unlock()
 	updateSeriesVersionsOnce()
 	if vers, ok := seriesVersions[series]; ok {
 		return vers, nil
 	}
 
-	return "", errors.Trace(unknownSeriesVersionError(series))
+	return "", errors.Trace(UnknownSeriesVersionError(series))
+}
+
+// SeriesVersionNumber returns the numeric components of the version of
+// the specified series, for example 22 and 4 for jammy, so that versions
+// can be compared arithmetically. The OS name prefix of non-Ubuntu
+// versions is ignored, so centos7 is 7 and 0. A NotValid error is
+// returned for series without a numeric version, such as genericlinux.
+func SeriesVersionNumber(series string) (major, minor int, err error) {
+	version, err := SeriesVersion(series)
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	digits := strings.TrimLeftFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	parts := strings.Split(digits, ".")
+	if len(parts) > 2 {
+		return 0, 0, errors.NotValidf("version %q of series %q", version, series)
+	}
+	major, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errors.NotValidf("version %q of series %q", version, series)
+	}
+	if len(parts) == 2 {
+		minor, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, 0, errors.NotValidf("version %q of series %q", version, series)
+		}
+	}
+	return major, minor, nil
 }
 
 // VersionSeries returns the series (e.g.trusty) for the specified version (e.g. 14.04).
 func VersionSeries(version string) (string, error) {
 	if version == "" {
-		return "", errors.Trace(unknownVersionSeriesError(""))
+		return "", errors.Trace(UnknownVersionSeriesError(""))
 	}
-	seriesVersionsMutex.Lock()
-	defer seriesVersionsMutex.Unlock()
-	if series, ok := versionSeries[version]; ok {
+	seriesVersionsMutex.RLock()
+	series, ok := versionSeries[version]
+	seriesVersionsMutex.RUnlock()
+	if ok {
 		return series, nil
 	}
+	seriesVersionsMutex.Lock()
+	defer seriesVersionsMutex.Unlock()
 	updateSeriesVersionsOnce()
 	if series, ok := versionSeries[version]; ok {
 		return series, nil
 	}
-	return "", errors.Trace(unknownVersionSeriesError(version))
-}
-
-// WindowsVersionSeries returns the series (eg: win2012r2) for the specified version
-// (eg: Windows Server 2012 R2 Standard)
-func WindowsVersionSeries(version string) (string, error) {
-	if version == "" {
-		return "", errors.Trace(unknownVersionSeriesError(""))
-	}
-	for _, val := range windowsVersionMatchOrder {
-		if strings.HasPrefix(version, val) {
-			return windowsVersions[val], nil
-		}
-	}
-	return "", errors.Trace(unknownVersionSeriesError(""))
+	return "", errors.Trace(UnknownVersionSeriesError(version))
 }
 
 // CentOSVersionSeries validates that the supplied series (eg: centos7)
 // is supported.
 func CentOSVersionSeries(version string) (string, error) {
 	if version == "" {
-		return "", errors.Trace(unknownVersionSeriesError(""))
+		return "", errors.Trace(UnknownVersionSeriesError(""))
 	}
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
 	if series, ok := centosSeries[version]; ok {
 		return series, nil
 	}
-	return "", errors.Trace(unknownVersionSeriesError(""))
+	return "", errors.Trace(UnknownVersionSeriesError(version))
 
 }
 
@@ -345,15 +436,20 @@ var latestLtsSeries string
 
 // LatestLts returns the Latest LTS Series found in distro-info
 func LatestLts() string {
-	if latestLtsSeries != "" {
-		return latestLtsSeries
+	seriesVersionsMutex.RLock()
+	latest := latestLtsSeries
+	seriesVersionsMutex.RUnlock()
+	if latest != "" {
+		return latest
 	}
 
 	seriesVersionsMutex.Lock()
 	defer seriesVersionsMutex.Unlock()
+	if latestLtsSeries != "" {
+		return latestLtsSeries
+	}
 	updateSeriesVersionsOnce()
 
-	var latest string
 	for k := range ubuntuLTS {
 		if ubuntuSeries[k] > ubuntuSeries[latest] {
 			latest = k
@@ -369,6 +465,8 @@ func LatestLts() string {
 // distro-info.  It returns the previous setting so that it may be set back to
 // the original value by the caller.
 func SetLatestLtsForTesting(series string) string {
+	seriesVersionsMutex.Lock()
+	defer seriesVersionsMutex.Unlock()
 	old := latestLtsSeries
 	latestLtsSeries = series
 	return old
@@ -430,6 +528,9 @@ func UpdateSeriesVersions() error {
 
 var updatedseriesVersions bool
 
+// updateSeriesVersionsOnce updates the known series from the host the
+// first time it is called. It must be called with seriesVersionsMutex
+// held for writing.
 func updateSeriesVersionsOnce() {
 	if !updatedseriesVersions {
 		if err := updateLocalSeriesVersions(); err != nil {
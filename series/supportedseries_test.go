@@ -4,6 +4,7 @@
 package series_test
 
 import (
+	"github.com/juju/errors"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
@@ -172,3 +173,98 @@ func (s *supportedSeriesSuite) TestSupportedLts(c *gc.C) {
 	want := []string{"precise", "trusty", "xenial", "bionic"}
 	c.Assert(got, gc.DeepEquals, want)
 }
+
+func (s *supportedSeriesSuite) TestGetOSFromSeriesUnknown(c *gc.C) {
+	_, err := series.GetOSFromSeries("plan9")
+	c.Assert(err, gc.ErrorMatches, `unknown OS for series: "plan9"`)
+	c.Assert(series.IsUnknownOSForSeriesError(err), jc.IsTrue)
+	c.Assert(errors.Cause(err), gc.Equals, series.UnknownOSForSeriesError("plan9"))
+}
+
+func (s *supportedSeriesSuite) TestCentOSVersionSeriesInvalid(c *gc.C) {
+	_, err := series.CentOSVersionSeries("centos5")
+	c.Assert(err, gc.ErrorMatches, `unknown series for version: "centos5"`)
+	c.Assert(series.IsUnknownVersionSeriesError(err), jc.IsTrue)
+}
+
+func (s *supportedSeriesSuite) TestUbuntuCore(c *gc.C) {
+	for _, name := range []string{"core16", "core18", "core20", "core22"} {
+		c.Check(series.IsUbuntuCore(name), jc.IsTrue)
+		osType, err := series.GetOSFromSeries(name)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(osType, gc.Equals, os.Ubuntu)
+	}
+	c.Check(series.IsUbuntuCore("bionic"), jc.IsFalse)
+}
+
+func (s *supportedSeriesSuite) TestOSFromSeriesOrDefault(c *gc.C) {
+	c.Check(series.OSFromSeriesOrDefault("win2012r2", os.Ubuntu), gc.Equals, os.Windows)
+	c.Check(series.OSFromSeriesOrDefault("plan9", os.Ubuntu), gc.Equals, os.Ubuntu)
+	c.Check(series.OSFromSeriesOrDefault("", os.GenericLinux), gc.Equals, os.GenericLinux)
+}
+
+func (s *supportedSeriesSuite) TestSeriesVersionNumber(c *gc.C) {
+	cleanup := series.SetSeriesVersions(map[string]string{
+		"trusty":       "14.04",
+		"jammy":        "22.04",
+		"centos7":      "centos7",
+		"genericlinux": "genericlinux",
+		"odd":          "1.2.3",
+	})
+	defer cleanup()
+	for i, test := range []struct {
+		series       string
+		major, minor int
+		err          string
+	}{
+		{series: "trusty", major: 14, minor: 4},
+		{series: "jammy", major: 22, minor: 4},
+		{series: "centos7", major: 7},
+		{series: "genericlinux", err: `version "genericlinux" of series "genericlinux" not valid`},
+		{series: "odd", err: `version "1.2.3" of series "odd" not valid`},
+		{series: "spock", err: `unknown version for series: "spock"`},
+	} {
+		c.Logf("test %d: %s", i, test.series)
+		major, minor, err := series.SeriesVersionNumber(test.series)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(major, gc.Equals, test.major)
+		c.Check(minor, gc.Equals, test.minor)
+	}
+}
+
+func (s *supportedSeriesSuite) TestGetOSVersionFromSeries(c *gc.C) {
+	setSeriesTestData()
+	for i, test := range []struct {
+		series  string
+		os      os.OSType
+		version string
+	}{
+		{"trusty", os.Ubuntu, "14.04"},
+		{"core18", os.Ubuntu, "18"},
+		{"centos7", os.CentOS, "centos7"},
+		{"opensuseleap", os.OpenSUSE, "opensuse42"},
+		{"win2016nano", os.Windows, "win2016nano"},
+		{"win2012r2", os.Windows, "win2012r2"},
+		{"mojave", os.OSX, "10.14"},
+		{"genericlinux", os.GenericLinux, "genericlinux"},
+		{"Trusty", os.Ubuntu, "14.04"},
+	} {
+		c.Logf("test %d: %s", i, test.series)
+		osType, version, err := series.GetOSVersionFromSeries(test.series)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(osType, gc.Equals, test.os)
+		c.Check(version, gc.Equals, test.version)
+	}
+}
+
+func (s *supportedSeriesSuite) TestGetOSVersionFromSeriesUnknown(c *gc.C) {
+	setSeriesTestData()
+	osType, version, err := series.GetOSVersionFromSeries("spock")
+	c.Assert(err, jc.Satisfies, series.IsUnknownOSForSeriesError)
+	c.Assert(osType, gc.Equals, os.Unknown)
+	c.Assert(version, gc.Equals, "")
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/juju/errors"
+)
+
+// maxSuggestionDistance is the largest edit distance between an unknown
+// series and a known one for the known one to be suggested.
+const maxSuggestionDistance = 2
+
+// ValidateSeries returns a NotValid error if series is not one we know
+// about. When the series looks like a misspelling of a known series the
+// error suggests the correct name.
+func ValidateSeries(series string) error {
+	if series == "" {
+		return errors.NotValidf("empty series")
+	}
+	known := knownSeries()
+	for _, s := range known {
+		if s == series {
+			return nil
+		}
+	}
+	if suggestion := closestSeries(series, known); suggestion != "" {
+		return errors.NewNotValid(nil, fmt.Sprintf("unknown series %q, did you mean %q?", series, suggestion))
+	}
+	return errors.NewNotValid(nil, fmt.Sprintf("unknown series %q", series))
+}
+
+// knownSeries returns all the series we know about, sorted by name.
+func knownSeries() []string {
+	known := SupportedSeries()
+	for _, s := range macOSXSeries {
+		known = append(known, s)
+	}
+	for s := range ubuntuCoreSeries {
+		known = append(known, s)
+	}
+	sort.Strings(known)
+	return known
+}
+
+// closestSeries returns the series in known that is the smallest edit
+// distance from series, or "" if none of them are close enough.
+func closestSeries(series string, known []string) string {
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, s := range known {
+		if d := editDistance(series, s); d < bestDistance {
+			best, bestDistance = s, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions,
+// substitutions and transpositions of adjacent characters needed to
+// turn a into b.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(first int, rest ...int) int {
+	result := first
+	for _, v := range rest {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/series"
+)
+
+type validateSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&validateSuite{})
+
+func (s *validateSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	cleanup := series.SetSeriesVersions(map[string]string{
+		"trusty":  "14.04",
+		"xenial":  "16.04",
+		"bionic":  "18.04",
+		"centos7": "centos7",
+	})
+	s.AddCleanup(func(*gc.C) { cleanup() })
+}
+
+func (s *validateSuite) TestValidateSeries(c *gc.C) {
+	for _, name := range []string{"trusty", "xenial", "centos7", "mojave", "core18"} {
+		c.Check(series.ValidateSeries(name), jc.ErrorIsNil)
+	}
+}
+
+func (s *validateSuite) TestValidateSeriesSuggestion(c *gc.C) {
+	for i, test := range []struct {
+		series string
+		err    string
+	}{
+		{"xenail", `unknown series "xenail", did you mean "xenial"\?`},
+		{"bionc", `unknown series "bionc", did you mean "bionic"\?`},
+		{"trustyy", `unknown series "trustyy", did you mean "trusty"\?`},
+		{"centos6", `unknown series "centos6", did you mean "centos7"\?`},
+		{"plan9", `unknown series "plan9"`},
+		{"", `empty series not valid`},
+	} {
+		c.Logf("test %d: %q", i, test.series)
+		err := series.ValidateSeries(test.series)
+		c.Check(err, gc.ErrorMatches, test.err)
+		c.Check(errors.IsNotValid(err), jc.IsTrue)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series
+
+import (
+	"encoding/csv"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// WindowsVersion describes a Windows release.
+type WindowsVersion struct {
+	// Name is the product name of the release, as reported by the
+	// following WMI query: (gwmi Win32_OperatingSystem).Name.
+	// Editions such as Standard or Datacenter are matched by prefix.
+	Name string
+
+	// Series is the series of the release.
+	Series string
+
+	// NanoSeries is the series of the Nano Server variant of the
+	// release, if there is one. The product name of Nano Server is the
+	// same as that of the main release.
+	NanoSeries string
+
+	// Build is the build number that identifies the release. Client
+	// and server releases share build numbers, so it is only set on
+	// one of the releases with a given build.
+	Build int
+}
+
+// windowsVersionTable holds the known Windows releases. It may be
+// updated with UpdateWindowsVersions.
+var windowsVersionTable = []WindowsVersion{
+	{Name: "Hyper-V Server 2012 R2", Series: "win2012hvr2"},
+	{Name: "Hyper-V Server 2012", Series: "win2012hv"},
+	{Name: "Windows Server 2008 R2", Series: "win2008r2", Build: 7601},
+	{Name: "Windows Server 2012 R2", Series: "win2012r2", Build: 9600},
+	{Name: "Windows Server 2012", Series: "win2012", Build: 9200},
+	{Name: "Hyper-V Server 2016", Series: "win2016hv"},
+	{Name: "Windows Server 2016", Series: "win2016", NanoSeries: "win2016nano", Build: 14393},
+	{Name: "Windows Storage Server 2012 R2", Series: "win2012r2"},
+	{Name: "Windows Storage Server 2012", Series: "win2012"},
+	{Name: "Windows Storage Server 2016", Series: "win2016"},
+	{Name: "Windows 7", Series: "win7"},
+	{Name: "Windows 8.1", Series: "win81"},
+	{Name: "Windows 8", Series: "win8"},
+	{Name: "Windows 10", Series: "win10", Build: 10240},
+}
+
+// windowsVersionMatchOrder holds the product names of the known Windows
+// releases, longest first, so that a prefix match of "Windows Server
+// 2012 R2 Standard" finds "Windows Server 2012 R2" rather than "Windows
+// Server 2012". windowsVersions maps from product name to series, and
+// windowsNanoVersions from product name to the series of the Nano
+// Server variant. All are derived from windowsVersionTable.
+var windowsVersionMatchOrder, windowsVersions, windowsNanoVersions = windowsVersionMaps(windowsVersionTable)
+
+// windowsVersionMaps returns the lookup tables derived from table.
+func windowsVersionMaps(table []WindowsVersion) ([]string, map[string]string, map[string]string) {
+	matchOrder := make(byLengthDescending, 0, len(table))
+	versions := make(map[string]string)
+	nanoVersions := make(map[string]string)
+	for _, v := range table {
+		matchOrder = append(matchOrder, v.Name)
+		versions[v.Name] = v.Series
+		if v.NanoSeries != "" {
+			nanoVersions[v.Name] = v.NanoSeries
+		}
+	}
+	sort.Stable(matchOrder)
+	return matchOrder, versions, nanoVersions
+}
+
+// byLengthDescending sorts strings longest first.
+type byLengthDescending []string
+
+func (s byLengthDescending) Len() int           { return len(s) }
+func (s byLengthDescending) Less(i, j int) bool { return len(s[i]) > len(s[j]) }
+func (s byLengthDescending) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// WindowsVersionTable returns the known Windows releases.
+func WindowsVersionTable() []WindowsVersion {
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
+	table := make([]WindowsVersion, len(windowsVersionTable))
+	copy(table, windowsVersionTable)
+	return table
+}
+
+// WindowsVersionSeries returns the series (eg: win2012r2) for the
+// specified version, which may be either a product name (eg: Windows
+// Server 2012 R2 Standard) or a build number (eg: 9600 or 6.3.9600).
+func WindowsVersionSeries(version string) (string, error) {
+	if version == "" {
+		return "", errors.Trace(UnknownVersionSeriesError(""))
+	}
+	seriesVersionsMutex.RLock()
+	defer seriesVersionsMutex.RUnlock()
+	if build, ok := windowsBuildNumber(version); ok {
+		for _, v := range windowsVersionTable {
+			if v.Build == build {
+				return v.Series, nil
+			}
+		}
+		return "", errors.Trace(UnknownVersionSeriesError(version))
+	}
+	for _, val := range windowsVersionMatchOrder {
+		if strings.HasPrefix(version, val) {
+			return windowsVersions[val], nil
+		}
+	}
+	return "", errors.Trace(UnknownVersionSeriesError(version))
+}
+
+// windowsBuildNumber returns the build number in version, which is
+// either a bare build number or a full version such as 10.0.14393.
+func windowsBuildNumber(version string) (int, bool) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 1 && len(parts) != 3 {
+		return 0, false
+	}
+	var build int
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		build = n
+	}
+	return build, true
+}
+
+// UpdateWindowsVersions reads Windows releases from the CSV file at
+// path and merges them into the known releases, so that new releases
+// can be recognised without a new binary. The first line of the file
+// names the fields; the recognised fields are "name", "series",
+// "nano-series" and "build". Releases with the same name as a known
+// release replace it.
+func UpdateWindowsVersions(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+
+	csvReader := csv.NewReader(f)
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return errors.Annotatef(err, "reading %s", path)
+	}
+	if len(records) == 0 {
+		return errors.Errorf("reading %s: no header", path)
+	}
+	fieldNames := records[0]
+	records = records[1:]
+
+	// Check everything before changing anything, so that a bad file
+	// doesn't leave us with partial updates.
+	versions := make([]WindowsVersion, 0, len(records))
+	for i, fields := range records {
+		var v WindowsVersion
+		for j, field := range fields {
+			if j >= len(fieldNames) {
+				break
+			}
+			switch fieldNames[j] {
+			case "name":
+				v.Name = field
+			case "series":
+				v.Series = field
+			case "nano-series":
+				v.NanoSeries = field
+			case "build":
+				if field == "" {
+					continue
+				}
+				if v.Build, err = strconv.Atoi(field); err != nil {
+					return errors.Errorf("reading %s: line %d: invalid build %q", path, i+2, field)
+				}
+			}
+		}
+		if v.Name == "" || v.Series == "" {
+			return errors.Errorf("reading %s: line %d: missing name or series", path, i+2)
+		}
+		versions = append(versions, v)
+	}
+
+	seriesVersionsMutex.Lock()
+	defer seriesVersionsMutex.Unlock()
+	for _, v := range versions {
+		replaced := false
+		for i := range windowsVersionTable {
+			if windowsVersionTable[i].Name == v.Name {
+				windowsVersionTable[i] = v
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			windowsVersionTable = append(windowsVersionTable, v)
+		}
+	}
+	windowsVersionMatchOrder, windowsVersions, windowsNanoVersions = windowsVersionMaps(windowsVersionTable)
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package series_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/os"
+	"github.com/juju/utils/series"
+)
+
+type windowsVersionsSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&windowsVersionsSuite{})
+
+func (s *windowsVersionsSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	restore := series.BackupSeriesTables()
+	s.AddCleanup(func(*gc.C) { restore() })
+}
+
+func (s *windowsVersionsSuite) TestWindowsVersionSeries(c *gc.C) {
+	for i, test := range []struct {
+		version string
+		series  string
+	}{
+		{"Windows Server 2012 R2 Standard", "win2012r2"},
+		{"Windows Server 2012 Datacenter", "win2012"},
+		{"Hyper-V Server 2016", "win2016hv"},
+		{"Windows 8.1 Pro", "win81"},
+		{"9600", "win2012r2"},
+		{"6.3.9600", "win2012r2"},
+		{"10.0.14393", "win2016"},
+		{"10240", "win10"},
+	} {
+		c.Logf("test %d: %s", i, test.version)
+		result, err := series.WindowsVersionSeries(test.version)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(result, gc.Equals, test.series)
+	}
+}
+
+func (s *windowsVersionsSuite) TestWindowsVersionSeriesUnknown(c *gc.C) {
+	for _, version := range []string{"", "Windows 95", "12345", "1.2.3.4"} {
+		_, err := series.WindowsVersionSeries(version)
+		c.Check(err, jc.Satisfies, series.IsUnknownVersionSeriesError)
+	}
+}
+
+func (s *windowsVersionsSuite) writeFile(c *gc.C, contents string) string {
+	path := filepath.Join(c.MkDir(), "windows.csv")
+	err := ioutil.WriteFile(path, []byte(contents), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	return path
+}
+
+func (s *windowsVersionsSuite) TestUpdateWindowsVersions(c *gc.C) {
+	path := s.writeFile(c, `name,series,nano-series,build
+Windows Server 2019,win2019,,17763
+Windows Server 2016,win2016,win2016nano2,14393
+`)
+	err := series.UpdateWindowsVersions(path)
+	c.Assert(err, jc.ErrorIsNil)
+
+	result, err := series.WindowsVersionSeries("Windows Server 2019 Datacenter")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.Equals, "win2019")
+	result, err = series.WindowsVersionSeries("17763")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.Equals, "win2019")
+
+	osType, err := series.GetOSFromSeries("win2019")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(osType, gc.Equals, os.Windows)
+	c.Assert(series.IsWindowsNano("win2016nano2"), jc.IsTrue)
+	c.Assert(series.IsWindowsNano("win2016nano"), jc.IsFalse)
+
+	var found bool
+	for _, v := range series.WindowsVersionTable() {
+		if v.Name == "Windows Server 2019" {
+			c.Assert(v, jc.DeepEquals, series.WindowsVersion{
+				Name:   "Windows Server 2019",
+				Series: "win2019",
+				Build:  17763,
+			})
+			found = true
+		}
+	}
+	c.Assert(found, jc.IsTrue)
+}
+
+func (s *windowsVersionsSuite) TestUpdateWindowsVersionsConcurrentLookups(c *gc.C) {
+	// Run with -race to check that lookups don't race with updates.
+	path := s.writeFile(c, `name,series,nano-series,build
+Windows Server 2019,win2019,,17763
+`)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			series.UpdateWindowsVersions(path)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		series.GetOSFromSeries("win2016")
+		series.IsWindowsNano("win2016nano")
+		series.WindowsVersions()
+	}
+	<-done
+	result, err := series.WindowsVersionSeries("Windows Server 2019")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result, gc.Equals, "win2019")
+}
+
+func (s *windowsVersionsSuite) TestUpdateWindowsVersionsInvalid(c *gc.C) {
+	path := s.writeFile(c, `name,series,build
+Windows Server 2019,win2019,17763
+Windows Server 2022,,20348
+`)
+	err := series.UpdateWindowsVersions(path)
+	c.Assert(err, gc.ErrorMatches, `reading .*windows.csv: line 3: missing name or series`)
+
+	// Nothing is changed by a bad file.
+	_, err = series.WindowsVersionSeries("Windows Server 2019")
+	c.Assert(err, jc.Satisfies, series.IsUnknownVersionSeriesError)
+
+	path = s.writeFile(c, "name,series,build\nWindows Server 2019,win2019,x\n")
+	err = series.UpdateWindowsVersions(path)
+	c.Assert(err, gc.ErrorMatches, `reading .*windows.csv: line 2: invalid build "x"`)
+}
+
+func (s *windowsVersionsSuite) TestUpdateWindowsVersionsMissingFile(c *gc.C) {
+	err := series.UpdateWindowsVersions(filepath.Join(c.MkDir(), "missing"))
+	c.Assert(err, gc.NotNil)
+}
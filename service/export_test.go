@@ -0,0 +1,13 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package service
+
+var (
+	RunCommand     = &runCommand
+	SystemdUnitDir = &systemdUnitDir
+	SystemdRunDir  = &systemdRunDir
+	UpstartConfDir = &upstartConfDir
+	UpstartInitctl = &upstartInitctl
+	HostSeries     = &hostSeries
+)
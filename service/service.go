@@ -0,0 +1,236 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// Package service provides a minimal way to install and control
+// long-running services under the init system of the host.
+package service
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"golang.org/x/net/context"
+
+	"github.com/juju/utils/exec"
+	jujuos "github.com/juju/utils/os"
+	"github.com/juju/utils/series"
+)
+
+var logger = loggo.GetLogger("juju.utils.service")
+
+// The init systems supported by this package.
+const (
+	InitSystemd = "systemd"
+	InitUpstart = "upstart"
+	InitWindows = "windows"
+)
+
+var (
+	// runCommand is used to run init system commands. It is a
+	// variable so that tests can avoid running real commands.
+	runCommand = exec.RunCommand
+
+	// These are variables so they can be overridden for testing.
+	systemdUnitDir    = "/etc/systemd/system"
+	systemdRunDir     = "/run/systemd/system"
+	upstartConfDir    = "/etc/init"
+	upstartInitctl    = "/sbin/initctl"
+	hostSeries        = series.HostSeries
+	firstSystemdMajor = 15
+)
+
+// Service is implemented by services managed by an init system.
+type Service interface {
+	// Name returns the name of the service.
+	Name() string
+
+	// Install registers the service with the init system so that it
+	// is started at boot. It does not start the service. Installing
+	// a service that is already installed replaces its
+	// configuration.
+	Install(ctx context.Context) error
+
+	// Start starts the service. Starting a running service does
+	// nothing.
+	Start(ctx context.Context) error
+
+	// Stop stops the service. Stopping a service that isn't running
+	// does nothing.
+	Stop(ctx context.Context) error
+
+	// Running reports whether the service is running.
+	Running(ctx context.Context) (bool, error)
+}
+
+// Conf describes a service.
+type Conf struct {
+	// Description holds a short description of the service.
+	Description string
+
+	// ExecStart holds the command line that runs the service. On
+	// Linux it is interpreted by the init system much as a shell
+	// would, so arguments containing spaces must be quoted.
+	ExecStart string
+
+	// Env holds environment variables to set for the service.
+	// It is not supported on Windows.
+	Env map[string]string
+}
+
+// Validate returns an error if the configuration is not valid.
+// ExecStart and Description are written into line-based configuration
+// files, so they may not contain control characters such as newlines.
+func (conf Conf) Validate() error {
+	if strings.TrimSpace(conf.ExecStart) == "" {
+		return errors.NotValidf("empty ExecStart")
+	}
+	if hasControlChars(conf.ExecStart) {
+		return errors.NotValidf("ExecStart %q containing control characters", conf.ExecStart)
+	}
+	if hasControlChars(conf.Description) {
+		return errors.NotValidf("Description %q containing control characters", conf.Description)
+	}
+	for name := range conf.Env {
+		if name == "" || strings.ContainsAny(name, "= \t\n") {
+			return errors.NotValidf("environment variable name %q", name)
+		}
+	}
+	return nil
+}
+
+func hasControlChars(s string) bool {
+	return strings.IndexFunc(s, unicode.IsControl) >= 0
+}
+
+// envNames returns the names of the environment variables in conf,
+// sorted so that generated configuration is stable.
+func (conf Conf) envNames() []string {
+	names := make([]string, 0, len(conf.Env))
+	for name := range conf.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewService returns a Service with the given name and configuration
+// managed by the given init system, which must be one of InitSystemd,
+// InitUpstart or InitWindows.
+func NewService(name string, conf Conf, initSystem string) (Service, error) {
+	if name == "" || strings.ContainsAny(name, `/\ `) {
+		return nil, errors.NotValidf("service name %q", name)
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	switch initSystem {
+	case InitSystemd:
+		return &systemdService{name: name, conf: conf}, nil
+	case InitUpstart:
+		return &upstartService{name: name, conf: conf}, nil
+	case InitWindows:
+		if len(conf.Env) > 0 {
+			return nil, errors.NotSupportedf("environment variables for Windows services")
+		}
+		return &windowsService{name: name, conf: conf}, nil
+	}
+	return nil, errors.NotSupportedf("init system %q", initSystem)
+}
+
+// NewHostService is like NewService, but uses the init system of the
+// machine the current process is running on.
+func NewHostService(name string, conf Conf) (Service, error) {
+	initSystem, err := HostInitSystem()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return NewService(name, conf, initSystem)
+}
+
+// InitSystemForSeries returns the init system used by the given
+// series. Ubuntu releases before 15.04 use upstart; all other Linux
+// series we know about use systemd.
+func InitSystemForSeries(s string) (string, error) {
+	osType, err := series.GetOSFromSeries(s)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	switch osType {
+	case jujuos.Windows:
+		return InitWindows, nil
+	case jujuos.Ubuntu:
+		major, _, err := series.SeriesVersionNumber(s)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if major < firstSystemdMajor {
+			return InitUpstart, nil
+		}
+		return InitSystemd, nil
+	case jujuos.CentOS, jujuos.OpenSUSE, jujuos.SUSE, jujuos.AmazonLinux:
+		return InitSystemd, nil
+	}
+	return "", errors.NotSupportedf("init system for %s", osType)
+}
+
+// HostInitSystem returns the init system of the machine the current
+// process is running on. The series is used where it determines the
+// init system; otherwise, as on "genericlinux", the running init
+// system is inspected.
+func HostInitSystem() (string, error) {
+	s, err := hostSeries()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	initSystem, err := InitSystemForSeries(s)
+	if err == nil {
+		return initSystem, nil
+	}
+	logger.Debugf("cannot determine init system from series %q: %v", s, err)
+	// This is the check made by sd_booted(3).
+	if isDir(systemdRunDir) {
+		return InitSystemd, nil
+	}
+	if _, err := os.Stat(upstartInitctl); err == nil {
+		return InitUpstart, nil
+	}
+	return "", errors.NotFoundf("init system on series %q", s)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// run runs the given command, returning an error if it fails.
+func run(ctx context.Context, args ...string) (*exec.ExecResponse, error) {
+	resp, err := runCommand(ctx, commandSpec(args...))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.Code != 0 {
+		return resp, commandError(args, resp)
+	}
+	return resp, nil
+}
+
+// commandSpec returns the spec for running the given command.
+func commandSpec(args ...string) exec.CommandSpec {
+	return exec.CommandSpec{
+		Path: args[0],
+		Args: args[1:],
+	}
+}
+
+// commandError returns an error describing a failed command.
+func commandError(args []string, resp *exec.ExecResponse) error {
+	output := strings.TrimSpace(string(resp.Stderr))
+	if output == "" {
+		output = strings.TrimSpace(string(resp.Stdout))
+	}
+	return errors.Errorf("%s exited with code %d: %s", strings.Join(args, " "), resp.Code, output)
+}
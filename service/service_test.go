@@ -0,0 +1,323 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package service_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/exec"
+	"github.com/juju/utils/service"
+)
+
+type serviceSuite struct {
+	testing.IsolationSuite
+	dir string
+
+	// commands records the commands run.
+	commands []string
+	// responses holds the responses to return, in order.
+	responses []*exec.ExecResponse
+}
+
+var _ = gc.Suite(&serviceSuite{})
+
+var testConf = service.Conf{
+	Description: `The "test" service at 100%`,
+	ExecStart:   "/usr/bin/testd --config /etc/testd.conf",
+	Env: map[string]string{
+		"TESTD_NAME":  "fred",
+		"TESTD_QUOTE": `say "hi"`,
+	},
+}
+
+func (s *serviceSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.dir = c.MkDir()
+	s.commands = nil
+	s.responses = nil
+	s.PatchValue(service.SystemdUnitDir, s.dir)
+	s.PatchValue(service.UpstartConfDir, s.dir)
+	s.PatchValue(service.SystemdRunDir, filepath.Join(s.dir, "run-systemd"))
+	s.PatchValue(service.UpstartInitctl, filepath.Join(s.dir, "initctl"))
+	s.PatchValue(service.RunCommand, func(ctx context.Context, spec exec.CommandSpec) (*exec.ExecResponse, error) {
+		s.commands = append(s.commands, strings.Join(append([]string{spec.Path}, spec.Args...), " "))
+		if len(s.responses) == 0 {
+			return &exec.ExecResponse{}, nil
+		}
+		resp := s.responses[0]
+		s.responses = s.responses[1:]
+		return resp, nil
+	})
+}
+
+func (s *serviceSuite) newService(c *gc.C, initSystem string, conf service.Conf) service.Service {
+	svc, err := service.NewService("testd", conf, initSystem)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(svc.Name(), gc.Equals, "testd")
+	return svc
+}
+
+func (s *serviceSuite) readFile(c *gc.C, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+	c.Assert(err, jc.ErrorIsNil)
+	return string(data)
+}
+
+func (s *serviceSuite) TestSystemdInstall(c *gc.C) {
+	svc := s.newService(c, service.InitSystemd, testConf)
+	err := svc.Install(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.readFile(c, "testd.service"), gc.Equals, `
+[Unit]
+Description=The "test" service at 100%%
+After=network.target
+
+[Service]
+Environment="TESTD_NAME=fred"
+Environment="TESTD_QUOTE=say \"hi\""
+ExecStart=/usr/bin/testd --config /etc/testd.conf
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+`[1:])
+	c.Assert(s.commands, jc.DeepEquals, []string{
+		"systemctl daemon-reload",
+		"systemctl enable testd.service",
+	})
+}
+
+func (s *serviceSuite) TestSystemdControl(c *gc.C) {
+	svc := s.newService(c, service.InitSystemd, testConf)
+	ctx := context.Background()
+	c.Assert(svc.Start(ctx), jc.ErrorIsNil)
+	c.Assert(svc.Stop(ctx), jc.ErrorIsNil)
+	c.Assert(s.commands, jc.DeepEquals, []string{
+		"systemctl start testd.service",
+		"systemctl stop testd.service",
+	})
+}
+
+func (s *serviceSuite) TestSystemdRunning(c *gc.C) {
+	svc := s.newService(c, service.InitSystemd, testConf)
+	s.responses = []*exec.ExecResponse{{}, {Code: 3}}
+	running, err := svc.Running(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(running, jc.IsTrue)
+	running, err = svc.Running(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(running, jc.IsFalse)
+	c.Assert(s.commands, gc.DeepEquals, []string{
+		"systemctl is-active --quiet testd.service",
+		"systemctl is-active --quiet testd.service",
+	})
+}
+
+func (s *serviceSuite) TestSystemdStartFails(c *gc.C) {
+	svc := s.newService(c, service.InitSystemd, testConf)
+	s.responses = []*exec.ExecResponse{{
+		Code:   5,
+		Stderr: []byte("Failed to start testd.service: Unit testd.service not found.\n"),
+	}}
+	err := svc.Start(context.Background())
+	c.Assert(err, gc.ErrorMatches, `cannot start testd: systemctl start testd.service exited with code 5: Failed to start .* not found.`)
+}
+
+func (s *serviceSuite) TestUpstartInstall(c *gc.C) {
+	svc := s.newService(c, service.InitUpstart, testConf)
+	err := svc.Install(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.readFile(c, "testd.conf"), gc.Equals, `
+description "The \"test\" service at 100%"
+start on runlevel [2345]
+stop on runlevel [!2345]
+respawn
+normal exit 0
+
+env TESTD_NAME="fred"
+env TESTD_QUOTE="say \"hi\""
+exec /usr/bin/testd --config /etc/testd.conf
+`[1:])
+	c.Assert(s.commands, gc.HasLen, 0)
+}
+
+func (s *serviceSuite) TestUpstartControl(c *gc.C) {
+	svc := s.newService(c, service.InitUpstart, testConf)
+	ctx := context.Background()
+	stopped := &exec.ExecResponse{Stdout: []byte("testd stop/waiting\n")}
+	running := &exec.ExecResponse{Stdout: []byte("testd start/running, process 1234\n")}
+
+	s.responses = []*exec.ExecResponse{stopped, {}, running, running, {}, stopped}
+	c.Assert(svc.Start(ctx), jc.ErrorIsNil)
+	c.Assert(svc.Start(ctx), jc.ErrorIsNil)
+	c.Assert(svc.Stop(ctx), jc.ErrorIsNil)
+	c.Assert(svc.Stop(ctx), jc.ErrorIsNil)
+	c.Assert(s.commands, jc.DeepEquals, []string{
+		"status testd",
+		"start testd",
+		"status testd",
+		"status testd",
+		"stop testd",
+		"status testd",
+	})
+}
+
+func (s *serviceSuite) TestWindowsInstall(c *gc.C) {
+	conf := testConf
+	conf.Env = nil
+	svc := s.newService(c, service.InitWindows, conf)
+	s.responses = []*exec.ExecResponse{{
+		Code:   1073,
+		Stdout: []byte("[SC] CreateService FAILED 1073:\n\nThe specified service already exists.\n"),
+	}}
+	err := svc.Install(context.Background())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.commands, jc.DeepEquals, []string{
+		"sc.exe create testd binPath= /usr/bin/testd --config /etc/testd.conf start= auto",
+		"sc.exe config testd binPath= /usr/bin/testd --config /etc/testd.conf start= auto",
+		`sc.exe description testd The "test" service at 100%`,
+	})
+}
+
+func (s *serviceSuite) TestWindowsControl(c *gc.C) {
+	conf := testConf
+	conf.Env = nil
+	svc := s.newService(c, service.InitWindows, conf)
+	ctx := context.Background()
+	s.responses = []*exec.ExecResponse{
+		{Code: 1056},
+		{Code: 1062},
+		{Stdout: []byte("SERVICE_NAME: testd\r\n        TYPE               : 10  WIN32_OWN_PROCESS\r\n        STATE              : 4  RUNNING\r\n")},
+		{Stdout: []byte("SERVICE_NAME: testd\r\n        STATE              : 1  STOPPED\r\n")},
+	}
+	c.Assert(svc.Start(ctx), jc.ErrorIsNil)
+	c.Assert(svc.Stop(ctx), jc.ErrorIsNil)
+	running, err := svc.Running(ctx)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(running, jc.IsTrue)
+	running, err = svc.Running(ctx)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(running, jc.IsFalse)
+}
+
+func (s *serviceSuite) TestNewServiceErrors(c *gc.C) {
+	for i, test := range []struct {
+		name       string
+		conf       service.Conf
+		initSystem string
+		err        string
+	}{{
+		name:       "",
+		conf:       testConf,
+		initSystem: service.InitSystemd,
+		err:        `service name "" not valid`,
+	}, {
+		name:       "../testd",
+		conf:       testConf,
+		initSystem: service.InitSystemd,
+		err:        `service name "../testd" not valid`,
+	}, {
+		name:       "testd",
+		conf:       service.Conf{},
+		initSystem: service.InitSystemd,
+		err:        `empty ExecStart not valid`,
+	}, {
+		name:       "testd",
+		conf:       service.Conf{ExecStart: "testd\nExecStartPre=/bin/evil"},
+		initSystem: service.InitSystemd,
+		err:        `ExecStart "testd\\nExecStartPre=/bin/evil" containing control characters not valid`,
+	}, {
+		name:       "testd",
+		conf:       service.Conf{ExecStart: "testd", Description: "test\rdaemon"},
+		initSystem: service.InitUpstart,
+		err:        `Description "test\\rdaemon" containing control characters not valid`,
+	}, {
+		name:       "testd",
+		conf:       service.Conf{ExecStart: "testd", Env: map[string]string{"A=B": "C"}},
+		initSystem: service.InitUpstart,
+		err:        `environment variable name "A=B" not valid`,
+	}, {
+		name:       "testd",
+		conf:       testConf,
+		initSystem: service.InitWindows,
+		err:        `environment variables for Windows services not supported`,
+	}, {
+		name:       "testd",
+		conf:       testConf,
+		initSystem: "sysvinit",
+		err:        `init system "sysvinit" not supported`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := service.NewService(test.name, test.conf, test.initSystem)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (*serviceSuite) TestInitSystemForSeries(c *gc.C) {
+	for _, test := range []struct {
+		series     string
+		initSystem string
+		err        string
+	}{
+		{series: "trusty", initSystem: service.InitUpstart},
+		{series: "xenial", initSystem: service.InitSystemd},
+		{series: "bionic", initSystem: service.InitSystemd},
+		{series: "centos7", initSystem: service.InitSystemd},
+		{series: "opensuseleap", initSystem: service.InitSystemd},
+		{series: "win2012r2", initSystem: service.InitWindows},
+		{series: "genericlinux", err: `init system for GenericLinux not supported`},
+	} {
+		c.Logf("series %s", test.series)
+		initSystem, err := service.InitSystemForSeries(test.series)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(initSystem, gc.Equals, test.initSystem)
+	}
+}
+
+func (s *serviceSuite) patchHostSeries(series string) {
+	s.PatchValue(service.HostSeries, func() (string, error) {
+		return series, nil
+	})
+}
+
+func (s *serviceSuite) TestHostInitSystemFromSeries(c *gc.C) {
+	s.patchHostSeries("trusty")
+	err := os.Mkdir(filepath.Join(s.dir, "run-systemd"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	initSystem, err := service.HostInitSystem()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(initSystem, gc.Equals, service.InitUpstart)
+}
+
+func (s *serviceSuite) TestHostInitSystemGenericLinux(c *gc.C) {
+	s.patchHostSeries("genericlinux")
+	_, err := service.HostInitSystem()
+	c.Assert(err, gc.ErrorMatches, `init system on series "genericlinux" not found`)
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+
+	err = ioutil.WriteFile(filepath.Join(s.dir, "initctl"), nil, 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	initSystem, err := service.HostInitSystem()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(initSystem, gc.Equals, service.InitUpstart)
+
+	err = os.Mkdir(filepath.Join(s.dir, "run-systemd"), 0755)
+	c.Assert(err, jc.ErrorIsNil)
+	initSystem, err = service.HostInitSystem()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(initSystem, gc.Equals, service.InitSystemd)
+}
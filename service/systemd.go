@@ -0,0 +1,101 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
+
+	"github.com/juju/utils"
+)
+
+// systemdService implements Service using systemd.
+type systemdService struct {
+	name string
+	conf Conf
+}
+
+// Name implements Service.
+func (s *systemdService) Name() string {
+	return s.name
+}
+
+func (s *systemdService) unitName() string {
+	return s.name + ".service"
+}
+
+// Install implements Service.
+func (s *systemdService) Install(ctx context.Context) error {
+	path := filepath.Join(systemdUnitDir, s.unitName())
+	if err := utils.AtomicWriteFile(path, s.unit(), 0644); err != nil {
+		return errors.Annotatef(err, "cannot write unit file for %s", s.name)
+	}
+	if _, err := run(ctx, "systemctl", "daemon-reload"); err != nil {
+		return errors.Annotatef(err, "cannot install %s", s.name)
+	}
+	if _, err := run(ctx, "systemctl", "enable", s.unitName()); err != nil {
+		return errors.Annotatef(err, "cannot install %s", s.name)
+	}
+	return nil
+}
+
+// Start implements Service.
+func (s *systemdService) Start(ctx context.Context) error {
+	_, err := run(ctx, "systemctl", "start", s.unitName())
+	return errors.Annotatef(err, "cannot start %s", s.name)
+}
+
+// Stop implements Service.
+func (s *systemdService) Stop(ctx context.Context) error {
+	_, err := run(ctx, "systemctl", "stop", s.unitName())
+	return errors.Annotatef(err, "cannot stop %s", s.name)
+}
+
+// Running implements Service.
+func (s *systemdService) Running(ctx context.Context) (bool, error) {
+	// is-active exits non-zero for every state but active, so
+	// there's no failure to distinguish.
+	resp, err := runCommand(ctx, commandSpec("systemctl", "is-active", "--quiet", s.unitName()))
+	if err != nil {
+		return false, errors.Annotatef(err, "cannot check %s", s.name)
+	}
+	return resp.Code == 0, nil
+}
+
+// unit returns the contents of the unit file for the service.
+func (s *systemdService) unit() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[Unit]\n")
+	if s.conf.Description != "" {
+		fmt.Fprintf(&buf, "Description=%s\n", systemdEscape(s.conf.Description))
+	}
+	fmt.Fprintf(&buf, "After=network.target\n\n")
+	fmt.Fprintf(&buf, "[Service]\n")
+	for _, name := range s.conf.envNames() {
+		fmt.Fprintf(&buf, "Environment=%s\n", systemdQuote(name+"="+s.conf.Env[name]))
+	}
+	fmt.Fprintf(&buf, "ExecStart=%s\n", systemdEscape(s.conf.ExecStart))
+	fmt.Fprintf(&buf, "Restart=on-failure\n\n")
+	fmt.Fprintf(&buf, "[Install]\n")
+	fmt.Fprintf(&buf, "WantedBy=multi-user.target\n")
+	return buf.Bytes()
+}
+
+// systemdEscape escapes the specifiers that systemd would otherwise
+// expand in s.
+func systemdEscape(s string) string {
+	return strings.Replace(s, "%", "%%", -1)
+}
+
+var systemdQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// systemdQuote returns s as a double-quoted systemd string.
+func systemdQuote(s string) string {
+	return `"` + systemdQuoter.Replace(systemdEscape(s)) + `"`
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
+
+	"github.com/juju/utils"
+)
+
+// upstartService implements Service using upstart.
+type upstartService struct {
+	name string
+	conf Conf
+}
+
+// Name implements Service.
+func (s *upstartService) Name() string {
+	return s.name
+}
+
+// Install implements Service. Upstart notices new job configuration
+// files itself, so there is nothing to run.
+func (s *upstartService) Install(ctx context.Context) error {
+	path := filepath.Join(upstartConfDir, s.name+".conf")
+	err := utils.AtomicWriteFile(path, s.job(), 0644)
+	return errors.Annotatef(err, "cannot write job file for %s", s.name)
+}
+
+// Start implements Service.
+func (s *upstartService) Start(ctx context.Context) error {
+	// Unlike systemctl, start fails if the job is already running.
+	running, err := s.Running(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if running {
+		return nil
+	}
+	_, err = run(ctx, "start", s.name)
+	return errors.Annotatef(err, "cannot start %s", s.name)
+}
+
+// Stop implements Service.
+func (s *upstartService) Stop(ctx context.Context) error {
+	running, err := s.Running(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !running {
+		return nil
+	}
+	_, err = run(ctx, "stop", s.name)
+	return errors.Annotatef(err, "cannot stop %s", s.name)
+}
+
+// Running implements Service.
+func (s *upstartService) Running(ctx context.Context) (bool, error) {
+	resp, err := run(ctx, "status", s.name)
+	if err != nil {
+		return false, errors.Annotatef(err, "cannot check %s", s.name)
+	}
+	// The output looks like "jujud start/running, process 1234".
+	return strings.Contains(string(resp.Stdout), "start/running"), nil
+}
+
+// job returns the contents of the job file for the service.
+func (s *upstartService) job() []byte {
+	var buf bytes.Buffer
+	if s.conf.Description != "" {
+		fmt.Fprintf(&buf, "description %s\n", upstartQuote(s.conf.Description))
+	}
+	fmt.Fprintf(&buf, "start on runlevel [2345]\n")
+	fmt.Fprintf(&buf, "stop on runlevel [!2345]\n")
+	fmt.Fprintf(&buf, "respawn\n")
+	fmt.Fprintf(&buf, "normal exit 0\n\n")
+	for _, name := range s.conf.envNames() {
+		fmt.Fprintf(&buf, "env %s=%s\n", name, upstartQuote(s.conf.Env[name]))
+	}
+	fmt.Fprintf(&buf, "exec %s\n", s.conf.ExecStart)
+	return buf.Bytes()
+}
+
+var upstartQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ")
+
+// upstartQuote returns s as a double-quoted upstart string.
+func upstartQuote(s string) string {
+	return `"` + upstartQuoter.Replace(s) + `"`
+}
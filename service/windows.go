@@ -0,0 +1,84 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package service
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"golang.org/x/net/context"
+)
+
+// The codes returned by sc.exe when the service is already in the
+// requested state.
+const (
+	errorServiceExists         = 1073
+	errorServiceAlreadyRunning = 1056
+	errorServiceNotActive      = 1062
+)
+
+// windowsService implements Service using the Windows service control
+// manager. The program it runs must implement the Windows service
+// protocol, for example with golang.org/x/sys/windows/svc.
+type windowsService struct {
+	name string
+	conf Conf
+}
+
+// Name implements Service.
+func (s *windowsService) Name() string {
+	return s.name
+}
+
+// Install implements Service.
+func (s *windowsService) Install(ctx context.Context) error {
+	// sc.exe requires each "option=" to be a separate argument.
+	resp, err := run(ctx, "sc.exe", "create", s.name, "binPath=", s.conf.ExecStart, "start=", "auto")
+	if resp != nil && resp.Code == errorServiceExists {
+		_, err = run(ctx, "sc.exe", "config", s.name, "binPath=", s.conf.ExecStart, "start=", "auto")
+	}
+	if err != nil {
+		return errors.Annotatef(err, "cannot install %s", s.name)
+	}
+	if s.conf.Description != "" {
+		if _, err := run(ctx, "sc.exe", "description", s.name, s.conf.Description); err != nil {
+			return errors.Annotatef(err, "cannot install %s", s.name)
+		}
+	}
+	return nil
+}
+
+// Start implements Service.
+func (s *windowsService) Start(ctx context.Context) error {
+	resp, err := run(ctx, "sc.exe", "start", s.name)
+	if resp != nil && resp.Code == errorServiceAlreadyRunning {
+		return nil
+	}
+	return errors.Annotatef(err, "cannot start %s", s.name)
+}
+
+// Stop implements Service.
+func (s *windowsService) Stop(ctx context.Context) error {
+	resp, err := run(ctx, "sc.exe", "stop", s.name)
+	if resp != nil && resp.Code == errorServiceNotActive {
+		return nil
+	}
+	return errors.Annotatef(err, "cannot stop %s", s.name)
+}
+
+// Running implements Service.
+func (s *windowsService) Running(ctx context.Context) (bool, error) {
+	resp, err := run(ctx, "sc.exe", "query", s.name)
+	if err != nil {
+		return false, errors.Annotatef(err, "cannot check %s", s.name)
+	}
+	// The output includes a line like "STATE : 4  RUNNING".
+	for _, line := range strings.Split(string(resp.Stdout), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "STATE" {
+			return fields[len(fields)-1] == "RUNNING", nil
+		}
+	}
+	return false, errors.Errorf("cannot check %s: no state in sc.exe output", s.name)
+}
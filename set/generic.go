@@ -0,0 +1,112 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//go:build go1.18
+// +build go1.18
+
+package set
+
+import (
+	"sort"
+)
+
+// Set represents the classic "set" data structure, and contains values
+// of any comparable type. It provides the same operations as Strings
+// and Ints for other element types, such as custom ids.
+type Set[T comparable] map[T]bool
+
+// New creates and initializes a Set and populates it with initial
+// values as specified in the parameters.
+func New[T comparable](initial ...T) Set[T] {
+	result := make(Set[T])
+	for _, value := range initial {
+		result.Add(value)
+	}
+	return result
+}
+
+// Size returns the number of elements in the set.
+func (s Set[T]) Size() int {
+	return len(s)
+}
+
+// IsEmpty is true for empty or uninitialized sets.
+func (s Set[T]) IsEmpty() bool {
+	return len(s) == 0
+}
+
+// Add puts a value into the set.
+func (s Set[T]) Add(value T) {
+	if s == nil {
+		panic("uninitalised set")
+	}
+	s[value] = true
+}
+
+// Remove takes a value out of the set. If value wasn't in the set to start
+// with, this method silently succeeds.
+func (s Set[T]) Remove(value T) {
+	delete(s, value)
+}
+
+// Contains returns true if the value is in the set, and false otherwise.
+func (s Set[T]) Contains(value T) bool {
+	_, exists := s[value]
+	return exists
+}
+
+// Values returns an unordered slice containing all the values in the set.
+func (s Set[T]) Values() []T {
+	result := make([]T, 0, len(s))
+	for key := range s {
+		result = append(result, key)
+	}
+	return result
+}
+
+// SortedValues returns a slice containing all the values in the set,
+// ordered by the given less function.
+func (s Set[T]) SortedValues(less func(a, b T) bool) []T {
+	values := s.Values()
+	sort.Slice(values, func(i, j int) bool {
+		return less(values[i], values[j])
+	})
+	return values
+}
+
+// Union returns a new Set representing a union of the elments in the
+// method target and the parameter.
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	result := make(Set[T])
+	for value := range s {
+		result[value] = true
+	}
+	for value := range other {
+		result[value] = true
+	}
+	return result
+}
+
+// Intersection returns a new Set representing a intersection of the elments in the
+// method target and the parameter.
+func (s Set[T]) Intersection(other Set[T]) Set[T] {
+	result := make(Set[T])
+	for value := range s {
+		if other.Contains(value) {
+			result[value] = true
+		}
+	}
+	return result
+}
+
+// Difference returns a new Set representing all the values in the
+// target that are not in the parameter.
+func (s Set[T]) Difference(other Set[T]) Set[T] {
+	result := make(Set[T])
+	for value := range s {
+		if !other.Contains(value) {
+			result[value] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//go:build go1.18
+// +build go1.18
+
+package set_test
+
+import (
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/set"
+)
+
+type genericSetSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(genericSetSuite{})
+
+type machineId struct {
+	model string
+	id    int
+}
+
+func lessInt(a, b int) bool {
+	return a < b
+}
+
+func (genericSetSuite) TestEmpty(c *gc.C) {
+	s := set.New[int]()
+	c.Assert(s.Size(), gc.Equals, 0)
+	c.Assert(s.IsEmpty(), jc.IsTrue)
+	c.Assert(s.Values(), gc.HasLen, 0)
+
+	var uninitialized set.Set[int]
+	c.Assert(uninitialized.IsEmpty(), jc.IsTrue)
+	c.Assert(uninitialized.Contains(1), jc.IsFalse)
+}
+
+func (genericSetSuite) TestAddRemoveContains(c *gc.C) {
+	s := set.New(3, 1)
+	s.Add(2)
+	s.Add(3)
+	c.Assert(s.Size(), gc.Equals, 3)
+	c.Assert(s.Contains(2), jc.IsTrue)
+	c.Assert(s.SortedValues(lessInt), jc.DeepEquals, []int{1, 2, 3})
+
+	s.Remove(2)
+	s.Remove(42)
+	c.Assert(s.Contains(2), jc.IsFalse)
+	c.Assert(s.SortedValues(lessInt), jc.DeepEquals, []int{1, 3})
+}
+
+func (genericSetSuite) TestStructValues(c *gc.C) {
+	s := set.New(machineId{"a", 0}, machineId{"b", 0})
+	c.Assert(s.Contains(machineId{"a", 0}), jc.IsTrue)
+	c.Assert(s.Contains(machineId{"a", 1}), jc.IsFalse)
+	c.Assert(s.Values(), jc.SameContents, []machineId{{"a", 0}, {"b", 0}})
+}
+
+func (genericSetSuite) TestSetOperations(c *gc.C) {
+	s1 := set.New(1, 2, 3)
+	s2 := set.New(2, 3, 4)
+	c.Assert(s1.Union(s2).SortedValues(lessInt), jc.DeepEquals, []int{1, 2, 3, 4})
+	c.Assert(s1.Intersection(s2).SortedValues(lessInt), jc.DeepEquals, []int{2, 3})
+	c.Assert(s1.Difference(s2).SortedValues(lessInt), jc.DeepEquals, []int{1})
+	c.Assert(s2.Difference(s1).SortedValues(lessInt), jc.DeepEquals, []int{4})
+	// The originals are unchanged.
+	c.Assert(s1.SortedValues(lessInt), jc.DeepEquals, []int{1, 2, 3})
+}
+
+func (genericSetSuite) TestUninitializedPanics(c *gc.C) {
+	f := func() {
+		var s set.Set[string]
+		s.Add("foo")
+	}
+	c.Assert(f, gc.PanicMatches, "uninitalised set")
+}
@@ -4,6 +4,7 @@
 package set
 
 import (
+	"encoding/json"
 	"sort"
 )
 
@@ -110,3 +111,29 @@ func (s Strings) Difference(other Strings) Strings {
 	}
 	return result
 }
+
+// MarshalJSON implements json.Marshaler. The set is encoded as an
+// object mapping each value to true, as it always has been, so that
+// existing readers of encoded sets are unaffected.
+func (s Strings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]bool(s))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts an object
+// mapping values to true, and also an array of strings.
+func (s *Strings) UnmarshalJSON(data []byte) error {
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		var m map[string]bool
+		if json.Unmarshal(data, &m) != nil {
+			return err
+		}
+		for value, ok := range m {
+			if ok {
+				values = append(values, value)
+			}
+		}
+	}
+	*s = NewStrings(values...)
+	return nil
+}
@@ -4,9 +4,11 @@
 package set_test
 
 import (
+	"encoding/json"
 	"sort"
 
 	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
 	"github.com/juju/utils/set"
@@ -134,3 +136,45 @@ func (stringSetSuite) TestUninitializedPanics(c *gc.C) {
 	}
 	c.Assert(f, gc.PanicMatches, "uninitalised set")
 }
+
+func (stringSetSuite) TestMarshalJSON(c *gc.C) {
+	data, err := json.Marshal(set.NewStrings("foo", "bar", "baz"))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{"bar":true,"baz":true,"foo":true}`)
+
+	data, err = json.Marshal(set.NewStrings())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{}`)
+
+	data, err = json.Marshal(struct {
+		Names set.Strings `json:"names"`
+	}{set.NewStrings("foo")})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{"names":{"foo":true}}`)
+
+	var s set.Strings
+	err = json.Unmarshal(data, &struct {
+		Names *set.Strings `json:"names"`
+	}{&s})
+	c.Assert(err, jc.ErrorIsNil)
+	AssertValues(c, s, "foo")
+}
+
+func (stringSetSuite) TestUnmarshalJSON(c *gc.C) {
+	var s set.Strings
+	err := json.Unmarshal([]byte(`["foo","bar","foo"]`), &s)
+	c.Assert(err, jc.ErrorIsNil)
+	AssertValues(c, s, "foo", "bar")
+
+	err = json.Unmarshal([]byte(`{"foo":true,"bar":true,"baz":false}`), &s)
+	c.Assert(err, jc.ErrorIsNil)
+	AssertValues(c, s, "foo", "bar")
+
+	err = json.Unmarshal([]byte(`[]`), &s)
+	c.Assert(err, jc.ErrorIsNil)
+	AssertValues(c, s)
+	s.Add("foo")
+
+	err = json.Unmarshal([]byte(`[1]`), &s)
+	c.Assert(err, gc.ErrorMatches, "json: cannot unmarshal number .*")
+}
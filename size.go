@@ -55,6 +55,98 @@ func sizeSuffixMultiplier(i int) int {
 	return 1 << uint(i*10)
 }
 
+// byteUnits holds the unit prefixes understood by ParseBytes and used
+// by FormatSize and FormatSizeSI, in increasing order of size.
+var byteUnits = "KMGTPE"
+
+// ParseBytes parses the string as a size in bytes. The string must be
+// a non-negative number, optionally followed by a unit. Units with an
+// "i", such as "KiB" and "GiB", and bare prefixes such as "K" and "G",
+// are binary (powers of 1024); units ending with just "B", such as "kB"
+// and "GB", are SI (powers of 1000). Units are not case sensitive and
+// may be separated from the number by spaces. With no unit, or "B", the
+// number is taken to be bytes.
+//
+// Unlike ParseBytes, ParseSize returns mebibytes and treats "GB" as
+// binary.
+func ParseBytes(str string) (uint64, error) {
+	str = strings.TrimSpace(str)
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return r != '.' && !unicode.IsDigit(r)
+	})
+	number, unit := str, ""
+	if i >= 0 {
+		number, unit = str[:i], strings.TrimSpace(str[i:])
+	}
+	val, err := strconv.ParseFloat(number, 64)
+	if err != nil || val < 0 {
+		return 0, errors.Errorf("expected a non-negative number, got %q", str)
+	}
+	multiplier, err := byteUnitMultiplier(unit)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	val = math.Ceil(val * multiplier)
+	if val >= math.MaxUint64 {
+		return 0, errors.Errorf("size %q too large", str)
+	}
+	return uint64(val), nil
+}
+
+func byteUnitMultiplier(unit string) (float64, error) {
+	lower := strings.ToLower(unit)
+	if lower == "" || lower == "b" {
+		return 1, nil
+	}
+	j := strings.IndexByte(strings.ToLower(byteUnits), lower[0])
+	if j < 0 {
+		return 0, errors.Errorf("invalid unit %q", unit)
+	}
+	switch lower[1:] {
+	case "", "i", "ib":
+		return math.Pow(1024, float64(j+1)), nil
+	case "b":
+		return math.Pow(1000, float64(j+1)), nil
+	}
+	return 0, errors.Errorf("invalid unit %q", unit)
+}
+
+// FormatSize formats a size in bytes for people to read, using binary
+// units, for example "1.5GiB". Sizes are given to one decimal place,
+// which is omitted when it is zero.
+func FormatSize(bytes uint64) string {
+	return formatSize(bytes, 1024, "iB")
+}
+
+// FormatSizeSI is like FormatSize, but uses SI units, for example
+// "1.6GB".
+func FormatSizeSI(bytes uint64) string {
+	return formatSize(bytes, 1000, "B")
+}
+
+func formatSize(bytes uint64, base float64, suffix string) string {
+	if float64(bytes) < base {
+		return strconv.FormatUint(bytes, 10) + "B"
+	}
+	val := float64(bytes)
+	unit := -1
+	for val >= base && unit < len(byteUnits)-1 {
+		val /= base
+		unit++
+	}
+	// Rounding may take us to the next unit; 1023.96KiB is 1MiB.
+	if math.Floor(val*10+0.5) >= base*10 && unit < len(byteUnits)-1 {
+		val /= base
+		unit++
+	}
+	prefix := string(byteUnits[unit])
+	if base == 1000 && prefix == "K" {
+		prefix = "k"
+	}
+	formatted := strconv.FormatFloat(val, 'f', 1, 64)
+	return strings.TrimSuffix(formatted, ".0") + prefix + suffix
+}
+
 // SizeTracker tracks the number of bytes passing through
 // its Write method (which is otherwise a no-op).
 //
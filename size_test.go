@@ -83,6 +83,98 @@ func (*sizeSuite) TestParseSize(c *gc.C) {
 	}
 }
 
+func (*sizeSuite) TestParseBytes(c *gc.C) {
+	for i, test := range []struct {
+		in  string
+		out uint64
+		err string
+	}{{
+		in:  "",
+		err: `expected a non-negative number, got ""`,
+	}, {
+		in:  "-1K",
+		err: `expected a non-negative number, got "-1K"`,
+	}, {
+		in:  "1QB",
+		err: `invalid unit "QB"`,
+	}, {
+		in:  "1KX",
+		err: `invalid unit "KX"`,
+	}, {
+		in:  "100000E",
+		err: `size "100000E" too large`,
+	}, {
+		in:  "123",
+		out: 123,
+	}, {
+		in:  "123B",
+		out: 123,
+	}, {
+		in:  "1.5GiB",
+		out: 1610612736,
+	}, {
+		in:  "1.5G",
+		out: 1610612736,
+	}, {
+		in:  "1.5 gi",
+		out: 1610612736,
+	}, {
+		in:  "1.5GB",
+		out: 1500000000,
+	}, {
+		in:  "2kB",
+		out: 2000,
+	}, {
+		in:  "2KiB",
+		out: 2048,
+	}, {
+		in:  "0.1K",
+		out: 103,
+	}, {
+		in:  "1EiB",
+		out: 1 << 60,
+	}} {
+		c.Logf("test %d: %q", i, test.in)
+		size, err := utils.ParseBytes(test.in)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+		} else {
+			c.Check(err, jc.ErrorIsNil)
+			c.Check(size, gc.Equals, test.out)
+		}
+	}
+}
+
+func (*sizeSuite) TestFormatSize(c *gc.C) {
+	for i, test := range []struct {
+		in     uint64
+		binary string
+		si     string
+	}{
+		{0, "0B", "0B"},
+		{999, "999B", "999B"},
+		{1000, "1000B", "1kB"},
+		{1024, "1KiB", "1kB"},
+		{1536, "1.5KiB", "1.5kB"},
+		{1048575, "1MiB", "1MB"},
+		{1610612736, "1.5GiB", "1.6GB"},
+		{1 << 62, "4EiB", "4.6EB"},
+		{1<<64 - 1, "16EiB", "18.4EB"},
+	} {
+		c.Logf("test %d: %d", i, test.in)
+		c.Check(utils.FormatSize(test.in), gc.Equals, test.binary)
+		c.Check(utils.FormatSizeSI(test.in), gc.Equals, test.si)
+	}
+}
+
+func (*sizeSuite) TestFormatSizeRoundTrip(c *gc.C) {
+	for _, size := range []uint64{1, 1024, 3 << 20, 5 << 40} {
+		parsed, err := utils.ParseBytes(utils.FormatSize(size))
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(parsed, gc.Equals, size)
+	}
+}
+
 func (*sizeSuite) TestSizingReaderOkay(c *gc.C) {
 	expected := "some data"
 	stub := &testing.Stub{}
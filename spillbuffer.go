@@ -0,0 +1,177 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/juju/errors"
+)
+
+// SpillBuffer is a buffer that keeps its data in memory until it grows
+// past a threshold, after which the data is moved to a temporary file,
+// so that data of unknown size, such as an HTTP response body, can be
+// buffered without using unbounded memory.
+//
+// Data written to a SpillBuffer is always appended; it is read with
+// Read, ReadAt and Seek, which use a separate read offset. The buffer
+// must be closed to remove any temporary file.
+type SpillBuffer struct {
+	threshold int64
+	dir       string
+
+	mem    bytes.Buffer
+	file   *os.File
+	size   int64
+	offset int64
+}
+
+var (
+	_ io.ReadSeeker = (*SpillBuffer)(nil)
+	_ io.ReaderAt   = (*SpillBuffer)(nil)
+	_ io.Writer     = (*SpillBuffer)(nil)
+	_ io.Closer     = (*SpillBuffer)(nil)
+)
+
+// NewSpillBuffer returns a SpillBuffer that keeps up to threshold bytes
+// in memory. If more is written, the data is moved to a temporary file
+// created in dir, or in the default directory for temporary files if
+// dir is empty.
+func NewSpillBuffer(threshold int64, dir string) *SpillBuffer {
+	return &SpillBuffer{
+		threshold: threshold,
+		dir:       dir,
+	}
+}
+
+// ReadToSpillBuffer reads all the data from r into a new SpillBuffer
+// with the given threshold and directory, as accepted by
+// NewSpillBuffer.
+func ReadToSpillBuffer(r io.Reader, threshold int64, dir string) (*SpillBuffer, error) {
+	b := NewSpillBuffer(threshold, dir)
+	if _, err := io.Copy(b, r); err != nil {
+		b.Close()
+		return nil, errors.Trace(err)
+	}
+	return b, nil
+}
+
+// Write implements io.Writer by appending p to the buffer.
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.size+int64(len(p)) > b.threshold {
+		if err := b.spill(); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.WriteAt(p, b.size)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// spill moves the data in memory to a new temporary file.
+func (b *SpillBuffer) spill() error {
+	file, err := ioutil.TempFile(b.dir, "spillbuffer")
+	if err != nil {
+		return errors.Annotate(err, "cannot create temporary file")
+	}
+	if _, err := file.Write(b.mem.Bytes()); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return errors.Annotate(err, "cannot write temporary file")
+	}
+	b.file = file
+	b.mem = bytes.Buffer{}
+	return nil
+}
+
+// Read implements io.Reader.
+func (b *SpillBuffer) Read(p []byte) (int, error) {
+	n, err := b.ReadAt(p, b.offset)
+	b.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt.
+func (b *SpillBuffer) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= b.size {
+		return 0, io.EOF
+	}
+	if b.file != nil {
+		if remaining := b.size - off; int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+		n, err := b.file.ReadAt(p, off)
+		if err == nil && off+int64(n) == b.size {
+			err = io.EOF
+		}
+		return n, err
+	}
+	n := copy(p, b.mem.Bytes()[off:])
+	if off+int64(n) == b.size {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek implements io.Seeker. It sets the offset for the next Read.
+func (b *SpillBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case os.SEEK_SET:
+	case os.SEEK_CUR:
+		offset += b.offset
+	case os.SEEK_END:
+		offset += b.size
+	default:
+		return 0, errors.NotValidf("whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	b.offset = offset
+	return offset, nil
+}
+
+// Size returns the number of bytes written to the buffer.
+func (b *SpillBuffer) Size() int64 {
+	return b.size
+}
+
+// InMemory reports whether the data is held in memory rather than in
+// a temporary file.
+func (b *SpillBuffer) InMemory() bool {
+	return b.file == nil
+}
+
+// Close implements io.Closer. It discards the data and removes any
+// temporary file.
+func (b *SpillBuffer) Close() error {
+	b.mem = bytes.Buffer{}
+	b.size = 0
+	b.offset = 0
+	if b.file == nil {
+		return nil
+	}
+	file := b.file
+	b.file = nil
+	err := file.Close()
+	if removeErr := os.Remove(file.Name()); err == nil {
+		err = removeErr
+	}
+	return errors.Trace(err)
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type spillBufferSuite struct {
+	testing.IsolationSuite
+	dir string
+}
+
+var _ = gc.Suite(&spillBufferSuite{})
+
+func (s *spillBufferSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.dir = c.MkDir()
+}
+
+func (s *spillBufferSuite) tempFiles(c *gc.C) []string {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*"))
+	c.Assert(err, jc.ErrorIsNil)
+	return names
+}
+
+func (s *spillBufferSuite) TestInMemory(c *gc.C) {
+	b := utils.NewSpillBuffer(10, s.dir)
+	defer b.Close()
+	_, err := io.WriteString(b, "hello")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = io.WriteString(b, "world")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(b.InMemory(), jc.IsTrue)
+	c.Assert(b.Size(), gc.Equals, int64(10))
+	c.Assert(s.tempFiles(c), gc.HasLen, 0)
+
+	data, err := ioutil.ReadAll(b)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "helloworld")
+}
+
+func (s *spillBufferSuite) TestSpill(c *gc.C) {
+	b := utils.NewSpillBuffer(10, s.dir)
+	_, err := io.WriteString(b, "hello")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = io.WriteString(b, " there world")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(b.InMemory(), jc.IsFalse)
+	c.Assert(b.Size(), gc.Equals, int64(17))
+	c.Assert(s.tempFiles(c), gc.HasLen, 1)
+
+	data, err := ioutil.ReadAll(b)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "hello there world")
+
+	c.Assert(b.Close(), jc.ErrorIsNil)
+	c.Assert(s.tempFiles(c), gc.HasLen, 0)
+}
+
+func (s *spillBufferSuite) TestSeekAndReadAt(c *gc.C) {
+	for _, threshold := range []int64{100, 5} {
+		c.Logf("threshold %d", threshold)
+		b := utils.NewSpillBuffer(threshold, s.dir)
+		_, err := io.WriteString(b, "0123456789")
+		c.Assert(err, jc.ErrorIsNil)
+
+		pos, err := b.Seek(-4, os.SEEK_END)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(pos, gc.Equals, int64(6))
+		buf := make([]byte, 3)
+		n, err := b.Read(buf)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(string(buf[:n]), gc.Equals, "678")
+
+		pos, err = b.Seek(-5, os.SEEK_CUR)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(pos, gc.Equals, int64(4))
+		data, err := ioutil.ReadAll(b)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(string(data), gc.Equals, "456789")
+
+		n, err = b.ReadAt(buf, 8)
+		c.Assert(err, gc.Equals, io.EOF)
+		c.Assert(string(buf[:n]), gc.Equals, "89")
+
+		_, err = b.Seek(-1, os.SEEK_SET)
+		c.Assert(err, gc.ErrorMatches, "negative position")
+
+		// Writes append, whatever the read offset.
+		_, err = b.Seek(0, os.SEEK_SET)
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = io.WriteString(b, "abc")
+		c.Assert(err, jc.ErrorIsNil)
+		data, err = ioutil.ReadAll(b)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(string(data), gc.Equals, "0123456789abc")
+
+		c.Assert(b.Close(), jc.ErrorIsNil)
+	}
+}
+
+func (s *spillBufferSuite) TestReadToSpillBuffer(c *gc.C) {
+	content := strings.Repeat("x", 1000)
+	b, err := utils.ReadToSpillBuffer(strings.NewReader(content), 100, s.dir)
+	c.Assert(err, jc.ErrorIsNil)
+	defer b.Close()
+	c.Assert(b.InMemory(), jc.IsFalse)
+	data, err := ioutil.ReadAll(b)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(bytes.Equal(data, []byte(content)), jc.IsTrue)
+}
+
+func (s *spillBufferSuite) TestReadToSpillBufferError(c *gc.C) {
+	r := io.MultiReader(strings.NewReader(strings.Repeat("x", 1000)), errorReader{errors.New("read failed")})
+	_, err := utils.ReadToSpillBuffer(r, 100, s.dir)
+	c.Assert(err, gc.ErrorMatches, "read failed")
+	c.Assert(s.tempFiles(c), gc.HasLen, 0)
+}
@@ -33,10 +33,15 @@ const (
 	authKeysFile = "authorized_keys"
 )
 
+// AuthorisedKey holds the parts of a line from an authorized_keys file.
 type AuthorisedKey struct {
 	Type    string
 	Key     []byte
 	Comment string
+
+	// Options holds any options given before the key, such as
+	// "no-pty" or `command="..."`.
+	Options []string
 }
 
 func authKeysDir(username string) (string, error) {
@@ -55,7 +60,7 @@ func authKeysDir(username string) (string, error) {
 // authorized_keys file and returns the constituent parts.
 // Based on description in "man sshd".
 func ParseAuthorisedKey(line string) (*AuthorisedKey, error) {
-	key, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	key, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
 	if err != nil {
 		return nil, errors.Errorf("invalid authorized_key %q", line)
 	}
@@ -63,6 +68,7 @@ func ParseAuthorisedKey(line string) (*AuthorisedKey, error) {
 		Type:    key.Type(),
 		Key:     key.Marshal(),
 		Comment: comment,
+		Options: options,
 	}, nil
 }
 
@@ -242,6 +242,7 @@ func (s *AuthorisedKeysKeysSuite) TestParseAuthorisedKey(c *gc.C) {
 		line    string
 		key     []byte
 		comment string
+		options []string
 		err     string
 	}{{
 		line: sshtesting.ValidKeyOne.Key,
@@ -255,8 +256,9 @@ func (s *AuthorisedKeysKeysSuite) TestParseAuthorisedKey(c *gc.C) {
 		err:  "invalid authorized_key \"ssh-xsa blah\"",
 	}, {
 		// options should be skipped
-		line: `no-pty,principals="\"",command="\!" ` + sshtesting.ValidKeyOne.Key,
-		key:  b64decode(c, strings.Fields(sshtesting.ValidKeyOne.Key)[1]),
+		line:    `no-pty,principals="\"",command="\!" ` + sshtesting.ValidKeyOne.Key,
+		key:     b64decode(c, strings.Fields(sshtesting.ValidKeyOne.Key)[1]),
+		options: []string{`no-pty`, `principals="\""`, `command="\!"`},
 	}, {
 		line: "ssh-rsa",
 		err:  "invalid authorized_key \"ssh-rsa\"",
@@ -270,6 +272,7 @@ func (s *AuthorisedKeysKeysSuite) TestParseAuthorisedKey(c *gc.C) {
 			c.Assert(ak, gc.Not(gc.IsNil))
 			c.Assert(ak.Key, gc.DeepEquals, test.key)
 			c.Assert(ak.Comment, gc.Equals, test.comment)
+			c.Assert(ak.Options, jc.DeepEquals, test.options)
 		}
 	}
 }
@@ -6,6 +6,8 @@ package ssh
 import (
 	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/juju/errors"
@@ -14,6 +16,9 @@ import (
 // KeyFingerprint returns the fingerprint and comment for the specified key
 // in authorized_key format. Fingerprints are generated according to RFC4716.
 // See ttp://www.ietf.org/rfc/rfc4716.txt, section 4.
+//
+// This is the legacy MD5 fingerprint format; recent versions of OpenSSH
+// show fingerprints as returned by KeyFingerprintSHA256 by default.
 func KeyFingerprint(key string) (fingerprint, comment string, err error) {
 	ak, err := ParseAuthorisedKey(key)
 	if err != nil {
@@ -31,3 +36,16 @@ func KeyFingerprint(key string) (fingerprint, comment string, err error) {
 	}
 	return buf.String(), ak.Comment, nil
 }
+
+// KeyFingerprintSHA256 returns the SHA256 fingerprint and comment for
+// the specified key in authorized_key format. The fingerprint is in the
+// format used by OpenSSH, for example
+// "SHA256:o4mn2Zj6qXG0gDlan4PqSrz9/5bt3OQvsUZADv4KXu0".
+func KeyFingerprintSHA256(key string) (fingerprint, comment string, err error) {
+	ak, err := ParseAuthorisedKey(key)
+	if err != nil {
+		return "", "", errors.Errorf("generating key fingerprint: %v", err)
+	}
+	sum := sha256.Sum256(ak.Key)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), ak.Comment, nil
+}
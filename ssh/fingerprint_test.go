@@ -35,3 +35,26 @@ func (s *FingerprintSuite) TestKeyFingerprintError(c *gc.C) {
 	_, _, err := ssh.KeyFingerprint("invalid key")
 	c.Assert(err, gc.ErrorMatches, `generating key fingerprint: invalid authorized_key "invalid key"`)
 }
+
+func (s *FingerprintSuite) TestKeyFingerprintSHA256(c *gc.C) {
+	for _, test := range []struct {
+		key         string
+		fingerprint string
+	}{
+		{sshtesting.ValidKeyOne.Key, "SHA256:o4mn2Zj6qXG0gDlan4PqSrz9/5bt3OQvsUZADv4KXu0"},
+		{sshtesting.ValidKeyTwo.Key, "SHA256:/gOSUCn3qYtAJbM8GnxhyjVSKLKjT7b+ItzQRjgEXtM"},
+		{sshtesting.ValidKeyThree.Key + " a comment", "SHA256:eSvjPib3cU9Gx7d9iF6631jQVEn9tF+a7IkthGHzvJ0"},
+	} {
+		fingerprint, _, err := ssh.KeyFingerprintSHA256(test.key)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(fingerprint, gc.Equals, test.fingerprint)
+	}
+	_, comment, err := ssh.KeyFingerprintSHA256(sshtesting.ValidKeyOne.Key + " user@host")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(comment, gc.Equals, "user@host")
+}
+
+func (s *FingerprintSuite) TestKeyFingerprintSHA256Error(c *gc.C) {
+	_, _, err := ssh.KeyFingerprintSHA256("invalid key")
+	c.Assert(err, gc.ErrorMatches, `generating key fingerprint: invalid authorized_key "invalid key"`)
+}
@@ -4,24 +4,142 @@
 package ssh
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/binary"
 	"encoding/pem"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/juju/errors"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/ssh"
 )
 
 // rsaGenerateKey allows for tests to patch out rsa key generation
 var rsaGenerateKey = rsa.GenerateKey
 
+// KeyType identifies a type of SSH key.
+type KeyType string
+
+const (
+	RSAKey     KeyType = "rsa"
+	ED25519Key KeyType = "ed25519"
+)
+
+// KeyFormat identifies an encoding of an SSH private key.
+type KeyFormat string
+
+const (
+	// PEMFormat is the traditional PEM encoding of a PKCS1 private
+	// key. It is only available for RSA keys.
+	PEMFormat KeyFormat = "pem"
+
+	// OpenSSHFormat is the encoding used by OpenSSH since version
+	// 6.5, and the only one it supports for ed25519 keys.
+	OpenSSHFormat KeyFormat = "openssh"
+)
+
+// GenerateOptions holds the options for GenerateKeyWithOptions.
+type GenerateOptions struct {
+	// Type holds the type of key to generate. If it is empty, an
+	// RSA key is generated.
+	Type KeyType
+
+	// Bits holds the size of RSA keys. If it is zero, KeyBits is
+	// used.
+	Bits int
+
+	// Format holds the encoding of the private key. If it is empty,
+	// PEMFormat is used for RSA keys and OpenSSHFormat otherwise.
+	Format KeyFormat
+
+	// Comment holds the comment part of the public key.
+	Comment string
+
+	// Passphrase, if not empty, is used to encrypt the private key.
+	// Encryption is only supported for keys in PEMFormat, which
+	// uses the legacy PEM encryption understood by OpenSSH and
+	// OpenSSL. That derives the key from the passphrase with a single
+	// round of MD5, so it does little to slow down guessing a weak
+	// passphrase; an encrypted key should be protected as carefully
+	// as an unencrypted one.
+	Passphrase string
+}
+
 // KeyBits is used to determine the number of bits to use for the RSA keys
 // created using the GenerateKey function.
 var KeyBits = 2048
 
+// GenerateKeyWithOptions makes a new SSH key as described by opts. The
+// private key is returned in the requested format, and the public key
+// is returned in authorized_keys format, with opts.Comment as its
+// comment.
+func GenerateKeyWithOptions(opts GenerateOptions) (private, public string, err error) {
+	if opts.Type == "" {
+		opts.Type = RSAKey
+	}
+	if opts.Format == "" {
+		opts.Format = OpenSSHFormat
+		if opts.Type == RSAKey {
+			opts.Format = PEMFormat
+		}
+	}
+	var key crypto.Signer
+	switch opts.Type {
+	case RSAKey:
+		bits := opts.Bits
+		if bits == 0 {
+			bits = KeyBits
+		}
+		key, err = rsaGenerateKey(rand.Reader, bits)
+	case ED25519Key:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return "", "", errors.NotValidf("key type %q", opts.Type)
+	}
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+
+	var block *pem.Block
+	switch opts.Format {
+	case PEMFormat:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", "", errors.NotSupportedf("%s keys in PEM format", opts.Type)
+		}
+		block = &pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+		}
+		if opts.Passphrase != "" {
+			block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte(opts.Passphrase), x509.PEMCipherAES128)
+			if err != nil {
+				return "", "", errors.Trace(err)
+			}
+		}
+	case OpenSSHFormat:
+		if opts.Passphrase != "" {
+			return "", "", errors.NotSupportedf("encrypting keys in OpenSSH format")
+		}
+		block, err = marshalOpenSSHPrivateKey(key, pub, opts.Comment)
+		if err != nil {
+			return "", "", errors.Trace(err)
+		}
+	default:
+		return "", "", errors.NotValidf("key format %q", opts.Format)
+	}
+	return string(pem.EncodeToMemory(block)), authorizedKeyLine(pub, opts.Comment), nil
+}
+
 // GenerateKey makes a 2048 bit RSA no-passphrase SSH capable key.  The bit
 // size is actually controlled by the KeyBits var. The private key returned is
 // encoded to ASCII using the PKCS1 encoding.  The public key is suitable to
@@ -56,10 +174,85 @@ func PublicKey(privateKey []byte, comment string) (string, error) {
 		return "", errors.Annotate(err, "failed to load key")
 	}
 
-	auth_key := string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	return authorizedKeyLine(signer.PublicKey(), comment), nil
+}
+
+// authorizedKeyLine returns the given public key in authorized_keys
+// format with the given comment.
+func authorizedKeyLine(key ssh.PublicKey, comment string) string {
+	auth_key := string(ssh.MarshalAuthorizedKey(key))
 	// Strip off the trailing new line so we can add a comment.
 	auth_key = strings.TrimSpace(auth_key)
-	public := fmt.Sprintf("%s %s\n", auth_key, comment)
+	return fmt.Sprintf("%s %s\n", auth_key, comment)
+}
+
+// openSSHMagic starts every key in OpenSSH format.
+const openSSHMagic = "openssh-key-v1\x00"
+
+// marshalOpenSSHPrivateKey encodes an unencrypted private key in
+// OpenSSH format, as described in the PROTOCOL.key file in the OpenSSH
+// sources.
+func marshalOpenSSHPrivateKey(key crypto.Signer, pub ssh.PublicKey, comment string) (*pem.Block, error) {
+	var keyFields []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		key.Precompute()
+		keyFields = ssh.Marshal(struct {
+			N       *big.Int
+			E       *big.Int
+			D       *big.Int
+			Iqmp    *big.Int
+			P       *big.Int
+			Q       *big.Int
+			Comment string
+		}{
+			key.N, big.NewInt(int64(key.E)), key.D,
+			key.Precomputed.Qinv, key.Primes[0], key.Primes[1],
+			comment,
+		})
+	case ed25519.PrivateKey:
+		keyFields = ssh.Marshal(struct {
+			Pub     []byte
+			Priv    []byte
+			Comment string
+		}{
+			[]byte(key.Public().(ed25519.PublicKey)), []byte(key), comment,
+		})
+	default:
+		return nil, errors.Errorf("unsupported key type %T", key)
+	}
+
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, errors.Trace(err)
+	}
+	checkInt := binary.BigEndian.Uint32(check[:])
+	privBlock := ssh.Marshal(struct {
+		Check1  uint32
+		Check2  uint32
+		KeyType string
+		Rest    []byte `ssh:"rest"`
+	}{
+		checkInt, checkInt, pub.Type(), keyFields,
+	})
+	// Unencrypted keys are padded to a multiple of 8 bytes with
+	// the bytes 1, 2, 3...
+	for i := byte(1); len(privBlock)%8 != 0; i++ {
+		privBlock = append(privBlock, i)
+	}
 
-	return public, nil
+	data := ssh.Marshal(struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{
+		"none", "none", "", 1, pub.Marshal(), privBlock,
+	})
+	return &pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte(openSSHMagic), data...),
+	}, nil
 }
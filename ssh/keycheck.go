@@ -0,0 +1,109 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package ssh
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/rsa"
+
+	"github.com/juju/errors"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyRequirements holds the requirements checked by CheckKey.
+type KeyRequirements struct {
+	// AllowedTypes holds the allowed key types, as named in
+	// authorized_keys files, for example "ssh-ed25519". If it is
+	// empty, keys of any type are allowed.
+	AllowedTypes []string
+
+	// MinRSABits holds the minimum size of RSA keys.
+	MinRSABits int
+}
+
+// DefaultKeyRequirements holds requirements suitable for checking keys
+// supplied by users. DSA keys, which recent versions of OpenSSH no
+// longer accept, and small RSA keys are rejected.
+var DefaultKeyRequirements = KeyRequirements{
+	AllowedTypes: []string{
+		ssh.KeyAlgoRSA,
+		ssh.KeyAlgoECDSA256,
+		ssh.KeyAlgoECDSA384,
+		ssh.KeyAlgoECDSA521,
+		ssh.KeyAlgoED25519,
+	},
+	MinRSABits: 2048,
+}
+
+// CheckKey checks that the specified key in authorized_key format is
+// valid and meets the given requirements.
+func CheckKey(key string, req KeyRequirements) error {
+	pub, err := parsePublicKey(key)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(req.AllowedTypes) > 0 && !containsString(req.AllowedTypes, pub.Type()) {
+		return errors.NotValidf("key type %q", pub.Type())
+	}
+	if pub.Type() == ssh.KeyAlgoRSA {
+		bits, err := publicKeySize(pub)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if bits < req.MinRSABits {
+			return errors.NotValidf("%d bit RSA key (minimum is %d bits)", bits, req.MinRSABits)
+		}
+	}
+	return nil
+}
+
+// KeySize returns the size in bits of the specified key in
+// authorized_key format.
+func KeySize(key string) (int, error) {
+	pub, err := parsePublicKey(key)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return publicKeySize(pub)
+}
+
+func parsePublicKey(key string) (ssh.PublicKey, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return nil, errors.Errorf("invalid authorized_key %q", key)
+	}
+	return pub, nil
+}
+
+func publicKeySize(pub ssh.PublicKey) (int, error) {
+	if cert, ok := pub.(*ssh.Certificate); ok {
+		pub = cert.Key
+	}
+	cpub, ok := pub.(ssh.CryptoPublicKey)
+	if !ok {
+		return 0, errors.NotSupportedf("key type %q", pub.Type())
+	}
+	switch key := cpub.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return key.N.BitLen(), nil
+	case *dsa.PublicKey:
+		return key.P.BitLen(), nil
+	case *ecdsa.PublicKey:
+		return key.Curve.Params().BitSize, nil
+	case ed25519.PublicKey:
+		return 256, nil
+	}
+	return 0, errors.NotSupportedf("key type %q", pub.Type())
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package ssh_test
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/ssh"
+	sshtesting "github.com/juju/utils/ssh/testing"
+)
+
+type KeyCheckSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&KeyCheckSuite{})
+
+const (
+	ed25519Key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGA8Q0O612FLbEzfnL61oUeneIYRBx20R83ijDjsj1k+ ed"
+
+	ecdsa384Key = "ecdsa-sha2-nistp384 AAAAE2VjZHNhLXNoYTItbmlzdHAzODQAAAAIbmlzdHAzODQAAABhBKXC0PB48+TK9uX9dJPM8OIXZJ3yV0efkN4RMRdX5Gx6eBQUl3TDXgENjzAS5gzDIlFe4CkIJ+DSYHBTJ7fdiYplHhqzaGe+Ktzj1F2jIJ6vrKDrZsQdb0UpJDHgAKz9Rg== ec"
+
+	rsa1024Key = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAgQDDGWUNeX3Vc8OrWiqg5Ay3ItXXqAzsYvKOL6DQ5sAq7QfcpPcT0rFZPAdiPXa33vx9vlcfQM5MPbEzt8svW6f7yrilXve5I4oHi1QMQ0Kd225va9yoA9esYSxDU5L7FgKBgtI/PGBG2FoJvYugCo81uC9sTlGhYHH1n+FPNqPElQ== small"
+)
+
+func (s *KeyCheckSuite) TestKeySize(c *gc.C) {
+	for i, test := range []struct {
+		key  string
+		bits int
+	}{
+		{sshtesting.ValidKeyOne.Key, 2048},
+		{rsa1024Key, 1024},
+		{ecdsa384Key, 384},
+		{ed25519Key, 256},
+	} {
+		c.Logf("test %d", i)
+		bits, err := ssh.KeySize(test.key)
+		c.Check(err, jc.ErrorIsNil)
+		c.Check(bits, gc.Equals, test.bits)
+	}
+}
+
+func (s *KeyCheckSuite) TestKeySizeInvalid(c *gc.C) {
+	_, err := ssh.KeySize("ssh-rsa bad key")
+	c.Assert(err, gc.ErrorMatches, `invalid authorized_key "ssh-rsa bad key"`)
+}
+
+func (s *KeyCheckSuite) TestCheckKeyDefaults(c *gc.C) {
+	for i, test := range []struct {
+		key string
+		err string
+	}{{
+		key: sshtesting.ValidKeyOne.Key,
+	}, {
+		key: `no-pty ` + ed25519Key,
+	}, {
+		key: ecdsa384Key,
+	}, {
+		key: rsa1024Key,
+		err: `1024 bit RSA key \(minimum is 2048 bits\) not valid`,
+	}, {
+		key: "ssh-rsa bad key",
+		err: `invalid authorized_key "ssh-rsa bad key"`,
+	}} {
+		c.Logf("test %d", i)
+		err := ssh.CheckKey(test.key, ssh.DefaultKeyRequirements)
+		if test.err == "" {
+			c.Check(err, jc.ErrorIsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *KeyCheckSuite) TestCheckKeyAllowedTypes(c *gc.C) {
+	req := ssh.KeyRequirements{
+		AllowedTypes: []string{"ssh-ed25519"},
+	}
+	c.Assert(ssh.CheckKey(ed25519Key, req), jc.ErrorIsNil)
+	err := ssh.CheckKey(rsa1024Key, req)
+	c.Assert(err, gc.ErrorMatches, `key type "ssh-rsa" not valid`)
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+}
@@ -0,0 +1,292 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package ssh
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+	"github.com/juju/utils/fslock"
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyFileFormat identifies the format of a KeyFile.
+type KeyFileFormat int
+
+const (
+	// AuthorizedKeysFormat is the format of authorized_keys files:
+	// optional options, followed by a key and an optional comment.
+	AuthorizedKeysFormat KeyFileFormat = iota
+
+	// KnownHostsFormat is the format of known_hosts files: an
+	// optional marker, followed by a list of host patterns, a key
+	// and an optional comment.
+	KnownHostsFormat
+)
+
+// keyFileLockTimeout holds how long KeyFile waits for other processes
+// to finish updating the file.
+const keyFileLockTimeout = 30 * time.Second
+
+// KeyFile provides access to a file of SSH keys, such as an
+// authorized_keys or known_hosts file. Updates are written atomically
+// while holding a lock, so that concurrent updates by different
+// processes are not lost. Lines that are not changed, including
+// comments, blank lines and any lines that can't be parsed, are
+// preserved exactly.
+type KeyFile struct {
+	path   string
+	format KeyFileFormat
+}
+
+// NewKeyFile returns a KeyFile that accesses the file at the given
+// path, which is in the given format. The file need not exist until it
+// is updated. The lock file used when updating it is the same path with
+// ".lock" appended.
+func NewKeyFile(path string, format KeyFileFormat) *KeyFile {
+	return &KeyFile{
+		path:   path,
+		format: format,
+	}
+}
+
+// KeyFileEntry holds a line of a KeyFile.
+type KeyFileEntry struct {
+	// Line holds the line as it appears in the file.
+	Line string
+
+	// Key holds the key parsed from the line. It is nil if the line
+	// is blank, a comment, or could not be parsed.
+	Key ssh.PublicKey
+
+	// Comment holds the comment that follows the key.
+	Comment string
+
+	// Options holds the options that precede the key in an
+	// authorized_keys file.
+	Options []string
+
+	// Marker holds the marker, such as "@revoked", that starts a
+	// line in a known_hosts file.
+	Marker string
+
+	// Hosts holds the host patterns from a line in a known_hosts
+	// file. Hashed host names are left as they are.
+	Hosts []string
+}
+
+// MatchesHost reports whether the entry is for the given host, which
+// should be normalized as by knownhosts.Normalize. Both plain and
+// hashed host names are matched; wildcard and negated patterns are
+// not.
+func (e KeyFileEntry) MatchesHost(host string) bool {
+	for _, h := range e.Hosts {
+		if h == host || hashedHostMatches(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// id returns a string that is the same for entries that are
+// duplicates of one another.
+func (e KeyFileEntry) id() string {
+	return e.Marker + " " + strings.Join(e.Hosts, ",") + " " + string(e.Key.Marshal())
+}
+
+// Entries returns all the lines in the file. If the file doesn't exist,
+// there are no entries.
+func (f *KeyFile) Entries() ([]KeyFileEntry, error) {
+	entries, err := f.read()
+	return entries, errors.Trace(err)
+}
+
+// Add adds the given lines to the end of the file, unless the file
+// already has an entry with the same key (and, for known_hosts files,
+// with the same hosts and marker). It returns an error without changing
+// the file if any of the lines can't be parsed.
+func (f *KeyFile) Add(lines ...string) error {
+	newEntries := make([]KeyFileEntry, len(lines))
+	for i, line := range lines {
+		newEntries[i] = f.parse(line)
+		if newEntries[i].Key == nil {
+			return errors.NotValidf("key file entry %q", line)
+		}
+	}
+	return errors.Trace(f.update(func(entries []KeyFileEntry) ([]KeyFileEntry, error) {
+		seen := make(map[string]bool)
+		for _, e := range entries {
+			if e.Key != nil {
+				seen[e.id()] = true
+			}
+		}
+		for _, e := range newEntries {
+			if !seen[e.id()] {
+				seen[e.id()] = true
+				entries = append(entries, e)
+			}
+		}
+		return entries, nil
+	}))
+}
+
+// Remove removes all the entries with keys for which match returns
+// true, and returns how many were removed. Lines without keys are
+// never removed.
+func (f *KeyFile) Remove(match func(KeyFileEntry) bool) (int, error) {
+	removed := 0
+	err := f.update(func(entries []KeyFileEntry) ([]KeyFileEntry, error) {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.Key != nil && match(e) {
+				removed++
+				continue
+			}
+			kept = append(kept, e)
+		}
+		return kept, nil
+	})
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return removed, nil
+}
+
+// Deduplicate removes entries that duplicate an earlier entry in the
+// file, and returns how many were removed.
+func (f *KeyFile) Deduplicate() (int, error) {
+	seen := make(map[string]bool)
+	return f.Remove(func(e KeyFileEntry) bool {
+		if seen[e.id()] {
+			return true
+		}
+		seen[e.id()] = true
+		return false
+	})
+}
+
+// update calls change with the current entries and writes the entries
+// it returns back to the file, holding the lock throughout. The file is
+// only written if the entries have changed.
+func (f *KeyFile) update(change func([]KeyFileEntry) ([]KeyFileEntry, error)) (err error) {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return errors.Trace(err)
+	}
+	lock, err := fslock.NewLock(f.path+".lock", fslock.LockConfig{})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := lock.LockWithTimeout(keyFileLockTimeout); err != nil {
+		return errors.Annotatef(err, "cannot lock %q", f.path)
+	}
+	defer func() {
+		if unlockErr := lock.Unlock(); unlockErr != nil && err == nil {
+			err = errors.Trace(unlockErr)
+		}
+	}()
+
+	entries, err := f.read()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	before := joinEntries(entries)
+	entries, err = change(entries)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	after := joinEntries(entries)
+	if after == before {
+		return nil
+	}
+	perms := os.FileMode(0600)
+	if info, err := os.Stat(f.path); err == nil {
+		perms = info.Mode().Perm()
+	}
+	logger.Debugf("writing ssh key file %s", f.path)
+	return errors.Trace(utils.AtomicWriteFile(f.path, []byte(after), perms))
+}
+
+func (f *KeyFile) read() ([]KeyFileEntry, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Annotate(err, "reading ssh key file")
+	}
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	lines := strings.Split(text, "\n")
+	entries := make([]KeyFileEntry, len(lines))
+	for i, line := range lines {
+		entries[i] = f.parse(line)
+	}
+	return entries, nil
+}
+
+func (f *KeyFile) parse(line string) KeyFileEntry {
+	e := KeyFileEntry{Line: line}
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == '#' {
+		return e
+	}
+	switch f.format {
+	case AuthorizedKeysFormat:
+		key, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err == nil {
+			e.Key, e.Comment, e.Options = key, comment, options
+		}
+	case KnownHostsFormat:
+		marker, hosts, key, comment, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err == nil {
+			e.Marker, e.Hosts, e.Key, e.Comment = marker, hosts, key, comment
+		}
+	}
+	return e
+}
+
+func joinEntries(entries []KeyFileEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = e.Line
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// hashedHostMatches reports whether pattern is a hashed host name, as
+// written by "ssh-keygen -H", that matches host.
+func hashedHostMatches(pattern, host string) bool {
+	const prefix = "|1|"
+	if !strings.HasPrefix(pattern, prefix) {
+		return false
+	}
+	parts := strings.Split(pattern[len(prefix):], "|")
+	if len(parts) != 2 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(host))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
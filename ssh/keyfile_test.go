@@ -0,0 +1,194 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package ssh_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/juju/errors"
+	gitjujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/ssh"
+	sshtesting "github.com/juju/utils/ssh/testing"
+)
+
+type KeyFileSuite struct {
+	gitjujutesting.IsolationSuite
+	path string
+}
+
+var _ = gc.Suite(&KeyFileSuite{})
+
+func (s *KeyFileSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.path = filepath.Join(c.MkDir(), ".ssh", "keys")
+}
+
+func (s *KeyFileSuite) writeFile(c *gc.C, content string) {
+	err := os.MkdirAll(filepath.Dir(s.path), 0700)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ioutil.WriteFile(s.path, []byte(content), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *KeyFileSuite) readFile(c *gc.C) string {
+	data, err := ioutil.ReadFile(s.path)
+	c.Assert(err, jc.ErrorIsNil)
+	return string(data)
+}
+
+func (s *KeyFileSuite) TestEntriesMissingFile(c *gc.C) {
+	entries, err := ssh.NewKeyFile(s.path, ssh.AuthorizedKeysFormat).Entries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(entries, gc.HasLen, 0)
+}
+
+func (s *KeyFileSuite) TestAddCreatesFile(c *gc.C) {
+	f := ssh.NewKeyFile(s.path, ssh.AuthorizedKeysFormat)
+	err := f.Add(sshtesting.ValidKeyOne.Key + " user@host")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.readFile(c), gc.Equals, sshtesting.ValidKeyOne.Key+" user@host\n")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(s.path)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0600))
+	}
+}
+
+func (s *KeyFileSuite) TestAddPreservesContent(c *gc.C) {
+	existing := "# managed by hand\n" +
+		"\n" +
+		`command="ls",no-pty ` + sshtesting.ValidKeyOne.Key + " user@host\n" +
+		"not a key\n"
+	s.writeFile(c, existing)
+	f := ssh.NewKeyFile(s.path, ssh.AuthorizedKeysFormat)
+	err := f.Add(
+		sshtesting.ValidKeyOne.Key+" another@host",
+		sshtesting.ValidKeyTwo.Key,
+		sshtesting.ValidKeyTwo.Key+" again",
+	)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.readFile(c), gc.Equals, existing+sshtesting.ValidKeyTwo.Key+"\n")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(s.path)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0644))
+	}
+
+	entries, err := f.Entries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(entries, gc.HasLen, 5)
+	c.Assert(entries[0].Key, gc.IsNil)
+	c.Assert(entries[1].Key, gc.IsNil)
+	c.Assert(entries[2].Key, gc.NotNil)
+	c.Assert(entries[2].Options, jc.DeepEquals, []string{`command="ls"`, "no-pty"})
+	c.Assert(entries[2].Comment, gc.Equals, "user@host")
+	c.Assert(entries[3].Key, gc.IsNil)
+	c.Assert(entries[3].Line, gc.Equals, "not a key")
+	c.Assert(entries[4].Key, gc.NotNil)
+}
+
+func (s *KeyFileSuite) TestAddInvalid(c *gc.C) {
+	s.writeFile(c, sshtesting.ValidKeyOne.Key+"\n")
+	f := ssh.NewKeyFile(s.path, ssh.AuthorizedKeysFormat)
+	err := f.Add(sshtesting.ValidKeyTwo.Key, "# comment")
+	c.Assert(err, gc.ErrorMatches, `key file entry "# comment" not valid`)
+	c.Assert(errors.IsNotValid(err), jc.IsTrue)
+	c.Assert(s.readFile(c), gc.Equals, sshtesting.ValidKeyOne.Key+"\n")
+}
+
+func (s *KeyFileSuite) TestRemove(c *gc.C) {
+	s.writeFile(c, "# keys\n"+
+		sshtesting.ValidKeyOne.Key+" one\n"+
+		sshtesting.ValidKeyTwo.Key+" two\n"+
+		"no-pty "+sshtesting.ValidKeyOne.Key+" three\n")
+	f := ssh.NewKeyFile(s.path, ssh.AuthorizedKeysFormat)
+	one, err := ssh.ParseAuthorisedKey(sshtesting.ValidKeyOne.Key)
+	c.Assert(err, jc.ErrorIsNil)
+	n, err := f.Remove(func(e ssh.KeyFileEntry) bool {
+		return bytes.Equal(e.Key.Marshal(), one.Key)
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(n, gc.Equals, 2)
+	c.Assert(s.readFile(c), gc.Equals, "# keys\n"+sshtesting.ValidKeyTwo.Key+" two\n")
+
+	n, err = f.Remove(func(e ssh.KeyFileEntry) bool {
+		return e.Comment != "two"
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(n, gc.Equals, 0)
+}
+
+func (s *KeyFileSuite) TestRemoveNothingLeavesFile(c *gc.C) {
+	f := ssh.NewKeyFile(s.path, ssh.AuthorizedKeysFormat)
+	n, err := f.Remove(func(ssh.KeyFileEntry) bool { return true })
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(n, gc.Equals, 0)
+	_, err = os.Stat(s.path)
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+}
+
+func (s *KeyFileSuite) TestDeduplicate(c *gc.C) {
+	s.writeFile(c, sshtesting.ValidKeyOne.Key+" one\n"+
+		"# comment\n"+
+		sshtesting.ValidKeyTwo.Key+" two\n"+
+		sshtesting.ValidKeyOne.Key+" one again\n"+
+		"# comment\n")
+	f := ssh.NewKeyFile(s.path, ssh.AuthorizedKeysFormat)
+	n, err := f.Deduplicate()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(n, gc.Equals, 1)
+	c.Assert(s.readFile(c), gc.Equals, sshtesting.ValidKeyOne.Key+" one\n"+
+		"# comment\n"+
+		sshtesting.ValidKeyTwo.Key+" two\n"+
+		"# comment\n")
+}
+
+// hashedExampleHost holds ValidKeyOne as a known_hosts entry for
+// example.com, hashed by "ssh-keygen -H".
+const hashedExampleHost = "|1|dOfAW6yPuBn4+BFEhk9yGnZEBVU=|LqZazbSFfs/+4bqPQLLJ01syEGM="
+
+func (s *KeyFileSuite) TestKnownHosts(c *gc.C) {
+	s.writeFile(c, hashedExampleHost+" "+sshtesting.ValidKeyOne.Key+"\n")
+	f := ssh.NewKeyFile(s.path, ssh.KnownHostsFormat)
+	err := f.Add(
+		"@cert-authority *.example.com "+sshtesting.ValidKeyTwo.Key,
+		"example.com,10.0.0.1 "+sshtesting.ValidKeyTwo.Key+" comment",
+		"[example.com]:2222 "+sshtesting.ValidKeyThree.Key,
+	)
+	c.Assert(err, jc.ErrorIsNil)
+
+	entries, err := f.Entries()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(entries, gc.HasLen, 4)
+	c.Assert(entries[0].Hosts, jc.DeepEquals, []string{hashedExampleHost})
+	c.Assert(entries[1].Marker, gc.Equals, "@cert-authority")
+	c.Assert(entries[2].Hosts, jc.DeepEquals, []string{"example.com", "10.0.0.1"})
+	c.Assert(entries[2].Comment, gc.Equals, "comment")
+
+	var matched []int
+	for i, e := range entries {
+		if e.MatchesHost("example.com") {
+			matched = append(matched, i)
+		}
+	}
+	c.Assert(matched, jc.DeepEquals, []int{0, 2})
+	c.Assert(entries[3].MatchesHost("[example.com]:2222"), jc.IsTrue)
+	c.Assert(entries[0].MatchesHost("example.org"), jc.IsFalse)
+
+	n, err := f.Remove(func(e ssh.KeyFileEntry) bool {
+		return e.MatchesHost("example.com")
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(n, gc.Equals, 2)
+	c.Assert(s.readFile(c), gc.Equals,
+		"@cert-authority *.example.com "+sshtesting.ValidKeyTwo.Key+"\n"+
+			"[example.com]:2222 "+sshtesting.ValidKeyThree.Key+"\n")
+}
@@ -0,0 +1,198 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package ssh
+
+import (
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/context"
+)
+
+// ErrTunnelClosed is returned when dialing through a TunnelDialer that
+// has been closed.
+var ErrTunnelClosed = errors.New("tunnel dialer closed")
+
+// TunnelConfig holds the configuration for a TunnelDialer.
+type TunnelConfig struct {
+	// JumpHost holds the address of the SSH server that connections
+	// are made through, in "host:port" form. If the port is omitted,
+	// port 22 is used.
+	JumpHost string
+
+	// ClientConfig holds the configuration used to connect to the
+	// jump host, including the user, the authentication methods and
+	// the host key callback.
+	ClientConfig *ssh.ClientConfig
+
+	// Dialer, if not nil, is used to connect to the jump host; for
+	// example, it may be another TunnelDialer to go through several
+	// jump hosts. If it has a DialContext method, that is used.
+	Dialer utils.Dialer
+}
+
+// TunnelDialer makes network connections through an SSH jump host, in
+// the same way as "ssh -W". It implements utils.Dialer, so it can be
+// passed to utils.InstallHTTPDialer to make HTTP requests to hosts that
+// are only reachable through a bastion.
+//
+// All the connections share a single SSH connection to the jump host,
+// which is made when first needed and made again if it fails.
+type TunnelDialer struct {
+	config TunnelConfig
+
+	// mu guards the fields below it. It is held while connecting to
+	// the jump host, so that only one connection is made.
+	mu     sync.Mutex
+	client *ssh.Client
+	closed bool
+}
+
+// NewTunnelDialer returns a TunnelDialer that connects through the jump
+// host described by config.
+func NewTunnelDialer(config TunnelConfig) (*TunnelDialer, error) {
+	if config.JumpHost == "" {
+		return nil, errors.NotValidf("empty JumpHost")
+	}
+	if config.ClientConfig == nil {
+		return nil, errors.NotValidf("nil ClientConfig")
+	}
+	if _, _, err := net.SplitHostPort(config.JumpHost); err != nil {
+		config.JumpHost = net.JoinHostPort(config.JumpHost, strconv.Itoa(sshDefaultPort))
+	}
+	if config.Dialer == nil {
+		config.Dialer = &net.Dialer{Timeout: config.ClientConfig.Timeout}
+	}
+	return &TunnelDialer{config: config}, nil
+}
+
+// Dial implements utils.Dialer by connecting to the given address
+// through the jump host. The address is resolved by the jump host.
+func (d *TunnelDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext is like Dial but gives up when ctx is done. The network
+// must be "tcp", "tcp4", "tcp6" or "unix".
+func (d *TunnelDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := d.sshClient(ctx)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot connect to jump host %s", d.config.JumpHost)
+	}
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	// The SSH client can't cancel opening a channel, so if ctx is
+	// done first, leave it to finish and close any connection made.
+	done := make(chan dialResult, 1)
+	go func() {
+		conn, err := client.Dial(network, addr)
+		done <- dialResult{conn, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, errors.Annotatef(r.err, "cannot dial %s through jump host %s", addr, d.config.JumpHost)
+		}
+		return r.conn, nil
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// Close closes the connection to the jump host, and with it all the
+// connections made through it. Subsequent dials fail with
+// ErrTunnelClosed.
+func (d *TunnelDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	if d.client == nil {
+		return nil
+	}
+	err := d.client.Close()
+	d.client = nil
+	return errors.Trace(err)
+}
+
+// sshClient returns the client connected to the jump host, connecting
+// if necessary.
+func (d *TunnelDialer) sshClient(ctx context.Context) (*ssh.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil, ErrTunnelClosed
+	}
+	if d.client != nil {
+		return d.client, nil
+	}
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	d.client = client
+	go func() {
+		// Forget the client when its connection fails, so
+		// that the next dial makes a new one.
+		client.Wait()
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.client == client {
+			d.client = nil
+		}
+	}()
+	return client, nil
+}
+
+// connect makes a new SSH connection to the jump host.
+func (d *TunnelDialer) connect(ctx context.Context) (*ssh.Client, error) {
+	logger.Debugf("connecting to ssh jump host %s", d.config.JumpHost)
+	conn, err := d.dialJumpHost(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// Abandon the handshake if ctx is done before it completes.
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, d.config.JumpHost, d.config.ClientConfig)
+	close(stop)
+	if ctx.Err() != nil {
+		if err == nil {
+			sshConn.Close()
+		}
+		conn.Close()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, errors.Trace(err)
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+func (d *TunnelDialer) dialJumpHost(ctx context.Context) (net.Conn, error) {
+	type contextDialer interface {
+		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	}
+	if dialer, ok := d.config.Dialer.(contextDialer); ok {
+		return dialer.DialContext(ctx, "tcp", d.config.JumpHost)
+	}
+	return d.config.Dialer.Dial("tcp", d.config.JumpHost)
+}
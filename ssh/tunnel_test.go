@@ -0,0 +1,265 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package ssh_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	cryptossh "golang.org/x/crypto/ssh"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+	"github.com/juju/utils/ssh"
+)
+
+// jumpHost is an SSH server that forwards direct-tcpip channels, as
+// used by "ssh -W".
+type jumpHost struct {
+	listener net.Listener
+	hostKey  cryptossh.PublicKey
+
+	mu      sync.Mutex
+	conns   []net.Conn
+	targets []string
+}
+
+func newJumpHost(c *gc.C) *jumpHost {
+	private, _, err := ssh.GenerateKey("jump-host")
+	c.Assert(err, jc.ErrorIsNil)
+	key, err := cryptossh.ParsePrivateKey([]byte(private))
+	c.Assert(err, jc.ErrorIsNil)
+	config := &cryptossh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(key)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, jc.ErrorIsNil)
+	h := &jumpHost{
+		listener: listener,
+		hostKey:  key.PublicKey(),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			h.mu.Lock()
+			h.conns = append(h.conns, conn)
+			h.mu.Unlock()
+			go h.serve(conn, config)
+		}
+	}()
+	return h
+}
+
+func (h *jumpHost) serve(conn net.Conn, config *cryptossh.ServerConfig) {
+	_, chans, reqs, err := cryptossh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go cryptossh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(cryptossh.UnknownChannelType, "unsupported")
+			continue
+		}
+		var payload struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if err := cryptossh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			newChannel.Reject(cryptossh.ConnectionFailed, err.Error())
+			continue
+		}
+		target := net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port)))
+		h.mu.Lock()
+		h.targets = append(h.targets, target)
+		h.mu.Unlock()
+		targetConn, err := net.Dial("tcp", target)
+		if err != nil {
+			newChannel.Reject(cryptossh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, chanReqs, err := newChannel.Accept()
+		if err != nil {
+			targetConn.Close()
+			continue
+		}
+		go cryptossh.DiscardRequests(chanReqs)
+		go func() {
+			io.Copy(targetConn, channel)
+			targetConn.Close()
+		}()
+		go func() {
+			io.Copy(channel, targetConn)
+			channel.Close()
+		}()
+	}
+}
+
+func (h *jumpHost) addr() string {
+	return h.listener.Addr().String()
+}
+
+// dropConnections closes all the SSH connections to the jump host.
+func (h *jumpHost) dropConnections() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, conn := range h.conns {
+		conn.Close()
+	}
+}
+
+// dialed returns the addresses that the jump host has been asked to
+// connect to.
+func (h *jumpHost) dialed() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.targets...)
+}
+
+func (h *jumpHost) connCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.conns)
+}
+
+func (h *jumpHost) close() {
+	h.listener.Close()
+	h.dropConnections()
+}
+
+type TunnelSuite struct {
+	testing.IsolationSuite
+	jumpHost *jumpHost
+	server   *httptest.Server
+}
+
+var _ = gc.Suite(&TunnelSuite{})
+
+func (s *TunnelSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.jumpHost = newJumpHost(c)
+	s.AddCleanup(func(*gc.C) { s.jumpHost.close() })
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	s.AddCleanup(func(*gc.C) { s.server.Close() })
+}
+
+func (s *TunnelSuite) newDialer(c *gc.C) *ssh.TunnelDialer {
+	dialer, err := ssh.NewTunnelDialer(ssh.TunnelConfig{
+		JumpHost: s.jumpHost.addr(),
+		ClientConfig: &cryptossh.ClientConfig{
+			User:            "test",
+			HostKeyCallback: cryptossh.FixedHostKey(s.jumpHost.hostKey),
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(*gc.C) { dialer.Close() })
+	return dialer
+}
+
+func (s *TunnelSuite) get(c *gc.C, dialer utils.Dialer) {
+	transport := utils.NewHttpTLSTransport(utils.SecureTLSConfig())
+	utils.InstallHTTPDialer(transport, dialer)
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(s.server.URL)
+	c.Assert(err, jc.ErrorIsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(body), gc.Equals, "hello")
+}
+
+func (s *TunnelSuite) TestNewTunnelDialerValidation(c *gc.C) {
+	_, err := ssh.NewTunnelDialer(ssh.TunnelConfig{
+		ClientConfig: &cryptossh.ClientConfig{},
+	})
+	c.Assert(err, gc.ErrorMatches, "empty JumpHost not valid")
+	c.Assert(errors.IsNotValid(err), jc.IsTrue)
+
+	_, err = ssh.NewTunnelDialer(ssh.TunnelConfig{
+		JumpHost: "bastion",
+	})
+	c.Assert(err, gc.ErrorMatches, "nil ClientConfig not valid")
+	c.Assert(errors.IsNotValid(err), jc.IsTrue)
+}
+
+func (s *TunnelSuite) TestHTTPThroughTunnel(c *gc.C) {
+	dialer := s.newDialer(c)
+	s.get(c, dialer)
+	s.get(c, dialer)
+	c.Assert(s.jumpHost.connCount(), gc.Equals, 1)
+	serverAddr := s.server.Listener.Addr().String()
+	c.Assert(s.jumpHost.dialed(), jc.DeepEquals, []string{serverAddr, serverAddr})
+}
+
+func (s *TunnelSuite) TestReconnects(c *gc.C) {
+	dialer := s.newDialer(c)
+	s.get(c, dialer)
+	s.jumpHost.dropConnections()
+	// Wait for the dialer to notice that the connection failed.
+	attempt := utils.AttemptStrategy{Total: testing.LongWait, Delay: 10 * time.Millisecond}
+	for a := attempt.Start(); a.Next(); {
+		conn, err := dialer.Dial("tcp", s.server.Listener.Addr().String())
+		if err == nil {
+			conn.Close()
+			break
+		}
+	}
+	s.get(c, dialer)
+	c.Assert(s.jumpHost.connCount(), gc.Equals, 2)
+}
+
+func (s *TunnelSuite) TestDialFailure(c *gc.C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, jc.ErrorIsNil)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	dialer := s.newDialer(c)
+	_, err = dialer.Dial("tcp", addr)
+	c.Assert(err, gc.ErrorMatches, `cannot dial `+addr+` through jump host .*`)
+}
+
+func (s *TunnelSuite) TestHostKeyMismatch(c *gc.C) {
+	other := newJumpHost(c)
+	defer other.close()
+	dialer, err := ssh.NewTunnelDialer(ssh.TunnelConfig{
+		JumpHost: s.jumpHost.addr(),
+		ClientConfig: &cryptossh.ClientConfig{
+			User:            "test",
+			HostKeyCallback: cryptossh.FixedHostKey(other.hostKey),
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = dialer.Dial("tcp", s.server.Listener.Addr().String())
+	c.Assert(err, gc.ErrorMatches, `cannot connect to jump host .*: ssh: handshake failed: .*`)
+}
+
+func (s *TunnelSuite) TestClose(c *gc.C) {
+	dialer := s.newDialer(c)
+	conn, err := dialer.Dial("tcp", s.server.Listener.Addr().String())
+	c.Assert(err, jc.ErrorIsNil)
+	err = dialer.Close()
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = conn.Read(make([]byte, 1))
+	c.Assert(err, gc.NotNil)
+	_, err = dialer.Dial("tcp", s.server.Listener.Addr().String())
+	c.Assert(errors.Cause(err), gc.Equals, ssh.ErrTunnelClosed)
+}
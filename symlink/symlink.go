@@ -11,7 +11,10 @@ import (
 	"github.com/juju/utils"
 )
 
-// Replace will do an atomic replacement of a symlink to a new path
+// Replace will do an atomic replacement of a symlink to a new path.
+// On Windows, where symlinks may not be overwritten, the old link is
+// removed first, so there is a short window in which link does not
+// exist.
 func Replace(link, newpath string) error {
 	dstDir := filepath.Dir(link)
 	uuid, err := utils.NewUUID()
@@ -26,16 +29,13 @@ func Replace(link, newpath string) error {
 	if err != nil {
 		return fmt.Errorf("cannot create symlink: %s", err)
 	}
-	// On Windows, symlinks may not be overwritten. We remove it first,
-	// and then rename tmpFile
-	if _, err := os.Stat(link); err == nil {
-		err = os.RemoveAll(link)
-		if err != nil {
-			return err
-		}
+	if err := removeLink(link); err != nil {
+		os.Remove(tmpFile)
+		return err
 	}
 	err = os.Rename(tmpFile, link)
 	if err != nil {
+		os.Remove(tmpFile)
 		return fmt.Errorf("cannot update tools symlink: %v", err)
 	}
 	return nil
@@ -21,6 +21,7 @@ func Read(link string) (string, error) {
 	return os.Readlink(link)
 }
 
+// IsSymlink reports whether path is a symbolic link.
 func IsSymlink(path string) (bool, error) {
 	st, err := os.Lstat(path)
 	if err != nil {
@@ -34,3 +35,9 @@ func IsSymlink(path string) (bool, error) {
 func getLongPathAsString(path string) (string, error) {
 	return path, nil
 }
+
+// removeLink does nothing on linux, where rename atomically replaces
+// an existing link.
+func removeLink(link string) error {
+	return nil
+}
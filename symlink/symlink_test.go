@@ -125,3 +125,71 @@ func (*SymlinkSuite) TestIsSymlinkFileDoesNotExist(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, ".*"+utils.NoSuchFileErrRegexp)
 	c.Assert(isSymlink, jc.IsFalse)
 }
+
+func (*SymlinkSuite) TestReplaceDanglingLink(c *gc.C) {
+	dir, err := symlink.GetLongPathAsString(c.MkDir())
+	c.Assert(err, gc.IsNil)
+	oldTarget := filepath.Join(dir, "old")
+	newTarget := filepath.Join(dir, "new")
+	c.Assert(os.Mkdir(oldTarget, 0755), gc.IsNil)
+	c.Assert(os.Mkdir(newTarget, 0755), gc.IsNil)
+	link := filepath.Join(dir, "link")
+
+	err = symlink.New(oldTarget, link)
+	c.Assert(err, gc.IsNil)
+	c.Assert(os.Remove(oldTarget), gc.IsNil)
+
+	err = symlink.Replace(link, newTarget)
+	c.Assert(err, gc.IsNil)
+
+	linkTarget, err := symlink.Read(link)
+	c.Assert(err, gc.IsNil)
+	c.Assert(linkTarget, gc.Equals, filepath.FromSlash(newTarget))
+	assertOnlyEntries(c, dir, "link", "new")
+}
+
+func (*SymlinkSuite) TestReplaceMissingLink(c *gc.C) {
+	dir, err := symlink.GetLongPathAsString(c.MkDir())
+	c.Assert(err, gc.IsNil)
+	target := filepath.Join(dir, "file")
+	err = ioutil.WriteFile(target, []byte("data"), 0644)
+	c.Assert(err, gc.IsNil)
+	link := filepath.Join(dir, "link")
+
+	err = symlink.Replace(link, target)
+	c.Assert(err, gc.IsNil)
+
+	data, err := ioutil.ReadFile(link)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "data")
+	isSymlink, err := symlink.IsSymlink(link)
+	c.Assert(err, gc.IsNil)
+	c.Assert(isSymlink, jc.IsTrue)
+}
+
+func (*SymlinkSuite) TestReplaceNonEmptyDirectory(c *gc.C) {
+	dir, err := symlink.GetLongPathAsString(c.MkDir())
+	c.Assert(err, gc.IsNil)
+	target := filepath.Join(dir, "target")
+	c.Assert(os.Mkdir(target, 0755), gc.IsNil)
+	link := filepath.Join(dir, "link")
+	c.Assert(os.Mkdir(link, 0755), gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(link, "precious"), []byte("data"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	err = symlink.Replace(link, target)
+	c.Assert(err, gc.NotNil)
+	_, err = os.Stat(filepath.Join(link, "precious"))
+	c.Assert(err, gc.IsNil)
+	assertOnlyEntries(c, dir, "link", "target")
+}
+
+func assertOnlyEntries(c *gc.C, dir string, expected ...string) {
+	infos, err := ioutil.ReadDir(dir)
+	c.Assert(err, gc.IsNil)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	c.Assert(names, jc.SameContents, expected)
+}
@@ -6,7 +6,10 @@
 package symlink
 
 import (
+	"bytes"
+	"encoding/binary"
 	"os"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"unicode/utf16"
@@ -17,6 +20,11 @@ import (
 
 const (
 	SYMBOLIC_LINK_FLAG_DIRECTORY = 1
+	// SYMBOLIC_LINK_FLAG_ALLOW_UNPRIVILEGED_CREATE allows symlinks to be
+	// created without the SeCreateSymbolicLinkPrivilege when developer
+	// mode is enabled. Windows versions before 10 (build 14972) reject it
+	// with ERROR_INVALID_PARAMETER.
+	SYMBOLIC_LINK_FLAG_ALLOW_UNPRIVILEGED_CREATE = 2
 	// This is the equivalent of syscall.GENERIC_EXECUTION
 	// Using syscall.GENERIC_EXECUTION results in an "Access denied" error
 	GENERIC_EXECUTION = 33554432
@@ -24,6 +32,12 @@ const (
 	// Remove this once we upgrade to a go version that has it in the syscall
 	// package
 	FILE_ATTRIBUTE_REPARSE_POINT = 0x00000400
+
+	FSCTL_SET_REPARSE_POINT    = 0x000900A4
+	IO_REPARSE_TAG_MOUNT_POINT = 0xA0000003
+
+	ERROR_INVALID_PARAMETER  syscall.Errno = 87
+	ERROR_PRIVILEGE_NOT_HELD syscall.Errno = 1314
 )
 
 //sys createSymbolicLink(symlinkname *uint16, targetname *uint16, flags uint32) (err error) = CreateSymbolicLinkW
@@ -51,13 +65,97 @@ func New(oldname, newname string) error {
 		return &os.LinkError{"symlink", oldname, newname, err}
 	}
 
-	err = createSymbolicLink(linkp, &targetp[0], flag)
+	err = createSymbolicLink(linkp, &targetp[0], flag|SYMBOLIC_LINK_FLAG_ALLOW_UNPRIVILEGED_CREATE)
+	if err == ERROR_INVALID_PARAMETER {
+		// Older versions of Windows don't know about unprivileged
+		// symlinks.
+		err = createSymbolicLink(linkp, &targetp[0], flag)
+	}
+	if err == ERROR_PRIVILEGE_NOT_HELD && fi.IsDir() {
+		// Without the privilege we can still link to a directory by
+		// creating a junction, which any user may do.
+		err = createJunction(syscall.UTF16ToString(targetp), newname)
+	}
 	if err != nil {
 		return &os.LinkError{"symlink", oldname, newname, err}
 	}
 	return nil
 }
 
+// createJunction creates link as an NTFS junction to the directory
+// target. Junctions behave like directory symlinks for most purposes,
+// but must point to an absolute local path.
+func createJunction(target, link string) error {
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.Mkdir(link, 0700); err != nil {
+		return errors.Trace(err)
+	}
+	if err := setMountPoint(target, link); err != nil {
+		os.Remove(link)
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// setMountPoint turns the empty directory link into a junction to the
+// directory target.
+func setMountPoint(target, link string) error {
+	linkp, err := syscall.UTF16PtrFromString(link)
+	if err != nil {
+		return err
+	}
+	h, err := syscall.CreateFile(
+		linkp,
+		syscall.GENERIC_WRITE,
+		0,
+		nil,
+		syscall.OPEN_EXISTING,
+		syscall.FILE_FLAG_OPEN_REPARSE_POINT|syscall.FILE_FLAG_BACKUP_SEMANTICS,
+		0)
+	if err != nil {
+		return err
+	}
+	defer syscall.CloseHandle(h)
+
+	// The substitute name is the NT path of the target; the print
+	// name is what tools such as dir show. Both are NUL terminated,
+	// but the lengths don't include the terminators.
+	substitute := utf16.Encode([]rune(`\??\` + target))
+	printName := utf16.Encode([]rune(target))
+	pathBuffer := make([]uint16, 0, len(substitute)+len(printName)+2)
+	pathBuffer = append(pathBuffer, substitute...)
+	pathBuffer = append(pathBuffer, 0)
+	pathBuffer = append(pathBuffer, printName...)
+	pathBuffer = append(pathBuffer, 0)
+
+	var buf bytes.Buffer
+	header := struct {
+		ReparseTag           uint32
+		ReparseDataLength    uint16
+		Reserved             uint16
+		SubstituteNameOffset uint16
+		SubstituteNameLength uint16
+		PrintNameOffset      uint16
+		PrintNameLength      uint16
+	}{
+		ReparseTag:           IO_REPARSE_TAG_MOUNT_POINT,
+		ReparseDataLength:    uint16(8 + 2*len(pathBuffer)),
+		SubstituteNameOffset: 0,
+		SubstituteNameLength: uint16(2 * len(substitute)),
+		PrintNameOffset:      uint16(2 * (len(substitute) + 1)),
+		PrintNameLength:      uint16(2 * len(printName)),
+	}
+	binary.Write(&buf, binary.LittleEndian, header)
+	binary.Write(&buf, binary.LittleEndian, pathBuffer)
+	data := buf.Bytes()
+
+	var returned uint32
+	return syscall.DeviceIoControl(h, FSCTL_SET_REPARSE_POINT, &data[0], uint32(len(data)), nil, 0, &returned, nil)
+}
+
 // Read returns the destination of the named symbolic link.
 // If there is an error, it will be of type *PathError.
 func Read(link string) (string, error) {
@@ -106,6 +204,7 @@ func Read(link string) (string, error) {
 	return syscall.UTF16ToString(retp), nil
 }
 
+// IsSymlink reports whether path is a symbolic link or a junction.
 func IsSymlink(path string) (bool, error) {
 	var fa syscall.Win32FileAttributeData
 	namep, err := syscall.UTF16PtrFromString(path)
@@ -151,3 +250,16 @@ func getLongPathAsString(path string) (string, error) {
 	}
 	return syscall.UTF16ToString(longp), nil
 }
+
+// removeLink removes an existing link before Replace renames the new
+// one over it, because Windows doesn't allow symlinks to be
+// overwritten.
+func removeLink(link string) error {
+	if _, err := os.Lstat(link); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return os.Remove(link)
+}
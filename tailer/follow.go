@@ -0,0 +1,248 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package tailer
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/juju/clock"
+	"golang.org/x/net/context"
+	"gopkg.in/tomb.v1"
+)
+
+// errStopped is used internally to stop the Follower's loop when it is
+// asked to stop.
+var errStopped = errors.New("follower stopped")
+
+// FollowParams holds the parameters for Follow.
+type FollowParams struct {
+	// Path holds the path of the file to follow. The file need not
+	// exist yet.
+	Path string
+
+	// FromStart specifies that the lines already in the file are
+	// delivered. Otherwise only lines written after Follow is
+	// called are delivered. Files that replace the original after
+	// rotation are always read from the start.
+	FromStart bool
+
+	// Filter, if not nil, is called with each line, without its
+	// newline, and only lines for which it returns true are
+	// delivered.
+	Filter TailerFilterFunc
+
+	// PollInterval holds how often the file is checked for new
+	// data. If it is zero, one second is used.
+	PollInterval time.Duration
+
+	// Clock is used to wait between polls. If it is nil,
+	// clock.WallClock is used.
+	Clock clock.Clock
+}
+
+// Follower delivers the lines written to a file as it grows, like
+// "tail -F". Unlike Tailer, it follows the file by name: if the file
+// is truncated, it is read again from the start, and if it is replaced,
+// as when log files are rotated, the rest of the old file is read
+// before the new one.
+type Follower struct {
+	tomb   tomb.Tomb
+	params FollowParams
+	lines  chan string
+
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	partial []byte
+}
+
+// Follow starts following the file described by params. The Follower
+// stops when ctx is done, when Stop is called, or when reading the
+// file fails.
+func Follow(ctx context.Context, params FollowParams) (*Follower, error) {
+	if params.Path == "" {
+		return nil, errors.New("empty Path")
+	}
+	if params.PollInterval < 0 {
+		return nil, errors.New("negative PollInterval")
+	}
+	if params.PollInterval == 0 {
+		params.PollInterval = polltime
+	}
+	if params.Clock == nil {
+		params.Clock = clock.WallClock
+	}
+	f := &Follower{
+		params: params,
+		lines:  make(chan string),
+	}
+	go func() {
+		defer f.tomb.Done()
+		defer close(f.lines)
+		defer f.closeFile()
+		err := f.loop(ctx)
+		if err == errStopped {
+			err = nil
+		}
+		f.tomb.Kill(err)
+	}()
+	return f, nil
+}
+
+// Lines returns the channel on which lines are delivered, without
+// their newlines. It is closed when the Follower stops. A final line
+// without a newline is only delivered once the file has been replaced.
+func (f *Follower) Lines() <-chan string {
+	return f.lines
+}
+
+// Stop stops the Follower and returns any error it encountered.
+func (f *Follower) Stop() error {
+	f.tomb.Kill(nil)
+	return f.tomb.Wait()
+}
+
+// Wait waits for the Follower to stop and returns any error it
+// encountered. Stopping because the context is done is not an error.
+func (f *Follower) Wait() error {
+	return f.tomb.Wait()
+}
+
+func (f *Follower) loop(ctx context.Context) error {
+	first := true
+	for {
+		if err := f.poll(ctx, first); err != nil {
+			return err
+		}
+		first = false
+		select {
+		case <-ctx.Done():
+			return errStopped
+		case <-f.tomb.Dying():
+			return errStopped
+		case <-f.params.Clock.After(f.params.PollInterval):
+		}
+	}
+}
+
+// poll delivers any complete lines written to the file since the last
+// poll, handling truncation and rotation.
+func (f *Follower) poll(ctx context.Context, first bool) error {
+	if f.file == nil {
+		opened, err := f.open(first && !f.params.FromStart)
+		if err != nil || !opened {
+			return err
+		}
+	}
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < f.offset {
+		// The file has been truncated, so start again.
+		if err := f.seek(0, os.SEEK_SET); err != nil {
+			return err
+		}
+		f.partial = nil
+	}
+	if err := f.readLines(ctx); err != nil {
+		return err
+	}
+	pathInfo, err := os.Stat(f.params.Path)
+	if err != nil || os.SameFile(info, pathInfo) {
+		// Either the file has been moved away and not yet
+		// replaced, or it's still the same file.
+		return nil
+	}
+	// The file has been replaced. Deliver anything written to the
+	// old file since we last read it, then start on the new one.
+	if err := f.readLines(ctx); err != nil {
+		return err
+	}
+	if len(f.partial) > 0 {
+		if err := f.send(ctx, f.partial); err != nil {
+			return err
+		}
+	}
+	f.closeFile()
+	return f.poll(ctx, false)
+}
+
+// open opens the file, reporting whether it exists.
+func (f *Follower) open(atEnd bool) (bool, error) {
+	file, err := os.Open(f.params.Path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	f.file = file
+	f.reader = bufio.NewReaderSize(file, bufferSize)
+	f.offset = 0
+	f.partial = nil
+	if atEnd {
+		if err := f.seek(0, os.SEEK_END); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (f *Follower) seek(offset int64, whence int) error {
+	offset, err := f.file.Seek(offset, whence)
+	if err != nil {
+		return err
+	}
+	f.reader.Reset(f.file)
+	f.offset = offset
+	return nil
+}
+
+// readLines delivers all the complete lines that can be read from the
+// file, keeping any incomplete line at the end for later.
+func (f *Follower) readLines(ctx context.Context) error {
+	for {
+		data, err := f.reader.ReadBytes(delimiter)
+		f.offset += int64(len(data))
+		if err == io.EOF {
+			f.partial = append(f.partial, data...)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line := append(f.partial, data[:len(data)-1]...)
+		f.partial = nil
+		if err := f.send(ctx, line); err != nil {
+			return err
+		}
+	}
+}
+
+// send delivers the line if it passes the filter.
+func (f *Follower) send(ctx context.Context, line []byte) error {
+	if f.params.Filter != nil && !f.params.Filter(line) {
+		return nil
+	}
+	select {
+	case f.lines <- string(line):
+		return nil
+	case <-ctx.Done():
+		return errStopped
+	case <-f.tomb.Dying():
+		return errStopped
+	}
+}
+
+func (f *Follower) closeFile() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
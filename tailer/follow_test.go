@@ -0,0 +1,168 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package tailer_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils/tailer"
+)
+
+type followSuite struct {
+	testing.IsolationSuite
+	path string
+}
+
+var _ = gc.Suite(&followSuite{})
+
+func (s *followSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.path = filepath.Join(c.MkDir(), "log")
+}
+
+func (s *followSuite) follow(c *gc.C, ctx context.Context, fromStart bool, filter tailer.TailerFilterFunc) *tailer.Follower {
+	f, err := tailer.Follow(ctx, tailer.FollowParams{
+		Path:         s.path,
+		FromStart:    fromStart,
+		Filter:       filter,
+		PollInterval: 10 * time.Millisecond,
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	s.AddCleanup(func(*gc.C) { f.Stop() })
+	return f
+}
+
+func (s *followSuite) write(c *gc.C, data string) {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	defer file.Close()
+	_, err = file.WriteString(data)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func assertLines(c *gc.C, f *tailer.Follower, expected ...string) {
+	for _, want := range expected {
+		select {
+		case line, ok := <-f.Lines():
+			c.Assert(ok, jc.IsTrue)
+			c.Assert(line, gc.Equals, want)
+		case <-time.After(testing.LongWait):
+			c.Fatalf("timed out waiting for line %q", want)
+		}
+	}
+}
+
+func assertNoLine(c *gc.C, f *tailer.Follower) {
+	select {
+	case line := <-f.Lines():
+		c.Fatalf("unexpected line %q", line)
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+func (s *followSuite) TestFollowFromEnd(c *gc.C) {
+	s.write(c, "old\n")
+	f := s.follow(c, context.Background(), false, nil)
+	// Give the follower time to open the file before it grows.
+	time.Sleep(testing.ShortWait)
+	s.write(c, "new one\nnew two\n")
+	assertLines(c, f, "new one", "new two")
+	assertNoLine(c, f)
+}
+
+func (s *followSuite) TestFollowFromStart(c *gc.C) {
+	s.write(c, "one\ntwo\n")
+	f := s.follow(c, context.Background(), true, nil)
+	assertLines(c, f, "one", "two")
+	s.write(c, "three\n")
+	assertLines(c, f, "three")
+}
+
+func (s *followSuite) TestFileCreatedLater(c *gc.C) {
+	f := s.follow(c, context.Background(), false, nil)
+	time.Sleep(testing.ShortWait)
+	s.write(c, "first\n")
+	assertLines(c, f, "first")
+}
+
+func (s *followSuite) TestPartialLines(c *gc.C) {
+	f := s.follow(c, context.Background(), true, nil)
+	s.write(c, "hello ")
+	assertNoLine(c, f)
+	s.write(c, "world\nand")
+	assertLines(c, f, "hello world")
+	assertNoLine(c, f)
+	s.write(c, " more\n")
+	assertLines(c, f, "and more")
+}
+
+func (s *followSuite) TestFilter(c *gc.C) {
+	filter := func(line []byte) bool {
+		return bytes.HasPrefix(line, []byte("keep"))
+	}
+	f := s.follow(c, context.Background(), true, filter)
+	s.write(c, "keep 1\ndrop 2\nkeep 3\n")
+	assertLines(c, f, "keep 1", "keep 3")
+	assertNoLine(c, f)
+}
+
+func (s *followSuite) TestTruncation(c *gc.C) {
+	f := s.follow(c, context.Background(), true, nil)
+	s.write(c, "a long first line\n")
+	assertLines(c, f, "a long first line")
+	err := ioutil.WriteFile(s.path, []byte("short\n"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	assertLines(c, f, "short")
+}
+
+func (s *followSuite) TestRotation(c *gc.C) {
+	f := s.follow(c, context.Background(), true, nil)
+	s.write(c, "before\n")
+	assertLines(c, f, "before")
+
+	// Rotate the file, writing a little more to the old one
+	// afterwards, as a writer that hasn't reopened the file would.
+	old, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0)
+	c.Assert(err, jc.ErrorIsNil)
+	defer old.Close()
+	err = os.Rename(s.path, s.path+".1")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = old.WriteString("late\nunterminated")
+	c.Assert(err, jc.ErrorIsNil)
+	s.write(c, "after\n")
+
+	assertLines(c, f, "late", "unterminated", "after")
+	assertNoLine(c, f)
+}
+
+func (s *followSuite) TestContextCancel(c *gc.C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := s.follow(c, ctx, true, nil)
+	cancel()
+	c.Assert(f.Wait(), jc.ErrorIsNil)
+	_, ok := <-f.Lines()
+	c.Assert(ok, jc.IsFalse)
+}
+
+func (s *followSuite) TestStopWhileSending(c *gc.C) {
+	s.write(c, "one\ntwo\n")
+	f := s.follow(c, context.Background(), true, nil)
+	// Nothing reads the lines, so the follower is blocked sending.
+	time.Sleep(testing.ShortWait)
+	c.Assert(f.Stop(), jc.ErrorIsNil)
+}
+
+func (s *followSuite) TestEmptyPath(c *gc.C) {
+	_, err := tailer.Follow(context.Background(), tailer.FollowParams{})
+	c.Assert(err, gc.ErrorMatches, "empty Path")
+}
@@ -8,16 +8,21 @@ package tar
 
 import (
 	"archive/tar"
+	"bufio"
+	"compress/gzip"
 	"crypto/sha1"
 	"encoding/base64"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/juju/errors"
 
+	"github.com/juju/utils/fs"
 	"github.com/juju/utils/symlink"
 )
 
@@ -54,15 +59,46 @@ func FindFile(tarFile io.Reader, filename string) (*tar.Header, io.Reader, error
 // however at least the bytes up to the inital size are written
 // successfully if no error is returned.
 func TarFiles(fileList []string, target io.Writer, strip string) (shaSum string, err error) {
+	return TarFilesExcluding(fileList, target, strip, nil)
+}
+
+// TarFilesExcluding is like TarFiles, but leaves out the files and
+// directories that match any of the exclude patterns. Patterns use the
+// syntax of fs.MatchGlob, and each is matched both against the name an
+// entry is stored under and against its base name, so "*.pyc"
+// excludes matching files in every directory. The contents of excluded
+// directories are left out too.
+func TarFilesExcluding(fileList []string, target io.Writer, strip string, exclude []string) (shaSum string, err error) {
+	shahash := sha1.New()
+	if err := tarAndHashFiles(fileList, target, strip, exclude, shahash); err != nil {
+		return "", err
+	}
+	encodedHash := base64.StdEncoding.EncodeToString(shahash.Sum(nil))
+	return encodedHash, nil
+}
+
+// TarGzFiles is like TarFiles, but writes a gzip compressed tar
+// stream. The returned sum is of the compressed stream.
+func TarGzFiles(fileList []string, target io.Writer, strip string) (shaSum string, err error) {
+	return TarGzFilesExcluding(fileList, target, strip, nil)
+}
+
+// TarGzFilesExcluding is like TarFilesExcluding, but writes a gzip
+// compressed tar stream. The returned sum is of the compressed stream.
+func TarGzFilesExcluding(fileList []string, target io.Writer, strip string, exclude []string) (shaSum string, err error) {
 	shahash := sha1.New()
-	if err := tarAndHashFiles(fileList, target, strip, shahash); err != nil {
+	gzw := gzip.NewWriter(io.MultiWriter(target, shahash))
+	if err := tarAndHashFiles(fileList, gzw, strip, exclude, ioutil.Discard); err != nil {
 		return "", err
 	}
+	if err := gzw.Close(); err != nil {
+		return "", fmt.Errorf("error closing gzip writer: %v", err)
+	}
 	encodedHash := base64.StdEncoding.EncodeToString(shahash.Sum(nil))
 	return encodedHash, nil
 }
 
-func tarAndHashFiles(fileList []string, target io.Writer, strip string, hashw io.Writer) (err error) {
+func tarAndHashFiles(fileList []string, target io.Writer, strip string, exclude []string, hashw io.Writer) (err error) {
 	checkClose := func(w io.Closer) {
 		if closeErr := w.Close(); closeErr != nil && err == nil {
 			err = fmt.Errorf("error closing tar writer: %v", closeErr)
@@ -73,16 +109,41 @@ func tarAndHashFiles(fileList []string, target io.Writer, strip string, hashw io
 	tarw := tar.NewWriter(w)
 	defer checkClose(tarw)
 	for _, ent := range fileList {
-		if err := writeContents(ent, strip, tarw); err != nil {
+		if err := writeContents(ent, strip, exclude, tarw); err != nil {
 			return fmt.Errorf("write to tar file failed: %v", err)
 		}
 	}
 	return nil
 }
 
+// excluded reports whether the entry stored under name matches any of
+// the exclude patterns.
+func excluded(name string, exclude []string) (bool, error) {
+	name = strings.TrimPrefix(name, "/")
+	base := path.Base(name)
+	for _, pattern := range exclude {
+		pattern = filepath.ToSlash(pattern)
+		for _, candidate := range []string{name, base} {
+			ok, err := fs.MatchGlob(pattern, candidate)
+			if err != nil {
+				return false, fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+			}
+			if ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // writeContents creates an entry for the given file
-// or directory in the given tar archive.
-func writeContents(fileName, strip string, tarw *tar.Writer) error {
+// or directory in the given tar archive, unless it is
+// excluded.
+func writeContents(fileName, strip string, exclude []string, tarw *tar.Writer) error {
+	name := filepath.ToSlash(strings.TrimPrefix(fileName, strip))
+	if skip, err := excluded(name, exclude); err != nil || skip {
+		return err
+	}
 	f, err := os.Open(fileName)
 	if err != nil {
 		return err
@@ -106,7 +167,7 @@ func writeContents(fileName, strip string, tarw *tar.Writer) error {
 	if err != nil {
 		return fmt.Errorf("cannot create tar header for %q: %v", fileName, err)
 	}
-	h.Name = filepath.ToSlash(strings.TrimPrefix(fileName, strip))
+	h.Name = name
 	if err := tarw.WriteHeader(h); err != nil {
 		return fmt.Errorf("cannot write header for %q: %v", fileName, err)
 	}
@@ -133,7 +194,7 @@ func writeContents(fileName, strip string, tarw *tar.Writer) error {
 			return fmt.Errorf("error reading directory %q: %v", fileName, err)
 		}
 		for _, name := range names {
-			if err := writeContents(filepath.Join(fileName, name), strip, tarw); err != nil {
+			if err := writeContents(filepath.Join(fileName, name), strip, exclude, tarw); err != nil {
 				return err
 			}
 		}
@@ -143,15 +204,15 @@ func writeContents(fileName, strip string, tarw *tar.Writer) error {
 
 func createAndFill(filePath string, mode int64, content io.Reader) error {
 	fh, err := os.Create(filePath)
-	defer fh.Close()
 	if err != nil {
 		return fmt.Errorf("some of the tar contents cannot be written to disk: %v", err)
 	}
+	defer fh.Close()
 	_, err = io.Copy(fh, content)
 	if err != nil {
 		return fmt.Errorf("failed while reading tar contents: %v", err)
 	}
-	err = os.Chmod(fh.Name(), os.FileMode(mode))
+	err = os.Chmod(fh.Name(), os.FileMode(mode).Perm())
 	if err != nil {
 		return fmt.Errorf("cannot set proper mode on file %q: %v", filePath, err)
 	}
@@ -165,8 +226,28 @@ func createAndFill(filePath string, mode int64, content io.Reader) error {
 }
 
 // UntarFiles will extract the contents of tarFile using
-// outputFolder as root
+// outputFolder as root. If tarFile is gzip compressed it is
+// decompressed as it is read.
+//
+// Entries that would be written outside outputFolder, either because
+// their names are absolute or contain "..", or because an entry
+// earlier in the archive has made one of their parent directories a
+// symbolic link, cause an error. Symbolic links are extracted with
+// their targets unchanged, but are never followed when writing later
+// entries. Permissions are preserved, but setuid, setgid and sticky
+// bits are not.
 func UntarFiles(tarFile io.Reader, outputFolder string) error {
+	br := bufio.NewReader(tarFile)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("failed while reading gzip header: %v", err)
+		}
+		defer gzr.Close()
+		tarFile = gzr
+	} else {
+		tarFile = br
+	}
 	tr := tar.NewReader(tarFile)
 	for {
 		hdr, err := tr.Next()
@@ -177,21 +258,78 @@ func UntarFiles(tarFile io.Reader, outputFolder string) error {
 		if err != nil {
 			return fmt.Errorf("failed while reading tar header: %v", err)
 		}
-		fullPath := filepath.Join(outputFolder, hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeDir, tar.TypeSymlink, tar.TypeReg, tar.TypeRegA:
+		default:
+			continue
+		}
+		fullPath, err := extractPath(outputFolder, hdr.Name)
+		if err != nil {
+			return errors.Trace(err)
+		}
 		switch hdr.Typeflag {
 		case tar.TypeDir:
-			if err = os.MkdirAll(fullPath, os.FileMode(hdr.Mode)); err != nil {
+			if err = os.MkdirAll(fullPath, os.FileMode(hdr.Mode).Perm()); err != nil {
 				return fmt.Errorf("cannot extract directory %q: %v", fullPath, err)
 			}
 		case tar.TypeSymlink:
+			if err := removeSymlink(fullPath); err != nil {
+				return errors.Trace(err)
+			}
 			if err = symlink.New(hdr.Linkname, fullPath); err != nil {
 				return fmt.Errorf("cannot extract symlink %q to %q: %v", hdr.Linkname, fullPath, err)
 			}
 			continue
 		case tar.TypeReg, tar.TypeRegA:
+			// Don't write through a symbolic link extracted
+			// earlier.
+			if err := removeSymlink(fullPath); err != nil {
+				return errors.Trace(err)
+			}
 			if err = createAndFill(fullPath, hdr.Mode, tr); err != nil {
 				return fmt.Errorf("cannot extract file %q: %v", fullPath, err)
 			}
 		}
 	}
 }
+
+// extractPath returns the path at which the tar entry with the given
+// name should be extracted into outputFolder, checking that it is
+// inside outputFolder and that none of its parent directories below
+// outputFolder are symbolic links.
+func extractPath(outputFolder, name string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(cleaned) || filepath.VolumeName(cleaned) != "" ||
+		cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("tar entry %q is outside the output folder", name)
+	}
+	parent := outputFolder
+	parts := strings.Split(cleaned, string(filepath.Separator))
+	for _, part := range parts[:len(parts)-1] {
+		parent = filepath.Join(parent, part)
+		info, err := os.Lstat(parent)
+		if os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return "", errors.Trace(err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", errors.Errorf("tar entry %q is inside symbolic link %q", name, parent)
+		}
+	}
+	return filepath.Join(outputFolder, cleaned), nil
+}
+
+// removeSymlink removes the file at path if it is a symbolic link.
+func removeSymlink(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	return errors.Trace(os.Remove(path))
+}
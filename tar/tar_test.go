@@ -6,6 +6,7 @@ package tar
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"crypto/sha1"
 	"encoding/base64"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 	stdtesting "testing"
 
 	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 )
 
@@ -205,6 +207,58 @@ func (t *TarSuite) TestTarFiles(c *gc.C) {
 	t.assertTarContents(c, testExpectedTarContents, bytes.NewBuffer(outputBytes))
 }
 
+// tarNames returns the names of the entries in the tar stream.
+func tarNames(c *gc.C, r io.Reader) []string {
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		c.Assert(err, gc.IsNil)
+		names = append(names, hdr.Name)
+	}
+}
+
+func (t *TarSuite) TestTarFilesExcluding(c *gc.C) {
+	t.createTestFiles(c)
+	var outputTar bytes.Buffer
+	trimPath := fmt.Sprintf("%s/", t.cwd)
+	exclude := []string{"TarFile2", "*Link", "**/*SubDirectory"}
+	shaSum, err := TarFilesExcluding(t.testFiles, &outputTar, trimPath, exclude)
+	c.Assert(err, gc.IsNil)
+	c.Assert(shaSum, gc.Equals, shaSumFile(c, bytes.NewReader(outputTar.Bytes())))
+	c.Assert(tarNames(c, &outputTar), jc.SameContents, []string{
+		"TarDirectoryEmpty",
+		"TarDirectoryPopulated",
+		"TarDirectoryPopulated/TarSubFile1",
+		"TarFile1",
+	})
+}
+
+func (t *TarSuite) TestTarGzFilesExcludingDirectory(c *gc.C) {
+	t.createTestFiles(c)
+	var outputTar bytes.Buffer
+	trimPath := fmt.Sprintf("%s/", t.cwd)
+	_, err := TarGzFilesExcluding(t.testFiles, &outputTar, trimPath, []string{"TarDirectory{Empty,Populated}"})
+	c.Assert(err, gc.IsNil)
+	gzr, err := gzip.NewReader(&outputTar)
+	c.Assert(err, gc.IsNil)
+	c.Assert(tarNames(c, gzr), jc.SameContents, []string{
+		"TarLink",
+		"TarFile1",
+		"TarFile2",
+	})
+}
+
+func (t *TarSuite) TestTarFilesExcludingInvalidPattern(c *gc.C) {
+	t.createTestFiles(c)
+	var outputTar bytes.Buffer
+	_, err := TarFilesExcluding(t.testFiles, &outputTar, t.cwd, []string{"{a,b"})
+	c.Assert(err, gc.ErrorMatches, `write to tar file failed: invalid exclude pattern "{a,b": .*`)
+}
+
 func (t *TarSuite) TestSymlinksTar(c *gc.C) {
 	tarDirP := filepath.Join(t.cwd, "TarDirectory")
 	err := os.Mkdir(tarDirP, os.FileMode(0755))
@@ -306,3 +360,129 @@ func (t *TarSuite) TestUntarFilesHeadersIgnored(c *gc.C) {
 	})
 	c.Assert(err, gc.IsNil)
 }
+
+func (t *TarSuite) TestUntarFilesGzip(c *gc.C) {
+	t.createTestFiles(c)
+	var outputTar bytes.Buffer
+	trimPath := fmt.Sprintf("%s/", t.cwd)
+	shaSum, err := TarGzFiles(t.testFiles, &outputTar, trimPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(shaSum, gc.Equals, shaSumFile(c, bytes.NewReader(outputTar.Bytes())))
+	t.removeTestFiles(c)
+
+	outputDir := filepath.Join(t.cwd, "TarOuputFolder")
+	err = os.Mkdir(outputDir, os.FileMode(0755))
+	c.Assert(err, gc.IsNil)
+
+	err = UntarFiles(&outputTar, outputDir)
+	c.Assert(err, gc.IsNil)
+	t.assertFilesWhereUntared(c, testExpectedTarContents, outputDir)
+}
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	mode     int64
+	body     string
+}
+
+func makeTar(c *gc.C, entries []tarEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		err := w.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     entry.mode,
+			Size:     int64(len(entry.body)),
+		})
+		c.Assert(err, gc.IsNil)
+		_, err = w.Write([]byte(entry.body))
+		c.Assert(err, gc.IsNil)
+	}
+	c.Assert(w.Close(), gc.IsNil)
+	return &buf
+}
+
+var untarTraversalTests = []struct {
+	about   string
+	entries []tarEntry
+	err     string
+}{{
+	about: "parent directory",
+	entries: []tarEntry{
+		{name: "../evil", typeflag: tar.TypeReg, mode: 0644, body: "evil"},
+	},
+	err: `tar entry "../evil" is outside the output folder`,
+}, {
+	about: "parent directory inside name",
+	entries: []tarEntry{
+		{name: "a/../../evil", typeflag: tar.TypeReg, mode: 0644, body: "evil"},
+	},
+	err: `tar entry "a/../../evil" is outside the output folder`,
+}, {
+	about: "absolute path",
+	entries: []tarEntry{
+		{name: "/evil", typeflag: tar.TypeReg, mode: 0644, body: "evil"},
+	},
+	err: `tar entry "/evil" is outside the output folder`,
+}, {
+	about: "through symlink",
+	entries: []tarEntry{
+		{name: "link", typeflag: tar.TypeSymlink, linkname: ".."},
+		{name: "link/evil", typeflag: tar.TypeReg, mode: 0644, body: "evil"},
+	},
+	err: `tar entry "link/evil" is inside symbolic link ".*link"`,
+}}
+
+func (t *TarSuite) TestUntarFilesTraversal(c *gc.C) {
+	for i, test := range untarTraversalTests {
+		c.Logf("test %d: %s", i, test.about)
+		parent := c.MkDir()
+		outputDir := filepath.Join(parent, "output")
+		err := os.Mkdir(outputDir, 0755)
+		c.Assert(err, gc.IsNil)
+
+		err = UntarFiles(makeTar(c, test.entries), outputDir)
+		c.Assert(err, gc.ErrorMatches, test.err)
+		_, err = os.Lstat(filepath.Join(parent, "evil"))
+		c.Assert(os.IsNotExist(err), gc.Equals, true)
+	}
+}
+
+func (t *TarSuite) TestUntarFilesReplacesSymlink(c *gc.C) {
+	parent := c.MkDir()
+	outputDir := filepath.Join(parent, "output")
+	err := os.Mkdir(outputDir, 0755)
+	c.Assert(err, gc.IsNil)
+	target := filepath.Join(parent, "target")
+	err = ioutil.WriteFile(target, []byte("safe"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	err = UntarFiles(makeTar(c, []tarEntry{
+		{name: "file", typeflag: tar.TypeSymlink, linkname: target},
+		{name: "file", typeflag: tar.TypeReg, mode: 0644, body: "evil"},
+	}), outputDir)
+	c.Assert(err, gc.IsNil)
+
+	data, err := ioutil.ReadFile(target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "safe")
+	data, err = ioutil.ReadFile(filepath.Join(outputDir, "file"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "evil")
+}
+
+func (t *TarSuite) TestUntarFilesModes(c *gc.C) {
+	err := UntarFiles(makeTar(c, []tarEntry{
+		{name: "dir", typeflag: tar.TypeDir, mode: 0750},
+		{name: "dir/file", typeflag: tar.TypeReg, mode: 04751, body: "data"},
+	}), t.cwd)
+	c.Assert(err, gc.IsNil)
+
+	info, err := os.Stat(filepath.Join(t.cwd, "dir", "file"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Mode(), gc.Equals, os.FileMode(0751))
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// TempDirOptions holds the options for NewTempDir.
+type TempDirOptions struct {
+	// Dir is the directory in which the temporary directory is
+	// created. If it is empty, os.TempDir() is used.
+	Dir string
+
+	// Prefix is the prefix of the temporary directory's name.
+	Prefix string
+
+	// CleanupOnExit records that the directory should be removed by
+	// CleanupTempDirs if it has not already been removed.
+	CleanupOnExit bool
+}
+
+// NewTempDir creates a new, uniquely named directory that is only
+// accessible by the current user, and returns its path and a function
+// that removes it and its contents. The cleanup function may be called
+// more than once; once it has succeeded, later calls do nothing. If it
+// fails, the directory is still removed by CleanupTempDirs when
+// CleanupOnExit is set.
+func NewTempDir(opts TempDirOptions) (string, func() error, error) {
+	dir, err := ioutil.TempDir(opts.Dir, opts.Prefix)
+	if err != nil {
+		return "", nil, errors.Annotate(err, "cannot create temporary directory")
+	}
+	// TempDir already uses 0700, but make sure the umask or a
+	// platform's defaults haven't given us anything more permissive.
+	if err := os.Chmod(dir, 0700); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, errors.Annotate(err, "cannot set temporary directory permissions")
+	}
+	var (
+		mu      sync.Mutex
+		removed bool
+	)
+	cleanup := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		if removed {
+			return nil
+		}
+		if err := removeAll(dir); err != nil {
+			return errors.Annotatef(err, "cannot remove temporary directory %q", dir)
+		}
+		removed = true
+		if opts.CleanupOnExit {
+			exitCleanups.remove(dir)
+		}
+		return nil
+	}
+	if opts.CleanupOnExit {
+		exitCleanups.add(dir, cleanup)
+	}
+	return dir, cleanup, nil
+}
+
+// CleanupTempDirs removes all the directories created by NewTempDir
+// with CleanupOnExit set that have not yet been removed. Go has no hook
+// that runs when a process exits, so programs that want this behaviour
+// should defer a call to CleanupTempDirs in main, and call it from any
+// signal handlers that end the process. It returns the first error
+// encountered, having tried to remove every directory.
+func CleanupTempDirs() error {
+	var firstErr error
+	for _, cleanup := range exitCleanups.all() {
+		if err := cleanup(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// removeAll is a variable so that it can be overridden for testing.
+var removeAll = os.RemoveAll
+
+var exitCleanups = &tempDirCleanups{funcs: make(map[string]func() error)}
+
+// tempDirCleanups holds the cleanup functions of the directories to be
+// removed by CleanupTempDirs.
+type tempDirCleanups struct {
+	mu    sync.Mutex
+	funcs map[string]func() error
+}
+
+func (c *tempDirCleanups) add(dir string, cleanup func() error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.funcs[dir] = cleanup
+}
+
+func (c *tempDirCleanups) remove(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.funcs, dir)
+}
+
+func (c *tempDirCleanups) all() []func() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	funcs := make([]func() error, 0, len(c.funcs))
+	for _, cleanup := range c.funcs {
+		funcs = append(funcs, cleanup)
+	}
+	return funcs
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type tempDirSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&tempDirSuite{})
+
+func (*tempDirSuite) TestNewTempDir(c *gc.C) {
+	parent := c.MkDir()
+	dir, cleanup, err := utils.NewTempDir(utils.TempDirOptions{
+		Dir:    parent,
+		Prefix: "juju-test",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(filepath.Dir(dir), gc.Equals, parent)
+	c.Assert(strings.HasPrefix(filepath.Base(dir), "juju-test"), jc.IsTrue)
+
+	info, err := os.Stat(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.IsDir(), jc.IsTrue)
+	if runtime.GOOS != "windows" {
+		c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0700))
+	}
+
+	other, otherCleanup, err := utils.NewTempDir(utils.TempDirOptions{Dir: parent, Prefix: "juju-test"})
+	c.Assert(err, jc.ErrorIsNil)
+	defer otherCleanup()
+	c.Assert(other, gc.Not(gc.Equals), dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "file"), []byte("data"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cleanup(), jc.ErrorIsNil)
+	_, err = os.Stat(dir)
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+
+	// Further calls do nothing, even if the path has been reused.
+	err = os.Mkdir(dir, 0700)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cleanup(), jc.ErrorIsNil)
+	c.Assert(dir, jc.IsDirectory)
+}
+
+func (*tempDirSuite) TestNewTempDirError(c *gc.C) {
+	missing := filepath.Join(c.MkDir(), "missing")
+	_, _, err := utils.NewTempDir(utils.TempDirOptions{Dir: missing})
+	c.Assert(err, gc.ErrorMatches, "cannot create temporary directory: .*")
+}
+
+func (*tempDirSuite) TestCleanupTempDirs(c *gc.C) {
+	parent := c.MkDir()
+	onExit, _, err := utils.NewTempDir(utils.TempDirOptions{Dir: parent, CleanupOnExit: true})
+	c.Assert(err, jc.ErrorIsNil)
+	removed, cleanup, err := utils.NewTempDir(utils.TempDirOptions{Dir: parent, CleanupOnExit: true})
+	c.Assert(err, jc.ErrorIsNil)
+	kept, keptCleanup, err := utils.NewTempDir(utils.TempDirOptions{Dir: parent})
+	c.Assert(err, jc.ErrorIsNil)
+	defer keptCleanup()
+
+	c.Assert(cleanup(), jc.ErrorIsNil)
+	c.Assert(removed, jc.DoesNotExist)
+
+	c.Assert(utils.CleanupTempDirs(), jc.ErrorIsNil)
+	c.Assert(onExit, jc.DoesNotExist)
+	c.Assert(kept, jc.IsDirectory)
+	c.Assert(utils.CleanupTempDirs(), jc.ErrorIsNil)
+}
+
+func (s *tempDirSuite) TestCleanupTempDirsAfterFailedCleanup(c *gc.C) {
+	dir, cleanup, err := utils.NewTempDir(utils.TempDirOptions{Dir: c.MkDir(), CleanupOnExit: true})
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.PatchValue(utils.RemoveAll, func(string) error {
+		return errors.New("boom")
+	})
+	c.Assert(cleanup(), gc.ErrorMatches, `cannot remove temporary directory ".*": boom`)
+	c.Assert(dir, jc.IsDirectory)
+	s.PatchValue(utils.RemoveAll, os.RemoveAll)
+
+	// The failed cleanup left the directory to be removed on exit.
+	c.Assert(utils.CleanupTempDirs(), jc.ErrorIsNil)
+	c.Assert(dir, jc.DoesNotExist)
+	c.Assert(cleanup(), jc.ErrorIsNil)
+}
@@ -6,7 +6,10 @@ package utils
 import (
 	"fmt"
 	"os"
+	"sync"
 	"time"
+
+	"github.com/juju/clock"
 )
 
 type timer struct {
@@ -55,3 +58,106 @@ func Timeit(action string) func() {
 		}
 	}
 }
+
+// Stopwatch measures elapsed time, optionally split into laps. It is
+// safe for concurrent use.
+type Stopwatch struct {
+	clock clock.Clock
+
+	mu    sync.Mutex
+	start time.Time
+	lap   time.Time
+}
+
+// NewStopwatch returns a Stopwatch, started now, that reads the time
+// from the given clock. If clock is nil, the wall clock is used.
+func NewStopwatch(clk clock.Clock) *Stopwatch {
+	if clk == nil {
+		clk = clock.WallClock
+	}
+	now := clk.Now()
+	return &Stopwatch{
+		clock: clk,
+		start: now,
+		lap:   now,
+	}
+}
+
+// Elapsed returns the time since the stopwatch was started or reset.
+func (s *Stopwatch) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clock.Now().Sub(s.start)
+}
+
+// Lap returns the time since the last call to Lap, or since the
+// stopwatch was started or reset if Lap hasn't been called, and starts
+// a new lap.
+func (s *Stopwatch) Lap() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	d := now.Sub(s.lap)
+	s.lap = now
+	return d
+}
+
+// Reset restarts the stopwatch.
+func (s *Stopwatch) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start = s.clock.Now()
+	s.lap = s.start
+}
+
+var (
+	recordersMu   sync.Mutex
+	recorders     = make(map[int]func(name string, d time.Duration))
+	nextRecorderN int
+)
+
+// AddTimingRecorder registers f to be called by RecordTiming with the
+// name and duration of each timed operation, so that timings can be
+// fed into histograms or other metrics. It returns a function that
+// unregisters f. The recorder is called synchronously, so it should be
+// quick.
+func AddTimingRecorder(f func(name string, d time.Duration)) (remove func()) {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	n := nextRecorderN
+	nextRecorderN++
+	recorders[n] = f
+	return func() {
+		recordersMu.Lock()
+		defer recordersMu.Unlock()
+		delete(recorders, n)
+	}
+}
+
+// RecordTiming passes the name and duration of a timed operation to
+// all the recorders registered with AddTimingRecorder.
+func RecordTiming(name string, d time.Duration) {
+	recordersMu.Lock()
+	fs := make([]func(string, time.Duration), 0, len(recorders))
+	for _, f := range recorders {
+		fs = append(fs, f)
+	}
+	recordersMu.Unlock()
+	for _, f := range fs {
+		f(name, d)
+	}
+}
+
+// LogTiming starts timing the named operation and returns a function
+// that, when called, logs the time taken with logf and passes it to
+// RecordTiming. It is intended to be deferred, as in:
+//
+//	defer utils.LogTiming(logger.Debugf, "loading charms")()
+func LogTiming(logf func(format string, args ...interface{}), name string) func() {
+	sw := NewStopwatch(nil)
+	return func() {
+		d := sw.Elapsed()
+		logf("%s took %v", name, d)
+		RecordTiming(name, d)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/clock/testclock"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type timeitSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&timeitSuite{})
+
+func (*timeitSuite) TestStopwatch(c *gc.C) {
+	clk := testclock.NewClock(time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC))
+	sw := utils.NewStopwatch(clk)
+	clk.Advance(time.Second)
+	c.Assert(sw.Lap(), gc.Equals, time.Second)
+	clk.Advance(2 * time.Second)
+	c.Assert(sw.Lap(), gc.Equals, 2*time.Second)
+	c.Assert(sw.Elapsed(), gc.Equals, 3*time.Second)
+
+	sw.Reset()
+	clk.Advance(time.Second)
+	c.Assert(sw.Elapsed(), gc.Equals, time.Second)
+	c.Assert(sw.Lap(), gc.Equals, time.Second)
+}
+
+func (*timeitSuite) TestTimingRecorders(c *gc.C) {
+	var got1, got2 []string
+	remove1 := utils.AddTimingRecorder(func(name string, d time.Duration) {
+		got1 = append(got1, fmt.Sprintf("%s %v", name, d))
+	})
+	defer remove1()
+	remove2 := utils.AddTimingRecorder(func(name string, d time.Duration) {
+		got2 = append(got2, fmt.Sprintf("%s %v", name, d))
+	})
+	utils.RecordTiming("a", time.Second)
+	remove2()
+	utils.RecordTiming("b", time.Millisecond)
+	c.Assert(got1, jc.DeepEquals, []string{"a 1s", "b 1ms"})
+	c.Assert(got2, jc.DeepEquals, []string{"a 1s"})
+}
+
+func (*timeitSuite) TestLogTiming(c *gc.C) {
+	var recorded []string
+	defer utils.AddTimingRecorder(func(name string, d time.Duration) {
+		recorded = append(recorded, name)
+	})()
+	var logged []string
+	logf := func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	done := utils.LogTiming(logf, "doing things")
+	c.Assert(logged, gc.HasLen, 0)
+	done()
+	c.Assert(logged, gc.HasLen, 1)
+	c.Assert(logged[0], gc.Matches, `doing things took .*s`)
+	c.Assert(recorded, jc.DeepEquals, []string{"doing things"})
+}
@@ -25,7 +25,8 @@ func ShQuote(s string) string {
 }
 
 // WinPSQuote quotes s so that when read by powershell, no metacharacters
-// within s will be interpreted as such.
+// within s will be interpreted as such. Any single quotes in s are
+// replaced by double quotes; use PSQuote to preserve them.
 func WinPSQuote(s string) string {
 	// See http://ss64.com/ps/syntax-esc.html#quotes.
 	// Double quotes inside single quotes are fine, double single quotes inside
@@ -34,6 +35,24 @@ func WinPSQuote(s string) string {
 	return `'` + strings.Replace(s, `'`, `"`, -1) + `'`
 }
 
+// PSQuote quotes s so that when read by powershell, no metacharacters
+// within s will be interpreted as such and s is preserved exactly.
+func PSQuote(s string) string {
+	// Inside single quotes, powershell treats the typographic single
+	// quotes as quotes too, and any two quotes as a literal one.
+	var buf bytes.Buffer
+	buf.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '‘', '’', '‚', '‛':
+			buf.WriteRune(r)
+		}
+		buf.WriteRune(r)
+	}
+	buf.WriteByte('\'')
+	return buf.String()
+}
+
 // WinCmdQuote quotes s so that when read by cmd.exe, no metacharacters
 // within s will be interpreted as such.
 func WinCmdQuote(s string) string {
@@ -98,6 +117,65 @@ func CommandString(args ...string) string {
 	return buf.String()
 }
 
+// ShCommandLine returns a command line that runs the given command and
+// arguments when read by bash. Unlike CommandString, each argument is
+// passed to the command exactly, whatever characters it contains.
+func ShCommandLine(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if isShSafe(arg) {
+			quoted[i] = arg
+		} else {
+			quoted[i] = ShQuote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// isShSafe reports whether s can be used as a word in a bash command
+// without quoting.
+func isShSafe(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune("_@%+=:,./-", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// PSCommandLine returns a command line that runs the given command and
+// arguments when read by powershell. Each argument is quoted with
+// PSQuote.
+func PSCommandLine(args ...string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = PSQuote(arg)
+	}
+	// The call operator is needed to run a command given as a
+	// quoted string rather than just evaluate the string.
+	return "& " + strings.Join(quoted, " ")
+}
+
+// WinCmdCommandLine returns a command line that runs the given command
+// and arguments when read by cmd.exe. Each argument is quoted with
+// WinCmdQuote.
+func WinCmdCommandLine(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = WinCmdQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
 // Gzip compresses the given data.
 func Gzip(data []byte) []byte {
 	var buf bytes.Buffer
@@ -7,7 +7,9 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/juju/testing"
@@ -81,6 +83,63 @@ func (*utilsSuite) TestWinPSQuote(c *gc.C) {
 	checkQuoting(c, utils.WinPSQuote, args)
 }
 
+func (*utilsSuite) TestPSQuote(c *gc.C) {
+	args := map[string]string{
+		"":                 "''",
+		"a":                `'a'`,
+		`"a"`:              `'"a"'`,
+		"'a":               `'''a'`,
+		"a'":               `'a'''`,
+		"'a'":              `'''a'''`,
+		"\u2018a\u2019":    "'\u2018\u2018a\u2019\u2019'",
+		"$a `b`":           "'$a `b`'",
+		"abc > xyz 2>&1 &": "'abc > xyz 2>&1 &'",
+	}
+	checkQuoting(c, utils.PSQuote, args)
+}
+
+func (*utilsSuite) TestShCommandLine(c *gc.C) {
+	for i, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"ls", "-l", "/tmp/a_b.c"}, "ls -l /tmp/a_b.c"},
+		{[]string{"echo", ""}, "echo ''"},
+		{[]string{"echo", "a b"}, "echo 'a b'"},
+		{[]string{"echo", "a;rm -rf /"}, "echo 'a;rm -rf /'"},
+		{[]string{"echo", "$HOME", "`id`"}, "echo '$HOME' '`id`'"},
+		{[]string{"echo", "it's"}, `echo 'it'"'"'s'`},
+	} {
+		c.Logf("test %d: %q", i, test.args)
+		c.Check(utils.ShCommandLine(test.args...), gc.Equals, test.expected)
+	}
+}
+
+func (*utilsSuite) TestShCommandLineRunsExactArgs(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("bash not available on windows")
+	}
+	args := []string{"", "a b", "it's", `"q"`, "$HOME", "`id`", "a;b|c&d", `\n`, "*"}
+	script := utils.ShCommandLine(append([]string{"printf", `%s\n`}, args...)...)
+	out, err := exec.Command("/bin/bash", "-c", script).Output()
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, strings.Join(args, "\n")+"\n")
+}
+
+func (*utilsSuite) TestPSCommandLine(c *gc.C) {
+	c.Check(utils.PSCommandLine(), gc.Equals, "")
+	c.Check(
+		utils.PSCommandLine(`C:\Program Files\x.exe`, "it's", "$a"),
+		gc.Equals,
+		`& 'C:\Program Files\x.exe' 'it''s' '$a'`,
+	)
+}
+
+func (*utilsSuite) TestWinCmdCommandLine(c *gc.C) {
+	c.Check(utils.WinCmdCommandLine("dir", "a & b"), gc.Equals, `^"dir^" ^"a ^& b^"`)
+}
+
 func (*utilsSuite) TestCommandString(c *gc.C) {
 	type test struct {
 		args     []string
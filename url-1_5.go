@@ -0,0 +1,33 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build !go1.8
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// escapePathSegment escapes s so that it can be used as a single
+// element of a URL path.
+//
+// url.PathEscape was introduced in Go 1.8, so before that the bytes it
+// would escape are escaped here: everything but letters, digits and
+// "-._~$&+:=@".
+func escapePathSegment(s string) string {
+	escaped := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			escaped = append(escaped, c)
+		case strings.IndexByte("-._~$&+:=@", c) >= 0:
+			escaped = append(escaped, c)
+		default:
+			escaped = append(escaped, fmt.Sprintf("%%%02X", c)...)
+		}
+	}
+	return string(escaped)
+}
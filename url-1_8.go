@@ -0,0 +1,14 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build go1.8
+
+package utils
+
+import "net/url"
+
+// escapePathSegment escapes s so that it can be used as a single
+// element of a URL path.
+func escapePathSegment(s string) string {
+	return url.PathEscape(s)
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// defaultPorts holds the default port for each URL scheme that
+// NormaliseURL knows about.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// ParseAbsoluteURL parses s as a URL, returning an error satisfying
+// errors.IsNotValid if it isn't an absolute URL with a scheme and a
+// host, such as "https://example.com/path".
+func ParseAbsoluteURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, errors.NewNotValid(err, "invalid URL")
+	}
+	if u.Scheme == "" {
+		return nil, errors.NotValidf("URL %q without scheme", s)
+	}
+	if u.Host == "" {
+		return nil, errors.NotValidf("URL %q without host", s)
+	}
+	return u, nil
+}
+
+// NormaliseURL returns a copy of u with the scheme and host lower
+// cased, any default port for the scheme removed, and any trailing
+// slashes removed from the path, so that URLs for the same resource
+// are more likely to compare equal. For example,
+// "HTTPS://Example.COM:443/api/" becomes "https://example.com/api".
+func NormaliseURL(u *url.URL) *url.URL {
+	result := *u
+	result.Scheme = strings.ToLower(u.Scheme)
+	result.Host = strings.ToLower(u.Host)
+	if host, port, err := net.SplitHostPort(result.Host); err == nil && port == defaultPorts[result.Scheme] {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		result.Host = host
+	}
+	setURLPath(&result, strings.TrimRight(u.Path, "/"), strings.TrimRight(escapedPath(u), "/"))
+	return &result
+}
+
+// JoinURLPath returns a copy of base with the given path segments
+// appended to its path. Each segment is escaped, so it always forms
+// exactly one element of the path, even if it contains slashes or
+// other special characters. It returns an error satisfying
+// errors.IsNotValid if a segment is empty, "." or "..", since those
+// would not be used as path elements.
+func JoinURLPath(base *url.URL, segments ...string) (*url.URL, error) {
+	path := strings.TrimRight(base.Path, "/")
+	rawPath := strings.TrimRight(escapedPath(base), "/")
+	for _, segment := range segments {
+		switch segment {
+		case "", ".", "..":
+			return nil, errors.NotValidf("URL path segment %q", segment)
+		}
+		path += "/" + segment
+		rawPath += "/" + escapePathSegment(segment)
+	}
+	result := *base
+	setURLPath(&result, path, rawPath)
+	return &result, nil
+}
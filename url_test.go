@@ -0,0 +1,113 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	"net/url"
+
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/utils"
+)
+
+type urlSuite struct {
+	testing.IsolationSuite
+}
+
+var _ = gc.Suite(&urlSuite{})
+
+func mustParseURL(c *gc.C, s string) *url.URL {
+	u, err := url.Parse(s)
+	c.Assert(err, jc.ErrorIsNil)
+	return u
+}
+
+func (*urlSuite) TestParseAbsoluteURL(c *gc.C) {
+	u, err := utils.ParseAbsoluteURL("https://example.com:17070/model?x=1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(u.Host, gc.Equals, "example.com:17070")
+	c.Assert(u.Path, gc.Equals, "/model")
+
+	for i, test := range []struct {
+		url         string
+		errorString string
+	}{
+		{"", `URL "" without scheme not valid`},
+		{"/relative/path", `URL "/relative/path" without scheme not valid`},
+		{"example.com/path", `URL "example.com/path" without scheme not valid`},
+		{"mailto:someone@example.com", `URL "mailto:someone@example.com" without host not valid`},
+		{"file:///etc/hosts", `URL "file:///etc/hosts" without host not valid`},
+		{"http://[::1", `invalid URL: .*`},
+	} {
+		c.Logf("test %d: %q", i, test.url)
+		_, err := utils.ParseAbsoluteURL(test.url)
+		c.Check(err, gc.ErrorMatches, test.errorString)
+		c.Check(errors.IsNotValid(err), jc.IsTrue)
+	}
+}
+
+func (*urlSuite) TestNormaliseURL(c *gc.C) {
+	for i, test := range []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com/api", "https://example.com/api"},
+		{"HTTPS://Example.COM:443/api/", "https://example.com/api"},
+		{"http://example.com:80/", "http://example.com"},
+		{"http://example.com:443/", "http://example.com:443"},
+		{"https://example.com:8443//", "https://example.com:8443"},
+		{"wss://[::1]:443/path", "wss://[::1]/path"},
+		{"http://[::1]:8080/path/", "http://[::1]:8080/path"},
+		{"http://example.com/a%2Fb/?q=A/", "http://example.com/a%2Fb?q=A/"},
+		{"unknown://example.com:80/x", "unknown://example.com:80/x"},
+	} {
+		c.Logf("test %d: %q", i, test.url)
+		u := mustParseURL(c, test.url)
+		original := *u
+		c.Check(utils.NormaliseURL(u).String(), gc.Equals, test.expected)
+		c.Check(*u, jc.DeepEquals, original)
+	}
+}
+
+func (*urlSuite) TestJoinURLPath(c *gc.C) {
+	for i, test := range []struct {
+		base     string
+		segments []string
+		expected string
+	}{
+		{"https://example.com", []string{"models", "uuid"}, "https://example.com/models/uuid"},
+		{"https://example.com/", []string{"models"}, "https://example.com/models"},
+		{"https://example.com/api/", []string{"a b", "c/d", "e?f#g", "%"}, "https://example.com/api/a%20b/c%2Fd/e%3Ff%23g/%25"},
+		{"https://example.com/a%2Fb", []string{"c"}, "https://example.com/a%2Fb/c"},
+		{"https://example.com/api?x=1", []string{"..x", "a.b"}, "https://example.com/api/..x/a.b?x=1"},
+		{"https://example.com/api", nil, "https://example.com/api"},
+	} {
+		c.Logf("test %d: %q %q", i, test.base, test.segments)
+		base := mustParseURL(c, test.base)
+		original := *base
+		u, err := utils.JoinURLPath(base, test.segments...)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(u.String(), gc.Equals, test.expected)
+		c.Check(*base, jc.DeepEquals, original)
+	}
+}
+
+func (*urlSuite) TestJoinURLPathPath(c *gc.C) {
+	u, err := utils.JoinURLPath(mustParseURL(c, "https://example.com/api"), "a/b", "c d")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(u.Path, gc.Equals, "/api/a/b/c d")
+	c.Assert(u.EscapedPath(), gc.Equals, "/api/a%2Fb/c%20d")
+}
+
+func (*urlSuite) TestJoinURLPathInvalid(c *gc.C) {
+	base := mustParseURL(c, "https://example.com/api")
+	for _, segment := range []string{"", ".", ".."} {
+		_, err := utils.JoinURLPath(base, "ok", segment)
+		c.Check(err, gc.ErrorMatches, `URL path segment ".*" not valid`)
+		c.Check(errors.IsNotValid(err), jc.IsTrue)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build !go1.5
+
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// escapedPath returns the escaped form of u's path.
+//
+// URL.RawPath was introduced in Go 1.5, so before that an escaped path
+// that differs from the default encoding is held in URL.Opaque, as
+// set by setURLPath.
+func escapedPath(u *url.URL) string {
+	if prefix := "//" + u.Host; u.Opaque != "" && strings.HasPrefix(u.Opaque, prefix) {
+		return strings.TrimPrefix(u.Opaque, prefix)
+	}
+	return defaultEscapedPath(u.Path)
+}
+
+// setURLPath sets u's path, and the escaped form used when it is
+// encoded. Before Go 1.5, an escaped form that differs from the
+// default encoding is kept in u.Opaque, which is how URL.String and
+// URL.RequestURI can be made to use it.
+func setURLPath(u *url.URL, path, rawPath string) {
+	u.Path = path
+	u.Opaque = ""
+	if rawPath != defaultEscapedPath(path) {
+		u.Opaque = "//" + u.Host + rawPath
+	}
+}
+
+// defaultEscapedPath returns the encoding of path used by URL.String.
+func defaultEscapedPath(path string) string {
+	return (&url.URL{Path: path}).String()
+}
@@ -0,0 +1,20 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//+build go1.5
+
+package utils
+
+import "net/url"
+
+// escapedPath returns the escaped form of u's path.
+func escapedPath(u *url.URL) string {
+	return u.EscapedPath()
+}
+
+// setURLPath sets u's path, and the escaped form used when it is
+// encoded.
+func setURLPath(u *url.URL, path, rawPath string) {
+	u.Path = path
+	u.RawPath = rawPath
+}
@@ -4,20 +4,23 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // UUID represent a universal identifier with 16 octets.
 type UUID [16]byte
 
 // regex for validating that the UUID matches RFC 4122.
-// This package generates version 4 UUIDs but
-// accepts any UUID version.
+// This package generates version 4 and version 7 UUIDs
+// but accepts any UUID version.
 // http://www.ietf.org/rfc/rfc4122.txt
 var (
 	block1 = "[0-9a-f]{8}"
@@ -30,6 +33,11 @@ var (
 	validUUID   = regexp.MustCompile("^" + UUIDSnippet + "$")
 )
 
+// uuidNow returns the time used for version 7 UUIDs. It is a variable
+// so it can be overridden for testing.
+var uuidNow = time.Now
+
+// UUIDFromString parses a UUID in its standard hexadecimal form.
 func UUIDFromString(s string) (UUID, error) {
 	if !IsValidUUIDString(s) {
 		return UUID{}, fmt.Errorf("invalid UUID: %q", s)
@@ -72,6 +80,84 @@ func NewUUID() (UUID, error) {
 	return uuid, nil
 }
 
+// NewUUIDv7 generates a new version 7 UUID, which starts with the
+// current Unix time in milliseconds followed by random numbers, so that
+// UUIDs generated in different milliseconds sort in the order they
+// were generated. This makes them suitable for use as database keys.
+func NewUUIDv7() (UUID, error) {
+	uuid := UUID{}
+	if _, err := io.ReadFull(rand.Reader, []byte(uuid[6:16])); err != nil {
+		return UUID{}, err
+	}
+	ms := uint64(uuidNow().UnixNano() / int64(time.Millisecond))
+	for i := 0; i < 6; i++ {
+		uuid[i] = byte(ms >> uint(40-8*i))
+	}
+	// Set version (7) and variant (2) according to RFC 4122 and its
+	// successor RFC 9562.
+	var version byte = 7 << 4
+	var variant byte = 8 << 4
+	uuid[6] = version | (uuid[6] & 15)
+	uuid[8] = variant | (uuid[8] & 15)
+	return uuid, nil
+}
+
+// MustNewUUIDv7 returns a new version 7 uuid, if an error occurs it
+// panics.
+func MustNewUUIDv7() UUID {
+	uuid, err := NewUUIDv7()
+	if err != nil {
+		panic(err)
+	}
+	return uuid
+}
+
+// Version returns the version of the UUID, for example 4 for a UUID
+// returned by NewUUID.
+func (uuid UUID) Version() int {
+	return int(uuid[6] >> 4)
+}
+
+// MarshalText implements encoding.TextMarshaler, so that a UUID is
+// encoded in its standard string form in JSON, YAML and other text
+// formats.
+func (uuid UUID) MarshalText() ([]byte, error) {
+	return []byte(uuid.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (uuid *UUID) UnmarshalText(text []byte) error {
+	parsed, err := UUIDFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*uuid = parsed
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. As well as the string
+// form written by MarshalText, it accepts the array of 16 numbers that
+// a UUID was encoded as before it implemented encoding.TextMarshaler.
+func (uuid *UUID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		var s *string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == nil {
+			return nil
+		}
+		return uuid.UnmarshalText([]byte(*s))
+	}
+	var raw [16]byte
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*uuid = UUID(raw)
+	return nil
+}
+
 // Copy returns a copy of the UUID.
 func (uuid UUID) Copy() UUID {
 	uuidCopy := uuid
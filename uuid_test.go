@@ -4,6 +4,9 @@
 package utils_test
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
@@ -79,3 +82,66 @@ func (*uuidSuite) TestUUIDFromString(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(uuid.String(), gc.Equals, validUUID)
 }
+
+func (*uuidSuite) TestVersion(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(uuid.Version(), gc.Equals, 4)
+}
+
+func (s *uuidSuite) TestNewUUIDv7(c *gc.C) {
+	now := time.Date(2018, time.September, 1, 12, 0, 0, 0, time.UTC)
+	s.PatchValue(utils.UUIDNow, func() time.Time { return now })
+	first, err := utils.NewUUIDv7()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(first.Version(), gc.Equals, 7)
+	c.Assert(first.String(), jc.Satisfies, utils.IsValidUUIDString)
+	// The variant is RFC 4122.
+	c.Assert(first[8]&0xc0, gc.Equals, byte(0x80))
+	// The first 48 bits hold the time in milliseconds.
+	c.Assert(first.String()[:13], gc.Equals, "01659501-1200")
+
+	now = now.Add(time.Millisecond)
+	second := utils.MustNewUUIDv7()
+	c.Assert(first.String() < second.String(), jc.IsTrue)
+}
+
+func (*uuidSuite) TestTextRoundTrip(c *gc.C) {
+	uuid := utils.MustNewUUID()
+	data, err := json.Marshal(map[string]utils.UUID{"uuid": uuid})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{"uuid":"`+uuid.String()+`"}`)
+
+	var result map[string]utils.UUID
+	err = json.Unmarshal(data, &result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result["uuid"], gc.Equals, uuid)
+}
+
+func (*uuidSuite) TestUnmarshalJSONLegacyArray(c *gc.C) {
+	uuid := utils.MustNewUUID()
+	// Before UUID implemented encoding.TextMarshaler, it was encoded
+	// as a JSON array of bytes.
+	raw := uuid.Raw()
+	data, err := json.Marshal(map[string][16]byte{"uuid": raw})
+	c.Assert(err, jc.ErrorIsNil)
+
+	var result map[string]utils.UUID
+	err = json.Unmarshal(data, &result)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(result["uuid"], gc.Equals, uuid)
+
+	var short utils.UUID
+	err = json.Unmarshal([]byte(`[1,2,3]`), &short)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(short, gc.Equals, utils.UUID{1, 2, 3})
+
+	err = json.Unmarshal([]byte(`"blah"`), &short)
+	c.Assert(err, gc.ErrorMatches, `invalid UUID: "blah"`)
+}
+
+func (*uuidSuite) TestUnmarshalTextInvalid(c *gc.C) {
+	var uuid utils.UUID
+	err := uuid.UnmarshalText([]byte("blah"))
+	c.Assert(err, gc.ErrorMatches, `invalid UUID: "blah"`)
+}
@@ -7,6 +7,8 @@ package voyeur
 
 import (
 	"sync"
+
+	"golang.org/x/net/context"
 )
 
 // Value represents a shared value that can be watched for changes. Methods on
@@ -53,6 +55,18 @@ func (v *Value) Set(val interface{}) {
 	v.wait.Broadcast()
 }
 
+// Update sets the shared value to the result of calling f with the
+// current value. The value is locked while f runs, so concurrent updates
+// are never lost; f must not call any methods on v.
+func (v *Value) Update(f func(old interface{}) interface{}) {
+	v.mu.Lock()
+	v.init()
+	v.val = f(v.val)
+	v.version++
+	v.mu.Unlock()
+	v.wait.Broadcast()
+}
+
 // Close closes the Value, unblocking any outstanding watchers.  Close always
 // returns nil.
 func (v *Value) Close() error {
@@ -96,6 +110,32 @@ type Watcher struct {
 // closed. Next returns false if the value or the Watcher itself have been
 // closed.
 func (w *Watcher) Next() bool {
+	return w.next(nil)
+}
+
+// NextContext is like Next but also returns false when the context is
+// done, leaving the Watcher open so that it can be used again.
+func (w *Watcher) NextContext(ctx context.Context) bool {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Taking the lock ensures that next is either waiting
+			// or yet to check the context, so the broadcast can't
+			// be missed.
+			w.value.mu.Lock()
+			w.value.mu.Unlock()
+			w.value.wait.Broadcast()
+		case <-stop:
+		}
+	}()
+	return w.next(ctx.Done())
+}
+
+// next implements Next and NextContext. It also returns false if done
+// is closed.
+func (w *Watcher) next(done <-chan struct{}) bool {
 	val := w.value
 	val.mu.RLock()
 	defer val.mu.RUnlock()
@@ -111,16 +151,16 @@ func (w *Watcher) Next() bool {
 	// because the only thing that can cause a Wait to
 	// return is for the condition to be triggered,
 	// which can only happen if the value is set (causing
-	// the version to increment) or it is closed
-	// causing the closed flag to be set.
-	// Both these cases will cause Next to return.
+	// the version to increment), it is closed
+	// causing the closed flag to be set, or done is closed.
+	// All these cases will cause Next to return.
 	for {
 		if w.version != val.version {
 			w.version = val.version
 			w.current = val.val
 			return true
 		}
-		if val.closed || w.closed {
+		if val.closed || w.closed || isClosed(done) {
 			return false
 		}
 
@@ -130,6 +170,15 @@ func (w *Watcher) Next() bool {
 	}
 }
 
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
 // Close closes the Watcher without closing the underlying
 // value. It may be called concurrently with Next.
 func (w *Watcher) Close() {
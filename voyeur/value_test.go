@@ -5,9 +5,12 @@ package voyeur
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
+	"golang.org/x/net/context"
 	gc "gopkg.in/check.v1"
 )
 
@@ -222,3 +225,65 @@ func (s *suite) TestWatchZeroValue(c *gc.C) {
 	v.Set(struct{}{})
 	c.Assert(<-ch, jc.IsTrue)
 }
+
+func (s *suite) TestUpdate(c *gc.C) {
+	v := NewValue(0)
+	w := v.Watch()
+	c.Assert(w.Next(), jc.IsTrue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v.Update(func(old interface{}) interface{} {
+				return old.(int) + 1
+			})
+		}()
+	}
+	wg.Wait()
+	c.Assert(v.Get(), gc.Equals, 50)
+	c.Assert(w.Next(), jc.IsTrue)
+	c.Assert(w.Value(), gc.Equals, 50)
+}
+
+func (s *suite) TestNextContext(c *gc.C) {
+	v := NewValue("one")
+	w := v.Watch()
+	c.Assert(w.NextContext(context.Background()), jc.IsTrue)
+	c.Assert(w.Value(), gc.Equals, "one")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan bool)
+	go func() {
+		result <- w.NextContext(ctx)
+	}()
+	select {
+	case <-result:
+		c.Fatalf("NextContext returned early")
+	case <-time.After(testing.ShortWait):
+	}
+	cancel()
+	select {
+	case ok := <-result:
+		c.Assert(ok, jc.IsFalse)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("NextContext not unblocked by cancel")
+	}
+
+	// The watcher can still be used after the context is done.
+	c.Assert(v.Closed(), jc.IsFalse)
+	v.Set("two")
+	c.Assert(w.Next(), jc.IsTrue)
+	c.Assert(w.Value(), gc.Equals, "two")
+}
+
+func (s *suite) TestNextContextAlreadyDone(c *gc.C) {
+	v := NewValue("one")
+	w := v.Watch()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// A value already waiting is still returned.
+	c.Assert(w.NextContext(ctx), jc.IsTrue)
+	c.Assert(w.NextContext(ctx), jc.IsFalse)
+}
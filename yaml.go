@@ -6,7 +6,6 @@ package utils
 import (
 	"io/ioutil"
 	"os"
-	"path/filepath"
 
 	"github.com/juju/errors"
 
@@ -14,46 +13,20 @@ import (
 )
 
 // WriteYaml marshals obj as yaml to a temporary file in the same directory
-// as path, than atomically replaces path with the temporary file.
+// as path, than atomically replaces path with the temporary file. The file
+// is world readable, with permissions 0644.
 func WriteYaml(path string, obj interface{}) error {
+	return WriteYamlFile(path, obj, 0644)
+}
+
+// WriteYamlFile is like WriteYaml, but writes the file with the given
+// permissions, so that files holding secrets need not be world readable.
+func WriteYamlFile(path string, obj interface{}, perms os.FileMode) error {
 	data, err := yaml.Marshal(obj)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	dir := filepath.Dir(path)
-	f, err := ioutil.TempFile(dir, "juju")
-	if err != nil {
-		return errors.Trace(err)
-	}
-	tmp := f.Name()
-	if _, err := f.Write(data); err != nil {
-		f.Close()      // don't leak file handle
-		os.Remove(tmp) // don't leak half written files on disk
-		return errors.Trace(err)
-	}
-
-	if err := f.Sync(); err != nil {
-		f.Close()      // don't leak file handle
-		os.Remove(tmp) // don't leak half written files on disk
-		return errors.Trace(err)
-	}
-	// Explicitly close the file before moving it. This is needed on Windows
-	// where the OS will not allow us to move a file that still has an open
-	// file handle. Must check the error on close because filesystems can delay
-	// reporting errors until the file is closed.
-	if err := f.Close(); err != nil {
-		os.Remove(tmp) // don't leak half written files on disk
-		return errors.Trace(err)
-	}
-
-	// ioutils.TempFile creates files 0600, but this function has a contract
-	// that files will be world readable, 0644 after replacement.
-	if err := os.Chmod(tmp, 0644); err != nil {
-		os.Remove(tmp) // remove file with incorrect permissions.
-		return errors.Trace(err)
-	}
-
-	return ReplaceFile(tmp, path)
+	return errors.Trace(AtomicWriteFile(path, data, perms))
 }
 
 // ReadYaml unmarshals the yaml contained in the file at path into obj. See
@@ -67,6 +40,20 @@ func ReadYaml(path string, obj interface{}) error {
 	return yaml.Unmarshal(data, obj)
 }
 
+// ReadYamlFileStrict is like ReadYaml, but returns an error if the yaml
+// contains keys that don't match any field of the struct they are
+// decoded into, or duplicate keys.
+func ReadYamlFileStrict(path string, obj interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err // cannot wrap here because callers check for NotFound.
+	}
+	if err := yaml.UnmarshalStrict(data, obj); err != nil {
+		return errors.Annotatef(err, "cannot parse %q", path)
+	}
+	return nil
+}
+
 // ConformYAML ensures all keys of any nested maps are strings.  This is
 // necessary because YAML unmarshals map[interface{}]interface{} in nested
 // maps, which cannot be serialized by json or bson. Also, handle
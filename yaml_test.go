@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
@@ -85,6 +86,45 @@ func (*yamlSuite) TestWriteYamlWriteGarbage(c *gc.C) {
 	c.Assert(err, gc.NotNil)
 }
 
+func (*yamlSuite) TestWriteYamlFilePerms(c *gc.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("file permissions are not supported on windows")
+	}
+	path := filepath.Join(c.MkDir(), "f")
+	err := WriteYamlFile(path, struct{ A int }{1}, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	info, err := os.Stat(path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0600))
+}
+
+func (*yamlSuite) TestReadYamlFileStrict(c *gc.C) {
+	type T struct {
+		A int `yaml:"a"`
+	}
+	path := filepath.Join(c.MkDir(), "f")
+	err := ioutil.WriteFile(path, []byte("a: 1\n"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	var v T
+	err = ReadYamlFileStrict(path, &v)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(v, gc.Equals, T{A: 1})
+
+	err = ioutil.WriteFile(path, []byte("a: 1\nb: 2\n"), 0644)
+	c.Assert(err, jc.ErrorIsNil)
+	err = ReadYamlFileStrict(path, &v)
+	c.Assert(err, gc.ErrorMatches, `(?s)cannot parse ".*": yaml: unmarshal errors:.*`)
+
+	// ReadYaml ignores the unknown field.
+	err = ReadYaml(path, &v)
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (*yamlSuite) TestReadYamlFileStrictReturnsNotFound(c *gc.C) {
+	err := ReadYamlFileStrict(filepath.Join(c.MkDir(), "missing"), nil)
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+}
+
 type ConformSuite struct{}
 
 var _ = gc.Suite(&ConformSuite{})
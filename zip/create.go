@@ -0,0 +1,97 @@
+// Copyright 2018 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package zip
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ZipFiles writes a zip archive into target holding the files listed
+// in fileList, including the contents of any directories. As with
+// tar.TarFiles, strip is removed from the beginning of all the paths
+// when stored. Symbolic links are stored as links, which Extract
+// recreates, rather than followed.
+func ZipFiles(fileList []string, target io.Writer, strip string) (err error) {
+	zipw := zip.NewWriter(target)
+	defer func() {
+		if closeErr := zipw.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing zip writer: %v", closeErr)
+		}
+	}()
+	for _, fileName := range fileList {
+		if err := writeZipContents(fileName, strip, zipw); err != nil {
+			return fmt.Errorf("write to zip file failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// writeZipContents creates an entry for the given file or directory,
+// and anything it contains, in the given zip archive.
+func writeZipContents(fileName, strip string, zipw *zip.Writer) error {
+	fInfo, err := os.Lstat(fileName)
+	if err != nil {
+		return err
+	}
+	h, err := zip.FileInfoHeader(fInfo)
+	if err != nil {
+		return fmt.Errorf("cannot create zip header for %q: %v", fileName, err)
+	}
+	h.Name = filepath.ToSlash(strings.TrimPrefix(fileName, strip))
+	switch mode := fInfo.Mode(); mode & os.ModeType {
+	case os.ModeDir:
+		h.Name += "/"
+		h.Method = zip.Store
+	case os.ModeSymlink:
+		h.Method = zip.Store
+	case 0:
+		h.Method = zip.Deflate
+	default:
+		return fmt.Errorf("cannot zip %q with mode %v", fileName, mode)
+	}
+	w, err := zipw.CreateHeader(h)
+	if err != nil {
+		return fmt.Errorf("cannot write header for %q: %v", fileName, err)
+	}
+	switch {
+	case fInfo.Mode()&os.ModeSymlink != 0:
+		// The contents of a symbolic link entry are its target.
+		linkTarget, err := os.Readlink(fileName)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, filepath.ToSlash(linkTarget))
+		return err
+	case fInfo.IsDir():
+		f, err := os.Open(fileName)
+		if err != nil {
+			return err
+		}
+		names, err := f.Readdirnames(-1)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("error reading directory %q: %v", fileName, err)
+		}
+		for _, name := range names {
+			if err := writeZipContents(filepath.Join(fileName, name), strip, zipw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to write %q: %v", fileName, err)
+	}
+	return nil
+}
@@ -5,9 +5,9 @@ package zip
 
 import (
 	"archive/zip"
-	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -49,6 +49,30 @@ func ExtractAll(reader *zip.Reader, targetRoot string) error {
 // source path does not reference a directory, the referenced file will be written
 // directly to the target path.
 func Extract(reader *zip.Reader, targetRoot, sourceRoot string) error {
+	return ExtractWithOptions(reader, targetRoot, sourceRoot, ExtractOptions{})
+}
+
+// ExtractOptions holds the options for ExtractWithOptions.
+type ExtractOptions struct {
+	// MaxFileSize, if positive, is the largest file that will be
+	// extracted, in bytes.
+	MaxFileSize int64
+
+	// MaxTotalSize, if positive, is the largest total size of the
+	// files that will be extracted, in bytes.
+	MaxTotalSize int64
+
+	// Filter, if not nil, is called with the cleaned, slash-separated
+	// path of each entry in the zip reader; only entries for which it
+	// returns true are extracted.
+	Filter func(name string) bool
+}
+
+// ExtractWithOptions is like Extract, but limits the size of the
+// extracted files and filters the entries as described by opts. The
+// sizes are checked against the data actually extracted, not just the
+// sizes recorded in the zip file.
+func ExtractWithOptions(reader *zip.Reader, targetRoot, sourceRoot string, opts ExtractOptions) error {
 	sourceRoot = path.Clean(sourceRoot)
 	if sourceRoot == "." {
 		sourceRoot = ""
@@ -56,7 +80,11 @@ func Extract(reader *zip.Reader, targetRoot, sourceRoot string) error {
 	if !isSanePath(sourceRoot) {
 		return fmt.Errorf("cannot extract files rooted at %q", sourceRoot)
 	}
-	extractor := extractor{targetRoot, sourceRoot}
+	extractor := &extractor{
+		targetRoot: targetRoot,
+		sourceRoot: sourceRoot,
+		opts:       opts,
+	}
 	for _, zipFile := range reader.File {
 		if err := extractor.extract(zipFile); err != nil {
 			cleanName := path.Clean(zipFile.Name)
@@ -69,11 +97,15 @@ func Extract(reader *zip.Reader, targetRoot, sourceRoot string) error {
 type extractor struct {
 	targetRoot string
 	sourceRoot string
+	opts       ExtractOptions
+
+	// total holds the number of bytes extracted so far.
+	total int64
 }
 
 // targetPath returns the target path for a given zip file and whether
 // it should be extracted.
-func (x extractor) targetPath(zipFile *zip.File) (string, bool) {
+func (x *extractor) targetPath(zipFile *zip.File) (string, bool) {
 	cleanPath := path.Clean(zipFile.Name)
 	if cleanPath == x.sourceRoot {
 		return x.targetRoot, true
@@ -88,7 +120,14 @@ func (x extractor) targetPath(zipFile *zip.File) (string, bool) {
 	return filepath.Join(x.targetRoot, filepath.FromSlash(cleanPath)), true
 }
 
-func (x extractor) extract(zipFile *zip.File) error {
+func (x *extractor) extract(zipFile *zip.File) error {
+	cleanPath := path.Clean(zipFile.Name)
+	if path.IsAbs(cleanPath) || !isSanePath(cleanPath) {
+		return fmt.Errorf("path leads out of scope")
+	}
+	if x.opts.Filter != nil && !x.opts.Filter(cleanPath) {
+		return nil
+	}
 	targetPath, ok := x.targetPath(zipFile)
 	if !ok {
 		return nil
@@ -111,7 +150,7 @@ func (x extractor) extract(zipFile *zip.File) error {
 	return fmt.Errorf("unknown file type %d", modeType)
 }
 
-func (x extractor) writeDir(targetPath string, modePerm os.FileMode) error {
+func (x *extractor) writeDir(targetPath string, modePerm os.FileMode) error {
 	fileInfo, err := os.Lstat(targetPath)
 	switch {
 	case err == nil:
@@ -131,33 +170,33 @@ func (x extractor) writeDir(targetPath string, modePerm os.FileMode) error {
 	return os.MkdirAll(targetPath, modePerm)
 }
 
-func (x extractor) writeFile(targetPath string, zipFile *zip.File, modePerm os.FileMode) error {
+func (x *extractor) writeFile(targetPath string, zipFile *zip.File, modePerm os.FileMode) error {
 	if _, err := os.Lstat(targetPath); !os.IsNotExist(err) {
 		if err := os.RemoveAll(targetPath); err != nil {
 			return err
 		}
 	}
+	if err := x.checkSize(zipFile.UncompressedSize64); err != nil {
+		return err
+	}
 	writer, err := os.OpenFile(targetPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, modePerm)
 	if err != nil {
 		return err
 	}
-	defer writer.Close()
-
-	if err := copyTo(writer, zipFile); err != nil {
+	if err := x.copyLimited(writer, zipFile); err != nil {
+		// Don't leave a partial file behind.
+		writer.Close()
+		os.Remove(targetPath)
 		return err
 	}
-
 	if err := writer.Sync(); err != nil {
+		writer.Close()
 		return err
 	}
-
-	if err := writer.Close(); err != nil {
-		return err
-	}
-	return nil
+	return writer.Close()
 }
 
-func (x extractor) writeSymlink(targetPath string, zipFile *zip.File) error {
+func (x *extractor) writeSymlink(targetPath string, zipFile *zip.File) error {
 	symlinkTarget, err := x.checkSymlink(targetPath, zipFile)
 	if err != nil {
 		return err
@@ -170,12 +209,32 @@ func (x extractor) writeSymlink(targetPath string, zipFile *zip.File) error {
 	return os.Symlink(symlinkTarget, targetPath)
 }
 
-func (x extractor) checkSymlink(targetPath string, zipFile *zip.File) (string, error) {
-	var buffer bytes.Buffer
-	if err := copyTo(&buffer, zipFile); err != nil {
+// maxSymlinkSize holds the largest symlink target that will be read
+// from a zip file, whatever the size limits.
+const maxSymlinkSize = 4096
+
+func (x *extractor) checkSymlink(targetPath string, zipFile *zip.File) (string, error) {
+	if err := x.checkSize(zipFile.UncompressedSize64); err != nil {
+		return "", err
+	}
+	reader, err := zipFile.Open()
+	if err != nil {
 		return "", err
 	}
-	symlinkTarget := buffer.String()
+	defer reader.Close()
+	// Read one more byte than allowed, to tell whether there's more.
+	data, err := ioutil.ReadAll(io.LimitReader(reader, maxSymlinkSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxSymlinkSize {
+		return "", fmt.Errorf("symlink exceeds maximum size of %d bytes", maxSymlinkSize)
+	}
+	if err := x.checkSize(uint64(len(data))); err != nil {
+		return "", err
+	}
+	x.total += int64(len(data))
+	symlinkTarget := string(data)
 	if filepath.IsAbs(symlinkTarget) {
 		return "", fmt.Errorf("symlink %q is absolute", symlinkTarget)
 	}
@@ -191,6 +250,46 @@ func (x extractor) checkSymlink(targetPath string, zipFile *zip.File) (string, e
 	return symlinkTarget, nil
 }
 
+// checkSize returns an error if a file of the given size would exceed
+// the size limits.
+func (x *extractor) checkSize(size uint64) error {
+	if x.opts.MaxFileSize > 0 && size > uint64(x.opts.MaxFileSize) {
+		return fmt.Errorf("file exceeds maximum size of %d bytes", x.opts.MaxFileSize)
+	}
+	if x.opts.MaxTotalSize > 0 && size > uint64(x.opts.MaxTotalSize-x.total) {
+		return fmt.Errorf("files exceed maximum total size of %d bytes", x.opts.MaxTotalSize)
+	}
+	return nil
+}
+
+// copyLimited copies the contents of zipFile to writer, stopping with
+// an error if the size limits are exceeded. The size recorded in the
+// zip file is not trusted.
+func (x *extractor) copyLimited(writer io.Writer, zipFile *zip.File) error {
+	if x.opts.MaxFileSize <= 0 && x.opts.MaxTotalSize <= 0 {
+		return copyTo(writer, zipFile)
+	}
+	limit := x.opts.MaxFileSize
+	if x.opts.MaxTotalSize > 0 && (limit <= 0 || x.opts.MaxTotalSize-x.total < limit) {
+		limit = x.opts.MaxTotalSize - x.total
+	}
+	reader, err := zipFile.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// Read one more byte than allowed, to tell whether there's more.
+	n, err := io.Copy(writer, io.LimitReader(reader, limit+1))
+	if err != nil {
+		return err
+	}
+	if err := x.checkSize(uint64(n)); err != nil {
+		return err
+	}
+	x.total += n
+	return nil
+}
+
 func copyTo(writer io.Writer, zipFile *zip.File) error {
 	reader, err := zipFile.Open()
 	if err != nil {
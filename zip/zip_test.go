@@ -355,3 +355,147 @@ func (s *ZipSuite) TestExtractSourceError(c *gc.C) {
 	err := zip.Extract(reader, c.MkDir(), "../lol")
 	c.Assert(err, gc.ErrorMatches, `cannot extract files rooted at "../lol"`)
 }
+
+func (s *ZipSuite) TestExtractAllTraversal(c *gc.C) {
+	var buf bytes.Buffer
+	w := stdzip.NewWriter(&buf)
+	f, err := w.Create("../evil")
+	c.Assert(err, gc.IsNil)
+	_, err = f.Write([]byte("evil"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(w.Close(), gc.IsNil)
+	reader, err := stdzip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	c.Assert(err, gc.IsNil)
+
+	parent := c.MkDir()
+	err = zip.ExtractAll(reader, filepath.Join(parent, "target"))
+	c.Assert(err, gc.ErrorMatches, `cannot extract "../evil": path leads out of scope`)
+	_, err = os.Lstat(filepath.Join(parent, "evil"))
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+}
+
+func (s *ZipSuite) TestExtractWithOptionsFilter(c *gc.C) {
+	reader := s.makeZip(c,
+		ft.File{"keep", "content", 0644},
+		ft.File{"skip.pyc", "compiled", 0644},
+		ft.Dir{"dir", 0755},
+		ft.File{"dir/skip.pyc", "compiled", 0644},
+	)
+	targetPath := c.MkDir()
+	var names []string
+	err := zip.ExtractWithOptions(reader, targetPath, "", zip.ExtractOptions{
+		Filter: func(name string) bool {
+			names = append(names, name)
+			return filepath.Ext(name) != ".pyc"
+		},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(names, jc.SameContents, []string{"keep", "skip.pyc", "dir", "dir/skip.pyc"})
+	ft.Entries{
+		ft.File{"keep", "content", 0644},
+		ft.Removed{"skip.pyc"},
+		ft.Dir{"dir", 0755},
+		ft.Removed{"dir/skip.pyc"},
+	}.Check(c, targetPath)
+}
+
+func (s *ZipSuite) TestExtractWithOptionsSizeLimits(c *gc.C) {
+	reader := s.makeZip(c,
+		ft.File{"one", "0123456789", 0644},
+		ft.File{"two", "0123456789", 0644},
+	)
+	for i, test := range []struct {
+		opts zip.ExtractOptions
+		err  string
+	}{{
+		opts: zip.ExtractOptions{MaxFileSize: 10, MaxTotalSize: 20},
+	}, {
+		opts: zip.ExtractOptions{MaxFileSize: 9},
+		err:  `cannot extract "(one|two)": file exceeds maximum size of 9 bytes`,
+	}, {
+		opts: zip.ExtractOptions{MaxTotalSize: 19},
+		err:  `cannot extract "(one|two)": files exceed maximum total size of 19 bytes`,
+	}} {
+		c.Logf("test %d", i)
+		err := zip.ExtractWithOptions(reader, c.MkDir(), "", test.opts)
+		if test.err == "" {
+			c.Check(err, jc.ErrorIsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *ZipSuite) TestExtractWithOptionsSymlinkSize(c *gc.C) {
+	reader := s.makeZip(c,
+		ft.File{"one", "0123456789", 0644},
+		ft.Symlink{"link", "one"},
+	)
+	// The symlink target counts towards the total.
+	err := zip.ExtractWithOptions(reader, c.MkDir(), "", zip.ExtractOptions{MaxTotalSize: 12})
+	c.Assert(err, gc.ErrorMatches, `cannot extract "link": files exceed maximum total size of 12 bytes`)
+	err = zip.ExtractWithOptions(reader, c.MkDir(), "", zip.ExtractOptions{MaxTotalSize: 13})
+	c.Assert(err, jc.ErrorIsNil)
+
+	// Symlinks are never read beyond the largest sane target.
+	var buf bytes.Buffer
+	w := stdzip.NewWriter(&buf)
+	header := &stdzip.FileHeader{Name: "link"}
+	header.SetMode(os.ModeSymlink | 0777)
+	f, err := w.CreateHeader(header)
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = f.Write(bytes.Repeat([]byte("a"), 5000))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(w.Close(), jc.ErrorIsNil)
+	reader, err = stdzip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	c.Assert(err, jc.ErrorIsNil)
+	err = zip.ExtractAll(reader, c.MkDir())
+	c.Assert(err, gc.ErrorMatches, `cannot extract "link": symlink exceeds maximum size of 4096 bytes`)
+}
+
+func (s *ZipSuite) TestExtractWithOptionsRemovesPartialFile(c *gc.C) {
+	reader := s.makeZip(c,
+		ft.File{"one", "0123456789", 0644},
+	)
+	// Understate the size of the file, so that it passes the check
+	// made before extracting it.
+	reader.File[0].UncompressedSize64 = 5
+	targetPath := c.MkDir()
+	err := zip.ExtractWithOptions(reader, targetPath, "", zip.ExtractOptions{MaxFileSize: 9})
+	c.Assert(err, gc.ErrorMatches, `cannot extract "one": .*`)
+	_, err = os.Lstat(filepath.Join(targetPath, "one"))
+	c.Assert(os.IsNotExist(err), jc.IsTrue)
+}
+
+func (s *ZipSuite) TestZipFiles(c *gc.C) {
+	sourcePath := c.MkDir()
+	ft.Entries{
+		ft.File{"file", "content", 0644},
+		ft.Dir{"dir", 0750},
+		ft.File{"dir/exec", "#!/bin/sh", 0755},
+		ft.Symlink{"dir/link", "exec"},
+	}.Create(c, sourcePath)
+
+	var buf bytes.Buffer
+	err := zip.ZipFiles([]string{
+		filepath.Join(sourcePath, "file"),
+		filepath.Join(sourcePath, "dir"),
+	}, &buf, sourcePath+string(filepath.Separator))
+	c.Assert(err, jc.ErrorIsNil)
+
+	reader, err := stdzip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	c.Assert(err, gc.IsNil)
+	names, err := zip.FindAll(reader)
+	c.Assert(err, gc.IsNil)
+	c.Assert(names, jc.SameContents, []string{"file", "dir", "dir/exec", "dir/link"})
+
+	targetPath := c.MkDir()
+	err = zip.ExtractAll(reader, targetPath)
+	c.Assert(err, jc.ErrorIsNil)
+	ft.Entries{
+		ft.File{"file", "content", 0644},
+		ft.Dir{"dir", 0750},
+		ft.File{"dir/exec", "#!/bin/sh", 0755},
+		ft.Symlink{"dir/link", "exec"},
+	}.Check(c, targetPath)
+}